@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// runBackup implements `tracker backup [--out FILE] [--keep N]`. Safe to run
+// while the tracker is running.
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", "", "snapshot file (default BACKUP_DIR/<db>-<timestamp>.db)")
+	keep := fs.Int("keep", -1, "snapshots to keep in BACKUP_DIR after this one (default BACKUP_KEEP, 0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config load: %w", err)
+	}
+	if !isSQLite(cfg) {
+		return fmt.Errorf("backup needs DB_DRIVER=sqlite; use pg_dump for postgres")
+	}
+	store, err := db.OpenNoMigrate(cfg.DBDriver, cfg.DBDSN())
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *keep < 0 {
+		*keep = cfg.BackupKeep
+	}
+	path, err := backupOnce(context.Background(), cfg, store, *out, *keep)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "backup written: %s\n", path)
+	return nil
+}
+
+// runRestore implements `tracker restore [--force] FILE`. The tracker must be
+// stopped; the current database is kept as <DB_PATH>.pre-restore-<timestamp>.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	force := fs.Bool("force", false, "confirm the tracker is stopped and the database may be replaced")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: tracker restore [--force] <backup.db>")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config load: %w", err)
+	}
+	if !isSQLite(cfg) {
+		return fmt.Errorf("restore needs DB_DRIVER=sqlite; use pg_restore for postgres")
+	}
+	src := fs.Arg(0)
+	if err := db.VerifySQLite(src); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "integrity check ok: %s\n", src)
+	if !*force {
+		return fmt.Errorf("stop the tracker, then re-run with --force to replace %s", cfg.DBDSN())
+	}
+
+	saved, err := db.RestoreSQLite(context.Background(), src, cfg.DBDSN())
+	if saved != "" {
+		fmt.Fprintf(os.Stdout, "previous database saved as %s\n", saved)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "restored %s from %s\n", cfg.DBDSN(), src)
+	return nil
+}
+
+// runBackupSchedule snapshots on BACKUP_INTERVAL until ctx is cancelled.
+func runBackupSchedule(ctx context.Context, cfg *config.Config, store db.Store) error {
+	t := time.NewTicker(cfg.BackupInterval); defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done(): return ctx.Err()
+		case <-t.C:
+			cur := cfg.Current()
+			path, err := backupOnce(ctx, cur, store, "", cur.BackupKeep)
+			if err != nil { log.Warn().Err(err).Msg("scheduled backup failed"); continue }
+			log.Info().Str("file", path).Msg("💾 backup written")
+		}
+	}
+}
+
+func backupOnce(ctx context.Context, cfg *config.Config, store db.Store, out string, keep int) (string, error) {
+	path := out
+	if path == "" {
+		path = db.BackupPath(cfg.BackupDir, cfg.DBDSN())
+	}
+	if err := store.Backup(ctx, path); err != nil {
+		return "", err
+	}
+	if out == "" {
+		removed, err := db.RotateBackups(cfg.BackupDir, cfg.DBDSN(), keep)
+		for _, r := range removed { log.Debug().Str("file", r).Msg("rotated out old backup") }
+		if err != nil { return path, fmt.Errorf("rotate: %w", err) }
+	}
+	return path, nil
+}
+
+func isSQLite(cfg *config.Config) bool {
+	return cfg.DBDriver == "" || strings.HasPrefix(strings.ToLower(cfg.DBDriver), "sqlite")
+}
@@ -0,0 +1,796 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kol-tracker/pkg/analyzer"
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+	"github.com/kol-tracker/pkg/extractor"
+	"github.com/kol-tracker/pkg/scanner"
+)
+
+// commands maps each subcommand to its handler. Everything except `run`
+// works without Twitter/Telegram login or the dashboard.
+var commands = map[string]func(args []string) error{
+	"run":      runDaemon,
+	"scan":     runScanCmd,
+	"backfill": runBackfillCmd,
+	"reprice":  runRepriceCmd,
+	"stream":   runStreamCmd,
+	"study":    runStudyCmd,
+	"trace":    runTraceCmd,
+	"bridge":   runBridgeCmd,
+	"score":    runScoreCmd,
+	"pnl":      runPnLCmd,
+	"kol":      runKOLCmd,
+	"alerts":   runAlertsCmd,
+	"export":   runExportCmd,
+	"migrate":  runMigrate,
+	"prune":    runPrune,
+	"backup":   runBackup,
+	"restore":  runRestore,
+	"config":   runConfigCmd,
+	"help":     func([]string) error { printUsage(); return nil },
+}
+
+func printUsage() {
+	fmt.Fprint(os.Stderr, `usage: tracker <command> [flags]
+
+  run                                  start the tracker daemon (default)
+  scan <address> [--chain C] [--kol K] fetch and store a wallet's transactions
+  backfill <address>|--all [--chain C] [--kol K] [--pages N]
+                                       walk full wallet history (resumable)
+  reprice [--since DUR] [--dry-run]    revalue stored trades at their historical USD price
+  stream [--chain C]... [--token ADDR]...
+                                       print live tracked-wallet trades (and token buyers)
+  study <address> --kol K [--chain C]  deep wallet study (links, funding, co-traders)
+  trace <address> [--chain C] [--depth N]
+                                       multi-hop funding trace
+  bridge <tx> [--chain C]              decode a bridge transfer from its source or destination tx
+  score <address> --kol K [--chain C]  wash-wallet score against a KOL
+  pnl --kol K | <address> [--chain C]  FIFO positions and PnL per KOL call, or a wallet's positions
+  kol add <name> [--twitter H] [--telegram C] [--wallet ADDR[:CHAIN[:LABEL]]]...
+  kol list
+  kol remove <id|handle>
+  alerts tail [-n N] [-f]              recent alerts, optionally follow
+  export <kols|wallets|wash|alerts|funding> [--format json|csv] [--out FILE] [--kol K]
+  migrate status|up|down [N]           schema migrations
+  prune [--dry-run]                    apply retention policies
+  backup [--out FILE]                  online SQLite snapshot
+  restore [--force] FILE               restore a snapshot
+  config validate                      print the resolved config (secrets redacted) and check it
+
+K is a KOL id or handle. C defaults to ethereum for 0x addresses, else solana.
+`)
+}
+
+// parseInterleaved parses flags that may appear before or after positional
+// arguments (`scan <addr> --chain base`) and returns the positionals.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func openStore() (*config.Config, db.Store, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("config load: %w", err)
+	}
+	store, err := db.Open(cfg.DBDriver, cfg.DBDSN())
+	if err != nil {
+		return nil, nil, fmt.Errorf("database init: %w", err)
+	}
+	return cfg, store, nil
+}
+
+// cliContext is cancelled on SIGINT/SIGTERM so long scans stop cleanly.
+func cliContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// chainFlagUsage lists the registered chains for --chain help.
+func chainFlagUsage() string {
+	var names []string
+	for _, ch := range config.AllChains() {
+		names = append(names, string(ch))
+	}
+	return "chain (" + strings.Join(names, ", ") + ")"
+}
+
+func chainFor(address, flagVal string) config.Chain {
+	if flagVal != "" {
+		return config.Chain(strings.ToLower(flagVal))
+	}
+	return extractor.ClassifyAddress(address)
+}
+
+func resolveKOL(store db.Store, ref string) (*db.KOLProfile, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("--kol is required")
+	}
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if k, err := store.GetKOLByID(id); err == nil {
+			return k, nil
+		}
+	}
+	k, err := store.GetKOLByHandle(strings.TrimPrefix(ref, "@"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown KOL %q", ref)
+	}
+	return k, nil
+}
+
+// ensureWallet returns the tracked wallet, tracking it under kolRef first if
+// needed.
+func ensureWallet(store db.Store, address string, chain config.Chain, kolRef string) (*db.TrackedWallet, error) {
+	if w, err := store.GetWalletByAddress(address, chain); err == nil {
+		return w, nil
+	}
+	if kolRef == "" {
+		return nil, fmt.Errorf("%s is not tracked on %s; pass --kol to track it", address, chain)
+	}
+	k, err := resolveKOL(store, kolRef)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := store.UpsertWallet(k.ID, address, chain, "cli", 0.5, "cli"); err != nil {
+		return nil, err
+	}
+	return store.GetWalletByAddress(address, chain)
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func oneAddress(pos []string, cmd string) (string, error) {
+	if len(pos) != 1 {
+		return "", fmt.Errorf("usage: tracker %s <address> (see tracker help)", cmd)
+	}
+	return pos[0], nil
+}
+
+// ---- scan / study / trace / score ----
+
+func runScanCmd(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage())
+	kolF := fs.String("kol", "", "KOL to track the wallet under if it is new")
+	limit := fs.Int("n", 20, "recent transactions to print")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	address, err := oneAddress(pos, "scan")
+	if err != nil {
+		return err
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	chain := chainFor(address, *chainF)
+	w, err := ensureWallet(store, address, chain, *kolF)
+	if err != nil {
+		return err
+	}
+	sc := scanner.New(cfg, store)
+	cnt, err := sc.ScanWallet(ctx, w.ID, address, chain)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%s on %s: %d new transactions\n", address, chain, cnt)
+	if fa, err := sc.CheckFunding(ctx, address, chain); err == nil && fa != nil {
+		fmt.Fprintf(os.Stdout, "funding: %+v\n", *fa)
+	}
+
+	txs, _ := store.GetTransactionsForWallet(w.ID, *limit)
+	for _, t := range txs {
+		fmt.Fprintf(os.Stdout, "  %s  %-10s %-10s %14.4f  $%10.2f  %s\n",
+			t.Timestamp.Format("2006-01-02 15:04"), t.TxType, t.TokenSymbol, t.AmountToken, t.AmountUSD, t.TxHash)
+	}
+	return nil
+}
+
+// runBackfillCmd walks a wallet's full history instead of just new activity.
+// Progress is kept in the database, so re-running continues where it stopped.
+func runBackfillCmd(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage())
+	kolF := fs.String("kol", "", "KOL to track the wallet under if it is new")
+	pages := fs.Int("pages", 0, "max pages per stream this run (0 = until done)")
+	all := fs.Bool("all", false, "backfill every tracked wallet")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if !*all {
+		if _, err := oneAddress(pos, "backfill"); err != nil {
+			return err
+		}
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	var wallets []db.TrackedWallet
+	if *all {
+		if wallets, err = store.GetAllTrackedAddresses(); err != nil {
+			return err
+		}
+	} else {
+		w, err := ensureWallet(store, pos[0], chainFor(pos[0], *chainF), *kolF)
+		if err != nil {
+			return err
+		}
+		wallets = append(wallets, *w)
+	}
+
+	sc := scanner.New(cfg, store)
+	for _, w := range wallets {
+		n, done, err := sc.BackfillWallet(ctx, w.ID, w.Address, w.Chain, *pages)
+		state := "complete"
+		if !done {
+			state = "partial (re-run to continue)"
+		}
+		fmt.Fprintf(os.Stdout, "%s on %s: %d transactions processed, %s\n", w.Address, w.Chain, n, state)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "  %v\n", err)
+		}
+	}
+	return nil
+}
+
+// runRepriceCmd revalues stored transactions at the USD price of their own
+// time, for rows stored before price history existed or while a price
+// source was down. Candles fetched along the way are kept.
+func runRepriceCmd(args []string) error {
+	fs := flag.NewFlagSet("reprice", flag.ContinueOnError)
+	since := fs.Duration("since", 0, "only transactions newer than this (e.g. 720h; 0 = all)")
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	var from time.Time
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+	st, err := scanner.New(cfg, store).RepriceTransactions(ctx, from, *dryRun)
+	verb := "updated"
+	if *dryRun {
+		verb = "would update"
+	}
+	fmt.Fprintf(os.Stdout, "%d transactions checked, %d %s, %d without a price\n", st.Checked, st.Updated, verb, st.Unpriced)
+	return err
+}
+
+func runStudyCmd(args []string) error {
+	fs := flag.NewFlagSet("study", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage())
+	kolF := fs.String("kol", "", "KOL the wallet belongs to (required)")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	address, err := oneAddress(pos, "study")
+	if err != nil {
+		return err
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	k, err := resolveKOL(store, *kolF)
+	if err != nil {
+		return err
+	}
+	chain := chainFor(address, *chainF)
+	if _, err := ensureWallet(store, address, chain, *kolF); err != nil {
+		return err
+	}
+	engine := scanner.NewWalletStudyEngine(scanner.New(cfg, store), store, cfg)
+	res, err := engine.StudyWallet(ctx, k.ID, address, chain)
+	if err != nil {
+		return err
+	}
+	return printJSON(res)
+}
+
+func runTraceCmd(args []string) error {
+	fs := flag.NewFlagSet("trace", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage())
+	depth := fs.Int("depth", 3, "maximum funding hops to follow")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	address, err := oneAddress(pos, "trace")
+	if err != nil {
+		return err
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	tracer := scanner.NewDeepFundingTracer(scanner.New(cfg, store), store, cfg)
+	trace, err := tracer.TraceAndSave(ctx, address, chainFor(address, *chainF), *depth)
+	if err != nil {
+		return err
+	}
+	return printJSON(trace)
+}
+
+func runBridgeCmd(args []string) error {
+	fs := flag.NewFlagSet("bridge", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage()+"; required for EVM txs")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return fmt.Errorf("usage: tracker bridge <tx> [--chain C] (see tracker help)")
+	}
+	hash := pos[0]
+	chain := config.Chain(strings.ToLower(*chainF))
+	if chain == "" {
+		if strings.HasPrefix(hash, "0x") {
+			return fmt.Errorf("--chain is required for EVM txs")
+		}
+		chain = config.ChainSolana
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	bt, err := scanner.New(cfg, store).DecodeBridgeTx(ctx, chain, hash)
+	if err != nil {
+		return err
+	}
+	if bt == nil {
+		return fmt.Errorf("%s on %s is not a transfer of a known bridge", hash, chain)
+	}
+	return printJSON(bt)
+}
+
+func runScoreCmd(args []string) error {
+	fs := flag.NewFlagSet("score", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage())
+	kolF := fs.String("kol", "", "KOL to score against (required)")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	address, err := oneAddress(pos, "score")
+	if err != nil {
+		return err
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	k, err := resolveKOL(store, *kolF)
+	if err != nil {
+		return err
+	}
+	an := analyzer.New(cfg, store)
+	// scoring compares against stored patterns; refresh them first
+	if _, err := an.BuildKOLFingerprint(k.ID); err != nil {
+		return err
+	}
+	ws, err := an.ScoreWashCandidate(k.ID, address, chainFor(address, *chainF))
+	if err != nil {
+		return err
+	}
+	return printJSON(ws)
+}
+
+func runPnLCmd(args []string) error {
+	fs := flag.NewFlagSet("pnl", flag.ContinueOnError)
+	chainF := fs.String("chain", "", chainFlagUsage())
+	kolF := fs.String("kol", "", "KOL to rebuild positions for")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if *kolF == "" && len(pos) != 1 {
+		return fmt.Errorf("usage: tracker pnl --kol K | <address> [--chain C]")
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *kolF == "" {
+		w, err := store.GetWalletByAddress(pos[0], chainFor(pos[0], *chainF))
+		if err != nil {
+			return fmt.Errorf("%s is not a tracked wallet: %w", pos[0], err)
+		}
+		ps, err := store.GetPositionsForWallet(w.ID)
+		if err != nil {
+			return err
+		}
+		return printJSON(ps)
+	}
+	k, err := resolveKOL(store, *kolF)
+	if err != nil {
+		return err
+	}
+	an := analyzer.New(cfg, store)
+	an.SetPricer(scanner.New(cfg, store).PriceAt)
+	pnl, err := an.BuildPositions(k.ID)
+	if err != nil {
+		return err
+	}
+	return printJSON(pnl)
+}
+
+// ---- config ----
+
+func runConfigCmd(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("usage: tracker config validate")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	src := "defaults + environment"
+	if cfg.ConfigFile != "" {
+		src = "defaults + " + cfg.ConfigFile + " + environment"
+	}
+	out, err := cfg.ToFile().Redact().YAML()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "# resolved from %s\n%s", src, out)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	problems := cfg.Problems()
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "error: %s\n", p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d config problems", len(problems))
+	}
+	fmt.Fprintln(os.Stderr, "config ok")
+	return nil
+}
+
+// ---- kol ----
+
+type walletFlags []string
+
+func (w *walletFlags) String() string     { return strings.Join(*w, ",") }
+func (w *walletFlags) Set(v string) error { *w = append(*w, v); return nil }
+
+func runKOLCmd(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tracker kol add|list|remove")
+	}
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("kol "+sub, flag.ContinueOnError)
+	twitter := fs.String("twitter", "", "twitter handle")
+	telegram := fs.String("telegram", "", "telegram channel")
+	var wallets walletFlags
+	fs.Var(&wallets, "wallet", "known wallet ADDR[:CHAIN[:LABEL]] (repeatable)")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch sub {
+	case "list":
+		kols, err := store.GetKOLs()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "%-5s %-24s %-20s %-20s %s\n", "ID", "NAME", "TWITTER", "TELEGRAM", "WALLETS")
+		for _, k := range kols {
+			ws, _ := store.GetWalletsForKOL(k.ID)
+			fmt.Fprintf(os.Stdout, "%-5d %-24s %-20s %-20s %d\n", k.ID, k.Name, k.TwitterHandle, k.TelegramChannel, len(ws))
+		}
+		return nil
+
+	case "add":
+		name := strings.Join(pos, " ")
+		if name == "" {
+			name = *twitter
+		}
+		if name == "" {
+			name = *telegram
+		}
+		if name == "" {
+			return fmt.Errorf("usage: tracker kol add <name> [--twitter H] [--telegram C] [--wallet ADDR[:CHAIN[:LABEL]]]")
+		}
+		id, err := store.UpsertKOL(name, strings.TrimPrefix(*twitter, "@"), *telegram)
+		if err != nil {
+			return err
+		}
+		for _, spec := range wallets {
+			parts := strings.SplitN(spec, ":", 3)
+			chainF, label := "", "manual"
+			if len(parts) > 1 {
+				chainF = parts[1]
+			}
+			if len(parts) > 2 {
+				label = parts[2]
+			}
+			if _, err := store.UpsertWallet(id, parts[0], chainFor(parts[0], chainF), label, 1.0, "manual"); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stdout, "added KOL %d (%s) with %d wallets\n", id, name, len(wallets))
+		return nil
+
+	case "remove":
+		if len(pos) != 1 {
+			return fmt.Errorf("usage: tracker kol remove <id|handle>")
+		}
+		k, err := resolveKOL(store, pos[0])
+		if err != nil {
+			return err
+		}
+		if err := store.DeleteKOL(k.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "removed KOL %d (%s)\n", k.ID, k.Name)
+		return nil
+	}
+	return fmt.Errorf("unknown kol command %q", sub)
+}
+
+// ---- alerts ----
+
+func runAlertsCmd(args []string) error {
+	if len(args) == 0 || args[0] != "tail" {
+		return fmt.Errorf("usage: tracker alerts tail [-n N] [-f]")
+	}
+	fs := flag.NewFlagSet("alerts tail", flag.ContinueOnError)
+	n := fs.Int("n", 20, "number of recent alerts")
+	follow := fs.Bool("f", false, "keep printing new alerts")
+	interval := fs.Duration("interval", 5*time.Second, "poll interval with -f")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	var lastID int64
+	show := func(limit int) error {
+		alerts, err := store.GetRecentAlerts(limit)
+		if err != nil {
+			return err
+		}
+		// newest first from the store; print oldest first
+		for i := len(alerts) - 1; i >= 0; i-- {
+			a := alerts[i]
+			if a.ID <= lastID {
+				continue
+			}
+			lastID = a.ID
+			fmt.Fprintf(os.Stdout, "%s  %-8s %-16s %s", a.CreatedAt.Format("2006-01-02 15:04:05"), a.Severity, a.AlertType, a.Title)
+			if a.RelatedWallet != "" {
+				fmt.Fprintf(os.Stdout, "  wallet=%s", a.RelatedWallet)
+			}
+			fmt.Fprintln(os.Stdout)
+		}
+		return nil
+	}
+	if err := show(*n); err != nil || !*follow {
+		return err
+	}
+	t := time.NewTicker(*interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := show(100); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ---- export ----
+
+func runExportCmd(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "json", "json or csv")
+	out := fs.String("out", "", "output file (default stdout)")
+	kolF := fs.String("kol", "", "restrict wallets/wash to one KOL")
+	limit := fs.Int("limit", 10000, "max alerts/funding matches")
+	pos, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return fmt.Errorf("usage: tracker export <kols|wallets|wash|alerts|funding> [--format json|csv] [--out FILE]")
+	}
+
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var kolID int64
+	if *kolF != "" {
+		k, err := resolveKOL(store, *kolF)
+		if err != nil {
+			return err
+		}
+		kolID = k.ID
+	}
+
+	var data interface{}
+	switch pos[0] {
+	case "kols":
+		data, err = store.GetKOLs()
+	case "wallets":
+		if kolID > 0 {
+			data, err = store.GetWalletsForKOL(kolID)
+		} else {
+			data, err = store.GetAllTrackedAddresses()
+		}
+	case "wash":
+		if kolID > 0 {
+			data, err = store.GetWashCandidatesForKOL(kolID)
+		} else {
+			data, err = store.GetWashCandidates(0)
+		}
+	case "alerts":
+		data, err = store.GetRecentAlerts(*limit)
+	case "funding":
+		data, err = store.GetFundingMatches(*limit)
+	default:
+		return fmt.Errorf("unknown export %q", pos[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case "csv":
+		return writeCSV(w, data)
+	}
+	return fmt.Errorf("unknown format %q", *format)
+}
+
+// writeCSV flattens a slice of structs through their JSON field names.
+func writeCSV(w io.Writer, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+	if len(rows) == 0 {
+		return nil
+	}
+	var cols []string
+	for c := range rows[0] {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		rec := make([]string, len(cols))
+		for i, c := range cols {
+			switch v := r[c].(type) {
+			case nil:
+			case string:
+				rec[i] = v
+			case float64:
+				rec[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				b, _ := json.Marshal(v)
+				rec[i] = string(b)
+			}
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
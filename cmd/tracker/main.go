@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -25,28 +26,36 @@ import (
 
 func main() {
 	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: "15:04:05"}).With().Timestamp().Logger()
+
+	// No subcommand (or only flags) keeps the old behaviour: run the daemon.
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") { name, args = args[0], args[1:] }
+	cmd, ok := commands[name]
+	if !ok { printUsage(); os.Exit(2) }
+	if err := cmd(args); err != nil { log.Fatal().Err(err).Msgf("%s failed", name) }
+}
+
+// runDaemon starts every monitor, the analysis loops and the dashboard.
+func runDaemon(args []string) error {
 	log.Info().Msg("🔍 KOL Wallet Tracker starting...")
 
 	cfg, err := config.Load()
-	if err != nil { log.Fatal().Err(err).Msg("config load failed") }
+	if err != nil { return fmt.Errorf("config load: %w", err) }
 
-	store, err := db.NewStore(cfg.DBPath)
-	if err != nil { log.Fatal().Err(err).Msg("database init failed") }
+	store, err := db.Open(cfg.DBDriver, cfg.DBDSN())
+	if err != nil { return fmt.Errorf("database init: %w", err) }
 	defer store.Close()
 
 	// Seed from config
-	for _, h := range cfg.KOLTwitterHandles { store.UpsertKOL(h, h, "") }
-	for _, c := range cfg.KOLTelegramChannels { store.UpsertKOL(c, "", c) }
-	for _, kw := range cfg.KOLKnownWallets {
-		kols, _ := store.GetKOLs(); kolID := int64(1)
-		if len(kols) > 0 { kolID = kols[0].ID }
-		store.UpsertWallet(kolID, kw.Address, kw.Chain, kw.Label, 1.0, "config")
-	}
+	seedKOLs(cfg, store)
 
 	sc := scanner.New(cfg, store)
 	an := analyzer.New(cfg, store)
+	an.SetPricer(sc.PriceAt)
 	studyEngine := scanner.NewWalletStudyEngine(sc, store, cfg)
+	tracer := scanner.NewDeepFundingTracer(sc, store, cfg)
 	freshMon := monitor.NewFreshWalletMonitor(cfg, store, sc, an)
+	freshMon.SetTracer(tracer)
 	twitterMon := twitter.NewMonitor(cfg, store)
 	telegramMon := telegram.NewMonitor(cfg, store)
 
@@ -55,6 +64,17 @@ func main() {
 	}
 	twitterMon.SetTokenCallback(cb)
 	telegramMon.SetTokenCallback(cb)
+	rl := newReloader(cfg, store, twitterMon, telegramMon)
+	solStream := scanner.NewSolanaStream(sc)
+	solStream.OnTrade(freshMon.OnWalletTrade)
+	var evmStreams []*scanner.EVMStream
+	for _, ch := range config.AllEVMChains() {
+		if cfg.EVMWS[ch] == "" { continue }
+		st := scanner.NewEVMStream(sc, ch)
+		st.OnTrade(freshMon.OnWalletTrade)
+		st.WatchBuyers(freshMon)
+		evmStreams = append(evmStreams, st)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -62,13 +82,23 @@ func main() {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() { <-sigCh; log.Info().Msg("shutting down..."); cancel() }()
 
-	errCh := make(chan error, 10)
+	errCh := make(chan error, 16)
 	// Twitter uses private API now - always start (login happens inside)
 	go func() { errCh <- twitterMon.Run(ctx) }()
-	if len(cfg.KOLTelegramChannels) > 0 { go func() { errCh <- telegramMon.Run(ctx) }() }
+	// always run: a reload may add channels later
+	go func() { errCh <- telegramMon.Run(ctx) }()
 	go func() { errCh <- freshMon.Run(ctx) }()
-	go func() { errCh <- runScan(ctx, cfg, store, sc) }()
-	go func() { errCh <- runAnalysis(ctx, cfg, store, an) }()
+	sched := scanner.NewScheduler(store, cfg.ScanWorkers, scanJob(store, sc))
+	go func() { errCh <- runScan(ctx, cfg, sched) }()
+	go func() { errCh <- sc.RunHealthChecks(ctx) }()
+	if cfg.StreamEnabled && cfg.SolanaWSURL != "" { go func() { errCh <- solStream.Run(ctx) }() }
+	for _, st := range evmStreams {
+		if cfg.StreamEnabled { go func(st *scanner.EVMStream) { errCh <- st.Run(ctx) }(st) }
+	}
+	go func() { errCh <- runAnalysis(ctx, cfg, store, an, tracer) }()
+	if cfg.JanitorInterval > 0 { go func() { errCh <- runJanitor(ctx, cfg, store) }() }
+	if cfg.BackupInterval > 0 && isSQLite(cfg) { go func() { errCh <- runBackupSchedule(ctx, cfg, store) }() }
+	go func() { errCh <- rl.Run(ctx) }()
 
 	// AI Engine (optional but recommended)
 	aiEngine := ai.NewEngine(cfg, store)
@@ -80,6 +110,12 @@ func main() {
 	dash := dashboard.New(store, cfg, cfg.DashboardPort)
 	dash.SetMonitors(twitterMon, telegramMon, studyEngine)
 	dash.SetAIInfo(aiEngine.GetProviderInfo)
+	dash.SetScanQueueInfo(func() interface{} {
+		return map[string]interface{}{
+			"scheduler": sched.Stats(), "providers": sc.ProviderStats(), "rpc": sc.RPCStats(),
+			"streams": streamStats(solStream, evmStreams),
+		}
+	})
 	go func() { errCh <- dash.Run() }()
 
 	printSummary(cfg, store)
@@ -89,53 +125,141 @@ func main() {
 		if err != nil && err != context.Canceled { log.Error().Err(err).Msg("error") }
 	}
 	log.Info().Msg("goodbye 👋")
+	return nil
 }
 
-func runScan(ctx context.Context, cfg *config.Config, store *db.Store, sc *scanner.Scanner) error {
-	scanAll(ctx, store, sc)
+// seedKOLs creates the configured KOLs (reusing existing rows matched by
+// handle) and attaches per-KOL wallets from the config file and
+// KOL_KNOWN_WALLETS. It runs at startup and on every reload that changes
+// them.
+func seedKOLs(cfg *config.Config, store db.Store) {
+	seed := func(name, twitter, telegram string) int64 {
+		for _, h := range []string{twitter, telegram} {
+			if h == "" { continue }
+			if k, err := store.GetKOLByHandle(h); err == nil { return k.ID }
+		}
+		id, err := store.UpsertKOL(name, twitter, telegram)
+		if err != nil { log.Warn().Err(err).Str("kol", name).Msg("seed KOL failed") }
+		return id
+	}
+	for _, k := range cfg.KOLs {
+		name := k.Name; if name == "" { name = k.Twitter }; if name == "" { name = k.Telegram }
+		id := seed(name, k.Twitter, k.Telegram)
+		for _, w := range k.Wallets { store.UpsertWallet(id, w.Address, w.Chain, w.Label, 1.0, "config") }
+	}
+	for _, h := range cfg.KOLTwitterHandles { seed(h, h, "") }
+	for _, c := range cfg.KOLTelegramChannels { seed(c, "", c) }
+	// KOL_KNOWN_WALLETS name no KOL; they go to the first one
+	for _, kw := range cfg.KOLKnownWallets {
+		kols, _ := store.GetKOLs(); kolID := int64(1)
+		if len(kols) > 0 { kolID = kols[0].ID }
+		store.UpsertWallet(kolID, kw.Address, kw.Chain, kw.Label, 1.0, "config")
+	}
+}
+
+func runScan(ctx context.Context, cfg *config.Config, sched *scanner.Scheduler) error {
+	// cycles run in their own goroutine so a slow one is reported as skipped ticks
+	go sched.TryCycle(ctx, 0.5)
 	t := time.NewTicker(cfg.ChainScanInterval); defer t.Stop()
-	for { select { case <-ctx.Done(): return ctx.Err(); case <-t.C: scanAll(ctx, store, sc) } }
+	for { select { case <-ctx.Done(): return ctx.Err(); case <-t.C: go sched.TryCycle(ctx, 0.5); case <-cfg.Reloaded(): t.Reset(cfg.Current().ChainScanInterval) } }
 }
 
-func scanAll(ctx context.Context, store *db.Store, sc *scanner.Scanner) {
-	ws, _ := store.GetAllTrackedAddresses()
-	for _, w := range ws {
-		if ctx.Err() != nil { return }
-		if w.Confidence < 0.5 { continue }
+// scanJob scans one wallet and tracks wallets it is linked to.
+func scanJob(store db.Store, sc *scanner.Scanner) scanner.ScanJob {
+	return func(ctx context.Context, w db.TrackedWallet) {
 		cnt, _ := sc.ScanWallet(ctx, w.ID, w.Address, w.Chain)
 		if cnt > 0 { log.Info().Str("addr", w.Address[:8]+"...").Int("txs", cnt).Msg("📦 scanned") }
 		linked, _ := sc.FindLinkedWallets(ctx, w.Address, w.Chain, 1)
 		for _, l := range linked { store.UpsertWallet(w.KOLID, l.SourceAddress, l.Chain, "linked:"+l.SourceType, 0.4, "linked:"+w.Address[:8]) }
-		time.Sleep(500 * time.Millisecond)
 	}
 }
 
-func runAnalysis(ctx context.Context, cfg *config.Config, store *db.Store, an *analyzer.Analyzer) error {
+func runAnalysis(ctx context.Context, cfg *config.Config, store db.Store, an *analyzer.Analyzer, tracer *scanner.DeepFundingTracer) error {
 	select { case <-ctx.Done(): return ctx.Err(); case <-time.After(30 * time.Second): }
-	doAnalysis(store, an, cfg)
+	doAnalysis(ctx, store, an, tracer, cfg)
+	doFundingScan(ctx, store, tracer)
 	t := time.NewTicker(cfg.PatternAnalysisInterval); defer t.Stop()
-	for { select { case <-ctx.Done(): return ctx.Err(); case <-t.C: doAnalysis(store, an, cfg) } }
+	ff := time.NewTicker(cfg.FixedFloatScanInterval); defer ff.Stop()
+	for {
+		select {
+		case <-ctx.Done(): return ctx.Err()
+		case <-t.C: doAnalysis(ctx, store, an, tracer, cfg)
+		case <-ff.C: doFundingScan(ctx, store, tracer)
+		case <-cfg.Reloaded(): cur := cfg.Current(); t.Reset(cur.PatternAnalysisInterval); ff.Reset(cur.FixedFloatScanInterval)
+		}
+	}
+}
+
+// maxTracesPerPass bounds how many untraced candidates one analysis pass
+// tries to trace, failed attempts included, so a backlog (or providers being
+// down) doesn't hold up scoring.
+const maxTracesPerPass = 20
+
+// traceBackoff is how long a candidate waits after its nth failed trace in a
+// row: 15 minutes, doubling up to a day.
+func traceBackoff(n int) time.Duration {
+	d := 15 * time.Minute
+	for i := 1; i < n && d < 24*time.Hour; i++ { d *= 2 }
+	if d > 24*time.Hour { d = 24 * time.Hour }
+	return d
 }
 
-func doAnalysis(store *db.Store, an *analyzer.Analyzer, cfg *config.Config) {
+func doAnalysis(ctx context.Context, store db.Store, an *analyzer.Analyzer, tracer *scanner.DeepFundingTracer, cfg *config.Config) {
+	cfg = cfg.Current()
+	// candidates the fresh-wallet monitor didn't get to trace (restart, API errors);
+	// ones that keep failing go to the back of the queue and wait out a backoff
+	attempts := 0
+	if cands, err := store.GetWashCandidates(0.0); err == nil {
+		sort.SliceStable(cands, func(i, j int) bool { return cands[i].TraceFailures < cands[j].TraceFailures })
+		for _, c := range cands {
+			if attempts >= maxTracesPerPass || ctx.Err() != nil { break }
+			if c.SuspicionLevel != "" || (c.TraceFailures > 0 && time.Since(c.TraceAttemptedAt) < traceBackoff(c.TraceFailures)) { continue }
+			attempts++
+			if _, err := tracer.TraceAndSave(ctx, c.Address, c.Chain, cfg.FundingTraceDepth); err != nil {
+				if ctx.Err() != nil { break }
+				log.Warn().Err(err).Str("addr", c.Address).Int("failures", c.TraceFailures+1).Msg("funding trace failed")
+				if err := store.RecordTraceFailure(c.Address, c.Chain); err != nil { log.Warn().Err(err).Msg("record trace failure") }
+			}
+		}
+	}
 	kols, _ := store.GetKOLs()
 	for _, k := range kols {
 		fp, _ := an.BuildKOLFingerprint(k.ID)
 		if fp != nil && fp.TradeCount > 0 { log.Info().Str("kol", k.Name).Int("trades", fp.TradeCount).Msg("📊 profile") }
 		cands, _ := store.GetWashCandidates(0.0)
-		for _, c := range cands { if c.LinkedKOLID == k.ID || c.LinkedKOLID == 0 { an.ScoreWashCandidate(k.ID, c.Address, c.Chain) } }
+		linked := map[string]bool{}
+		if ws, err := store.GetWalletsForKOL(k.ID); err == nil { for _, w := range ws { linked[w.Address+":"+string(w.Chain)] = true } }
+		for _, c := range cands { if c.LinkedKOLID == k.ID || c.LinkedKOLID == 0 || linked[c.Address+":"+string(c.Chain)] { an.ScoreWashCandidate(k.ID, c.Address, c.Chain) } }
 		an.MatchFundingAmounts(k.ID, cfg.AmountMatchTolerancePct, 24)
 	}
 }
 
-func printSummary(cfg *config.Config, store *db.Store) {
+// doFundingScan matches each KOL's outgoing transfers against the funding
+// of wash candidates, across chains and assets, and follows the ones sent
+// into bridges to their recipients.
+func doFundingScan(ctx context.Context, store db.Store, tracer *scanner.DeepFundingTracer) {
+	kols, _ := store.GetKOLs()
+	for _, k := range kols {
+		if ctx.Err() != nil { return }
+		matches, err := tracer.ScanForFixedFloatPatterns(ctx, k.ID)
+		if err != nil { log.Warn().Err(err).Str("kol", k.Name).Msg("FixedFloat scan failed"); continue }
+		if len(matches) > 0 { log.Info().Str("kol", k.Name).Int("matches", len(matches)).Msg("🔁 FixedFloat matches") }
+		bridged, err := tracer.FollowBridgeTransfers(ctx, k.ID)
+		if err != nil { log.Warn().Err(err).Str("kol", k.Name).Msg("bridge follow-up failed"); continue }
+		if len(bridged) > 0 { log.Info().Str("kol", k.Name).Int("transfers", len(bridged)).Msg("🌉 bridge transfers followed") }
+	}
+}
+
+func printSummary(cfg *config.Config, store db.Store) {
 	stats, _ := store.GetStats()
 	fmt.Println("\n" + strings.Repeat("═", 60))
 	fmt.Println("  🔍 KOL WALLET TRACKER - RUNNING")
 	fmt.Println(strings.Repeat("═", 60))
 	fmt.Printf("  Twitter:   %v\n", cfg.KOLTwitterHandles)
 	fmt.Printf("  Telegram:  %v\n", cfg.KOLTelegramChannels)
-	fmt.Printf("  Chains:    Solana, Ethereum, Base, BSC\n")
+	var chains []string
+	for _, ch := range config.AllChains() { chains = append(chains, string(ch)) }
+	fmt.Printf("  Chains:    %s\n", strings.Join(chains, ", "))
 	fmt.Printf("  Dashboard: http://localhost:%d\n", cfg.DashboardPort)
 	aiStatus := "❌ Disabled (set AI_PROVIDER + credentials)"
 	if cfg.AnthropicAPIKey != "" {
@@ -169,6 +293,7 @@ func runAIAnalysis(ctx context.Context, cfg *config.Config, engine *ai.Engine) e
 			if err := engine.RunPeriodicAnalysis(ctx); err != nil {
 				log.Error().Err(err).Msg("AI analysis error")
 			}
+		case <-cfg.Reloaded(): t.Reset(cfg.Current().AIAnalysisInterval)
 		}
 	}
 }
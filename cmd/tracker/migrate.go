@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// runMigrate implements `tracker migrate status|up|down [version]`.
+//
+//	status       list known migrations and whether each is applied
+//	up [N]       apply pending migrations up to N (default: latest)
+//	down [N]     roll back to version N (default: one step back)
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tracker migrate status|up|down [version]")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config load: %w", err)
+	}
+	store, err := db.OpenNoMigrate(cfg.DBDriver, cfg.DBDSN())
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	target := -1
+	if len(args) > 1 {
+		if target, err = strconv.Atoi(args[1]); err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+	}
+
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(store)
+	case "up":
+		if target < 0 {
+			target = 0
+		}
+		if err := store.MigrateUp(target); err != nil {
+			return err
+		}
+	case "down":
+		if target < 0 {
+			cur, err := store.SchemaVersion()
+			if err != nil {
+				return err
+			}
+			target = cur - 1
+		}
+		if err := store.MigrateDown(target); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	return printMigrationStatus(store)
+}
+
+func printMigrationStatus(store db.Store) error {
+	status, err := store.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	cur, _ := store.SchemaVersion()
+	fmt.Fprintf(os.Stdout, "schema version: %d (latest %d)\n", cur, db.LatestVersion())
+	for _, st := range status {
+		mark, at := "pending", ""
+		if st.Applied {
+			mark = "applied"
+			at = st.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(os.Stdout, "  %4d  %-28s %-8s %s\n", st.Version, st.Name, mark, at)
+	}
+	return nil
+}
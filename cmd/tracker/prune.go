@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// retentionPolicies maps the RETENTION_*_DAYS settings onto db policies.
+func retentionPolicies(cfg *config.Config) []db.RetentionPolicy {
+	day := 24 * time.Hour
+	return []db.RetentionPolicy{
+		{Table: "wallet_transactions", MaxAge: time.Duration(cfg.RetentionTxDays) * day},
+		{Table: "social_posts", MaxAge: time.Duration(cfg.RetentionPostsDays) * day},
+		{Table: "alerts", MaxAge: time.Duration(cfg.RetentionAlertsDays) * day},
+	}
+}
+
+// runPrune implements `tracker prune [--dry-run] [--archive-dir DIR]`.
+func runPrune(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be pruned without deleting")
+	archiveDir := fs.String("archive-dir", "", "archive directory (default ARCHIVE_DIR; \"-\" disables archival)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config load: %w", err)
+	}
+	dir := cfg.ArchiveDir
+	if *archiveDir == "-" {
+		dir = ""
+	} else if *archiveDir != "" {
+		dir = *archiveDir
+	}
+
+	store, err := db.Open(cfg.DBDriver, cfg.DBDSN())
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	results, err := store.Prune(retentionPolicies(cfg), dir, *dryRun)
+	verb := "pruned"
+	if *dryRun {
+		verb = "would prune"
+	}
+	for _, r := range results {
+		fmt.Fprintf(os.Stdout, "  %-22s %s %d rows", r.Table, verb, r.Deleted)
+		if r.ArchiveFile != "" {
+			fmt.Fprintf(os.Stdout, " → %s", r.ArchiveFile)
+		}
+		fmt.Fprintln(os.Stdout)
+	}
+	return err
+}
+
+// runJanitor prunes on JANITOR_INTERVAL until ctx is cancelled.
+func runJanitor(ctx context.Context, cfg *config.Config, store db.Store) error {
+	t := time.NewTicker(cfg.JanitorInterval); defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done(): return ctx.Err()
+		case <-t.C:
+			cur := cfg.Current()
+			results, err := store.Prune(retentionPolicies(cur), cur.ArchiveDir, false)
+			if err != nil { log.Warn().Err(err).Msg("janitor prune failed") }
+			for _, r := range results {
+				if r.Deleted > 0 { log.Info().Str("table", r.Table).Int64("rows", r.Deleted).Str("archive", r.ArchiveFile).Msg("🧹 pruned") }
+			}
+		}
+	}
+}
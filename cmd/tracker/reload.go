@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+	"github.com/kol-tracker/pkg/telegram"
+	"github.com/kol-tracker/pkg/twitter"
+)
+
+// reloader re-reads the config on SIGHUP, or when the config file or .env
+// changes on disk, and pushes the result into the running daemon: handles
+// and channels go through the monitors, everything else reloadable is
+// published as a new snapshot of the shared config (see Config.Current) and
+// wakes the tickers. A config that fails to load or validate changes
+// nothing, the chain registry and known services included.
+type reloader struct {
+	cfg    *config.Config // live config shared by every component
+	loaded *config.Config // what the last load produced, before runtime edits
+	store  db.Store
+	tw     *twitter.Monitor
+	tg     *telegram.Monitor
+}
+
+// newReloader must be called before the monitors start so the snapshot does
+// not include handles added at runtime (e.g. from the dashboard); those are
+// never removed by a reload.
+func newReloader(cfg *config.Config, store db.Store, tw *twitter.Monitor, tg *telegram.Monitor) *reloader {
+	snap := *cfg
+	snap.KOLTwitterHandles = append([]string(nil), cfg.KOLTwitterHandles...)
+	snap.KOLTelegramChannels = append([]string(nil), cfg.KOLTelegramChannels...)
+	return &reloader{cfg: cfg, loaded: &snap, store: store, tw: tw, tg: tg}
+}
+
+func (r *reloader) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	files := []string{".env"}
+	if r.cfg.ConfigFile != "" { files = append(files, r.cfg.ConfigFile) }
+	seen := modTimes(files)
+	t := time.NewTicker(5 * time.Second); defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done(): return ctx.Err()
+		case <-hup:
+			log.Info().Msg("SIGHUP: reloading config")
+			r.reload()
+		case <-t.C:
+			now := modTimes(files)
+			if changed := changedFiles(seen, now); len(changed) > 0 {
+				log.Info().Strs("files", changed).Msg("config changed on disk: reloading")
+				r.reload()
+			}
+			seen = now
+		}
+	}
+}
+
+func (r *reloader) reload() {
+	next, err := config.Parse()
+	if err != nil { log.Error().Err(err).Msg("config reload failed, keeping current config"); return }
+	if p := next.Problems(); len(p) > 0 { log.Error().Strs("problems", p).Msg("config reload rejected, keeping current config"); return }
+
+	changes := config.Diff(r.loaded, next)
+	if len(changes) == 0 { next.Publish(); log.Info().Msg("config reloaded: no changes"); return } // known_services may still differ
+	for _, ch := range changes {
+		if ch.Restart { log.Warn().Msg("config: " + ch.String()) } else { log.Info().Msg("config: " + ch.String()) }
+	}
+
+	added, removed := diffLists(r.loaded.KOLTwitterHandles, next.KOLTwitterHandles)
+	for _, h := range removed { r.tw.RemoveHandle(h) }
+	for _, h := range added { r.tw.AddHandle(h) }
+	addedCh, removedCh := diffLists(r.loaded.KOLTelegramChannels, next.KOLTelegramChannels)
+	for _, c := range removedCh { r.tg.RemoveChannel(c) }
+	for _, c := range addedCh { r.tg.AddChannel(c) }
+
+	r.cfg.Apply(next, changes)
+	next.Publish()
+	for _, ch := range changes {
+		// new KOLs / wallets need rows before the monitors and scanner see them
+		if ch.Field == "KOLs" || ch.Field == "KOLKnownWallets" || ch.Field == "KOLTwitterHandles" || ch.Field == "KOLTelegramChannels" { seedKOLs(next, r.store); break }
+	}
+	r.loaded = next
+}
+
+// diffLists returns the entries of next missing from prev and vice versa,
+// compared case-insensitively like the monitors do.
+func diffLists(prev, next []string) (added, removed []string) {
+	has := func(list []string, s string) bool {
+		for _, v := range list { if strings.EqualFold(v, s) { return true } }
+		return false
+	}
+	for _, s := range next { if !has(prev, s) { added = append(added, s) } }
+	for _, s := range prev { if !has(next, s) { removed = append(removed, s) } }
+	return added, removed
+}
+
+func modTimes(files []string) map[string]time.Time {
+	out := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if st, err := os.Stat(f); err == nil { out[f] = st.ModTime() }
+	}
+	return out
+}
+
+func changedFiles(prev, now map[string]time.Time) []string {
+	var out []string
+	for f, t := range now { if !prev[f].Equal(t) { out = append(out, f) } }
+	for f := range prev { if _, ok := now[f]; !ok { out = append(out, f) } }
+	return out
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/scanner"
+)
+
+// streamStats collects the dashboard state of every real-time stream.
+func streamStats(sol *scanner.SolanaStream, evm []*scanner.EVMStream) []scanner.StreamStats {
+	out := []scanner.StreamStats{sol.Stats()}
+	for _, st := range evm {
+		out = append(out, st.Stats())
+	}
+	return out
+}
+
+// printBuyers is a BuyerWatcher for `tracker stream --token`: it follows a
+// fixed token list and prints every buyer.
+type printBuyers struct {
+	tokens []string
+	print  func(v interface{})
+}
+
+func (p printBuyers) WatchedTokens(config.Chain) []string { return p.tokens }
+
+func (p printBuyers) OnTokenBuyer(_ context.Context, chain config.Chain, token string, b scanner.TokenBuyer) {
+	p.print(map[string]interface{}{"event": "buyer", "chain": chain, "token": token, "buyer": b})
+}
+
+// runStreamCmd implements `tracker stream [--chain C]... [--token ADDR]...`:
+// the daemon's real-time streams on their own, printing each event as a JSON
+// line. Pointing ETH_WS_URL/ETH_RPC_URL at a local anvil or geth --dev node
+// makes it a quick end-to-end check.
+func runStreamCmd(args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ContinueOnError)
+	var chains, tokens walletFlags
+	fs.Var(&chains, "chain", "chain to stream (repeatable; default every chain with a ws endpoint)")
+	fs.Var(&tokens, "token", "EVM token to report buyers of (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	ctx, cancel := cliContext()
+	defer cancel()
+	sc := scanner.New(cfg, store)
+	go sc.RunHealthChecks(ctx)
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(os.Stdout)
+	emit := func(v interface{}) { mu.Lock(); defer mu.Unlock(); enc.Encode(v) }
+	onTrade := func(_ context.Context, ev scanner.TradeEvent) {
+		emit(map[string]interface{}{"event": "trade", "wallet": ev.Wallet.Address, "tx": ev.Tx})
+	}
+
+	if len(chains) == 0 {
+		if cfg.SolanaWSURL != "" {
+			chains = append(chains, string(config.ChainSolana))
+		}
+		for _, ch := range config.AllEVMChains() {
+			if cfg.EVMWS[ch] != "" {
+				chains = append(chains, string(ch))
+			}
+		}
+	}
+	var runs []func(context.Context) error
+	for _, c := range chains {
+		ch := config.Chain(strings.ToLower(c))
+		switch {
+		case ch == config.ChainSolana && cfg.SolanaWSURL != "":
+			st := scanner.NewSolanaStream(sc)
+			st.OnTrade(onTrade)
+			runs = append(runs, st.Run)
+		case cfg.EVMWS[ch] != "":
+			st := scanner.NewEVMStream(sc, ch)
+			st.OnTrade(onTrade)
+			if len(tokens) > 0 {
+				st.WatchBuyers(printBuyers{tokens: tokens, print: emit})
+			}
+			runs = append(runs, st.Run)
+		default:
+			return fmt.Errorf("no websocket endpoint configured for %s", ch)
+		}
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("no websocket endpoints configured")
+	}
+
+	errCh := make(chan error, len(runs))
+	for _, run := range runs {
+		go func(run func(context.Context) error) { errCh <- run(ctx) }(run)
+	}
+	err = <-errCh
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -21,7 +21,7 @@ import (
 // cheaper tasks use the fast model (e.g., Haiku or a small Ollama model).
 type Engine struct {
 	cfg    *config.Config
-	store  *db.Store
+	store  db.Store
 	client *http.Client
 
 	provider      string // "anthropic", "openai", "ollama"
@@ -49,7 +49,7 @@ const (
 	OllamaGemma2       = "gemma2"
 )
 
-func NewEngine(cfg *config.Config, store *db.Store) *Engine {
+func NewEngine(cfg *config.Config, store db.Store) *Engine {
 	e := &Engine{
 		cfg:       cfg,
 		store:     store,
@@ -14,11 +14,12 @@ import (
 )
 
 type Analyzer struct {
-	store *db.Store
+	store db.Store
 	cfg   *config.Config
+	price PriceFunc
 }
 
-func New(cfg *config.Config, store *db.Store) *Analyzer {
+func New(cfg *config.Config, store db.Store) *Analyzer {
 	return &Analyzer{store: store, cfg: cfg}
 }
 
@@ -34,6 +35,7 @@ type KOLFingerprint struct {
 	GasProfile      *GasProfile      `json:"gas_profile,omitempty"`
 	TimingProfile   *TimingProfile   `json:"timing_profile,omitempty"`
 	SellBehavior    *SellBehavior    `json:"sell_behavior,omitempty"`
+	PnL             *PnLSummary      `json:"pnl,omitempty"`
 	TokenPrefs      *TokenPrefs      `json:"token_prefs,omitempty"`
 	CEXProfile      *CEXProfile      `json:"cex_profile,omitempty"`
 	ENSProfile      *ENSProfile      `json:"ens_profile,omitempty"`
@@ -90,26 +92,24 @@ func (a *Analyzer) BuildKOLFingerprint(kolID int64) (*KOLFingerprint, error) {
 	fp := &KOLFingerprint{KOLID: kolID, PreferredDEX: map[string]int{}, BotSignatures: map[string]int{}, ChainPreference: map[string]int{}}
 	var allTrades []db.WalletTransaction
 	for _, w := range wallets {
-		if w.Confidence < 0.5 { continue }
+		// per-link confidence: shared/suspected wallets don't shape this KOL's fingerprint
+		if w.Confidence < 0.5 || w.Label == "wash_suspected" { continue }
 		trades, _ := a.store.GetTransactionsForWallet(w.ID, 1000)
 		allTrades = append(allTrades, trades...)
 		fp.ChainPreference[string(w.Chain)] += len(trades)
 	}
+	pnl, positions := a.buildPositions(kolID, wallets)
 	if len(allTrades) == 0 { return fp, nil }
 	fp.TradeCount = len(allTrades)
 	var buyAmts, sellAmts, fees []float64
 	tokenBuys := map[string]int{}
-	tokenFirst := map[string]time.Time{}
-	tokenLastSell := map[string]time.Time{}
 	for _, t := range allTrades {
 		switch t.TxType {
 		case "swap_buy":
 			if t.AmountUSD > 0 { buyAmts = append(buyAmts, t.AmountUSD) }
 			tokenBuys[t.TokenAddress]++
-			if f, ok := tokenFirst[t.TokenAddress]; !ok || t.Timestamp.Before(f) { tokenFirst[t.TokenAddress] = t.Timestamp }
 		case "swap_sell":
 			if t.AmountUSD > 0 { sellAmts = append(sellAmts, t.AmountUSD) }
-			if l, ok := tokenLastSell[t.TokenAddress]; !ok || t.Timestamp.After(l) { tokenLastSell[t.TokenAddress] = t.Timestamp }
 		}
 		if t.Platform != "" { fp.PreferredDEX[t.Platform]++ }
 		if t.PriorityFee > 0 { fees = append(fees, t.PriorityFee) }
@@ -119,8 +119,10 @@ func (a *Analyzer) BuildKOLFingerprint(kolID int64) (*KOLFingerprint, error) {
 	if len(sellAmts) > 0 { fp.SellSize = buildSizePattern(sellAmts); a.store.UpsertPattern(kolID, "sell_pattern", fp.SellSize, len(sellAmts)) }
 	if len(fp.PreferredDEX) > 0 { fp.PreferredRouter = topKey(fp.PreferredDEX); a.store.UpsertPattern(kolID, "preferred_dex", fp.PreferredDEX, fp.TradeCount) }
 	if len(fees) > 0 { fp.GasProfile = buildGasProfile(fees); a.store.UpsertPattern(kolID, "gas_priority", fp.GasProfile, len(fees)) }
-	fp.TimingProfile = a.buildTimingProfile(kolID, allTrades, tokenFirst, tokenLastSell)
+	fp.TimingProfile = a.buildTimingProfile(kolID, allTrades, exitHoldMinutes(positions))
 	if fp.TimingProfile != nil { a.store.UpsertPattern(kolID, "timing_pattern", fp.TimingProfile, fp.TimingProfile.PreBuyCount+fp.TimingProfile.PostBuyCount) }
+	if pnl.Positions > 0 { fp.PnL = &pnl.PnLSummary }
+	if fp.SellBehavior = buildSellBehavior(positions); fp.SellBehavior != nil { a.store.UpsertPattern(kolID, "sell_behavior", fp.SellBehavior, pnl.Exited) }
 	repeatBuys := 0; for _, c := range tokenBuys { if c > 1 { repeatBuys++ } }
 	fp.TokenPrefs = &TokenPrefs{UniqueTokens: len(tokenBuys), RepeatBuyPct: safePct(repeatBuys, len(tokenBuys))}
 	a.store.UpsertPattern(kolID, "full_fingerprint", fp, fp.TradeCount)
@@ -159,7 +161,9 @@ func buildGasProfile(fees []float64) *GasProfile {
 	return g
 }
 
-func (a *Analyzer) buildTimingProfile(kolID int64, trades []db.WalletTransaction, tokenFirst, tokenLastSell map[string]time.Time) *TimingProfile {
+// buildTimingProfile relates trades to the KOL's mentions; holdTimes are the
+// FIFO lot ages (minutes) at each exit.
+func (a *Analyzer) buildTimingProfile(kolID int64, trades []db.WalletTransaction, holdTimes []float64) *TimingProfile {
 	mentions, _ := a.store.GetRecentTokenMentions(720)
 	if len(mentions) == 0 { return nil }
 	var preDiffs, postDiffs []float64
@@ -177,14 +181,17 @@ func (a *Analyzer) buildTimingProfile(kolID int64, trades []db.WalletTransaction
 	tp := &TimingProfile{PreBuyCount: len(preDiffs), PostBuyCount: len(postDiffs), PreBuyPct: safePct(len(preDiffs), total), DayOfWeek: dw}
 	if len(preDiffs) > 0 { sort.Float64s(preDiffs); tp.AvgPreBuySec = avg(preDiffs); tp.MedianPreBuySec = preDiffs[len(preDiffs)/2] }
 	if len(postDiffs) > 0 { sort.Float64s(postDiffs); tp.AvgPostBuySec = avg(postDiffs); tp.MedianPostBuySec = postDiffs[len(postDiffs)/2] }
-	var holdTimes []float64
-	for tok, fb := range tokenFirst { if ls, ok := tokenLastSell[tok]; ok { h := ls.Sub(fb).Minutes(); if h > 0 && h < 43200 { holdTimes = append(holdTimes, h) } } }
 	if len(holdTimes) > 0 { sort.Float64s(holdTimes); tp.AvgHoldMin = avg(holdTimes); tp.MedianHoldMin = holdTimes[len(holdTimes)/2] }
 	return tp
 }
 
 func (a *Analyzer) ScoreWashCandidate(kolID int64, address string, chain config.Chain) (*db.WashScore, error) {
 	ws := &db.WashScore{Address: address, Chain: chain, Signals: map[string]interface{}{}}
+	links, _ := a.store.GetWalletLinks(address, chain)
+	for _, l := range links {
+		// confirmed own wallet of this KOL: it matches the fingerprint by construction
+		if l.KOLID == kolID && l.Confidence >= 0.9 && l.Label != "wash_suspected" { ws.Signals["own_wallet"] = l.Confidence; return ws, nil }
+	}
 	patterns, _ := a.store.GetPatternsForKOL(kolID)
 	pm := map[string]string{}; for _, p := range patterns { pm[p.PatternType] = p.PatternData }
 	score := 0.0
@@ -205,6 +212,8 @@ func (a *Analyzer) ScoreWashCandidate(kolID int64, address string, chain config.
 	if s, sig := a.scoreFunding(address, chain); s > 0 { score += s; ws.Signals["funding"] = sig }
 	// 7-Wallet age
 	if s, sig := a.scoreAge(address, chain); s > 0 { score += s; ws.Signals["age"] = sig }
+	// 8-Shared with other KOLs
+	if s, sig := scoreShared(kolID, links, a.cfg.Current().Weights.SharedWithOtherKOL); s > 0 { score += s; ws.Signals["shared"] = sig }
 	ws.TotalScore = math.Min(score, 1.0)
 	sigs := map[string]bool{}
 	if _, ok := ws.Signals["token_overlap"]; ok { sigs["bought_same_token"] = true }
@@ -212,7 +221,7 @@ func (a *Analyzer) ScoreWashCandidate(kolID int64, address string, chain config.
 	if _, ok := ws.Signals["amount"]; ok { sigs["amount_pattern_match"] = true }
 	if _, ok := ws.Signals["gas"]; ok { sigs["bot_signature_match"] = true }
 	a.store.UpdateWashScore(address, chain, ws.TotalScore, sigs)
-	if ws.TotalScore >= a.cfg.WashWalletMinScore {
+	if ws.TotalScore >= a.cfg.Current().WashWalletMinScore {
 		sev := "info"; if ws.TotalScore >= 0.7 { sev = "critical" } else if ws.TotalScore >= 0.5 { sev = "warning" }
 		sj, _ := json.Marshal(ws.Signals)
 		a.store.InsertAlert(kolID, "wash_wallet", sev, fmt.Sprintf("Wash wallet: %s (%.0f%%)", abbrev(address), ws.TotalScore*100), string(sj), address, "")
@@ -220,13 +229,22 @@ func (a *Analyzer) ScoreWashCandidate(kolID int64, address string, chain config.
 	return ws, nil
 }
 
+// scoreShared weights links to other KOLs by their confidence: one address
+// used across several KOLs of a group is a strong insider-cluster tell.
+func scoreShared(kolID int64, links []db.WalletKOLLink, weight float64) (float64, map[string]interface{}) {
+	var kols []int64; best := 0.0
+	for _, l := range links { if l.KOLID != kolID { kols = append(kols, l.KOLID); best = math.Max(best, l.Confidence) } }
+	if len(kols) == 0 { return 0, nil }
+	return weight * best, map[string]interface{}{"kols": kols, "max_confidence": best}
+}
+
 func (a *Analyzer) scoreTokenOverlap(kolID int64, addr string) (float64, map[string]interface{}) {
 	mentions, _ := a.store.GetRecentTokenMentions(168); kt := map[string]bool{}
 	for _, m := range mentions { if m.KOLID == kolID && m.TokenAddress != "" { kt[m.TokenAddress] = true } }
 	buys, _ := a.store.GetBuyTransactionsForAddress(addr); o := 0
 	for _, b := range buys { if kt[b.TokenAddress] { o++ } }
 	if o == 0 { return 0, nil }
-	return math.Min(float64(o)*0.1, 0.3), map[string]interface{}{"overlap": o}
+	w := a.cfg.Current().Weights; return math.Min(float64(o)*w.TokenOverlapPerToken, w.TokenOverlapMax), map[string]interface{}{"overlap": o}
 }
 
 func (a *Analyzer) scoreTimingCorr(kolID int64, addr string) (float64, map[string]interface{}) {
@@ -235,7 +253,7 @@ func (a *Analyzer) scoreTimingCorr(kolID int64, addr string) (float64, map[strin
 	buys, _ := a.store.GetBuyTransactionsForAddress(addr); corr, tot := 0, 0
 	for _, b := range buys { if times, ok := mt[b.TokenAddress]; ok { tot++; for _, t := range times { if math.Abs(b.Timestamp.Sub(t).Seconds()) < 7200 { corr++; break } } } }
 	if tot == 0 || safePct(corr, tot) < 50 { return 0, nil }
-	return 0.2, map[string]interface{}{"pct": safePct(corr, tot)}
+	return a.cfg.Current().Weights.Timing, map[string]interface{}{"pct": safePct(corr, tot)}
 }
 
 func (a *Analyzer) scoreAmount(addr string, kp *SizePattern) (float64, map[string]interface{}) {
@@ -245,14 +263,14 @@ func (a *Analyzer) scoreAmount(addr string, kp *SizePattern) (float64, map[strin
 	d := math.Abs(avg(amts)-kp.Mean) / kp.Mean * 100
 	cm := 0; for _, a := range amts { for _, ka := range kp.CommonAmounts { if ka.Amount > 0 && math.Abs(a-ka.Amount)/ka.Amount < 0.15 { cm++; break } } }
 	if d > 30 && cm < 2 { return 0, nil }
-	return 0.15, map[string]interface{}{"diff_pct": d, "common": cm}
+	return a.cfg.Current().Weights.Amount, map[string]interface{}{"diff_pct": d, "common": cm}
 }
 
 func (a *Analyzer) scoreDEX(addr string, kd map[string]int) (float64, map[string]interface{}) {
 	buys, _ := a.store.GetBuyTransactionsForAddress(addr); cd := map[string]int{}
 	for _, b := range buys { if b.Platform != "" { cd[b.Platform]++ } }
 	if len(cd) == 0 || topKey(kd) != topKey(cd) { return 0, nil }
-	return 0.1, map[string]interface{}{"dex": topKey(kd)}
+	return a.cfg.Current().Weights.DEX, map[string]interface{}{"dex": topKey(kd)}
 }
 
 func (a *Analyzer) scoreGas(addr string, kp *GasProfile) (float64, map[string]interface{}) {
@@ -261,17 +279,17 @@ func (a *Analyzer) scoreGas(addr string, kp *GasProfile) (float64, map[string]in
 	if len(fees) == 0 || kp.AvgFee == 0 { return 0, nil }
 	d := math.Abs(avg(fees)-kp.AvgFee) / kp.AvgFee * 100
 	if d > 20 { return 0, nil }
-	return 0.15, map[string]interface{}{"diff_pct": d}
+	return a.cfg.Current().Weights.Gas, map[string]interface{}{"diff_pct": d}
 }
 
 func (a *Analyzer) scoreFunding(addr string, chain config.Chain) (float64, map[string]interface{}) {
-	cs, _ := a.store.GetWashCandidates(0.0)
+	cs, _ := a.store.GetWashCandidates(0.0); w := a.cfg.Current().Weights
 	for _, c := range cs { if c.Address == addr && c.Chain == chain {
 		switch c.FundingSourceType {
-		case "fixedfloat": return 0.30, map[string]interface{}{"type": "fixedfloat", "amount": c.FundingAmount}
-		case "bridge": return 0.20, map[string]interface{}{"type": "bridge"}
-		case "mixer": return 0.35, map[string]interface{}{"type": "mixer"}
-		case "swap_service": return 0.25, map[string]interface{}{"type": "swap_service"}
+		case "fixedfloat": return w.FundingFixedFloat, map[string]interface{}{"type": "fixedfloat", "amount": c.FundingAmount}
+		case "bridge": return w.FundingBridge, map[string]interface{}{"type": "bridge"}
+		case "mixer": return w.FundingMixer, map[string]interface{}{"type": "mixer"}
+		case "swap_service": return w.FundingSwapService, map[string]interface{}{"type": "swap_service"}
 		}
 	}}; return 0, nil
 }
@@ -280,8 +298,8 @@ func (a *Analyzer) scoreAge(addr string, chain config.Chain) (float64, map[strin
 	cs, _ := a.store.GetWashCandidates(0.0)
 	for _, c := range cs { if c.Address == addr && c.Chain == chain && !c.FirstSeen.IsZero() {
 		age := time.Since(c.FirstSeen)
-		if age < 24*time.Hour { return 0.2, map[string]interface{}{"hours": age.Hours()} }
-		if age < 7*24*time.Hour { return 0.1, map[string]interface{}{"days": age.Hours() / 24} }
+		if age < 24*time.Hour { return a.cfg.Current().Weights.AgeUnderDay, map[string]interface{}{"hours": age.Hours()} }
+		if age < 7*24*time.Hour { return a.cfg.Current().Weights.AgeUnderWeek, map[string]interface{}{"days": age.Hours() / 24} }
 	}}; return 0, nil
 }
 
@@ -0,0 +1,427 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// markBudget bounds how long one KOL pass spends pricing open positions;
+// whatever is not priced in time is marked at its last trade price.
+const markBudget = 30 * time.Second
+
+// dustHeld is the share of a position's bought amount below which what is
+// left counts as sold out (fee-on-transfer tokens and rounding leave crumbs).
+const dustHeld = 1e-6
+
+// PriceFunc returns the USD price of token on chain at time at.
+type PriceFunc func(ctx context.Context, chain config.Chain, token string, at time.Time) (float64, error)
+
+// SetPricer marks open positions at a live price instead of the last trade.
+func (a *Analyzer) SetPricer(fn PriceFunc) {
+	a.price = fn
+}
+
+// PnLSummary totals a set of positions. AvgProfitPct is the return on the
+// cost basis of what was sold, averaged over positions with exits; WinRatePct
+// is over positions with a priced exit. UnpricedExits counts sells left out
+// of realized PnL because they, or the lots they consumed, have no price.
+type PnLSummary struct {
+	Positions     int     `json:"positions"`
+	Open          int     `json:"open"`
+	Exited        int     `json:"exited"`
+	CostUSD       float64 `json:"cost_usd"`
+	ProceedsUSD   float64 `json:"proceeds_usd"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	WinRatePct    float64 `json:"win_rate_pct"`
+	AvgProfitPct  float64 `json:"avg_profit_pct"`
+	AvgExitChunks float64 `json:"avg_exit_chunks"`
+	UnpricedExits int     `json:"unpriced_exits,omitempty"`
+}
+
+type WalletPnL struct {
+	WalletID int64        `json:"wallet_id"`
+	Address  string       `json:"address"`
+	Chain    config.Chain `json:"chain"`
+	PnLSummary
+}
+
+// CallPnL is what a KOL's wallets made on one token they called: the entry
+// held going into the mention and what was sold into the buyers after it.
+type CallPnL struct {
+	TokenAddress   string       `json:"token_address"`
+	TokenSymbol    string       `json:"token_symbol"`
+	Chain          config.Chain `json:"chain"`
+	MentionedAt    time.Time    `json:"mentioned_at"`
+	PreCallCostUSD float64      `json:"pre_call_cost_usd"`
+	PostCallBuyUSD float64      `json:"post_call_buy_usd"`
+	ExitUSD        float64      `json:"exit_usd"`
+	ExtractedUSD   float64      `json:"extracted_usd"` // realized PnL of exits after the mention
+	UnrealizedPnL  float64      `json:"unrealized_pnl"`
+	PctSold        float64      `json:"pct_sold"` // of the pre-call entry
+}
+
+type KOLPnL struct {
+	KOLID int64 `json:"kol_id"`
+	PnLSummary
+	Wallets []WalletPnL `json:"wallets"`
+	Calls   []CallPnL   `json:"calls,omitempty"`
+}
+
+// BuildPositions rebuilds and stores the position ledger of every wallet of a
+// KOL and saves the KOL's totals and per-call extraction as the "pnl" pattern.
+func (a *Analyzer) BuildPositions(kolID int64) (*KOLPnL, error) {
+	wallets, err := a.store.GetWalletsForKOL(kolID)
+	if err != nil {
+		return nil, err
+	}
+	pnl, _ := a.buildPositions(kolID, wallets)
+	return pnl, nil
+}
+
+// buildPositions stores positions for all wallets but, like the fingerprint,
+// leaves shared and wash-suspected ones out of the KOL totals. It returns the
+// positions that were counted.
+func (a *Analyzer) buildPositions(kolID int64, wallets []db.TrackedWallet) (*KOLPnL, []db.Position) {
+	ctx, cancel := context.WithTimeout(context.Background(), markBudget)
+	defer cancel()
+	out := &KOLPnL{KOLID: kolID}
+	var counted []db.Position
+	swapsByToken := map[string][]db.WalletTransaction{}
+	for _, w := range wallets {
+		swaps, err := a.store.GetSwapsForWallet(w.ID)
+		if err != nil {
+			log.Warn().Err(err).Int64("wallet", w.ID).Msg("load swaps")
+			continue
+		}
+		ledgers := replayFIFO(swaps)
+		positions := a.mark(ctx, ledgers)
+		if err := a.store.ReplacePositions(w.ID, positions); err != nil {
+			log.Warn().Err(err).Int64("wallet", w.ID).Msg("store positions")
+		}
+		if w.Confidence < 0.5 || w.Label == "wash_suspected" || len(positions) == 0 {
+			continue
+		}
+		out.Wallets = append(out.Wallets, WalletPnL{WalletID: w.ID, Address: w.Address, Chain: w.Chain, PnLSummary: summarize(positions)})
+		counted = append(counted, positions...)
+		for _, t := range swaps {
+			swapsByToken[t.TokenAddress] = append(swapsByToken[t.TokenAddress], t)
+		}
+	}
+	out.PnLSummary = summarize(counted)
+	out.Calls = a.callPnL(kolID, counted, swapsByToken)
+	if out.Positions > 0 {
+		a.store.UpsertPattern(kolID, "pnl", out, out.Positions)
+	}
+	return out, counted
+}
+
+// lot is one buy still held. An unpriced lot (bought without a USD value)
+// has no cost basis, so the sells that consume it can't realize PnL.
+type lot struct {
+	qty, cost float64
+	at        time.Time
+	unpriced  bool
+}
+
+// ledger is a position being replayed: its open lots, oldest first, and the
+// price of its latest trade as a fallback mark.
+type ledger struct {
+	pos  db.Position
+	lots []lot
+	last float64
+}
+
+// replayFIFO runs a wallet's swaps, oldest first, through FIFO lots, one
+// ledger per chain and token. Sells beyond the bought amount (airdrops,
+// tokens received by transfer) have no cost basis. Sells without a USD
+// value, or that consume a lot bought without one, still consume their lots
+// but are marked unpriced and add nothing to realized PnL.
+func replayFIFO(swaps []db.WalletTransaction) []*ledger {
+	byKey := map[string]*ledger{}
+	var order []*ledger
+	for _, t := range swaps {
+		if t.TokenAddress == "" || t.AmountToken <= 0 {
+			continue
+		}
+		key := string(t.Chain) + ":" + t.TokenAddress
+		l := byKey[key]
+		if l == nil {
+			l = &ledger{pos: db.Position{WalletID: t.WalletID, Chain: t.Chain, TokenAddress: t.TokenAddress}}
+			byKey[key] = l
+			order = append(order, l)
+		}
+		p := &l.pos
+		if t.TokenSymbol != "" {
+			p.TokenSymbol = t.TokenSymbol
+		}
+		if t.AmountUSD > 0 {
+			l.last = t.AmountUSD / t.AmountToken
+		}
+		p.LastTradeAt = t.Timestamp
+		switch t.TxType {
+		case "swap_buy":
+			p.Buys++
+			p.Bought += t.AmountToken
+			p.CostUSD += t.AmountUSD
+			if p.FirstBuyAt.IsZero() {
+				p.FirstBuyAt = t.Timestamp
+			}
+			l.lots = append(l.lots, lot{qty: t.AmountToken, cost: t.AmountUSD, at: t.Timestamp, unpriced: t.AmountUSD <= 0})
+		case "swap_sell":
+			held := l.held()
+			ex := db.PositionExit{TxHash: t.TxHash, At: t.Timestamp, Amount: t.AmountToken, USD: t.AmountUSD, PctSold: 100}
+			if held > 0 {
+				ex.PctSold = math.Min(t.AmountToken/held, 1) * 100
+			}
+			left, matched, ageSum := t.AmountToken, 0.0, 0.0
+			for left > 0 && len(l.lots) > 0 {
+				lt := &l.lots[0]
+				take := math.Min(left, lt.qty)
+				part := lt.cost * take / lt.qty
+				ex.CostUSD += part
+				lt.qty -= take
+				lt.cost -= part
+				left -= take
+				matched += take
+				ageSum += take * t.Timestamp.Sub(lt.at).Seconds()
+				ex.Unpriced = ex.Unpriced || lt.unpriced
+				if lt.qty <= 0 {
+					l.lots = l.lots[1:]
+				}
+			}
+			if matched > 0 {
+				ex.HoldSec = ageSum / matched
+			}
+			p.Sold += t.AmountToken
+			if t.AmountUSD <= 0 {
+				ex.Unpriced = true
+			}
+			if !ex.Unpriced {
+				p.ProceedsUSD += t.AmountUSD
+				p.RealizedPnL += t.AmountUSD - ex.CostUSD
+			}
+			p.Exits = append(p.Exits, ex)
+			if l.held() <= p.Bought*dustHeld {
+				l.lots = nil
+			}
+		}
+	}
+	return order
+}
+
+func (l *ledger) held() float64 {
+	h := 0.0
+	for _, lt := range l.lots {
+		h += lt.qty
+	}
+	return h
+}
+
+// mark settles the open amount of each ledger and values it at the live
+// price when a pricer is set and answers in time, else at the last trade.
+// Unpriced lots count as held but not towards unrealized PnL.
+func (a *Analyzer) mark(ctx context.Context, ledgers []*ledger) []db.Position {
+	now := time.Now().UTC()
+	out := make([]db.Position, 0, len(ledgers))
+	for _, l := range ledgers {
+		p := l.pos
+		p.UpdatedAt = now
+		priced := 0.0
+		for _, lt := range l.lots {
+			p.Held += lt.qty
+			p.OpenCostUSD += lt.cost
+			if !lt.unpriced {
+				priced += lt.qty
+			}
+		}
+		if p.Held > 0 {
+			price := l.last
+			if a.price != nil && ctx.Err() == nil {
+				if px, err := a.price(ctx, p.Chain, p.TokenAddress, now); err == nil && px > 0 {
+					price = px
+				}
+			}
+			if price > 0 {
+				p.UnrealizedPnL = priced*price - p.OpenCostUSD
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func summarize(positions []db.Position) PnLSummary {
+	var s PnLSummary
+	var profitPcts []float64
+	wins, priced, chunks := 0, 0, 0
+	for _, p := range positions {
+		s.Positions++
+		if p.Held > 0 {
+			s.Open++
+		}
+		s.CostUSD += p.CostUSD
+		s.ProceedsUSD += p.ProceedsUSD
+		s.RealizedPnL += p.RealizedPnL
+		s.UnrealizedPnL += p.UnrealizedPnL
+		if len(p.Exits) == 0 {
+			continue
+		}
+		s.Exited++
+		chunks += len(p.Exits)
+		hasPriced := false
+		for _, e := range p.Exits {
+			if e.Unpriced {
+				s.UnpricedExits++
+			} else {
+				hasPriced = true
+			}
+		}
+		if !hasPriced {
+			continue
+		}
+		priced++
+		if p.RealizedPnL > 0 {
+			wins++
+		}
+		if pct, ok := exitReturnPct(p); ok {
+			profitPcts = append(profitPcts, pct)
+		}
+	}
+	s.WinRatePct = safePct(wins, priced)
+	s.AvgProfitPct = avg(profitPcts)
+	if s.Exited > 0 {
+		s.AvgExitChunks = float64(chunks) / float64(s.Exited)
+	}
+	return s
+}
+
+// exitReturnPct is the return of a position's priced sells on the basis
+// they consumed; false when nothing sold had a known cost.
+func exitReturnPct(p db.Position) (float64, bool) {
+	usd, cost := 0.0, 0.0
+	for _, e := range p.Exits {
+		if e.Unpriced {
+			continue
+		}
+		usd += e.USD
+		cost += e.CostUSD
+	}
+	if cost <= 0 {
+		return 0, false
+	}
+	return (usd - cost) / cost * 100, true
+}
+
+// buildSellBehavior describes how positions are exited: how many sells per
+// position, the share sold per sell (rounded to 5%), and the return.
+func buildSellBehavior(positions []db.Position) *SellBehavior {
+	s := summarize(positions)
+	if s.Exited == 0 {
+		return nil
+	}
+	pcts := map[float64]int{}
+	for _, p := range positions {
+		for _, e := range p.Exits {
+			pcts[math.Max(math.Round(e.PctSold/5)*5, 5)]++
+		}
+	}
+	var common []CommonAmount
+	for pct, c := range pcts {
+		if c >= 2 {
+			common = append(common, CommonAmount{pct, c})
+		}
+	}
+	sort.Slice(common, func(i, j int) bool { return common[i].Count > common[j].Count })
+	sb := &SellBehavior{AvgChunks: s.AvgExitChunks, AvgProfitPct: s.AvgProfitPct}
+	for i, c := range common {
+		if i == 5 {
+			break
+		}
+		sb.CommonSellPcts = append(sb.CommonSellPcts, c.Amount)
+	}
+	return sb
+}
+
+// exitHoldMinutes returns how long the lots behind each exit were held.
+func exitHoldMinutes(positions []db.Position) []float64 {
+	var out []float64
+	for _, p := range positions {
+		for _, e := range p.Exits {
+			if h := e.HoldSec / 60; h > 0 && h < 43200 {
+				out = append(out, h)
+			}
+		}
+	}
+	return out
+}
+
+// callPnL splits each token the KOL mentioned in the last 30 days at its
+// first mention: what their wallets bought before it, and what they sold
+// into it afterwards.
+func (a *Analyzer) callPnL(kolID int64, positions []db.Position, swapsByToken map[string][]db.WalletTransaction) []CallPnL {
+	mentions, _ := a.store.GetRecentTokenMentions(720)
+	first := map[string]db.TokenMention{}
+	for _, m := range mentions {
+		if m.KOLID != kolID || m.TokenAddress == "" || m.MentionedAt.IsZero() {
+			continue
+		}
+		if f, ok := first[m.TokenAddress]; !ok || m.MentionedAt.Before(f.MentionedAt) {
+			first[m.TokenAddress] = m
+		}
+	}
+	var calls []CallPnL
+	for tok, m := range first {
+		swaps := swapsByToken[tok]
+		if len(swaps) == 0 {
+			continue
+		}
+		c := CallPnL{TokenAddress: tok, TokenSymbol: m.TokenSymbol, Chain: m.Chain, MentionedAt: m.MentionedAt}
+		preQty, soldQty := 0.0, 0.0
+		for _, t := range swaps {
+			if t.TxType != "swap_buy" {
+				continue
+			}
+			if t.Timestamp.Before(m.MentionedAt) {
+				c.PreCallCostUSD += t.AmountUSD
+				preQty += t.AmountToken
+			} else {
+				c.PostCallBuyUSD += t.AmountUSD
+			}
+		}
+		for _, p := range positions {
+			if p.TokenAddress != tok {
+				continue
+			}
+			if c.TokenSymbol == "" {
+				c.TokenSymbol = p.TokenSymbol
+			}
+			c.Chain = p.Chain
+			c.UnrealizedPnL += p.UnrealizedPnL
+			for _, e := range p.Exits {
+				if e.At.Before(m.MentionedAt) {
+					continue
+				}
+				soldQty += e.Amount
+				if e.Unpriced {
+					continue
+				}
+				c.ExitUSD += e.USD
+				c.ExtractedUSD += e.USD - e.CostUSD
+			}
+		}
+		if preQty > 0 {
+			c.PctSold = math.Min(soldQty/preQty, 1) * 100
+		}
+		calls = append(calls, c)
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].ExtractedUSD > calls[j].ExtractedUSD })
+	return calls
+}
@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+func TestReplayFIFO(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	swap := func(kind string, qty, usd float64, mins int) db.WalletTransaction {
+		return db.WalletTransaction{WalletID: 1, Chain: config.ChainSolana, TokenAddress: "TOKEN", TxType: kind,
+			TxHash: fmt.Sprintf("%s-%d", kind, mins), AmountToken: qty, AmountUSD: usd,
+			Timestamp: t0.Add(time.Duration(mins) * time.Minute)}
+	}
+	tests := []struct {
+		name         string
+		swaps        []db.WalletTransaction
+		wantRealized float64
+		wantHeld     float64
+		wantOpenCost float64
+		wantExits    int
+		wantUnpriced int
+		wantWinRate  float64
+	}{
+		{
+			name:         "buy only",
+			swaps:        []db.WalletTransaction{swap("swap_buy", 100, 50, 0)},
+			wantHeld:     100,
+			wantOpenCost: 50,
+		},
+		{
+			name: "sells consume oldest lots first",
+			swaps: []db.WalletTransaction{
+				swap("swap_buy", 100, 100, 0),  // $1.00
+				swap("swap_buy", 100, 200, 1),  // $2.00
+				swap("swap_sell", 150, 450, 2), // $3.00: 100 @1 + 50 @2 = $200 basis
+			},
+			wantRealized: 250, wantHeld: 50, wantOpenCost: 100, wantExits: 1, wantWinRate: 100,
+		},
+		{
+			name: "sell beyond bought has no basis",
+			swaps: []db.WalletTransaction{
+				swap("swap_buy", 10, 10, 0),
+				swap("swap_sell", 20, 40, 1),
+			},
+			wantRealized: 30, wantExits: 1, wantWinRate: 100,
+		},
+		{
+			name: "unpriced sell consumes lots without realizing a loss",
+			swaps: []db.WalletTransaction{
+				swap("swap_buy", 100, 100, 0),
+				swap("swap_sell", 50, 0, 1),
+				swap("swap_sell", 50, 150, 2),
+			},
+			wantRealized: 100, wantExits: 2, wantUnpriced: 1, wantWinRate: 100,
+		},
+		{
+			name: "sell of an unpriced buy realizes nothing",
+			swaps: []db.WalletTransaction{
+				swap("swap_buy", 100, 0, 0),
+				swap("swap_sell", 100, 150, 1),
+			},
+			wantExits: 1, wantUnpriced: 1,
+		},
+		{
+			name: "only the sell reaching the unpriced lot is left out",
+			swaps: []db.WalletTransaction{
+				swap("swap_buy", 100, 100, 0),
+				swap("swap_buy", 100, 0, 1),
+				swap("swap_sell", 100, 300, 2),
+				swap("swap_sell", 50, 150, 3),
+			},
+			wantRealized: 200, wantHeld: 50, wantExits: 2, wantUnpriced: 1, wantWinRate: 100,
+		},
+		{
+			name: "dust left after selling out is dropped",
+			swaps: []db.WalletTransaction{
+				swap("swap_buy", 1000, 100, 0),
+				swap("swap_sell", 999.9999999, 200, 1),
+			},
+			wantRealized: 100, wantExits: 1, wantWinRate: 100,
+		},
+	}
+	a := &Analyzer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledgers := replayFIFO(tt.swaps)
+			if len(ledgers) != 1 {
+				t.Fatalf("%d ledgers, want 1", len(ledgers))
+			}
+			p := a.mark(context.Background(), ledgers)[0]
+			if !near(p.RealizedPnL, tt.wantRealized) {
+				t.Errorf("realized = %v, want %v", p.RealizedPnL, tt.wantRealized)
+			}
+			if !near(p.Held, tt.wantHeld) || !near(p.OpenCostUSD, tt.wantOpenCost) {
+				t.Errorf("held %v at cost %v, want %v at %v", p.Held, p.OpenCostUSD, tt.wantHeld, tt.wantOpenCost)
+			}
+			if len(p.Exits) != tt.wantExits {
+				t.Fatalf("%d exits, want %d", len(p.Exits), tt.wantExits)
+			}
+			sum := summarize([]db.Position{p})
+			if sum.UnpricedExits != tt.wantUnpriced {
+				t.Errorf("unpriced exits = %d, want %d", sum.UnpricedExits, tt.wantUnpriced)
+			}
+			if sum.WinRatePct != tt.wantWinRate {
+				t.Errorf("win rate = %v, want %v", sum.WinRatePct, tt.wantWinRate)
+			}
+		})
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
@@ -0,0 +1,524 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChainInfo is everything chain-specific the tracker needs. The built-in
+// chains are listed in builtinChains; more EVM chains (or overrides of the
+// built-in fields) come from the config file's chains section.
+type ChainInfo struct {
+	Chain         Chain         `json:"chain"`
+	EVM           bool          `json:"evm"`
+	ChainID       int64         `json:"chain_id,omitempty"`
+	NativeSymbol  string        `json:"native_symbol"`
+	WrappedNative string        `json:"wrapped_native,omitempty"`
+	ExplorerAPI   string        `json:"explorer_api,omitempty"` // Etherscan-compatible API base
+	DefaultRPC    string        `json:"-"`
+	BlockTime     time.Duration `json:"block_time"`
+	GeckoNetwork  string        `json:"gecko_network,omitempty"` // GeckoTerminal network id
+	DexScreener   string        `json:"dexscreener,omitempty"`   // DexScreener chain id
+	// FallbackPrice is the native coin's USD price used when every price
+	// source is down.
+	FallbackPrice float64 `json:"-"`
+	// Env vars that override the RPC, WebSocket and explorer key; derived
+	// from the chain name (<NAME>_RPC_URL, ...) when empty.
+	RPCEnv, WSEnv, ExplorerKeyEnv string   `json:"-"`
+	Stablecoins                   []string `json:"stablecoins,omitempty"`
+	FixedFloat                    []string `json:"-"` // instant-exchange hot wallets
+	// Bridges maps bridge contracts and programs to their protocol: the
+	// ones funds arrive from and the emitters of bridge messages (Wormhole
+	// core). Canonical rollup bridges are "native:<rollup>"; "" is a bridge
+	// no decoder knows.
+	Bridges map[string]string `json:"-"`
+	// BridgeIDs are the ids bridge protocols use for the chain where they
+	// differ from ChainID: "wormhole" (also Mayan), "layerzero" (v2
+	// endpoint ids), "debridge" and "across".
+	BridgeIDs map[string]int64 `json:"bridge_ids,omitempty"`
+}
+
+var builtinChains = []ChainInfo{
+	{
+		Chain: ChainSolana, NativeSymbol: "SOL", WrappedNative: "So11111111111111111111111111111111111111112",
+		DefaultRPC: "https://api.mainnet-beta.solana.com", BlockTime: 400 * time.Millisecond,
+		GeckoNetwork: "solana", DexScreener: "solana", FallbackPrice: 150, RPCEnv: "SOLANA_RPC_URL", WSEnv: "SOLANA_WS_URL",
+		Stablecoins: []string{
+			"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", // USDC
+			"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB", // USDT
+		},
+		FixedFloat: []string{
+			"FFixpaKkNRRKmRD1tFGqFrMBF26gKiNaaTPfbSdrFETS", // FixedFloat Solana hot wallet
+			"FFSoLNFqJZuxyaqGG1GXMEfLEVf5pGAfRqVAWfTormYr", // FixedFloat Solana secondary
+		},
+		Bridges: map[string]string{
+			"worm2ZoG2kUd4vFXhvjh93UUH596ayRfgQ2MgjNMTth":  "wormhole", // Wormhole core
+			"wormDTUJ6AWPNvk59vGQbDvGJmqbDTdgWgAqcLBCgUb":  "wormhole", // Wormhole token bridge
+			"src5qyZHqTqecJV4aY6Cb6zDZLMDzrDKKezs22MPHr4":  "debridge", // deBridge DLN source
+			"dst5MGcFPoBeREFAA5E3tU5ij8m5uVYwkzkSAbsLbNo":  "debridge", // deBridge DLN destination
+			"BLZRi6frs4X4DNLw56V4EXai1b6QVESN1BhHBTYM9VcY": "mayan",    // Mayan Swift
+			"FC4eXxkyrMPTjiYUpp4EAnkmwMbQyZ6NDCh1kfLn6vsf": "mayan",    // Mayan forwarder
+		},
+		BridgeIDs: map[string]int64{"wormhole": 1, "layerzero": 30168, "debridge": 7565164, "across": 34268394551451},
+	},
+	{
+		Chain: ChainEthereum, EVM: true, ChainID: 1, NativeSymbol: "ETH", WrappedNative: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+		ExplorerAPI: "https://api.etherscan.io/api", DefaultRPC: "https://eth.llamarpc.com", BlockTime: 12 * time.Second,
+		GeckoNetwork: "eth", DexScreener: "ethereum", FallbackPrice: 2500,
+		RPCEnv: "ETH_RPC_URL", WSEnv: "ETH_WS_URL", ExplorerKeyEnv: "ETHERSCAN_API_KEY",
+		Stablecoins: []string{
+			"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", // USDC
+			"0xdac17f958d2ee523a2206206994597c13d831ec7", // USDT
+			"0x6b175474e89094c44da98b954eedeac495271d0f", // DAI
+		},
+		FixedFloat: []string{
+			"0x4E5B2e1dc63F6b91cb6Cd759936495434C7e972F", // FixedFloat ETH hot wallet
+			"0xf1dA173228fcf015F43f3eA15aBBB51f0d8f1123", // FixedFloat ETH secondary
+			"0x36928500Bc1dCd7af6a2B4008875CC336b927D57", // ChangeNow hot wallet
+			"0x0D0707963952f2fBA59dD06f2b425ace40b492Fe", // SimpleSwap
+		},
+		Bridges: map[string]string{
+			"0x98f3c9e6e3face36baad05fe09d375ef1464288b": "wormhole",    // Wormhole core
+			"0x3ee18B2214AFF97000D974cf647E7C347E8fa585": "wormhole",    // Wormhole token bridge
+			"0x4D73AdB72bC3DD368966edD0f0b2148401A178E2": "",            // unlabelled bridge: no decoder, left to the funding trace
+			"0x5c7BCd6E7De5423a257D81B442095A1a6ced35C5": "across",      // Across spoke pool
+			"0x77b2043768d28E9C9aB44E1aBfC95944bcE57931": "stargate",    // Stargate ETH pool
+			"0xc026395860Db2d07ee33e05fE50ed7bD583189C7": "stargate",    // Stargate USDC pool
+			"0x49048044d57e1c92a77f79988d21fa8faf74e97e": "native:base", // Base portal
+		},
+		BridgeIDs: map[string]int64{"wormhole": 2, "layerzero": 30101},
+	},
+	{
+		Chain: ChainBase, EVM: true, ChainID: 8453, NativeSymbol: "ETH", WrappedNative: "0x4200000000000000000000000000000000000006",
+		ExplorerAPI: "https://api.basescan.org/api", DefaultRPC: "https://mainnet.base.org", BlockTime: 2 * time.Second,
+		GeckoNetwork: "base", DexScreener: "base", FallbackPrice: 2500,
+		RPCEnv: "BASE_RPC_URL", WSEnv: "BASE_WS_URL", ExplorerKeyEnv: "BASESCAN_API_KEY",
+		Stablecoins: []string{"0x833589fcd6edb6e08f4c7c32d4f71b54bda02913"}, // USDC
+		FixedFloat:  []string{"0x4E5B2e1dc63F6b91cb6Cd759936495434C7e972F"}, // FixedFloat Base (same address)
+		Bridges: map[string]string{
+			"0x4200000000000000000000000000000000000010": "native:base", // Base Bridge
+			"0xbebdb6c8ddc678ffa9f8748f85c815c556dd8ac6": "wormhole",    // Wormhole core
+			"0x8d2de8d2f73F1F4cAB472AC9A881C9b123C79627": "wormhole",    // Wormhole token bridge
+			"0x09aea4b2242abC8bb4BB78D537A67a245A7bEC64": "across",      // Across spoke pool
+			"0xdc181Bd607330aeeBEF6ea62e03e5e1Fb4B6F7C7": "stargate",    // Stargate ETH pool
+		},
+		BridgeIDs: map[string]int64{"wormhole": 30, "layerzero": 30184},
+	},
+	{
+		Chain: ChainBSC, EVM: true, ChainID: 56, NativeSymbol: "BNB", WrappedNative: "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c",
+		ExplorerAPI: "https://api.bscscan.com/api", DefaultRPC: "https://bsc-dataseed.binance.org", BlockTime: 3 * time.Second,
+		GeckoNetwork: "bsc", DexScreener: "bsc", FallbackPrice: 300,
+		RPCEnv: "BSC_RPC_URL", WSEnv: "BSC_WS_URL", ExplorerKeyEnv: "BSCSCAN_API_KEY",
+		Stablecoins: []string{
+			"0x55d398326f99059ff775485246999027b3197955", // USDT
+			"0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d", // USDC
+			"0xe9e7cea3dedca5984780bafc599bd69add087d56", // BUSD
+		},
+		FixedFloat: []string{
+			"0x4E5B2e1dc63F6b91cb6Cd759936495434C7e972F", // FixedFloat BSC (same address)
+			"0x0D0707963952f2fBA59dD06f2b425ace40b492Fe", // SimpleSwap BSC
+		},
+		Bridges: map[string]string{
+			"0x98f3c9e6e3face36baad05fe09d375ef1464288b": "wormhole", // Wormhole core
+			"0xB6F6D86a8f9879A9c87f643768d9efc38c1Da6E7": "wormhole", // Wormhole token bridge
+			"0x4e8E101924eDE233C13e2D8622DC8aED2872d505": "across",   // Across spoke pool
+		},
+		BridgeIDs: map[string]int64{"wormhole": 4, "layerzero": 30102},
+	},
+	{
+		Chain: ChainArbitrum, EVM: true, ChainID: 42161, NativeSymbol: "ETH", WrappedNative: "0x82af49447d8a07e3bd95bd0d56f35241523fbab1",
+		ExplorerAPI: "https://api.arbiscan.io/api", DefaultRPC: "https://arb1.arbitrum.io/rpc", BlockTime: 250 * time.Millisecond,
+		GeckoNetwork: "arbitrum", DexScreener: "arbitrum", FallbackPrice: 2500, ExplorerKeyEnv: "ARBISCAN_API_KEY",
+		Stablecoins: []string{
+			"0xaf88d065e77c8cc2239327c5edb3a432268e5831", // USDC
+			"0xfd086bc7cd5c481dcc9c85ebe478a1c0b69fcbb9", // USDT
+		},
+		Bridges: map[string]string{
+			"0x5288c571fd7ad117bea99bf60fe0846c4e84f933": "native:arbitrum", // Arbitrum L2 gateway router
+			"0xe35e9842fceaca96570b734083f4a58e8f7c5f2a": "across",          // Across spoke pool
+			"0xa5f208e072434bc67592e4c49c1b991ba79bca46": "wormhole",        // Wormhole core
+			"0x0b2402144Bb366A632D14B83F244D2e0e21bD39c": "wormhole",        // Wormhole token bridge
+			"0xA45B5130f36CDcA45667738e2a258AB09f4A5f7F": "stargate",        // Stargate ETH pool
+		},
+		BridgeIDs: map[string]int64{"wormhole": 23, "layerzero": 30110},
+	},
+	{
+		Chain: ChainPolygon, EVM: true, ChainID: 137, NativeSymbol: "POL", WrappedNative: "0x0d500b1d8e8ef31e21c99d1db9a6444d3adf1270",
+		ExplorerAPI: "https://api.polygonscan.com/api", DefaultRPC: "https://polygon-rpc.com", BlockTime: 2 * time.Second,
+		GeckoNetwork: "polygon_pos", DexScreener: "polygon", FallbackPrice: 0.4, ExplorerKeyEnv: "POLYGONSCAN_API_KEY",
+		Stablecoins: []string{
+			"0x3c499c542cef5e3811e1192ce70d8cc03d5c3359", // USDC
+			"0xc2132d05d31c914a87c6611c10748aeb04b58e8f", // USDT
+		},
+		Bridges: map[string]string{
+			"0x9295ee1d8c5b022be115a2ad3c30c72e34e7f096": "across",   // Across spoke pool
+			"0x7a4b5a56256163f07b2c80a7ca55abe66c4ec4d7": "wormhole", // Wormhole core
+			"0x5a58505a96D1dbf8dF91cB21B54419FC36e93fdE": "wormhole", // Wormhole token bridge
+		},
+		BridgeIDs: map[string]int64{"wormhole": 5, "layerzero": 30109},
+	},
+	{
+		Chain: ChainAvalanche, EVM: true, ChainID: 43114, NativeSymbol: "AVAX", WrappedNative: "0xb31f66aa3c1e785363f0875a1b74e27ee85957b7",
+		ExplorerAPI: "https://api.snowtrace.io/api", DefaultRPC: "https://api.avax.network/ext/bc/C/rpc", BlockTime: 2 * time.Second,
+		GeckoNetwork: "avax", DexScreener: "avalanche", FallbackPrice: 25, ExplorerKeyEnv: "SNOWTRACE_API_KEY",
+		Stablecoins: []string{
+			"0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e", // USDC
+			"0x9702230a8ea53601f5cd2dc00fdbc13d4df4a8c7", // USDT
+		},
+		Bridges: map[string]string{
+			"0x54a8e5f9c4cba08f9943965859f6c34eaf03e26c": "wormhole", // Wormhole core
+			"0x0e082F06FF657D94310cB8cE8B0D9a04541d8052": "wormhole", // Wormhole token bridge
+		},
+		BridgeIDs: map[string]int64{"wormhole": 6, "layerzero": 30106},
+	},
+	{
+		Chain: ChainOptimism, EVM: true, ChainID: 10, NativeSymbol: "ETH", WrappedNative: "0x4200000000000000000000000000000000000006",
+		ExplorerAPI: "https://api-optimistic.etherscan.io/api", DefaultRPC: "https://mainnet.optimism.io", BlockTime: 2 * time.Second,
+		GeckoNetwork: "optimism", DexScreener: "optimism", FallbackPrice: 2500, ExplorerKeyEnv: "OPTIMISTIC_ETHERSCAN_API_KEY",
+		Stablecoins: []string{
+			"0x0b2c639c533813f4aa9d7837caf62653d097ff85", // USDC
+			"0x94b008aa00579c1307b0ef2c499ad98a8ce58e58", // USDT
+		},
+		Bridges: map[string]string{
+			"0x4200000000000000000000000000000000000010": "native:optimism", // L2 standard bridge
+			"0x6f26bf09b1c792e3228e5467807a900a503c0281": "across",          // Across spoke pool
+			"0xee91c335eab126df5fdb3797ea9d6ad93aec9722": "wormhole",        // Wormhole core
+			"0x1D68124e65faFC907325e3EDbF8c4d84499DAa8b": "wormhole",        // Wormhole token bridge
+			"0xe8CDF27AcD73a434D661C84887215F7598e7d0d3": "stargate",        // Stargate ETH pool
+		},
+		BridgeIDs: map[string]int64{"wormhole": 24, "layerzero": 30111},
+	},
+	{
+		Chain: ChainBlast, EVM: true, ChainID: 81457, NativeSymbol: "ETH", WrappedNative: "0x4300000000000000000000000000000000000004",
+		ExplorerAPI: "https://api.blastscan.io/api", DefaultRPC: "https://rpc.blast.io", BlockTime: 2 * time.Second,
+		GeckoNetwork: "blast", DexScreener: "blast", FallbackPrice: 2500, ExplorerKeyEnv: "BLASTSCAN_API_KEY",
+		Stablecoins: []string{"0x4300000000000000000000000000000000000003"}, // USDB
+		Bridges: map[string]string{
+			"0x4300000000000000000000000000000000000005": "native:blast", // L2 bridge
+			"0x2D509190Ed0172ba588407D4c2df918F955Cc6E1": "across",       // Across spoke pool
+		},
+		BridgeIDs: map[string]int64{"wormhole": 36, "layerzero": 30243},
+	},
+	{
+		// Not EVM for scanning: accounts and TRC-20 transfers come from the
+		// TronGrid REST API (ExplorerAPI); DefaultRPC is its Ethereum-style
+		// JSON-RPC, used for health checks.
+		Chain: ChainTron, NativeSymbol: "TRX", WrappedNative: "TNUC9Qb1rRpS5CbWLmNMxXBjyFoydXjWFR",
+		ExplorerAPI: "https://api.trongrid.io", DefaultRPC: "https://api.trongrid.io/jsonrpc", BlockTime: 3 * time.Second,
+		GeckoNetwork: "tron", DexScreener: "tron", FallbackPrice: 0.15, ExplorerKeyEnv: "TRONGRID_API_KEY",
+		Stablecoins: []string{
+			"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", // USDT
+			"TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8", // USDC
+		},
+		BridgeIDs: map[string]int64{"layerzero": 30420},
+	},
+	{
+		// UTXO chain served by an Esplora API (ExplorerAPI). BTC has no DEX
+		// pairs of its own, so it is priced through WBTC on Ethereum.
+		Chain: ChainBitcoin, NativeSymbol: "BTC", WrappedNative: "0x2260fac5e5542a773aa44fbcb63b8f4f73ba5c8f",
+		ExplorerAPI: "https://blockstream.info/api", BlockTime: 10 * time.Minute,
+		GeckoNetwork: "eth", DexScreener: "ethereum", FallbackPrice: 60000,
+	},
+}
+
+// chainRegistry is a set of chains in registration order. A published
+// registry is never modified: changes are made to a clone and swapped in
+// whole, so readers never see a half-registered chain.
+type chainRegistry struct {
+	order []Chain
+	info  map[Chain]*ChainInfo
+}
+
+var (
+	chainsMu sync.Mutex // serializes RegisterChain
+	registry atomic.Pointer[chainRegistry]
+	builtins *chainRegistry // builtinChains alone; each config load starts here
+)
+
+func init() {
+	builtins = &chainRegistry{info: map[Chain]*ChainInfo{}}
+	for _, ci := range builtinChains {
+		builtins.register(ci)
+	}
+	registry.Store(builtins.clone())
+}
+
+func currentRegistry() *chainRegistry {
+	return registry.Load()
+}
+
+// clone copies r deeply enough that registering into the copy leaves r as
+// it was.
+func (r *chainRegistry) clone() *chainRegistry {
+	out := &chainRegistry{order: append([]Chain(nil), r.order...), info: make(map[Chain]*ChainInfo, len(r.info))}
+	for ch, ci := range r.info {
+		c := *ci
+		c.Stablecoins = append([]string(nil), ci.Stablecoins...)
+		c.FixedFloat = append([]string(nil), ci.FixedFloat...)
+		c.Bridges = copyMap(ci.Bridges)
+		c.BridgeIDs = copyMap(ci.BridgeIDs)
+		out.info[ch] = &c
+	}
+	return out
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+var envNameRe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// RegisterChain adds a chain, or fills in fields of a known one: non-empty
+// fields of ci replace the registered values and address lists are merged.
+// The next config load replaces the registry and drops what was added here.
+func RegisterChain(ci ChainInfo) {
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	r := currentRegistry().clone()
+	r.register(ci)
+	registry.Store(r)
+}
+
+func (r *chainRegistry) register(ci ChainInfo) {
+	cur, ok := r.info[ci.Chain]
+	if !ok {
+		cur = &ChainInfo{Chain: ci.Chain}
+		r.info[ci.Chain] = cur
+		r.order = append(r.order, ci.Chain)
+	}
+	cur.EVM = cur.EVM || ci.EVM || ci.ChainID != 0
+	if ci.ChainID != 0 {
+		cur.ChainID = ci.ChainID
+	}
+	wrapped := ci.WrappedNative
+	if strings.HasPrefix(wrapped, "0x") {
+		wrapped = strings.ToLower(wrapped)
+	}
+	for _, f := range []struct {
+		dst *string
+		v   string
+	}{
+		{&cur.NativeSymbol, ci.NativeSymbol}, {&cur.WrappedNative, wrapped},
+		{&cur.ExplorerAPI, ci.ExplorerAPI}, {&cur.DefaultRPC, ci.DefaultRPC},
+		{&cur.GeckoNetwork, ci.GeckoNetwork}, {&cur.DexScreener, ci.DexScreener},
+		{&cur.RPCEnv, ci.RPCEnv}, {&cur.WSEnv, ci.WSEnv}, {&cur.ExplorerKeyEnv, ci.ExplorerKeyEnv},
+	} {
+		if f.v != "" {
+			*f.dst = f.v
+		}
+	}
+	if ci.BlockTime > 0 {
+		cur.BlockTime = ci.BlockTime
+	}
+	if ci.FallbackPrice > 0 {
+		cur.FallbackPrice = ci.FallbackPrice
+	}
+	cur.Stablecoins = appendMissing(cur.Stablecoins, ci.Stablecoins...)
+	cur.FixedFloat = appendMissing(cur.FixedFloat, ci.FixedFloat...)
+	for addr, proto := range ci.Bridges {
+		if cur.Bridges == nil {
+			cur.Bridges = map[string]string{}
+		}
+		cur.Bridges[normalizeBridgeKey(addr)] = proto
+	}
+	for proto, id := range ci.BridgeIDs {
+		if cur.BridgeIDs == nil {
+			cur.BridgeIDs = map[string]int64{}
+		}
+		cur.BridgeIDs[proto] = id
+	}
+
+	prefix := envNameRe.ReplaceAllString(strings.ToUpper(string(ci.Chain)), "_")
+	if cur.RPCEnv == "" {
+		cur.RPCEnv = prefix + "_RPC_URL"
+	}
+	if cur.WSEnv == "" && cur.EVM {
+		cur.WSEnv = prefix + "_WS_URL"
+	}
+	if cur.ExplorerKeyEnv == "" && cur.EVM {
+		cur.ExplorerKeyEnv = prefix + "_EXPLORER_API_KEY"
+	}
+	if cur.NativeSymbol == "" && cur.EVM {
+		cur.NativeSymbol = "ETH"
+	}
+}
+
+// LookupChain returns the registry entry for ch.
+func LookupChain(ch Chain) (ChainInfo, bool) {
+	return currentRegistry().lookup(ch)
+}
+
+func (r *chainRegistry) lookup(ch Chain) (ChainInfo, bool) {
+	ci, ok := r.info[ch]
+	if !ok {
+		return ChainInfo{Chain: ch}, false
+	}
+	return *ci, true
+}
+
+// ChainByID returns the EVM chain with the given chain ID.
+func ChainByID(id int64) (Chain, bool) {
+	r := currentRegistry()
+	for _, ch := range r.order {
+		if r.info[ch].ChainID == id && id != 0 {
+			return ch, true
+		}
+	}
+	return "", false
+}
+
+// ChainByBridgeID returns the chain a bridge protocol knows by id. deBridge
+// and Across use EVM chain IDs, so those fall back to ChainByID.
+func ChainByBridgeID(protocol string, id int64) (Chain, bool) {
+	for _, ch := range AllChains() {
+		ci, _ := LookupChain(ch)
+		if bid, ok := ci.BridgeIDs[protocol]; ok && bid == id {
+			return ch, true
+		}
+	}
+	if protocol == "debridge" || protocol == "across" {
+		return ChainByID(id)
+	}
+	return "", false
+}
+
+// IsEVM reports whether ch is a registered EVM chain.
+func (ch Chain) IsEVM() bool {
+	ci, _ := LookupChain(ch)
+	return ci.EVM
+}
+
+// NativeSymbol is the symbol of ch's gas coin; unknown chains get ETH, as
+// most EVM chains use it.
+func NativeSymbol(ch Chain) string {
+	if ci, ok := LookupChain(ch); ok && ci.NativeSymbol != "" {
+		return ci.NativeSymbol
+	}
+	return "ETH"
+}
+
+// IsStablecoin reports whether token is a USD stablecoin on ch.
+func IsStablecoin(ch Chain, token string) bool {
+	ci, _ := LookupChain(ch)
+	for _, s := range ci.Stablecoins {
+		if strings.EqualFold(s, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// FixedFloatAddresses are the instant-exchange hot wallets known on ch.
+func FixedFloatAddresses(ch Chain) []string {
+	ci, _ := LookupChain(ch)
+	return appendMissing(append([]string(nil), ci.FixedFloat...), loadedServices().fixedFloat[ch]...)
+}
+
+// evmBridges are deployed at the same address on every EVM chain.
+var evmBridges = map[string]string{
+	"0xef4fb24ad0916217251f553c0596f8edc630eb66": "debridge", // deBridge DLN source
+	"0xe7351fd770a37282b91d153ee690b63579d6dd7f": "debridge", // deBridge DLN destination
+	"0xc38e4e6a15593f908255214653d3d947ca1c2338": "mayan",    // Mayan Swift
+	"0x337685fdab40d39bd02028545a4ffa7d287cc3e2": "mayan",    // Mayan forwarder
+}
+
+// normalizeBridgeKey lowercases EVM addresses; Solana programs are
+// case-sensitive and kept as they are.
+func normalizeBridgeKey(addr string) string {
+	if strings.HasPrefix(addr, "0x") {
+		return strings.ToLower(addr)
+	}
+	return addr
+}
+
+// BridgeContracts are the bridge contracts known on ch: the registry's,
+// then the config file's.
+func BridgeContracts(ch Chain) []string {
+	ci, _ := LookupChain(ch)
+	out := sortedKeys(ci.Bridges)
+	if ci.EVM {
+		out = appendMissing(out, sortedKeys(evmBridges)...)
+	}
+	return appendMissing(out, loadedServices().bridges[ch]...)
+}
+
+// BridgeProtocol returns the protocol of a bridge contract or program on ch:
+// "wormhole", "native:base" and so on, or "" if address is not a bridge the
+// registry knows the protocol of.
+func BridgeProtocol(ch Chain, address string) string {
+	ci, _ := LookupChain(ch)
+	key := normalizeBridgeKey(address)
+	if p, ok := ci.Bridges[key]; ok {
+		return p
+	}
+	if ci.EVM {
+		return evmBridges[key]
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func AllEVMChains() []Chain {
+	return currentRegistry().evm()
+}
+
+func (r *chainRegistry) evm() []Chain {
+	var out []Chain
+	for _, ch := range r.all() {
+		if r.info[ch].EVM {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// AllChains lists the registered chains: built-ins first, then chains from
+// the config file by name.
+func AllChains() []Chain {
+	return currentRegistry().all()
+}
+
+func (r *chainRegistry) all() []Chain {
+	out := append([]Chain(nil), r.order...)
+	extra := out[len(builtinChains):]
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	return out
+}
+
+// registerFile adds the config file's chain settings to r and returns the
+// entries it could not use: a chain that isn't built in needs a chain_id to
+// be added as an EVM chain.
+func (r *chainRegistry) registerFile(chains map[Chain]ChainFileConfig) (unknown []Chain) {
+	for ch, cc := range chains {
+		if _, ok := r.lookup(ch); !ok && cc.ChainID == 0 {
+			unknown = append(unknown, ch)
+			continue
+		}
+		r.register(ChainInfo{
+			Chain: ch, ChainID: cc.ChainID, NativeSymbol: cc.NativeSymbol, WrappedNative: cc.WrappedNative,
+			ExplorerAPI: cc.ExplorerAPI, BlockTime: time.Duration(cc.BlockTime * float64(time.Second)),
+			GeckoNetwork: cc.GeckoNetwork, DexScreener: cc.DexScreener, Stablecoins: cc.Stablecoins,
+		})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+	return unknown
+}
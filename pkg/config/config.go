@@ -2,38 +2,42 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 type Chain string
 
+// Built-in chains; their details are in the chain registry (chains.go).
 const (
-	ChainSolana   Chain = "solana"
-	ChainEthereum Chain = "ethereum"
-	ChainBase     Chain = "base"
-	ChainBSC      Chain = "bsc"
+	ChainSolana    Chain = "solana"
+	ChainEthereum  Chain = "ethereum"
+	ChainBase      Chain = "base"
+	ChainBSC       Chain = "bsc"
+	ChainArbitrum  Chain = "arbitrum"
+	ChainPolygon   Chain = "polygon"
+	ChainAvalanche Chain = "avalanche"
+	ChainOptimism  Chain = "optimism"
+	ChainBlast     Chain = "blast"
+	ChainTron      Chain = "tron"
+	ChainBitcoin   Chain = "bitcoin"
 )
 
-func AllEVMChains() []Chain {
-	return []Chain{ChainEthereum, ChainBase, ChainBSC}
-}
-
-func AllChains() []Chain {
-	return []Chain{ChainSolana, ChainEthereum, ChainBase, ChainBSC}
-}
-
 type KnownWallet struct {
-	Address string
-	Chain   Chain
-	Label   string
+	Address string `yaml:"address"`
+	Chain   Chain  `yaml:"chain"`
+	Label   string `yaml:"label,omitempty"`
 }
 
 type Config struct {
+	// ConfigFile is the YAML file Load read, or "" if none.
+	ConfigFile string
+
 	// Twitter
 	TwitterBearerToken string
 	NitterInstances    []string
@@ -59,8 +63,17 @@ type Config struct {
 	HeliusRPCURL  string
 	SolscanAPIKey string
 
-	// EVM RPCs
+	// EVM RPCs (primary endpoint per chain)
 	EVMRPC map[Chain]string
+	// EVMWS is each EVM chain's WebSocket endpoint for real-time log
+	// subscriptions; chains without one are only polled.
+	EVMWS map[Chain]string
+	// RPCEndpoints lists every configured RPC per chain, primary first.
+	RPCEndpoints map[Chain][]string
+	// Endpoints are probed every RPCHealthInterval; one whose head is more
+	// than RPCMaxLag behind the chain's best is quarantined.
+	RPCHealthInterval time.Duration
+	RPCMaxLag         time.Duration
 
 	// Block Explorer API keys
 	ExplorerKeys map[Chain]string
@@ -73,11 +86,14 @@ type Config struct {
 	KOLTwitterHandles  []string
 	KOLTelegramChannels []string
 	KOLKnownWallets    []KnownWallet
+	// KOLs from the config file, each with its own handles and wallets.
+	KOLs []KOLConfig
 
 	// Intervals
 	ChainScanInterval       time.Duration
 	PatternAnalysisInterval time.Duration
 	FreshBuyerScanInterval  time.Duration
+	FixedFloatScanInterval  time.Duration
 
 	// Detection thresholds
 	WashWalletMinScore      float64
@@ -85,9 +101,41 @@ type Config struct {
 	FreshWalletAgeHours     int
 	PreBuyWindowSeconds     int
 	PostBuyWindowSeconds    int
+	FundingTraceDepth       int // funding hops followed back from a wash candidate
+	Weights                 AnalyzerWeights
 
 	// DB
-	DBPath string
+	// DB_DRIVER: "sqlite" (default, DB_PATH file) | "postgres" (DB_URL connection string)
+	DBDriver string
+	DBURL    string
+	DBPath   string
+
+	// Retention (days; 0 = keep forever). Pruned rows are archived to
+	// gzip-compressed JSONL under ArchiveDir before deletion.
+	RetentionTxDays     int
+	RetentionPostsDays  int
+	RetentionAlertsDays int
+	ArchiveDir          string
+	JanitorInterval     time.Duration // 0 disables the background janitor
+
+	// Scheduled SQLite backups (online backup API), newest BackupKeep kept
+	BackupDir      string
+	BackupInterval time.Duration // 0 disables scheduled backups
+	BackupKeep     int
+
+	// Scan scheduler: concurrent wallet scans, throttled per API host
+	// (requests/second). Hosts not listed use RateLimits["default"].
+	ScanWorkers int
+	RateLimits  map[string]float64
+	// Chain data providers in priority order; the scanner falls back down
+	// the list when one fails. Providers not listed are not used.
+	ScanProviders []string
+
+	// Real-time streaming: tracked wallets with confidence >=
+	// StreamMinConfidence are subscribed over the chain's WebSocket endpoint
+	// and stored within seconds of confirming, alongside regular scans.
+	StreamEnabled       bool
+	StreamMinConfidence float64
 
 	// Dashboard
 	DashboardPort int
@@ -109,92 +157,219 @@ type Config struct {
 	AIModelFast     string // fast/cheap model for simple tasks
 	AIAnalysisInterval time.Duration
 	AIMaxTokens     int    // max response tokens (default 4096)
+
+	reload *notifier
+	live   *atomic.Pointer[Config] // latest reload, see Current
+	// unknownChains are config file chain entries that are neither built in
+	// nor define a chain_id.
+	unknownChains []Chain
+	// chains and known are the chain registry and known services this
+	// config was parsed with, for Publish.
+	chains *chainRegistry
+	known  *knownServices
 }
 
+// Load parses the config and publishes its chains and known services (see
+// Parse and Publish).
 func Load() (*Config, error) {
-	_ = godotenv.Load()
+	cfg, err := Parse()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Publish()
+	return cfg, nil
+}
 
-	cfg := &Config{
-		TwitterBearerToken: os.Getenv("TWITTER_BEARER_TOKEN"),
-		TwitterUsername:    os.Getenv("TWITTER_USERNAME"),
-		TwitterPassword:    os.Getenv("TWITTER_PASSWORD"),
-		TwitterEmail:       os.Getenv("TWITTER_EMAIL"),
-		TwitterAuthToken:   os.Getenv("TWITTER_AUTH_TOKEN"),
-		TwitterCSRFToken:   os.Getenv("TWITTER_CSRF_TOKEN"),
-		TwitterCookieFile:  envOr("TWITTER_COOKIE_FILE", "twitter_cookies.json"),
-		TelegramAPIHash:    os.Getenv("TELEGRAM_API_HASH"),
-		TelegramPhone:      os.Getenv("TELEGRAM_PHONE"),
-
-		SolanaRPCURL:  envOr("SOLANA_RPC_URL", "https://api.mainnet-beta.solana.com"),
-		SolanaWSURL:   envOr("SOLANA_WS_URL", "wss://api.mainnet-beta.solana.com"),
-		HeliusAPIKey:  os.Getenv("HELIUS_API_KEY"),
-		HeliusRPCURL:  os.Getenv("HELIUS_RPC_URL"),
-		SolscanAPIKey: os.Getenv("SOLSCAN_API_KEY"),
-
-		BirdeyeAPIKey:  os.Getenv("BIRDEYE_API_KEY"),
-		DexScreenerAPI: envOr("DEXSCREENER_API", "https://api.dexscreener.com"),
-
-		DBPath:        envOr("DB_PATH", "kol_tracker.db"),
-		DashboardPort: envInt("DASHBOARD_PORT", 8080),
-
-		AnthropicAPIKey: os.Getenv("ANTHROPIC_API_KEY"),
-		OpenAIAPIKey:    os.Getenv("OPENAI_API_KEY"),
-		OllamaURL:       envOr("OLLAMA_URL", ""),
-		OllamaModel:     envOr("OLLAMA_MODEL", "llama3.1"),
-		OllamaAutoStart: envOr("OLLAMA_AUTO_PULL", "true") == "true",
-		AIProvider:      os.Getenv("AI_PROVIDER"), // explicit: "anthropic","ollama","openai"
-		AIModel:         envOr("AI_MODEL", ""),     // auto-resolved in AI engine
-		AIModelFast:     envOr("AI_MODEL_FAST", ""), // auto-resolved in AI engine
-		AIMaxTokens:     envInt("AI_MAX_TOKENS", 4096),
-		AIAnalysisInterval: time.Duration(envInt("AI_ANALYSIS_INTERVAL", 600)) * time.Second,
-
-		WashWalletMinScore:      envFloat("WASH_WALLET_MIN_SCORE", 0.4),
-		AmountMatchTolerancePct: envFloat("AMOUNT_MATCH_TOLERANCE_PCT", 3.0),
-		FreshWalletAgeHours:     envInt("FRESH_WALLET_AGE_HOURS", 168),
-		PreBuyWindowSeconds:     envInt("PRE_BUY_WINDOW_SECONDS", 3600),
-		PostBuyWindowSeconds:    envInt("POST_BUY_WINDOW_SECONDS", 7200),
-
-		TwitterPollInterval:     time.Duration(envInt("TWITTER_POLL_INTERVAL", 60)) * time.Second,
-		TelegramPollInterval:    time.Duration(envInt("TELEGRAM_POLL_INTERVAL", 30)) * time.Second,
-		ChainScanInterval:       time.Duration(envInt("CHAIN_SCAN_INTERVAL", 120)) * time.Second,
-		PatternAnalysisInterval: time.Duration(envInt("PATTERN_ANALYSIS_INTERVAL", 300)) * time.Second,
-		FreshBuyerScanInterval:  time.Duration(envInt("FRESH_BUYER_SCAN_INTERVAL", 15)) * time.Second,
-	}
-
-	// Telegram API ID
-	if v := os.Getenv("TELEGRAM_API_ID"); v != "" {
-		id, err := strconv.Atoi(v)
-		if err == nil {
-			cfg.TelegramAPIID = id
+// Parse resolves the config from built-in defaults, then the YAML config file
+// (CONFIG_FILE, or tracker.yaml if present), then environment variables /
+// .env — each layer overriding the one before. Apart from applying .env to
+// the environment it changes nothing: the chain registry and known services
+// it builds stay with the returned config until Publish.
+func Parse() (*Config, error) {
+	loadDotEnv()
+
+	fc := &FileConfig{}
+	path := configFilePath()
+	if path != "" {
+		var err error
+		if fc, err = LoadFile(path); err != nil {
+			return nil, err
 		}
 	}
+	tw, tg, ai := fc.Twitter, fc.Telegram, fc.AI
+	th, iv := fc.Thresholds, fc.Intervals
+	// a fresh registry, so chains and fields removed from the file are gone
+	reg := builtins.clone()
+	unknownChains := reg.registerFile(fc.Chains)
+
+	cfg := &Config{
+		ConfigFile:    path,
+		reload:        newNotifier(),
+		live:          new(atomic.Pointer[Config]),
+		unknownChains: unknownChains,
+		chains:        reg,
+
+		TwitterBearerToken: envOr("TWITTER_BEARER_TOKEN", tw.BearerToken),
+		TwitterUsername:    envOr("TWITTER_USERNAME", tw.Username),
+		TwitterPassword:    envOr("TWITTER_PASSWORD", tw.Password),
+		TwitterEmail:       envOr("TWITTER_EMAIL", tw.Email),
+		TwitterAuthToken:   envOr("TWITTER_AUTH_TOKEN", tw.AuthToken),
+		TwitterCSRFToken:   envOr("TWITTER_CSRF_TOKEN", tw.CSRFToken),
+		TwitterCookieFile:  envOr("TWITTER_COOKIE_FILE", orStr(tw.CookieFile, "twitter_cookies.json")),
+		TelegramAPIID:      envInt("TELEGRAM_API_ID", tg.APIID),
+		TelegramAPIHash:    envOr("TELEGRAM_API_HASH", tg.APIHash),
+		TelegramPhone:      envOr("TELEGRAM_PHONE", tg.Phone),
+
+		SolanaWSURL:   envOr("SOLANA_WS_URL", orStr(fc.Chains[ChainSolana].WS, "wss://api.mainnet-beta.solana.com")),
+		HeliusAPIKey:  envOr("HELIUS_API_KEY", fc.Helius.APIKey),
+		HeliusRPCURL:  envOr("HELIUS_RPC_URL", fc.Helius.RPCURL),
+		SolscanAPIKey: envOr("SOLSCAN_API_KEY", fc.SolscanAPIKey),
+
+		BirdeyeAPIKey:  envOr("BIRDEYE_API_KEY", fc.BirdeyeAPIKey),
+		DexScreenerAPI: envOr("DEXSCREENER_API", orStr(fc.DexScreenerAPI, "https://api.dexscreener.com")),
+
+		DBDriver:      envOr("DB_DRIVER", orStr(fc.Database.Driver, "sqlite")),
+		DBURL:         envOr("DB_URL", fc.Database.URL),
+		DBPath:        envOr("DB_PATH", orStr(fc.Database.Path, "kol_tracker.db")),
+
+		RetentionTxDays:     envInt("RETENTION_TX_DAYS", orInt(fc.Retention.TxDays, 180)),
+		RetentionPostsDays:  envInt("RETENTION_POSTS_DAYS", orInt(fc.Retention.PostsDays, 180)),
+		RetentionAlertsDays: envInt("RETENTION_ALERTS_DAYS", orInt(fc.Retention.AlertsDays, 30)),
+		ArchiveDir:          envOr("ARCHIVE_DIR", orStr(fc.Retention.ArchiveDir, "archive")),
+		JanitorInterval:     seconds(envInt("JANITOR_INTERVAL", orInt(fc.Retention.JanitorInterval, 3600))),
+
+		BackupDir:      envOr("BACKUP_DIR", orStr(fc.Backup.Dir, "backups")),
+		BackupInterval: seconds(envInt("BACKUP_INTERVAL", fc.Backup.Interval)),
+		BackupKeep:     envInt("BACKUP_KEEP", orInt(fc.Backup.Keep, 7)),
+
+		ScanWorkers: envInt("SCAN_WORKERS", orInt(fc.Scan.Workers, 4)),
+
+		RPCHealthInterval: seconds(envInt("RPC_HEALTH_INTERVAL", orInt(fc.RPC.HealthInterval, 30))),
+		RPCMaxLag:         seconds(envInt("RPC_MAX_LAG", orInt(fc.RPC.MaxLag, 30))),
+
+		StreamEnabled:       envOr("STREAM_ENABLED", strconv.FormatBool(fc.Stream.Enabled == nil || *fc.Stream.Enabled)) == "true",
+		StreamMinConfidence: envFloat("STREAM_MIN_CONFIDENCE", orFloat(fc.Stream.MinConfidence, 0.7)),
+
+		DashboardPort: envInt("DASHBOARD_PORT", orInt(fc.Dashboard.Port, 8080)),
+
+		AnthropicAPIKey: envOr("ANTHROPIC_API_KEY", ai.AnthropicAPIKey),
+		OpenAIAPIKey:    envOr("OPENAI_API_KEY", ai.OpenAIAPIKey),
+		OllamaURL:       envOr("OLLAMA_URL", ai.OllamaURL),
+		OllamaModel:     envOr("OLLAMA_MODEL", orStr(ai.OllamaModel, "llama3.1")),
+		OllamaAutoStart: envOr("OLLAMA_AUTO_PULL", strconv.FormatBool(ai.OllamaAutoPull == nil || *ai.OllamaAutoPull)) == "true",
+		AIProvider:      envOr("AI_PROVIDER", ai.Provider), // explicit: "anthropic","ollama","openai"
+		AIModel:         envOr("AI_MODEL", ai.Model),         // auto-resolved in AI engine
+		AIModelFast:     envOr("AI_MODEL_FAST", ai.ModelFast), // auto-resolved in AI engine
+		AIMaxTokens:     envInt("AI_MAX_TOKENS", orInt(ai.MaxTokens, 4096)),
+		AIAnalysisInterval: seconds(envInt("AI_ANALYSIS_INTERVAL", orInt(iv.AIAnalysis, 600))),
+
+		WashWalletMinScore:      envFloat("WASH_WALLET_MIN_SCORE", orFloat(th.WashWalletMinScore, 0.4)),
+		AmountMatchTolerancePct: envFloat("AMOUNT_MATCH_TOLERANCE_PCT", orFloat(th.AmountMatchTolerancePct, 3.0)),
+		FreshWalletAgeHours:     envInt("FRESH_WALLET_AGE_HOURS", orInt(th.FreshWalletAgeHours, 168)),
+		PreBuyWindowSeconds:     envInt("PRE_BUY_WINDOW_SECONDS", orInt(th.PreBuyWindowSeconds, 3600)),
+		PostBuyWindowSeconds:    envInt("POST_BUY_WINDOW_SECONDS", orInt(th.PostBuyWindowSeconds, 7200)),
+		FundingTraceDepth:       envInt("FUNDING_TRACE_DEPTH", orInt(th.FundingTraceDepth, 3)),
+		Weights:                 fc.AnalyzerWeights.merge(DefaultAnalyzerWeights()),
+
+		TwitterPollInterval:     seconds(envInt("TWITTER_POLL_INTERVAL", orInt(tw.PollInterval, 60))),
+		TelegramPollInterval:    seconds(envInt("TELEGRAM_POLL_INTERVAL", orInt(tg.PollInterval, 30))),
+		ChainScanInterval:       seconds(envInt("CHAIN_SCAN_INTERVAL", orInt(iv.ChainScan, 120))),
+		PatternAnalysisInterval: seconds(envInt("PATTERN_ANALYSIS_INTERVAL", orInt(iv.PatternAnalysis, 300))),
+		FreshBuyerScanInterval:  seconds(envInt("FRESH_BUYER_SCAN_INTERVAL", orInt(iv.FreshBuyerScan, 15))),
+		FixedFloatScanInterval:  seconds(envInt("FIXEDFLOAT_SCAN_INTERVAL", orInt(iv.FixedFloatScan, 900))),
+	}
 
 	// Nitter instances
 	if v := os.Getenv("NITTER_INSTANCES"); v != "" {
 		cfg.NitterInstances = splitTrim(v)
+	} else if len(tw.NitterInstances) > 0 {
+		cfg.NitterInstances = tw.NitterInstances
 	} else {
 		cfg.NitterInstances = []string{
 			"https://nitter.privacydev.net",
 		}
 	}
 
-	// EVM RPCs
-	cfg.EVMRPC = map[Chain]string{
-		ChainEthereum: envOr("ETH_RPC_URL", "https://eth.llamarpc.com"),
-		ChainBase:     envOr("BASE_RPC_URL", "https://mainnet.base.org"),
-		ChainBSC:      envOr("BSC_RPC_URL", "https://bsc-dataseed.binance.org"),
+	// Bitcoin has no RPC here, only an Esplora API; ESPLORA_URL points it at
+	// another server (mempool.space, a local regtest esplora)
+	if u := os.Getenv("ESPLORA_URL"); u != "" {
+		reg.register(ChainInfo{Chain: ChainBitcoin, ExplorerAPI: u})
+	}
+
+	// RPC endpoints: an env var replaces the file's list with a single URL.
+	// A chain with neither and no registry default gets no endpoints.
+	cfg.RPCEndpoints = map[Chain][]string{}
+	cfg.EVMRPC = map[Chain]string{}
+	cfg.EVMWS = map[Chain]string{}
+	cfg.ExplorerKeys = map[Chain]string{}
+	for _, ch := range reg.all() {
+		ci, _ := reg.lookup(ch)
+		switch {
+		case os.Getenv(ci.RPCEnv) != "":
+			cfg.RPCEndpoints[ch] = []string{os.Getenv(ci.RPCEnv)}
+		case len(fc.Chains[ch].RPC) > 0:
+			cfg.RPCEndpoints[ch] = fc.Chains[ch].RPC
+		case ci.DefaultRPC != "":
+			cfg.RPCEndpoints[ch] = []string{ci.DefaultRPC}
+		}
+		if ci.ExplorerKeyEnv != "" {
+			cfg.ExplorerKeys[ch] = envOr(ci.ExplorerKeyEnv, fc.Chains[ch].ExplorerKey)
+		}
+		if !ci.EVM {
+			continue
+		}
+		if urls := cfg.RPCEndpoints[ch]; len(urls) > 0 {
+			cfg.EVMRPC[ch] = urls[0]
+		}
+		if u := envOr(ci.WSEnv, fc.Chains[ch].WS); u != "" {
+			cfg.EVMWS[ch] = u
+		}
+	}
+	cfg.SolanaRPCURL = cfg.RPCEndpoints[ChainSolana][0]
+
+	// Rate limits: defaults < file < RATE_LIMITS ("host=rps,host=rps")
+	cfg.RateLimits = DefaultRateLimits()
+	for host, rps := range fc.Scan.RateLimits {
+		cfg.RateLimits[strings.ToLower(host)] = rps
+	}
+	for _, kv := range splitTrim(os.Getenv("RATE_LIMITS")) {
+		host, v, ok := strings.Cut(kv, "=")
+		if rps, err := strconv.ParseFloat(strings.TrimSpace(v), 64); ok && err == nil {
+			cfg.RateLimits[strings.ToLower(strings.TrimSpace(host))] = rps
+		}
 	}
 
-	// Explorer keys
-	cfg.ExplorerKeys = map[Chain]string{
-		ChainEthereum: os.Getenv("ETHERSCAN_API_KEY"),
-		ChainBase:     os.Getenv("BASESCAN_API_KEY"),
-		ChainBSC:      os.Getenv("BSCSCAN_API_KEY"),
+	// Provider priority: defaults < file < SCAN_PROVIDERS ("helius,solana_rpc,...")
+	cfg.ScanProviders = DefaultScanProviders()
+	if v := splitTrim(os.Getenv("SCAN_PROVIDERS")); len(v) > 0 {
+		cfg.ScanProviders = v
+	} else if len(fc.Scan.Providers) > 0 {
+		cfg.ScanProviders = fc.Scan.Providers
+	}
+	for i, p := range cfg.ScanProviders {
+		cfg.ScanProviders[i] = strings.ToLower(strings.TrimSpace(p))
 	}
 
-	// KOL targets
-	cfg.KOLTwitterHandles = splitTrim(os.Getenv("KOL_TWITTER_HANDLES"))
-	cfg.KOLTelegramChannels = splitTrim(os.Getenv("KOL_TELEGRAM_CHANNELS"))
+	// KOL targets: file entries first, env handles/wallets added on top.
+	cfg.KOLs = append(cfg.KOLs, fc.KOLs...)
+	for _, k := range cfg.KOLs {
+		if k.Twitter != "" {
+			cfg.KOLTwitterHandles = append(cfg.KOLTwitterHandles, k.Twitter)
+		}
+		if k.Telegram != "" {
+			cfg.KOLTelegramChannels = append(cfg.KOLTelegramChannels, k.Telegram)
+		}
+	}
+	for _, h := range splitTrim(os.Getenv("KOL_TWITTER_HANDLES")) {
+		if !contains(cfg.KOLTwitterHandles, h) {
+			cfg.KOLTwitterHandles = append(cfg.KOLTwitterHandles, h)
+		}
+	}
+	for _, c := range splitTrim(os.Getenv("KOL_TELEGRAM_CHANNELS")) {
+		if !contains(cfg.KOLTelegramChannels, c) {
+			cfg.KOLTelegramChannels = append(cfg.KOLTelegramChannels, c)
+		}
+	}
 
 	// Parse known wallets: "addr:chain:label,addr:chain:label"
 	for _, w := range splitTrim(os.Getenv("KOL_KNOWN_WALLETS")) {
@@ -211,20 +386,230 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Extra known-service addresses
+	ks := &knownServices{fixedFloat: map[Chain][]string{}, bridges: map[Chain][]string{},
+		evm: map[string]string{}, tron: map[string]string{}}
+	for ch, addrs := range fc.KnownServices.FixedFloat {
+		if _, ok := reg.lookup(ch); ok {
+			ks.fixedFloat[ch] = appendMissing(nil, addrs...)
+		}
+	}
+	for ch, addrs := range fc.KnownServices.Bridges {
+		if _, ok := reg.lookup(ch); ok {
+			ks.bridges[ch] = appendMissing(nil, addrs...)
+		}
+	}
+	for addr, label := range fc.KnownServices.EVMAddresses {
+		ks.evm[strings.ToLower(addr)] = label
+	}
+	for addr, label := range fc.KnownServices.TronAddresses {
+		ks.tron[addr] = label
+	}
+	cfg.known = ks
+
 	return cfg, nil
 }
 
-func (c *Config) GetExplorerURL(chain Chain) string {
-	switch chain {
-	case ChainEthereum:
-		return "https://api.etherscan.io/api"
-	case ChainBase:
-		return "https://api.basescan.org/api"
-	case ChainBSC:
-		return "https://api.bscscan.com/api"
+// Publish makes the chains and known services c was parsed with the ones
+// every lookup (LookupChain, BridgeContracts, ...) sees. A reload publishes
+// only after the new config passed Problems and was applied.
+func (c *Config) Publish() {
+	if c.chains != nil {
+		chainsMu.Lock()
+		registry.Store(c.chains)
+		chainsMu.Unlock()
+	}
+	if c.known != nil {
+		services.Store(c.known)
+	}
+}
+
+// parsedChains returns the chains c was parsed with, or the published
+// ones for a config that wasn't parsed.
+func (c *Config) parsedChains() *chainRegistry {
+	if c.chains != nil {
+		return c.chains
+	}
+	return currentRegistry()
+}
+
+// knownServices are the known_services entries of the loaded config file,
+// consulted on top of the built-in lists. Parse builds a fresh set and
+// Publish swaps it in whole, so scanners never see a half-applied reload and
+// entries removed from the file are gone after the next reload.
+type knownServices struct {
+	fixedFloat map[Chain][]string
+	bridges    map[Chain][]string
+	evm        map[string]string // lowercase address -> label
+	tron       map[string]string
+}
+
+var services atomic.Pointer[knownServices]
+
+func loadedServices() *knownServices {
+	if ks := services.Load(); ks != nil {
+		return ks
+	}
+	return &knownServices{}
+}
+
+// Problems reports settings that are invalid rather than merely missing
+// (unknown chains, out-of-range thresholds, unusable intervals).
+func (c *Config) Problems() []string {
+	var out []string
+	reg := c.parsedChains()
+	known := map[Chain]bool{}
+	for _, ch := range reg.all() {
+		known[ch] = true
+	}
+	for i, k := range c.KOLs {
+		if k.Name == "" && k.Twitter == "" && k.Telegram == "" {
+			out = append(out, fmt.Sprintf("kols[%d]: needs a name, twitter or telegram", i))
+		}
+		for _, w := range k.Wallets {
+			if w.Address == "" || !known[w.Chain] {
+				out = append(out, fmt.Sprintf("kols[%d] (%s): wallet %q has unknown chain %q", i, k.Name, w.Address, w.Chain))
+			}
+		}
+	}
+	for _, w := range c.KOLKnownWallets {
+		if !known[w.Chain] {
+			out = append(out, fmt.Sprintf("KOL_KNOWN_WALLETS: %q has unknown chain %q", w.Address, w.Chain))
+		}
+	}
+	for _, ch := range c.unknownChains {
+		out = append(out, fmt.Sprintf("chains.%s: unknown chain; set chain_id to add it as an EVM chain", ch))
+	}
+	for _, ch := range reg.evm() {
+		if len(c.RPCEndpoints[ch]) == 0 {
+			out = append(out, fmt.Sprintf("chains.%s: no rpc endpoint configured", ch))
+		}
+	}
+	for ch, urls := range c.RPCEndpoints {
+		for _, u := range urls {
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+				out = append(out, fmt.Sprintf("chains.%s.rpc: %q is not an http(s) URL", ch, RedactURL(u)))
+			}
+		}
+	}
+	if c.WashWalletMinScore < 0 || c.WashWalletMinScore > 1 {
+		out = append(out, fmt.Sprintf("wash_wallet_min_score %.2f is outside 0-1", c.WashWalletMinScore))
+	}
+	w := c.Weights
+	for name, v := range map[string]float64{
+		"token_overlap_per_token": w.TokenOverlapPerToken, "token_overlap_max": w.TokenOverlapMax,
+		"timing": w.Timing, "amount": w.Amount, "dex": w.DEX, "gas": w.Gas,
+		"funding_fixedfloat": w.FundingFixedFloat, "funding_bridge": w.FundingBridge,
+		"funding_mixer": w.FundingMixer, "funding_swap_service": w.FundingSwapService,
+		"age_under_day": w.AgeUnderDay, "age_under_week": w.AgeUnderWeek, "shared_with_other_kol": w.SharedWithOtherKOL,
+	} {
+		if v < 0 || v > 1 {
+			out = append(out, fmt.Sprintf("analyzer_weights.%s %.2f is outside 0-1", name, v))
+		}
+	}
+	for name, d := range map[string]time.Duration{
+		"twitter poll": c.TwitterPollInterval, "telegram poll": c.TelegramPollInterval,
+		"chain scan": c.ChainScanInterval, "pattern analysis": c.PatternAnalysisInterval,
+		"fresh buyer scan": c.FreshBuyerScanInterval, "ai analysis": c.AIAnalysisInterval,
+		"fixedfloat scan": c.FixedFloatScanInterval,
+	} {
+		if d <= 0 {
+			out = append(out, fmt.Sprintf("%s interval must be > 0", name))
+		}
+	}
+	if c.RPCHealthInterval <= 0 {
+		out = append(out, "rpc health interval must be > 0")
+	}
+	if c.RPCMaxLag <= 0 {
+		out = append(out, "rpc max lag must be > 0")
+	}
+	if c.StreamMinConfidence < 0 || c.StreamMinConfidence > 1 {
+		out = append(out, fmt.Sprintf("stream.min_confidence %.2f is outside 0-1", c.StreamMinConfidence))
+	}
+	wsURLs := map[Chain]string{ChainSolana: c.SolanaWSURL}
+	for ch, u := range c.EVMWS {
+		wsURLs[ch] = u
+	}
+	for ch, u := range wsURLs {
+		if c.StreamEnabled && u != "" && !strings.HasPrefix(u, "ws://") && !strings.HasPrefix(u, "wss://") {
+			out = append(out, fmt.Sprintf("chains.%s.ws: %q is not a ws(s) URL", ch, RedactURL(u)))
+		}
+	}
+	if c.FundingTraceDepth < 1 {
+		out = append(out, "funding trace depth must be >= 1")
+	}
+	if c.ScanWorkers < 1 {
+		out = append(out, "scan workers must be >= 1")
+	}
+	for host, rps := range c.RateLimits {
+		if rps <= 0 {
+			out = append(out, fmt.Sprintf("scan.rate_limits.%s must be > 0", host))
+		}
+	}
+	for _, p := range c.ScanProviders {
+		if !contains(DefaultScanProviders(), p) {
+			out = append(out, fmt.Sprintf("unknown scan provider %q (known: %s)", p, strings.Join(DefaultScanProviders(), ", ")))
+		}
+	}
+	switch strings.ToLower(c.DBDriver) {
+	case "", "sqlite", "sqlite3", "postgres", "postgresql", "pg":
 	default:
-		return ""
+		out = append(out, fmt.Sprintf("unknown database driver %q", c.DBDriver))
 	}
+	sort.Strings(out)
+	return out
+}
+
+// DefaultRateLimits are requests/second per API host, matching the free
+// tiers. Etherscan-family limits apply per key, and each chain has its own
+// host and key (5/s for every registered explorer, TronGrid included).
+// Anything else (RPC endpoints, price APIs) uses "default".
+func DefaultRateLimits() map[string]float64 {
+	limits := map[string]float64{
+		"api.helius.xyz":          10,
+		"public-api.birdeye.so":   1,
+		"api.dexscreener.com":     5,
+		"api.geckoterminal.com":   0.5,
+		"apilist.tronscanapi.com": 1,
+		// bridge explorers, for decoding cross-chain transfers
+		"api.wormholescan.io":        2,
+		"stats-api.dln.trade":        2,
+		"explorer-api.mayan.finance": 2,
+		"app.across.to":              2,
+		"scan.layerzero-api.com":     2,
+		"default":                    10,
+	}
+	for _, ch := range AllChains() {
+		ci, _ := LookupChain(ch)
+		if u, err := url.Parse(ci.ExplorerAPI); err == nil && u.Host != "" {
+			limits[strings.ToLower(u.Host)] = 5
+		}
+	}
+	return limits
+}
+
+// DefaultScanProviders lists every chain data provider, in the order the
+// scanner tries them by default: Helius before plain Solana RPC for its
+// parsed swaps, and EVM RPC before the rate-limited explorers.
+func DefaultScanProviders() []string {
+	return []string{"helius", "solana_rpc", "evm_rpc", "etherscan", "trongrid", "esplora", "birdeye", "solscan"}
+}
+
+// DBDSN returns the data source for the configured DB driver. For SQLite,
+// DB_URL takes precedence over DB_PATH when both are set.
+func (c *Config) DBDSN() string {
+	if c.DBURL != "" {
+		return c.DBURL
+	}
+	if c.DBDriver == "" || strings.HasPrefix(c.DBDriver, "sqlite") {
+		return c.DBPath
+	}
+	return ""
+}
+
+func (c *Config) GetExplorerURL(chain Chain) string {
+	ci, _ := LookupChain(chain)
+	return ci.ExplorerAPI
 }
 
 func (c *Config) GetExplorerKey(chain Chain) string {
@@ -237,7 +622,10 @@ func (c *Config) Validate() error {
 	hasTwitterAuth := c.TwitterUsername != "" || c.TwitterAuthToken != ""
 	hasTelegramAuth := len(c.KOLTelegramChannels) > 0
 	hasSolana := c.HeliusAPIKey != ""
-	hasEVM := c.ExplorerKeys[ChainEthereum] != "" || c.ExplorerKeys[ChainBase] != "" || c.ExplorerKeys[ChainBSC] != ""
+	hasEVM := false
+	for _, ch := range AllEVMChains() {
+		hasEVM = hasEVM || c.ExplorerKeys[ch] != ""
+	}
 
 	if !hasTwitterAuth && !hasTelegramAuth && !hasSolana && !hasEVM {
 		return fmt.Errorf("no API credentials configured — need at least one of: TWITTER credentials, HELIUS_API_KEY (Solana), or ETHERSCAN_API_KEY (EVM)")
@@ -247,33 +635,6 @@ func (c *Config) Validate() error {
 
 // --- Known service addresses for wash detection ---
 
-var KnownFixedFloatAddresses = map[Chain][]string{
-	ChainSolana: {
-		"FFixpaKkNRRKmRD1tFGqFrMBF26gKiNaaTPfbSdrFETS", // FixedFloat Solana hot wallet
-		"FFSoLNFqJZuxyaqGG1GXMEfLEVf5pGAfRqVAWfTormYr", // FixedFloat Solana secondary
-	},
-	ChainEthereum: {
-		"0x4E5B2e1dc63F6b91cb6Cd759936495434C7e972F", // FixedFloat ETH hot wallet
-		"0xf1dA173228fcf015F43f3eA15aBBB51f0d8f1123", // FixedFloat ETH secondary
-		"0x36928500Bc1dCd7af6a2B4008875CC336b927D57", // ChangeNow hot wallet
-		"0x0D0707963952f2fBA59dD06f2b425ace40b492Fe", // SimpleSwap
-	},
-	ChainBase: {
-		"0x4E5B2e1dc63F6b91cb6Cd759936495434C7e972F", // FixedFloat Base (same address)
-	},
-	ChainBSC: {
-		"0x4E5B2e1dc63F6b91cb6Cd759936495434C7e972F", // FixedFloat BSC (same address)
-		"0x0D0707963952f2fBA59dD06f2b425ace40b492Fe", // SimpleSwap BSC
-	},
-}
-
-var KnownBridgeContracts = map[Chain][]string{
-	ChainSolana:   {"worm2ZoG2kUd4vFXhvjh93UUH596ayRfgQ2MgjNMTth"},
-	ChainEthereum: {"0x3ee18B2214AFF97000D974cf647E7C347E8fa585", "0x4D73AdB72bC3DD368966edD0f0b2148401A178E2"},
-	ChainBase:     {"0x4200000000000000000000000000000000000010"}, // Base Bridge
-	ChainBSC:      {},
-}
-
 var ServiceLabels = map[string]string{
 	"fixedfloat":     "fixedfloat",
 	"fixed float":    "fixedfloat",
@@ -302,9 +663,12 @@ var KnownEVMAddresses = map[string]string{
 	"0xe592427a0aece92de3edee1f18e0157c05861564": "dex:uniswap_v3",
 	"0x68b3465833fb72a70ecdf485e0e4c7bd8665fc45": "dex:uniswap_v3_router2",
 	"0x3fc91a3afd70395cd496c647d5a6cc9d4b2b7fad": "dex:uniswap_universal",
+	"0x66a9893cc07d91d95644aedd05d03f95e1dba8af": "dex:uniswap_universal",
+	"0x000000000004444c5dc75cb358380d2e3de08a90": "dex:uniswap_v4",
 	// PancakeSwap
 	"0x10ed43c718714eb63d5aa57b78b54704e256024e": "dex:pancakeswap_v2",
 	"0x13f4ea83d0bd40e75c8222255bc855a974568dd4": "dex:pancakeswap_v3",
+	"0x1a0a18ac4becddbd6389559687d1a73d8927e416": "dex:pancakeswap_universal",
 	// SushiSwap
 	"0xd9e1ce17f2641f24ae83637ab66a2cca9c378b9f": "dex:sushiswap",
 	// 1inch
@@ -333,12 +697,41 @@ var KnownEVMAddresses = map[string]string{
 
 // IdentifyKnownEVMAddress checks if an address is a known service on EVM chains.
 func IdentifyKnownEVMAddress(address string) string {
-	if label, ok := KnownEVMAddresses[strings.ToLower(address)]; ok {
+	addr := strings.ToLower(address)
+	if label, ok := loadedServices().evm[addr]; ok {
+		return label
+	}
+	if label, ok := KnownEVMAddresses[addr]; ok {
 		return label
 	}
 	return ""
 }
 
+// KnownTronAddresses maps Tron addresses (base58, case-sensitive) to their
+// service type. Tronscan's address tags cover the big exchanges; this is for
+// the rest, extended by known_services.tron_addresses.
+var KnownTronAddresses = map[string]string{}
+
+// IdentifyKnownTronAddress checks if an address is a known service on Tron.
+func IdentifyKnownTronAddress(address string) string {
+	if label, ok := loadedServices().tron[address]; ok {
+		return label
+	}
+	return KnownTronAddresses[address]
+}
+
+// knownAddresses merges the built-in labels with the file's, file winning.
+func knownAddresses(builtin, extra map[string]string) map[string]string {
+	out := make(map[string]string, len(builtin)+len(extra))
+	for k, v := range builtin {
+		out[k] = v
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
+
 // ClassifyEVMDEX returns the DEX name from an Etherscan "to" address in a swap tx.
 func ClassifyEVMDEX(toAddr string) string {
 	if label := IdentifyKnownEVMAddress(toAddr); strings.HasPrefix(label, "dex:") {
@@ -373,6 +766,49 @@ func envFloat(key string, fallback float64) float64 {
 	return fallback
 }
 
+func orStr(v, fallback string) string {
+	if v != "" {
+		return v
+	}
+	return fallback
+}
+
+func orInt(v, fallback int) int {
+	if v != 0 {
+		return v
+	}
+	return fallback
+}
+
+func orFloat(v, fallback float64) float64 {
+	if v != 0 {
+		return v
+	}
+	return fallback
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func appendMissing(list []string, items ...string) []string {
+	for _, it := range items {
+		if !contains(list, it) {
+			list = append(list, it)
+		}
+	}
+	return list
+}
+
 func splitTrim(s string) []string {
 	if s == "" {
 		return nil
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useConfigFile points Parse at a config file holding yaml and restores the
+// published registry and known services afterwards.
+func useConfigFile(t *testing.T, yaml string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tracker.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	reg, ks := currentRegistry(), services.Load()
+	t.Cleanup(func() {
+		registry.Store(reg)
+		services.Store(ks)
+	})
+}
+
+func TestParsePublishesNothing(t *testing.T) {
+	const exchange = "0x00000000000000000000000000000000000ce0ce"
+	useConfigFile(t, `
+chains:
+  zora:
+    chain_id: 7777777
+    rpc: ["https://rpc.zora.energy"]
+known_services:
+  evm_addresses:
+    "`+exchange+`": "cex:test"
+`)
+	cfg, err := Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LookupChain("zora"); ok {
+		t.Error("Parse registered zora")
+	}
+	if got := IdentifyKnownEVMAddress(exchange); got != "" {
+		t.Errorf("Parse published known service %q", got)
+	}
+	for _, p := range cfg.Problems() {
+		t.Errorf("problem: %s", p)
+	}
+
+	cfg.Publish()
+	if ci, ok := LookupChain("zora"); !ok || ci.ChainID != 7777777 {
+		t.Errorf("after Publish zora = %+v, %v", ci, ok)
+	}
+	if got := IdentifyKnownEVMAddress(exchange); got != "cex:test" {
+		t.Errorf("after Publish known service = %q, want cex:test", got)
+	}
+}
+
+func TestReloadDropsRemovedChains(t *testing.T) {
+	const extraStable = "0x00000000000000000000000000000000005afe01"
+	load := func(yaml string) {
+		t.Helper()
+		useConfigFile(t, yaml)
+		cfg, err := Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Publish()
+	}
+	load(`
+chains:
+  zora:
+    chain_id: 7777777
+    rpc: ["https://rpc.zora.energy"]
+  ethereum:
+    explorer_api: "https://eth.example/api"
+    stablecoins: ["` + extraStable + `"]
+`)
+	if _, ok := LookupChain("zora"); !ok || !IsStablecoin(ChainEthereum, extraStable) {
+		t.Fatal("file chains not registered")
+	}
+
+	load("chains: {}\n")
+	if _, ok := LookupChain("zora"); ok {
+		t.Error("zora still registered after it was removed from the file")
+	}
+	if IsStablecoin(ChainEthereum, extraStable) {
+		t.Error("removed stablecoin still registered")
+	}
+	if ci, _ := LookupChain(ChainEthereum); ci.ExplorerAPI != "https://api.etherscan.io/api" {
+		t.Errorf("ethereum explorer = %q, want the built-in one back", ci.ExplorerAPI)
+	}
+}
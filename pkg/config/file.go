@@ -0,0 +1,389 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is read when CONFIG_FILE is unset and the file exists.
+const DefaultConfigFile = "tracker.yaml"
+
+// FileConfig is the YAML config file schema. Every field is optional; unset
+// fields fall back to defaults, and env vars override whatever is set here.
+// Intervals are in seconds.
+type FileConfig struct {
+	Twitter struct {
+		Username        string   `yaml:"username,omitempty"`
+		Password        string   `yaml:"password,omitempty"`
+		Email           string   `yaml:"email,omitempty"`
+		AuthToken       string   `yaml:"auth_token,omitempty"`
+		CSRFToken       string   `yaml:"csrf_token,omitempty"`
+		CookieFile      string   `yaml:"cookie_file,omitempty"`
+		BearerToken     string   `yaml:"bearer_token,omitempty"`
+		NitterInstances []string `yaml:"nitter_instances,omitempty"`
+		PollInterval    int      `yaml:"poll_interval,omitempty"`
+	} `yaml:"twitter"`
+
+	Telegram struct {
+		APIID        int    `yaml:"api_id,omitempty"`
+		APIHash      string `yaml:"api_hash,omitempty"`
+		Phone        string `yaml:"phone,omitempty"`
+		PollInterval int    `yaml:"poll_interval,omitempty"`
+	} `yaml:"telegram"`
+
+	// Chains holds per-chain RPC lists (first entry is primary) and keys.
+	// An entry with a chain_id that isn't built in adds an EVM chain.
+	Chains map[Chain]ChainFileConfig `yaml:"chains,omitempty"`
+
+	Helius struct {
+		APIKey string `yaml:"api_key,omitempty"`
+		RPCURL string `yaml:"rpc_url,omitempty"`
+	} `yaml:"helius"`
+	SolscanAPIKey  string `yaml:"solscan_api_key,omitempty"`
+	BirdeyeAPIKey  string `yaml:"birdeye_api_key,omitempty"`
+	DexScreenerAPI string `yaml:"dexscreener_api,omitempty"`
+
+	KOLs []KOLConfig `yaml:"kols,omitempty"`
+
+	Intervals struct {
+		ChainScan       int `yaml:"chain_scan,omitempty"`
+		PatternAnalysis int `yaml:"pattern_analysis,omitempty"`
+		FreshBuyerScan  int `yaml:"fresh_buyer_scan,omitempty"`
+		AIAnalysis      int `yaml:"ai_analysis,omitempty"`
+		FixedFloatScan  int `yaml:"fixedfloat_scan,omitempty"`
+	} `yaml:"intervals"`
+
+	Thresholds struct {
+		WashWalletMinScore      float64 `yaml:"wash_wallet_min_score,omitempty"`
+		AmountMatchTolerancePct float64 `yaml:"amount_match_tolerance_pct,omitempty"`
+		FreshWalletAgeHours     int     `yaml:"fresh_wallet_age_hours,omitempty"`
+		PreBuyWindowSeconds     int     `yaml:"pre_buy_window_seconds,omitempty"`
+		PostBuyWindowSeconds    int     `yaml:"post_buy_window_seconds,omitempty"`
+		FundingTraceDepth       int     `yaml:"funding_trace_depth,omitempty"`
+	} `yaml:"thresholds"`
+
+	// AnalyzerWeights overrides individual wash-score weights; zero keeps the default.
+	AnalyzerWeights AnalyzerWeights `yaml:"analyzer_weights"`
+
+	// KnownServices extends the built-in service address lists.
+	KnownServices struct {
+		FixedFloat    map[Chain][]string `yaml:"fixedfloat,omitempty"`
+		Bridges       map[Chain][]string `yaml:"bridges,omitempty"`
+		EVMAddresses  map[string]string  `yaml:"evm_addresses,omitempty"` // address → "cex:binance", "dex:uniswap_v2", …
+		TronAddresses map[string]string  `yaml:"tron_addresses,omitempty"`
+	} `yaml:"known_services"`
+
+	Database struct {
+		Driver string `yaml:"driver,omitempty"`
+		URL    string `yaml:"url,omitempty"`
+		Path   string `yaml:"path,omitempty"`
+	} `yaml:"database"`
+
+	Retention struct {
+		TxDays          int    `yaml:"tx_days,omitempty"`
+		PostsDays       int    `yaml:"posts_days,omitempty"`
+		AlertsDays      int    `yaml:"alerts_days,omitempty"`
+		ArchiveDir      string `yaml:"archive_dir,omitempty"`
+		JanitorInterval int    `yaml:"janitor_interval,omitempty"`
+	} `yaml:"retention"`
+
+	Backup struct {
+		Dir      string `yaml:"dir,omitempty"`
+		Interval int    `yaml:"interval,omitempty"`
+		Keep     int    `yaml:"keep,omitempty"`
+	} `yaml:"backup"`
+
+	Scan struct {
+		Workers    int                `yaml:"workers,omitempty"`
+		RateLimits map[string]float64 `yaml:"rate_limits,omitempty"` // API host -> requests/second
+		Providers  []string           `yaml:"providers,omitempty"`   // priority order
+	} `yaml:"scan"`
+
+	RPC struct {
+		HealthInterval int `yaml:"health_interval,omitempty"` // seconds between endpoint probes
+		MaxLag         int `yaml:"max_lag,omitempty"`         // seconds behind the best head before quarantine
+	} `yaml:"rpc"`
+
+	Stream struct {
+		Enabled       *bool   `yaml:"enabled,omitempty"`
+		MinConfidence float64 `yaml:"min_confidence,omitempty"` // subscribe wallets at or above this confidence
+	} `yaml:"stream"`
+
+	Dashboard struct {
+		Port int `yaml:"port,omitempty"`
+	} `yaml:"dashboard"`
+
+	AI struct {
+		Provider        string `yaml:"provider,omitempty"`
+		AnthropicAPIKey string `yaml:"anthropic_api_key,omitempty"`
+		OpenAIAPIKey    string `yaml:"openai_api_key,omitempty"`
+		OllamaURL       string `yaml:"ollama_url,omitempty"`
+		OllamaModel     string `yaml:"ollama_model,omitempty"`
+		OllamaAutoPull  *bool  `yaml:"ollama_auto_pull,omitempty"`
+		Model           string `yaml:"model,omitempty"`
+		ModelFast       string `yaml:"model_fast,omitempty"`
+		MaxTokens       int    `yaml:"max_tokens,omitempty"`
+	} `yaml:"ai"`
+}
+
+type ChainFileConfig struct {
+	RPC         []string `yaml:"rpc,omitempty"`
+	WS          string   `yaml:"ws,omitempty"`
+	ExplorerKey string   `yaml:"explorer_key,omitempty"`
+
+	// Registry fields; set them to add a chain or override a built-in one.
+	ChainID       int64    `yaml:"chain_id,omitempty"`
+	NativeSymbol  string   `yaml:"native_symbol,omitempty"`
+	WrappedNative string   `yaml:"wrapped_native,omitempty"`
+	ExplorerAPI   string   `yaml:"explorer_api,omitempty"`  // Etherscan-compatible API base
+	BlockTime     float64  `yaml:"block_time,omitempty"`    // seconds
+	GeckoNetwork  string   `yaml:"gecko_network,omitempty"` // GeckoTerminal network id
+	DexScreener   string   `yaml:"dexscreener,omitempty"`   // DexScreener chain id
+	Stablecoins   []string `yaml:"stablecoins,omitempty"`
+}
+
+// KOLConfig is one KOL target with its known wallets.
+type KOLConfig struct {
+	Name     string        `yaml:"name"`
+	Twitter  string        `yaml:"twitter,omitempty"`
+	Telegram string        `yaml:"telegram,omitempty"`
+	Wallets  []KnownWallet `yaml:"wallets,omitempty"`
+}
+
+// AnalyzerWeights are the per-signal contributions to a wash score (0-1).
+type AnalyzerWeights struct {
+	TokenOverlapPerToken float64 `yaml:"token_overlap_per_token,omitempty"`
+	TokenOverlapMax      float64 `yaml:"token_overlap_max,omitempty"`
+	Timing               float64 `yaml:"timing,omitempty"`
+	Amount               float64 `yaml:"amount,omitempty"`
+	DEX                  float64 `yaml:"dex,omitempty"`
+	Gas                  float64 `yaml:"gas,omitempty"`
+	FundingFixedFloat    float64 `yaml:"funding_fixedfloat,omitempty"`
+	FundingBridge        float64 `yaml:"funding_bridge,omitempty"`
+	FundingMixer         float64 `yaml:"funding_mixer,omitempty"`
+	FundingSwapService   float64 `yaml:"funding_swap_service,omitempty"`
+	AgeUnderDay          float64 `yaml:"age_under_day,omitempty"`
+	AgeUnderWeek         float64 `yaml:"age_under_week,omitempty"`
+	SharedWithOtherKOL   float64 `yaml:"shared_with_other_kol,omitempty"`
+}
+
+// DefaultAnalyzerWeights are the built-in wash-score weights.
+func DefaultAnalyzerWeights() AnalyzerWeights {
+	return AnalyzerWeights{
+		TokenOverlapPerToken: 0.1, TokenOverlapMax: 0.3,
+		Timing: 0.2, Amount: 0.15, DEX: 0.1, Gas: 0.15,
+		FundingFixedFloat: 0.30, FundingBridge: 0.20, FundingMixer: 0.35, FundingSwapService: 0.25,
+		AgeUnderDay: 0.2, AgeUnderWeek: 0.1,
+		SharedWithOtherKOL: 0.15,
+	}
+}
+
+// merge fills zero fields of w from d.
+func (w AnalyzerWeights) merge(d AnalyzerWeights) AnalyzerWeights {
+	pick := func(v, def float64) float64 {
+		if v == 0 {
+			return def
+		}
+		return v
+	}
+	return AnalyzerWeights{
+		TokenOverlapPerToken: pick(w.TokenOverlapPerToken, d.TokenOverlapPerToken),
+		TokenOverlapMax:      pick(w.TokenOverlapMax, d.TokenOverlapMax),
+		Timing:               pick(w.Timing, d.Timing),
+		Amount:               pick(w.Amount, d.Amount),
+		DEX:                  pick(w.DEX, d.DEX),
+		Gas:                  pick(w.Gas, d.Gas),
+		FundingFixedFloat:    pick(w.FundingFixedFloat, d.FundingFixedFloat),
+		FundingBridge:        pick(w.FundingBridge, d.FundingBridge),
+		FundingMixer:         pick(w.FundingMixer, d.FundingMixer),
+		FundingSwapService:   pick(w.FundingSwapService, d.FundingSwapService),
+		AgeUnderDay:          pick(w.AgeUnderDay, d.AgeUnderDay),
+		AgeUnderWeek:         pick(w.AgeUnderWeek, d.AgeUnderWeek),
+		SharedWithOtherKOL:   pick(w.SharedWithOtherKOL, d.SharedWithOtherKOL),
+	}
+}
+
+// LoadFile parses a YAML config file. Unknown keys are an error so typos
+// don't silently fall back to defaults.
+func LoadFile(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fc := &FileConfig{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// configFilePath returns CONFIG_FILE, or DefaultConfigFile if it exists.
+func configFilePath() string {
+	if p := os.Getenv("CONFIG_FILE"); p != "" {
+		return p
+	}
+	if _, err := os.Stat(DefaultConfigFile); err == nil {
+		return DefaultConfigFile
+	}
+	return ""
+}
+
+// ToFile renders the resolved config in the config file schema.
+func (c *Config) ToFile() *FileConfig {
+	fc := &FileConfig{}
+	fc.Twitter.Username, fc.Twitter.Password, fc.Twitter.Email = c.TwitterUsername, c.TwitterPassword, c.TwitterEmail
+	fc.Twitter.AuthToken, fc.Twitter.CSRFToken = c.TwitterAuthToken, c.TwitterCSRFToken
+	fc.Twitter.CookieFile, fc.Twitter.BearerToken = c.TwitterCookieFile, c.TwitterBearerToken
+	fc.Twitter.NitterInstances = c.NitterInstances
+	fc.Twitter.PollInterval = int(c.TwitterPollInterval.Seconds())
+
+	fc.Telegram.APIID, fc.Telegram.APIHash, fc.Telegram.Phone = c.TelegramAPIID, c.TelegramAPIHash, c.TelegramPhone
+	fc.Telegram.PollInterval = int(c.TelegramPollInterval.Seconds())
+
+	fc.Chains = map[Chain]ChainFileConfig{}
+	fc.KnownServices.FixedFloat = map[Chain][]string{}
+	fc.KnownServices.Bridges = map[Chain][]string{}
+	for _, ch := range AllChains() {
+		ci, _ := LookupChain(ch)
+		cc := ChainFileConfig{RPC: c.RPCEndpoints[ch], ExplorerKey: c.ExplorerKeys[ch],
+			ChainID: ci.ChainID, NativeSymbol: ci.NativeSymbol, WrappedNative: ci.WrappedNative,
+			ExplorerAPI: ci.ExplorerAPI, BlockTime: ci.BlockTime.Seconds(),
+			GeckoNetwork: ci.GeckoNetwork, DexScreener: ci.DexScreener, Stablecoins: ci.Stablecoins}
+		if ch == ChainSolana {
+			cc.WS = c.SolanaWSURL
+		} else {
+			cc.WS = c.EVMWS[ch]
+		}
+		fc.Chains[ch] = cc
+		fc.KnownServices.FixedFloat[ch] = FixedFloatAddresses(ch)
+		fc.KnownServices.Bridges[ch] = BridgeContracts(ch)
+	}
+	fc.Helius.APIKey, fc.Helius.RPCURL = c.HeliusAPIKey, c.HeliusRPCURL
+	fc.SolscanAPIKey, fc.BirdeyeAPIKey, fc.DexScreenerAPI = c.SolscanAPIKey, c.BirdeyeAPIKey, c.DexScreenerAPI
+	fc.KOLs = c.KOLs
+
+	fc.Intervals.ChainScan = int(c.ChainScanInterval.Seconds())
+	fc.Intervals.PatternAnalysis = int(c.PatternAnalysisInterval.Seconds())
+	fc.Intervals.FreshBuyerScan = int(c.FreshBuyerScanInterval.Seconds())
+	fc.Intervals.AIAnalysis = int(c.AIAnalysisInterval.Seconds())
+	fc.Intervals.FixedFloatScan = int(c.FixedFloatScanInterval.Seconds())
+
+	fc.Thresholds.WashWalletMinScore = c.WashWalletMinScore
+	fc.Thresholds.AmountMatchTolerancePct = c.AmountMatchTolerancePct
+	fc.Thresholds.FreshWalletAgeHours = c.FreshWalletAgeHours
+	fc.Thresholds.PreBuyWindowSeconds = c.PreBuyWindowSeconds
+	fc.Thresholds.PostBuyWindowSeconds = c.PostBuyWindowSeconds
+	fc.Thresholds.FundingTraceDepth = c.FundingTraceDepth
+	fc.AnalyzerWeights = c.Weights
+
+	ks := loadedServices()
+	fc.KnownServices.EVMAddresses = knownAddresses(KnownEVMAddresses, ks.evm)
+	fc.KnownServices.TronAddresses = knownAddresses(KnownTronAddresses, ks.tron)
+
+	fc.Database.Driver, fc.Database.URL, fc.Database.Path = c.DBDriver, c.DBURL, c.DBPath
+	fc.Retention.TxDays, fc.Retention.PostsDays, fc.Retention.AlertsDays = c.RetentionTxDays, c.RetentionPostsDays, c.RetentionAlertsDays
+	fc.Retention.ArchiveDir = c.ArchiveDir
+	fc.Retention.JanitorInterval = int(c.JanitorInterval.Seconds())
+	fc.Backup.Dir, fc.Backup.Keep = c.BackupDir, c.BackupKeep
+	fc.Backup.Interval = int(c.BackupInterval.Seconds())
+	fc.Scan.Workers, fc.Scan.RateLimits, fc.Scan.Providers = c.ScanWorkers, c.RateLimits, c.ScanProviders
+	fc.RPC.HealthInterval, fc.RPC.MaxLag = int(c.RPCHealthInterval.Seconds()), int(c.RPCMaxLag.Seconds())
+	stream := c.StreamEnabled
+	fc.Stream.Enabled, fc.Stream.MinConfidence = &stream, c.StreamMinConfidence
+	fc.Dashboard.Port = c.DashboardPort
+
+	fc.AI.Provider, fc.AI.AnthropicAPIKey, fc.AI.OpenAIAPIKey = c.AIProvider, c.AnthropicAPIKey, c.OpenAIAPIKey
+	fc.AI.OllamaURL, fc.AI.OllamaModel = c.OllamaURL, c.OllamaModel
+	autoPull := c.OllamaAutoStart
+	fc.AI.OllamaAutoPull = &autoPull
+	fc.AI.Model, fc.AI.ModelFast, fc.AI.MaxTokens = c.AIModel, c.AIModelFast, c.AIMaxTokens
+	return fc
+}
+
+const redacted = "<redacted>"
+
+// Redact blanks credentials and API keys embedded in URLs, in place.
+func (fc *FileConfig) Redact() *FileConfig {
+	for _, s := range []*string{
+		&fc.Twitter.Password, &fc.Twitter.AuthToken, &fc.Twitter.CSRFToken, &fc.Twitter.BearerToken,
+		&fc.Telegram.APIHash, &fc.Telegram.Phone,
+		&fc.Helius.APIKey, &fc.SolscanAPIKey, &fc.BirdeyeAPIKey,
+		&fc.AI.AnthropicAPIKey, &fc.AI.OpenAIAPIKey,
+	} {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+	fc.Helius.RPCURL = RedactURL(fc.Helius.RPCURL)
+	fc.Database.URL = RedactURL(fc.Database.URL)
+	chains := map[Chain]ChainFileConfig{}
+	for ch, cc := range fc.Chains {
+		rpcs := make([]string, len(cc.RPC))
+		for i, u := range cc.RPC {
+			rpcs[i] = RedactURL(u)
+		}
+		cc.RPC, cc.WS = rpcs, RedactURL(cc.WS)
+		if cc.ExplorerKey != "" {
+			cc.ExplorerKey = redacted
+		}
+		chains[ch] = cc
+	}
+	fc.Chains = chains
+	return fc
+}
+
+var (
+	secretParamRe = regexp.MustCompile(`(?i)(key|token|secret|password|auth)`)
+	secretPathRe  = regexp.MustCompile(`^[A-Za-z0-9_-]{24,}$`)
+)
+
+// RedactURL hides passwords, key-like query params and long opaque path
+// segments (Chainstack/Alchemy style keys) in a URL.
+func RedactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	q := u.Query()
+	for k := range q {
+		if secretParamRe.MatchString(k) {
+			q.Set(k, "xxxxx")
+		}
+	}
+	u.RawQuery = q.Encode()
+	segs := strings.Split(u.Path, "/")
+	for i, s := range segs {
+		if secretPathRe.MatchString(s) {
+			segs[i] = "xxxxx"
+		}
+	}
+	u.Path = strings.Join(segs, "/")
+	return u.String()
+}
+
+// YAML renders the file config.
+func (fc *FileConfig) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(fc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// reloadable are the fields a running daemon picks up on reload. Everything
+// else (credentials, RPCs, DB, ports) is read once at startup.
+var reloadable = map[string]bool{
+	"TwitterPollInterval":     true,
+	"TelegramPollInterval":    true,
+	"ChainScanInterval":       true,
+	"PatternAnalysisInterval": true,
+	"FreshBuyerScanInterval":  true,
+	"AIAnalysisInterval":      true,
+	"FixedFloatScanInterval":  true,
+	"WashWalletMinScore":      true,
+	"AmountMatchTolerancePct": true,
+	"FreshWalletAgeHours":     true,
+	"PreBuyWindowSeconds":     true,
+	"PostBuyWindowSeconds":    true,
+	"FundingTraceDepth":       true,
+	"Weights":                 true,
+	"KOLs":                    true,
+	"KOLKnownWallets":         true,
+	"RetentionTxDays":         true,
+	"RetentionPostsDays":      true,
+	"RetentionAlertsDays":     true,
+	"ArchiveDir":              true,
+	"BackupDir":               true,
+	"BackupKeep":              true,
+	"StreamMinConfidence":     true,
+}
+
+// targetFields are reloadable too, but the monitors own the live lists, so
+// Apply leaves them to the caller (AddHandle/RemoveHandle etc.).
+var targetFields = map[string]bool{
+	"KOLTwitterHandles":   true,
+	"KOLTelegramChannels": true,
+}
+
+// Change is one field that differs between two loaded configs. Old and New
+// are left empty for restart-only fields since those may hold secrets.
+type Change struct {
+	Field   string
+	Old     string
+	New     string
+	Restart bool
+}
+
+func (ch Change) String() string {
+	if ch.Restart {
+		return ch.Field + " changed (restart required)"
+	}
+	return fmt.Sprintf("%s: %s -> %s", ch.Field, ch.Old, ch.New)
+}
+
+// Diff lists the fields that differ between old and next.
+func Diff(old, next *Config) []Change {
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem()
+	t := ov.Type()
+	var out []Change
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		a, b := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		ch := Change{Field: f.Name, Restart: !reloadable[f.Name] && !targetFields[f.Name]}
+		if !ch.Restart {
+			ch.Old, ch.New = fmt.Sprintf("%+v", a), fmt.Sprintf("%+v", b)
+		}
+		out = append(out, ch)
+	}
+	return out
+}
+
+// Apply publishes a new snapshot holding the reloadable fields from next
+// and everything else from the current one, then wakes everything waiting
+// on Reloaded. c and earlier snapshots are never modified, so a component
+// reading Current() while the reload lands sees either the old or the new
+// values, never a mix.
+func (c *Config) Apply(next *Config, changes []Change) {
+	cur := c.Current()
+	snap := *next
+	sv, cv := reflect.ValueOf(&snap).Elem(), reflect.ValueOf(cur).Elem()
+	t := sv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && !reloadable[f.Name] && !targetFields[f.Name] {
+			sv.Field(i).Set(cv.Field(i))
+		}
+	}
+	snap.reload, snap.live = c.reload, c.live
+	if c.live != nil {
+		c.live.Store(&snap)
+	}
+	c.reload.notify()
+}
+
+// Current returns the config as of the last reload: c itself until Apply
+// first runs, then the snapshot it published. Read reloadable fields through
+// Current rather than from c, and read them once per pass.
+func (c *Config) Current() *Config {
+	if c.live != nil {
+		if p := c.live.Load(); p != nil {
+			return p
+		}
+	}
+	return c
+}
+
+// Reloaded returns a channel that is closed on the next Apply. Loops select
+// on it to reset their tickers; call it again after each wake-up.
+func (c *Config) Reloaded() <-chan struct{} {
+	return c.reload.wait()
+}
+
+type notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{ch: make(chan struct{})}
+}
+
+func (n *notifier) wait() <-chan struct{} {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ch
+}
+
+func (n *notifier) notify() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.ch)
+	n.ch = make(chan struct{})
+}
+
+var (
+	procEnvOnce sync.Once
+	procEnv     map[string]bool // set before .env was first read; .env never overrides these
+	dotenvKeys  map[string]bool // currently set from .env
+)
+
+// loadDotEnv applies .env on top of the process environment. Unlike
+// godotenv.Load it can run again on reload: values edited in .env replace
+// the ones it set earlier, and keys removed from .env are unset.
+func loadDotEnv() {
+	procEnvOnce.Do(func() {
+		procEnv = map[string]bool{}
+		for _, kv := range os.Environ() {
+			k, _, _ := strings.Cut(kv, "=")
+			procEnv[k] = true
+		}
+	})
+	vals, err := godotenv.Read()
+	if err != nil {
+		return
+	}
+	for k := range dotenvKeys {
+		if _, ok := vals[k]; !ok {
+			os.Unsetenv(k)
+		}
+	}
+	dotenvKeys = map[string]bool{}
+	for k, v := range vals {
+		if !procEnv[k] {
+			os.Setenv(k, v)
+			dotenvKeys[k] = true
+		}
+	}
+}
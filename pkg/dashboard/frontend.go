@@ -91,6 +91,7 @@ tr:hover td{background:rgba(59,130,246,.02)}
 const{useState,useEffect,useCallback}=React;
 const useFetch=(u,ms=8000)=>{const[d,sD]=useState(null);const ld=useCallback(()=>{fetch(u).then(r=>r.json()).then(sD).catch(()=>{})},[u]);useEffect(()=>{ld();const i=setInterval(ld,ms);return()=>clearInterval(i)},[ld,ms]);return{d,r:ld}};
 const ab=a=>a?(a.slice(0,6)+'...'+a.slice(-4)):'-';
+const ChainOpts=()=>{const{d}=useFetch('/api/chains',600000);return(d||[{chain:'solana'},{chain:'ethereum'},{chain:'base'},{chain:'bsc'}]).map(c=><option key={c.chain} value={c.chain}>{c.chain}</option>)};
 const CB=c=>{const m={solana:'bg-sol',ethereum:'bg-eth',base:'bg-base',bsc:'bg-bsc'};return<span className={'bg '+(m[c]||'')}>{c||'?'}</span>};
 const SB=s=>{const p=Math.round((s||0)*100);return<span className={'sc '+(p>=70?'sc-h':p>=40?'sc-m':'sc-l')}>{p}%</span>};
 const FB=t=>{const m={fixedfloat:'bg-ff',bridge:'bg-br',mixer:'bg-mx',swap_service:'bg-ff'};return t?<span className={'bg '+(m[t]||'')}>{t}</span>:null};
@@ -104,6 +105,7 @@ function App(){
   const{d:wash}=useFetch('/api/wash-candidates');
   const{d:alerts}=useFetch('/api/alerts');
   const{d:wallets,r:rW}=useFetch('/api/wallets');
+  const{d:sq}=useFetch('/api/scan-queue',3000);
   const notify=m=>{sToast(m);setTimeout(()=>sToast(''),4000)};
 
   return<div className="app">
@@ -122,6 +124,8 @@ function App(){
       <div className="st"><div className="v o">{stats?.high_confidence_wash||0}</div><div className="l">Wash Suspects</div></div>
       <div className="st"><div className="v r">{stats?.alerts||0}</div><div className="l">Alerts</div></div>
       <div className="st"><div className="v c">{stats?.token_mentions||0}</div><div className="l">Token Mentions</div></div>
+      <div className="st" title={(sq?.providers||[]).map(p=>p.host+': '+p.rate_per_sec+'/s, '+p.waiting+' waiting').join('\n')}><div className="v b">{sq?.scheduler?.queued||0}<span style={{fontSize:14,opacity:.6}}> / {sq?.scheduler?.in_flight||0}</span></div><div className="l">Scan Queue / Active</div></div>
+      <div className="st" title={(sq?.rpc||[]).map(e=>e.chain+' '+e.url+': '+(e.quarantined?'quarantined ('+(e.last_error||'')+')':'head '+e.head+', '+e.latency_ms+'ms')).join('\n')}><div className={'v '+((sq?.rpc||[]).some(e=>e.quarantined)?'o':'g')}>{(sq?.rpc||[]).filter(e=>!e.quarantined).length}<span style={{fontSize:14,opacity:.6}}> / {(sq?.rpc||[]).length}</span></div><div className="l">RPC Healthy</div></div>
     </div>
     <div className="nav">
       {[['overview','📊 Overview'],['kols','👤 KOLs'],['wallets','👛 Wallets'],['wash','🧹 Wash Detection'],['alerts','⚠️ Alerts']].map(([k,l])=>
@@ -227,9 +231,10 @@ function WalletsTab({wallets,kols,onAddWallet}){
 
 function WashTab({wash}){
   return<div className="pn"><div className="pn-h"><h2>🧹 Wash Wallet Candidates ({(wash||[]).length})</h2></div>
-    <div className="pn-b scy" style={{maxHeight:700}}><table><thead><tr><th>Address</th><th>Chain</th><th>Score</th><th>Funding</th><th>Amount</th><th>Signals</th><th>Detected</th></tr></thead><tbody>
+    <div className="pn-b scy" style={{maxHeight:700}}><table><thead><tr><th>Address</th><th>Chain</th><th>Score</th><th>Funding</th><th>Amount</th><th>Trace</th><th>Signals</th><th>Detected</th></tr></thead><tbody>
       {(wash||[]).map((c,i)=><tr key={i}><td className="addr" title={c.address}>{ab(c.address)}</td><td>{CB(c.chain)}</td><td>{SB(c.confidence_score)}</td><td>{FB(c.funding_source_type)}</td>
         <td style={{fontFamily:'monospace',fontSize:11}}>{c.funding_amount>0?c.funding_amount.toFixed(4)+' '+c.funding_token:'-'}</td>
+        <td style={{fontSize:11}}>{c.suspicion_level||'-'} {FB(c.origin_type)}</td>
         <td>{c.bought_same_token&&<span className="sig">🎯</span>}{c.timing_match&&<span className="sig">⏰</span>}{c.amount_pattern_match&&<span className="sig">💰</span>}{c.bot_signature_match&&<span className="sig">🤖</span>}</td>
         <td style={{color:'var(--tx3)',fontSize:10}}>{TA(c.created_at)}</td></tr>)}
     </tbody></table>{(!wash||!wash.length)&&<div className="emp"><div className="ic">🔍</div>No wash candidates yet. The system detects them as it monitors KOL activity.</div>}</div>
@@ -275,7 +280,7 @@ function AddKOLModal({onClose,onAdded}){
       <div style={{display:'flex',gap:6}}>
         <input value={wa} onChange={e=>sWa(e.target.value)} placeholder="Wallet address (0x... or Sol...)" style={{flex:1}} onKeyDown={e=>{if(e.key==='Enter')addW()}}/>
         <select value={ch} onChange={e=>sCh(e.target.value)} style={{background:'var(--sf2)',border:'1px solid var(--bd)',color:'var(--tx)',padding:'8px 10px',borderRadius:8,fontFamily:'JetBrains Mono',fontSize:11,width:100}}>
-          <option value="solana">Solana</option><option value="ethereum">ETH</option><option value="base">Base</option><option value="bsc">BSC</option>
+          <ChainOpts/>
         </select>
         <button className="btn btn-s" style={{padding:'8px 12px'}} onClick={addW}>Add</button>
       </div>
@@ -290,7 +295,7 @@ function AddKOLModal({onClose,onAdded}){
 
 function AddWalletModal({kol,onClose,onAdded}){
   const[addr,sA]=useState('');const[ch,sCh]=useState('solana');const[label,sL]=useState('');const[ld,sLd]=useState(false);
-  const autoChain=v=>{sA(v);if(v.startsWith('0x'))sCh('ethereum');else if(v.length>40&&!v.startsWith('0x'))sCh('solana')};
+  const autoChain=v=>{sA(v);if(v.startsWith('0x'))sCh('ethereum');else if(/^T[1-9A-HJ-NP-Za-km-z]{33}$/.test(v))sCh('tron');else if(/^(bc1[02-9ac-hj-np-z]{11,71}|[13][1-9A-HJ-NP-Za-km-z]{25,33})$/.test(v))sCh('bitcoin');else if(v.length>40&&!v.startsWith('0x'))sCh('solana')};
   const submit=async()=>{
     if(!addr.trim()){alert('Address required');return}
     sLd(true);
@@ -304,10 +309,10 @@ function AddWalletModal({kol,onClose,onAdded}){
   return<div className="mo" onClick={onClose}><div className="md" onClick={e=>e.stopPropagation()}>
     <h2>👛 Add Wallet to {kol.name}</h2>
     <p style={{fontSize:11,color:'var(--tx2)',marginBottom:16,lineHeight:1.5}}>The AI tracking system will immediately study this wallet — fetch full transaction history, find linked wallets, check cross-chain activity, and trace funding sources.</p>
-    <div className="fg"><label>Wallet Address</label><input value={addr} onChange={e=>autoChain(e.target.value)} placeholder="0x... (EVM) or base58 (Solana)"/></div>
+    <div className="fg"><label>Wallet Address</label><input value={addr} onChange={e=>autoChain(e.target.value)} placeholder="0x... (EVM), T... (Tron), bc1... (Bitcoin) or base58 (Solana)"/></div>
     <div className="fg"><label>Chain</label>
       <select value={ch} onChange={e=>sCh(e.target.value)} style={{background:'var(--sf2)',border:'1px solid var(--bd)',color:'var(--tx)',padding:'10px 12px',borderRadius:8,fontFamily:'JetBrains Mono',fontSize:12}}>
-        <option value="solana">Solana</option><option value="ethereum">Ethereum</option><option value="base">Base</option><option value="bsc">BSC</option>
+        <ChainOpts/>
       </select>
     </div>
     <div className="fg"><label>Label <span style={{color:'var(--tx3)'}}>(optional)</span></label><input value={label} onChange={e=>sL(e.target.value)} placeholder="e.g. main, trading, cold"/></div>
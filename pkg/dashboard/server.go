@@ -14,22 +14,24 @@ import (
 
 	"github.com/kol-tracker/pkg/config"
 	"github.com/kol-tracker/pkg/db"
+	"github.com/kol-tracker/pkg/extractor"
 	"github.com/kol-tracker/pkg/scanner"
 	"github.com/kol-tracker/pkg/telegram"
 	"github.com/kol-tracker/pkg/twitter"
 )
 
 type Dashboard struct {
-	store       *db.Store
+	store       db.Store
 	cfg         *config.Config
 	port        int
 	twitterMon  *twitter.Monitor
 	telegramMon *telegram.Monitor
 	studyEngine *scanner.WalletStudyEngine
 	aiInfo      func() map[string]interface{} // returns AI provider info
+	scanQueue   func() interface{}            // returns scan scheduler, rate limiter and RPC health state
 }
 
-func New(store *db.Store, cfg *config.Config, port int) *Dashboard {
+func New(store db.Store, cfg *config.Config, port int) *Dashboard {
 	return &Dashboard{store: store, cfg: cfg, port: port}
 }
 
@@ -45,6 +47,10 @@ func (d *Dashboard) SetAIInfo(fn func() map[string]interface{}) {
 	d.aiInfo = fn
 }
 
+func (d *Dashboard) SetScanQueueInfo(fn func() interface{}) {
+	d.scanQueue = fn
+}
+
 func (d *Dashboard) Run() error {
 	mux := http.NewServeMux()
 
@@ -53,11 +59,17 @@ func (d *Dashboard) Run() error {
 	mux.HandleFunc("/api/kols/add", cors(d.handleAddKOL))
 	mux.HandleFunc("/api/wallets", cors(d.handleWallets))
 	mux.HandleFunc("/api/wallets/add", cors(d.handleAddWallet))
+	mux.HandleFunc("/api/wallets/history", cors(d.handleWalletHistory))
+	mux.HandleFunc("/api/wallets/links", cors(d.handleWalletLinks))
+	mux.HandleFunc("/api/wallets/funding-trace", cors(d.handleFundingTrace))
 	mux.HandleFunc("/api/wash-candidates", cors(d.handleWashCandidates))
+	mux.HandleFunc("/api/wash-candidates/status", cors(d.handleWashStatus))
 	mux.HandleFunc("/api/alerts", cors(d.handleAlerts))
 	mux.HandleFunc("/api/funding-matches", cors(d.handleFundingMatches))
 	mux.HandleFunc("/api/kol/", cors(d.handleKOLDetail))
 	mux.HandleFunc("/api/ai/info", cors(d.handleAIInfo))
+	mux.HandleFunc("/api/scan-queue", cors(d.handleScanQueue))
+	mux.HandleFunc("/api/chains", cors(d.handleChains))
 
 	mux.HandleFunc("/", d.serveFrontend)
 
@@ -153,7 +165,7 @@ func (d *Dashboard) handleAddKOL(w http.ResponseWriter, r *http.Request) {
 	for _, kw := range req.KnownWallets {
 		chain := config.Chain(kw.Chain)
 		if chain == "" {
-			if strings.HasPrefix(kw.Address, "0x") { chain = config.ChainEthereum } else { chain = config.ChainSolana }
+			chain = extractor.ClassifyAddress(kw.Address)
 		}
 		label := kw.Label
 		if label == "" { label = "manual" }
@@ -231,7 +243,7 @@ func (d *Dashboard) handleAddWallet(w http.ResponseWriter, r *http.Request) {
 
 	chain := config.Chain(req.Chain)
 	if chain == "" {
-		if strings.HasPrefix(req.Address, "0x") { chain = config.ChainEthereum } else { chain = config.ChainSolana }
+		chain = extractor.ClassifyAddress(req.Address)
 	}
 	label := req.Label
 	if label == "" { label = "manual" }
@@ -279,6 +291,50 @@ func (d *Dashboard) handleWallets(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, wallets)
 }
 
+// handleWalletHistory returns the attribution log for an address: every
+// source that linked it, with label and confidence, oldest first.
+func (d *Dashboard) handleWalletHistory(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" { http.Error(w, "address required", 400); return }
+	atts, err := d.store.GetWalletAttributions(address, config.Chain(r.URL.Query().Get("chain")))
+	if err != nil { http.Error(w, err.Error(), 500); return }
+	writeJSON(w, atts)
+}
+
+// handleWalletLinks lists every KOL an address is linked to, with per-link
+// confidence and evidence.
+func (d *Dashboard) handleWalletLinks(w http.ResponseWriter, r *http.Request) {
+	address, chain := r.URL.Query().Get("address"), config.Chain(r.URL.Query().Get("chain"))
+	if address == "" || chain == "" { http.Error(w, "address and chain required", 400); return }
+	links, err := d.store.GetWalletLinks(address, chain)
+	if err != nil { http.Error(w, err.Error(), 500); return }
+	writeJSON(w, links)
+}
+
+// handleFundingTrace returns the hops of a wallet's last funding trace.
+func (d *Dashboard) handleFundingTrace(w http.ResponseWriter, r *http.Request) {
+	address, chain := r.URL.Query().Get("address"), config.Chain(r.URL.Query().Get("chain"))
+	if address == "" || chain == "" { http.Error(w, "address and chain required", 400); return }
+	hops, err := d.store.GetFundingHops(address, chain)
+	if err != nil { http.Error(w, err.Error(), 500); return }
+	if hops == nil { hops = []db.FundingHop{} }
+	writeJSON(w, hops)
+}
+
+// handleWashStatus confirms or dismisses a wash candidate. Confirmed
+// candidates' transactions and alerts are exempt from retention pruning.
+func (d *Dashboard) handleWashStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" { http.Error(w, "POST only", 405); return }
+	var req struct {
+		Address string `json:"address"`
+		Chain   string `json:"chain"`
+		Status  string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil { http.Error(w, "invalid json", 400); return }
+	if err := d.store.SetWashStatus(req.Address, config.Chain(req.Chain), req.Status); err != nil { http.Error(w, err.Error(), 400); return }
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
 func (d *Dashboard) handleWashCandidates(w http.ResponseWriter, r *http.Request) {
 	minScore := 0.0
 	if s := r.URL.Query().Get("min_score"); s != "" { minScore, _ = strconv.ParseFloat(s, 64) }
@@ -310,6 +366,21 @@ func (d *Dashboard) handleAIInfo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (d *Dashboard) handleScanQueue(w http.ResponseWriter, r *http.Request) {
+	if d.scanQueue != nil {
+		writeJSON(w, d.scanQueue())
+	} else {
+		writeJSON(w, map[string]interface{}{})
+	}
+}
+
+// handleChains lists the chain registry, for the chain pickers.
+func (d *Dashboard) handleChains(w http.ResponseWriter, r *http.Request) {
+	var out []config.ChainInfo
+	for _, ch := range config.AllChains() { ci, _ := config.LookupChain(ch); out = append(out, ci) }
+	writeJSON(w, out)
+}
+
 func (d *Dashboard) handleKOLDetail(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) < 4 { http.Error(w, "not found", 404); return }
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// Merge policy for deriving a TrackedWallet from its attribution log:
+//
+//  1. Pinned sources ("manual", "config") are analyst decisions. If any exist,
+//     the most recent pinned attribution wins outright.
+//  2. A review source ("ai_reclassify") re-evaluates the whole wallet, so every
+//     non-pinned attribution older than the latest review is superseded by it.
+//  3. Of what remains, only the latest attribution per KOL and source kind
+//     counts, so a source can revise (and lower) its own earlier estimate for
+//     a KOL without erasing what it said about another. The source kind is
+//     the prefix before the first ':' ("tweet:123" → "tweet").
+//  4. The highest confidence among those wins; ties go to the most recent.
+//
+// Label, confidence, source and primary kol_id of the tracked wallet follow the
+// winner. The same policy, applied to one KOL's attributions only, yields the
+// confidence of that KOL's link in wallet_kol_links (see links.go).
+var (
+	pinnedSources = map[string]bool{"manual": true, "config": true}
+	reviewSources = map[string]bool{"ai_reclassify": true}
+)
+
+// SourceKind returns the attribution source without its per-item suffix.
+func SourceKind(source string) string {
+	if i := strings.Index(source, ":"); i >= 0 {
+		return source[:i]
+	}
+	return source
+}
+
+// MergeAttributions applies the merge policy above. atts may be in any order;
+// ok is false when atts is empty.
+func MergeAttributions(atts []WalletAttribution) (winner WalletAttribution, ok bool) {
+	if len(atts) == 0 {
+		return winner, false
+	}
+
+	newer := func(a, b WalletAttribution) bool {
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID > b.ID
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+
+	// 1. Pinned
+	var pinned *WalletAttribution
+	for i := range atts {
+		if pinnedSources[SourceKind(atts[i].Source)] && (pinned == nil || newer(atts[i], *pinned)) {
+			pinned = &atts[i]
+		}
+	}
+	if pinned != nil {
+		return *pinned, true
+	}
+
+	// 2. Latest review supersedes everything before it
+	var review *WalletAttribution
+	for i := range atts {
+		if reviewSources[SourceKind(atts[i].Source)] && (review == nil || newer(atts[i], *review)) {
+			review = &atts[i]
+		}
+	}
+
+	// 3. Latest per KOL and source kind
+	type sourceKey struct {
+		kolID int64
+		kind  string
+	}
+	latest := map[sourceKey]WalletAttribution{}
+	for _, a := range atts {
+		if review != nil && newer(*review, a) {
+			continue
+		}
+		k := sourceKey{a.KOLID, SourceKind(a.Source)}
+		if cur, ok := latest[k]; !ok || newer(a, cur) {
+			latest[k] = a
+		}
+	}
+
+	// 4. Highest confidence, most recent on ties
+	for _, a := range latest {
+		if !ok || a.Confidence > winner.Confidence || (a.Confidence == winner.Confidence && newer(a, winner)) {
+			winner, ok = a, true
+		}
+	}
+	return winner, ok
+}
+
+// GetWalletAttributions returns the attribution history for an address, oldest
+// first. An empty chain returns history across all chains.
+func (s *SQLStore) GetWalletAttributions(address string, chain config.Chain) ([]WalletAttribution, error) {
+	q := `SELECT id, address, chain, COALESCE(kol_id,0), COALESCE(label,''), confidence, COALESCE(source,''), created_at
+		FROM wallet_attributions WHERE address=?`
+	args := []interface{}{address}
+	if chain != "" {
+		q += " AND chain=?"
+		args = append(args, string(chain))
+	}
+	rows, err := s.query(q+" ORDER BY created_at ASC, id ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WalletAttribution
+	for rows.Next() {
+		var a WalletAttribution
+		var ch string
+		if err := rows.Scan(&a.ID, &a.Address, &ch, &a.KOLID, &a.Label, &a.Confidence, &a.Source, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.Chain = config.Chain(ch)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// UpsertWallet appends an attribution to the log and re-derives the tracked
+// wallet row and its KOL links from the full history under the merge policy.
+// The one exception to appending is config seeding, which runs on every start
+// and reload: a "config" attribution that repeats the KOL's latest one only
+// re-derives. Every other source is logged each time, so a repeated review
+// moves ahead of what was recorded since.
+func (s *SQLStore) UpsertWallet(kolID int64, address string, chain config.Chain, label string, confidence float64, source string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	atts, err := s.attributionsTx(tx, address, chain)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	if source != "config" || !repeatsLatest(atts, kolID, label, confidence, source) {
+		if _, err := tx.Exec(s.dialect.rebind(`
+			INSERT INTO wallet_attributions (address, chain, kol_id, label, confidence, source, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`),
+			address, string(chain), nullID(kolID), label, confidence, source, now); err != nil {
+			return 0, fmt.Errorf("append attribution: %w", err)
+		}
+		if atts, err = s.attributionsTx(tx, address, chain); err != nil {
+			return 0, err
+		}
+	}
+
+	w, ok := MergeAttributions(atts)
+	if !ok {
+		return 0, fmt.Errorf("no attributions for %s", address)
+	}
+
+	owner := w.KOLID
+	if owner == 0 {
+		owner = kolID
+	}
+
+	var id int64
+	err = tx.QueryRow(s.dialect.rebind(`
+		INSERT INTO tracked_wallets (kol_id, address, chain, label, confidence, source)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(address, chain) DO UPDATE SET
+			kol_id = excluded.kol_id,
+			label = excluded.label,
+			confidence = excluded.confidence,
+			source = excluded.source
+		RETURNING id`),
+		nullID(owner), address, string(chain), w.Label, w.Confidence, w.Source).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if kolID > 0 {
+		if err := s.upsertLink(tx, id, kolID, atts, now); err != nil {
+			return 0, fmt.Errorf("link wallet: %w", err)
+		}
+	}
+	return id, tx.Commit()
+}
+
+// attributionsTx reads the attribution log of one wallet inside tx.
+func (s *SQLStore) attributionsTx(tx *sql.Tx, address string, chain config.Chain) ([]WalletAttribution, error) {
+	rows, err := tx.Query(s.dialect.rebind(`
+		SELECT id, COALESCE(kol_id,0), COALESCE(label,''), confidence, COALESCE(source,''), created_at
+		FROM wallet_attributions WHERE address=? AND chain=?`), address, string(chain))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var atts []WalletAttribution
+	for rows.Next() {
+		a := WalletAttribution{Address: address, Chain: chain}
+		if err := rows.Scan(&a.ID, &a.KOLID, &a.Label, &a.Confidence, &a.Source, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		atts = append(atts, a)
+	}
+	return atts, rows.Err()
+}
+
+// repeatsLatest reports whether the newest attribution kolID has from source
+// already says label at confidence.
+func repeatsLatest(atts []WalletAttribution, kolID int64, label string, confidence float64, source string) bool {
+	var last *WalletAttribution
+	for i := range atts {
+		a := &atts[i]
+		if a.KOLID != kolID || a.Source != source {
+			continue
+		}
+		if last == nil || a.CreatedAt.After(last.CreatedAt) || (a.CreatedAt.Equal(last.CreatedAt) && a.ID > last.ID) {
+			last = a
+		}
+	}
+	return last != nil && last.Label == label && last.Confidence == confidence
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+func TestMergeAttributions(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(id int64, kolID int64, source string, conf float64, mins int) WalletAttribution {
+		return WalletAttribution{ID: id, KOLID: kolID, Label: source, Source: source, Confidence: conf,
+			CreatedAt: t0.Add(time.Duration(mins) * time.Minute)}
+	}
+	tests := []struct {
+		name   string
+		atts   []WalletAttribution
+		wantID int64
+		wantOK bool
+	}{
+		{name: "empty", atts: nil, wantOK: false},
+		{
+			name:   "pinned beats higher confidence",
+			atts:   []WalletAttribution{at(1, 1, "tweet:1", 0.9, 0), at(2, 1, "config", 0.5, 1)},
+			wantID: 2, wantOK: true,
+		},
+		{
+			name:   "latest pinned wins",
+			atts:   []WalletAttribution{at(1, 1, "config", 1, 0), at(2, 1, "manual", 0.3, 5), at(3, 1, "tweet:1", 1, 10)},
+			wantID: 2, wantOK: true,
+		},
+		{
+			name:   "source revises its own estimate down",
+			atts:   []WalletAttribution{at(1, 1, "tweet:1", 0.9, 0), at(2, 1, "tweet:2", 0.4, 5), at(3, 1, "linked:abc", 0.5, 1)},
+			wantID: 3, wantOK: true,
+		},
+		{
+			name:   "same source kind for another KOL is kept",
+			atts:   []WalletAttribution{at(1, 1, "tweet:1", 0.9, 0), at(2, 2, "tweet:2", 0.4, 5)},
+			wantID: 1, wantOK: true,
+		},
+		{
+			name:   "review supersedes older estimates",
+			atts:   []WalletAttribution{at(1, 1, "tweet:1", 0.9, 0), at(2, 1, "ai_reclassify", 0.2, 5), at(3, 1, "linked:x", 0.3, 10)},
+			wantID: 3, wantOK: true,
+		},
+		{
+			name:   "ties go to the most recent",
+			atts:   []WalletAttribution{at(1, 1, "tweet:1", 0.5, 0), at(2, 1, "linked:x", 0.5, 0)},
+			wantID: 2, wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, ok := MergeAttributions(tt.atts)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && w.ID != tt.wantID {
+				t.Errorf("winner = %d (%s), want %d", w.ID, w.Source, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestUpsertWalletSkipsConfigRepeats(t *testing.T) {
+	s, err := Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	kolID, err := s.UpsertKOL("kol", "kol", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := "So1anaWa11et"
+	steps := []struct {
+		label  string
+		conf   float64
+		source string
+		want   int
+	}{
+		{"main", 1.0, "config", 1},
+		{"main", 1.0, "config", 1}, // restart re-seeds the same config entry
+		{"main", 0.8, "config", 2},
+		{"main", 1.0, "config", 3},
+		{"alt", 0.7, "ai_reclassify", 4},
+		{"alt", 0.7, "ai_reclassify", 5}, // a repeated review is still recorded
+	}
+	for i, st := range steps {
+		if _, err := s.UpsertWallet(kolID, addr, config.ChainSolana, st.label, st.conf, st.source); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		atts, err := s.GetWalletAttributions(addr, config.ChainSolana)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if len(atts) != st.want {
+			t.Errorf("step %d: %d attributions, want %d", i, len(atts), st.want)
+		}
+	}
+}
+
+func TestRepeatedReviewRegainsPrecedence(t *testing.T) {
+	s, err := Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	kolID, err := s.UpsertKOL("kol", "kol", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := "So1anaWa11et"
+	upsert := func(label string, conf float64, source string) {
+		t.Helper()
+		if _, err := s.UpsertWallet(kolID, addr, config.ChainSolana, label, conf, source); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond) // distinct created_at
+	}
+	upsert("trading", 0.6, "ai_reclassify")
+	upsert("main", 0.9, "funding")
+	upsert("trading", 0.6, "ai_reclassify")
+
+	atts, err := s.GetWalletAttributions(addr, config.ChainSolana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _ := MergeAttributions(atts)
+	if len(atts) != 3 || w.Source != "ai_reclassify" || w.Label != "trading" {
+		t.Errorf("%d attributions, winner %s/%s; want 3, ai_reclassify/trading", len(atts), w.Source, w.Label)
+	}
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// Store is the persistence API used by every component. SQLStore implements
+// it for both SQLite (single process, local file) and PostgreSQL (several
+// tracker instances sharing one database). Pick a backend with Open.
+type Store interface {
+	Migrator
+	Close() error
+
+	// KOL profiles
+	UpsertKOL(name, twitterHandle, telegramChannel string) (int64, error)
+	GetKOLs() ([]KOLProfile, error)
+	GetKOLByHandle(handle string) (*KOLProfile, error)
+	GetKOLByID(id int64) (*KOLProfile, error)
+	DeleteKOL(id int64) error
+
+	// Tracked wallets
+	UpsertWallet(kolID int64, address string, chain config.Chain, label string, confidence float64, source string) (int64, error)
+	GetWalletsForKOL(kolID int64) ([]TrackedWallet, error)
+	GetAllTrackedAddresses() ([]TrackedWallet, error)
+	GetWalletByAddress(address string, chain config.Chain) (*TrackedWallet, error)
+	GetWalletAttributions(address string, chain config.Chain) ([]WalletAttribution, error)
+	GetWalletLinks(address string, chain config.Chain) ([]WalletKOLLink, error)
+
+	// Social posts + token mentions
+	InsertPost(kolID int64, platform, postID, content string, postedAt time.Time, tokens, wallets, links []string) (int64, error)
+	GetUnprocessedPosts() ([]SocialPost, error)
+	MarkPostProcessed(id int64) error
+	GetPostsForKOL(kolID int64, limit int) ([]SocialPost, error)
+	InsertTokenMention(kolID, postID int64, tokenAddr, tokenSymbol string, chain config.Chain, mentionedAt time.Time) error
+	GetRecentTokenMentions(hours int) ([]TokenMention, error)
+
+	// Fresh-buyer watches
+	UpsertTokenWatch(w TokenWatch) error
+	SetTokenWatchChecked(kolID int64, tokenAddr string, checked []string) error
+	GetActiveTokenWatches(now time.Time) ([]TokenWatch, error)
+	DeleteExpiredTokenWatches(now time.Time) (int64, error)
+
+	// Wallet transactions
+	InsertTransaction(tx WalletTransaction) error
+	DeleteTransaction(txHash string, chain config.Chain) (int64, error)
+	GetTransactionsForWallet(walletID int64, limit int) ([]WalletTransaction, error)
+	GetBuyTransactionsForAddress(address string) ([]WalletTransaction, error)
+	GetLastActivity() (map[int64]time.Time, error)
+	GetScanCursor(walletID int64, stream string) (string, error)
+	SetScanCursor(walletID int64, stream, cursor string) error
+	GetTransactionsAfter(afterID int64, since time.Time, limit int) ([]WalletTransaction, error)
+	UpdateTransactionUSD(id int64, amountUSD float64) error
+
+	// Price history
+	UpsertCandles(candles []PriceCandle) error
+	GetCandleAt(asset string, resolution int, at time.Time, maxAge time.Duration) (*PriceCandle, error)
+
+	// Positions
+	GetSwapsForWallet(walletID int64) ([]WalletTransaction, error)
+	ReplacePositions(walletID int64, positions []Position) error
+	GetPositionsForWallet(walletID int64) ([]Position, error)
+
+	// Wash candidates
+	UpsertWashCandidate(wc WashWalletCandidate) error
+	UpdateWashScore(address string, chain config.Chain, score float64, signals map[string]bool) error
+	GetWashCandidates(minScore float64) ([]WashWalletCandidate, error)
+	GetWashCandidatesForKOL(kolID int64) ([]WashWalletCandidate, error)
+	SetWashStatus(address string, chain config.Chain, status string) error
+	SetWashTrace(address string, chain config.Chain, suspicionLevel, originType string) error
+	RecordTraceFailure(address string, chain config.Chain) error
+
+	// Funding traces
+	ReplaceFundingHops(address string, chain config.Chain, hops []FundingHop) error
+	GetFundingHops(address string, chain config.Chain) ([]FundingHop, error)
+
+	// Patterns, alerts, funding matches, stats
+	UpsertPattern(kolID int64, patternType string, data interface{}, sampleCount int) error
+	GetPatternsForKOL(kolID int64) ([]TradingPattern, error)
+	InsertAlert(kolID int64, alertType, severity, title, description, wallet, token string) error
+	GetRecentAlerts(limit int) ([]Alert, error)
+	InsertFundingMatch(fm FundingFlowMatch) error
+	GetFundingMatches(limit int) ([]FundingFlowMatch, error)
+	GetStats() (map[string]int64, error)
+
+	// Retention + backup
+	Prune(policies []RetentionPolicy, archiveDir string, dryRun bool) ([]PruneResult, error)
+	Backup(ctx context.Context, dest string) error
+}
+
+// Migrator is the schema-versioning part of Store (see migrations.go).
+type Migrator interface {
+	SchemaVersion() (int, error)
+	MigrationStatus() ([]MigrationStatus, error)
+	MigrateUp(target int) error
+	MigrateDown(target int) error
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// Open connects to the configured backend and applies pending migrations.
+// driver is "sqlite" (dsn = file path) or "postgres" (dsn = connection URL).
+func Open(driver, dsn string) (*SQLStore, error) {
+	s, err := OpenNoMigrate(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.MigrateUp(0); err != nil {
+		s.db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	return s, nil
+}
+
+// OpenNoMigrate connects without touching the schema. Used by the migrate
+// subcommand so it can inspect or roll back.
+func OpenNoMigrate(driver, dsn string) (*SQLStore, error) {
+	switch strings.ToLower(driver) {
+	case "", "sqlite", "sqlite3":
+		return openSQLite(dsn)
+	case "postgres", "postgresql", "pg":
+		return openPostgres(dsn)
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q (want sqlite or postgres)", driver)
+	}
+}
+
+// ---- Dialect handling ----
+
+type dialect int
+
+const (
+	dialectSQLite dialect = iota
+	dialectPostgres
+)
+
+func (d dialect) String() string {
+	if d == dialectPostgres {
+		return "postgres"
+	}
+	return "sqlite"
+}
+
+// greatest is the scalar two-argument max function.
+func (d dialect) greatest() string {
+	if d == dialectPostgres {
+		return "GREATEST"
+	}
+	return "MAX"
+}
+
+// rebind converts a query written for SQLite into the target dialect:
+// `?` placeholders become `$n` and INSERT OR IGNORE becomes ON CONFLICT DO NOTHING.
+// A `?` inside a quoted string or identifier is left alone.
+func (d dialect) rebind(q string) string {
+	if d != dialectPostgres {
+		return q
+	}
+	if strings.Contains(q, "INSERT OR IGNORE") {
+		q = strings.Replace(q, "INSERT OR IGNORE", "INSERT", 1)
+		if i := strings.Index(q, "RETURNING"); i >= 0 {
+			q = q[:i] + "ON CONFLICT DO NOTHING " + q[i:]
+		} else {
+			q += " ON CONFLICT DO NOTHING"
+		}
+	}
+	var b strings.Builder
+	n := 0
+	var quote rune // the open quote, 0 outside quotes; '' and "" escapes close and reopen
+	for _, r := range q {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// nullID binds an optional KOL reference: the models use 0 for "none", which
+// has to be NULL in the database to satisfy REFERENCES kol_profiles(id).
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+var (
+	autoIncRe = regexp.MustCompile(`(?i)INTEGER PRIMARY KEY AUTOINCREMENT`)
+	integerRe = regexp.MustCompile(`\bINTEGER\b`)
+	realRe    = regexp.MustCompile(`\bREAL\b`)
+)
+
+// ddl translates migration DDL, which is written in SQLite syntax, for the
+// target dialect.
+func (d dialect) ddl(stmt string) string {
+	if d != dialectPostgres {
+		return stmt
+	}
+	stmt = autoIncRe.ReplaceAllString(stmt, "BIGSERIAL PRIMARY KEY")
+	stmt = integerRe.ReplaceAllString(stmt, "BIGINT")
+	stmt = realRe.ReplaceAllString(stmt, "DOUBLE PRECISION")
+	return stmt
+}
+
+func (s *SQLStore) exec(q string, args ...interface{}) (sql.Result, error) {
+	return s.db.Exec(s.dialect.rebind(q), args...)
+}
+
+func (s *SQLStore) query(q string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.Query(s.dialect.rebind(q), args...)
+}
+
+func (s *SQLStore) queryRow(q string, args ...interface{}) *sql.Row {
+	return s.db.QueryRow(s.dialect.rebind(q), args...)
+}
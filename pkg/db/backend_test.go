@@ -0,0 +1,93 @@
+package db
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"placeholders", "SELECT * FROM t WHERE a=? AND b=?", "SELECT * FROM t WHERE a=$1 AND b=$2"},
+		{"string literal", "SELECT * FROM t WHERE a=? AND b LIKE '%?%' AND c=?", "SELECT * FROM t WHERE a=$1 AND b LIKE '%?%' AND c=$2"},
+		{"escaped quote", "SELECT 'it''s ?', ?", "SELECT 'it''s ?', $1"},
+		{"quoted identifier", `SELECT "odd?col" FROM t WHERE a=?`, `SELECT "odd?col" FROM t WHERE a=$1`},
+		{"insert or ignore", "INSERT OR IGNORE INTO t (a) VALUES (?)", "INSERT INTO t (a) VALUES ($1) ON CONFLICT DO NOTHING"},
+		{"insert or ignore returning", "INSERT OR IGNORE INTO t (a) VALUES (?) RETURNING id", "INSERT INTO t (a) VALUES ($1) ON CONFLICT DO NOTHING RETURNING id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dialectPostgres.rebind(tt.in); got != tt.want {
+				t.Errorf("rebind(%q)\n got  %q\n want %q", tt.in, got, tt.want)
+			}
+			if got := dialectSQLite.rebind(tt.in); got != tt.in {
+				t.Errorf("sqlite rebind changed the query: %q", got)
+			}
+		})
+	}
+}
+
+// TestPostgres runs the migrations and the attribution path against a real
+// server. Point TEST_POSTGRES_DSN at a throwaway database: the test rolls the
+// schema all the way back when it is done.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	s, err := Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	t.Cleanup(func() {
+		if err := s.MigrateDown(0); err != nil {
+			t.Errorf("migrate down: %v", err)
+		}
+	})
+
+	if v, err := s.SchemaVersion(); err != nil || v != LatestVersion() {
+		t.Fatalf("schema version = %d, %v; want %d", v, err, LatestVersion())
+	}
+
+	kolID, err := s.UpsertKOL("pg-kol", "pgkol", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := "0x00000000000000000000000000000000000000aa"
+	if _, err := s.UpsertWallet(kolID, addr, config.ChainBase, "main", 1.0, "config"); err != nil {
+		t.Fatalf("upsert with KOL: %v", err)
+	}
+	// recipients found by tracing have no KOL yet
+	if _, err := s.UpsertWallet(0, addr, config.ChainBase, "bridge_recipient", 0.9, "bridge:across:0x1"); err != nil {
+		t.Fatalf("upsert without KOL: %v", err)
+	}
+	if _, err := s.UpsertWallet(kolID, addr, config.ChainBase, "main", 1.0, "config"); err != nil {
+		t.Fatalf("repeat upsert: %v", err)
+	}
+
+	atts, err := s.GetWalletAttributions(addr, config.ChainBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(atts) != 2 {
+		t.Errorf("%d attributions, want 2", len(atts))
+	}
+	w, err := s.GetWalletByAddress(addr, config.ChainBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.KOLID != kolID || w.Label != "main" {
+		t.Errorf("tracked wallet = kol %d %q, want kol %d \"main\"", w.KOLID, w.Label, kolID)
+	}
+
+	if err := s.MigrateDown(0); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if err := s.MigrateUp(0); err != nil {
+		t.Fatalf("migrate up again: %v", err)
+	}
+}
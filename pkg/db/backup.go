@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup writes a consistent snapshot of the live SQLite database to dest
+// using SQLite's online backup API, so the tracker keeps running while it
+// copies. The snapshot is written to dest.tmp, integrity-checked, then
+// renamed into place. PostgreSQL deployments should use pg_dump instead.
+func (s *SQLStore) Backup(ctx context.Context, dest string) error {
+	if s.dialect != dialectSQLite {
+		return fmt.Errorf("backup is only supported for sqlite (use pg_dump for postgres)")
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup target %s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	os.Remove(tmp)
+
+	if err := copySQLite(ctx, s.db, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("backup: %w", err)
+	}
+	if err := VerifySQLite(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// RestoreSQLite replaces the database at dbPath with the snapshot at
+// backupPath. The snapshot is integrity-checked first, and the current
+// database (if any) is kept as dbPath.pre-restore-<timestamp>. The tracker
+// must not be running against dbPath while this runs.
+func RestoreSQLite(ctx context.Context, backupPath, dbPath string) (string, error) {
+	if err := VerifySQLite(backupPath); err != nil {
+		return "", err
+	}
+
+	var saved string
+	if _, err := os.Stat(dbPath); err == nil {
+		cur, err := openSQLite(dbPath)
+		if err != nil {
+			return "", err
+		}
+		saved = fmt.Sprintf("%s.pre-restore-%s", dbPath, time.Now().UTC().Format("20060102T150405Z"))
+		err = copySQLite(ctx, cur.db, saved)
+		cur.Close()
+		if err != nil {
+			return "", fmt.Errorf("save current db: %w", err)
+		}
+	}
+
+	src, err := sql.Open("sqlite3", backupPath+"?mode=ro")
+	if err != nil {
+		return saved, err
+	}
+	defer src.Close()
+	if err := copySQLite(ctx, src, dbPath); err != nil {
+		return saved, fmt.Errorf("restore: %w", err)
+	}
+	return saved, VerifySQLite(dbPath)
+}
+
+// VerifySQLite runs PRAGMA integrity_check on the database file at path.
+func VerifySQLite(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("integrity check %s: %w", path, err)
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check %s failed: %s", path, strings.Join(problems, "; "))
+	}
+	return rows.Err()
+}
+
+// copySQLite copies every page of src into the database file at dest.
+func copySQLite(ctx context.Context, src *sql.DB, dest string) error {
+	dst, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	dc, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dc.Close()
+	sc, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	return dc.Raw(func(d interface{}) error {
+		return sc.Raw(func(s interface{}) error {
+			dconn, ok1 := d.(*sqlite3.SQLiteConn)
+			sconn, ok2 := s.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return fmt.Errorf("not a sqlite connection")
+			}
+			b, err := dconn.Backup("main", sconn, "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := b.Step(-1)
+				if err != nil {
+					b.Finish()
+					return err
+				}
+				if done {
+					break
+				}
+			}
+			return b.Finish()
+		})
+	})
+}
+
+// BackupPath returns dir/<db base name>-<timestamp>.db.
+func BackupPath(dir, dbPath string) string {
+	base := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.db", base, time.Now().UTC().Format("20060102T150405Z")))
+}
+
+// RotateBackups keeps the newest keep snapshots of dbPath in dir and removes
+// the rest. Returns the removed paths.
+func RotateBackups(dir, dbPath string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	base := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	matches, err := filepath.Glob(filepath.Join(dir, base+"-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	// timestamped names sort chronologically
+	sort.Strings(matches)
+	var removed []string
+	for len(matches) > keep {
+		if err := os.Remove(matches[0]); err != nil {
+			return removed, err
+		}
+		removed = append(removed, matches[0])
+		matches = matches[1:]
+	}
+	return removed, nil
+}
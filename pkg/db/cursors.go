@@ -0,0 +1,27 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GetScanCursor returns where the last scan of stream stopped for a wallet,
+// or "" if it has never been scanned.
+func (s *SQLStore) GetScanCursor(walletID int64, stream string) (string, error) {
+	var c string
+	err := s.queryRow(`SELECT cursor FROM scan_cursors WHERE wallet_id=? AND stream=?`, walletID, stream).Scan(&c)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return c, err
+}
+
+// SetScanCursor records the scan position for stream. Callers set it only
+// after the fetched transactions are stored.
+func (s *SQLStore) SetScanCursor(walletID int64, stream, cursor string) error {
+	_, err := s.exec(`
+		INSERT INTO scan_cursors (wallet_id, stream, cursor, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(wallet_id, stream) DO UPDATE SET cursor = excluded.cursor, updated_at = excluded.updated_at`,
+		walletID, stream, cursor, time.Now().UTC())
+	return err
+}
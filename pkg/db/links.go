@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// upsertLink re-derives the (wallet, KOL) link from that KOL's attributions.
+// Runs inside UpsertWallet's transaction.
+func (s *SQLStore) upsertLink(tx *sql.Tx, walletID, kolID int64, atts []WalletAttribution, now time.Time) error {
+	var mine []WalletAttribution
+	seen := map[string]bool{}
+	var evidence []string
+	for _, a := range atts {
+		if a.KOLID != kolID {
+			continue
+		}
+		mine = append(mine, a)
+		if a.Source != "" && !seen[a.Source] {
+			seen[a.Source] = true
+			evidence = append(evidence, a.Source)
+		}
+	}
+	w, ok := MergeAttributions(mine)
+	if !ok {
+		return nil
+	}
+	sort.Strings(evidence)
+	ej, _ := json.Marshal(evidence)
+
+	_, err := tx.Exec(s.dialect.rebind(`
+		INSERT INTO wallet_kol_links (wallet_id, kol_id, label, confidence, evidence, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(wallet_id, kol_id) DO UPDATE SET
+			label = excluded.label,
+			confidence = excluded.confidence,
+			evidence = excluded.evidence,
+			updated_at = excluded.updated_at`),
+		walletID, kolID, w.Label, w.Confidence, string(ej), now, now)
+	return err
+}
+
+// GetWalletLinks returns every KOL an address is linked to, most confident first.
+func (s *SQLStore) GetWalletLinks(address string, chain config.Chain) ([]WalletKOLLink, error) {
+	rows, err := s.query(`
+		SELECT l.wallet_id, l.kol_id, w.address, w.chain, COALESCE(l.label,''), l.confidence,
+			COALESCE(l.evidence,'[]'), l.created_at, l.updated_at
+		FROM wallet_kol_links l JOIN tracked_wallets w ON w.id = l.wallet_id
+		WHERE w.address=? AND w.chain=? ORDER BY l.confidence DESC`, address, string(chain))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []WalletKOLLink
+	for rows.Next() {
+		var l WalletKOLLink
+		var ch, ev string
+		if err := rows.Scan(&l.WalletID, &l.KOLID, &l.Address, &ch, &l.Label, &l.Confidence, &ev, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			continue
+		}
+		l.Chain = config.Chain(ch)
+		json.Unmarshal([]byte(ev), &l.Evidence)
+		links = append(links, l)
+	}
+	return links, nil
+}
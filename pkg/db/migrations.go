@@ -0,0 +1,400 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Migration is a numbered, reversible schema change. Migrations are applied in
+// Version order and each runs inside its own transaction together with the
+// schema_version bookkeeping row, so a failed step leaves the DB untouched.
+//
+// To change the schema, append a new entry to migrations — never edit one that
+// has already shipped, since existing databases have recorded it as applied.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a known migration has been applied.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up:      baselineSchema,
+		Down: `
+DROP TABLE IF EXISTS alerts;
+DROP TABLE IF EXISTS funding_flow_matches;
+DROP TABLE IF EXISTS trading_patterns;
+DROP TABLE IF EXISTS wash_wallet_candidates;
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TABLE IF EXISTS token_mentions;
+DROP TABLE IF EXISTS social_posts;
+DROP TABLE IF EXISTS tracked_wallets;
+DROP TABLE IF EXISTS kol_profiles;`,
+	},
+	{
+		Version: 2,
+		Name:    "wallet_attributions",
+		Up: `
+CREATE TABLE IF NOT EXISTS wallet_attributions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    address TEXT NOT NULL,
+    chain TEXT NOT NULL,
+    kol_id INTEGER REFERENCES kol_profiles(id),
+    label TEXT,
+    confidence REAL NOT NULL,
+    source TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_attr_addr ON wallet_attributions(address, chain);
+INSERT INTO wallet_attributions (address, chain, kol_id, label, confidence, source, created_at)
+    SELECT address, chain, kol_id, label, confidence, source, discovered_at FROM tracked_wallets;`,
+		Down: `DROP TABLE IF EXISTS wallet_attributions;`,
+	},
+	{
+		Version: 3,
+		Name:    "wallet_kol_links",
+		Up: `
+CREATE TABLE IF NOT EXISTS wallet_kol_links (
+    wallet_id INTEGER NOT NULL REFERENCES tracked_wallets(id),
+    kol_id INTEGER NOT NULL REFERENCES kol_profiles(id),
+    label TEXT,
+    confidence REAL NOT NULL,
+    evidence TEXT DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, kol_id)
+);
+CREATE INDEX IF NOT EXISTS idx_links_kol ON wallet_kol_links(kol_id);
+INSERT INTO wallet_kol_links (wallet_id, kol_id, label, confidence, evidence, created_at, updated_at)
+    SELECT id, kol_id, label, confidence,
+        CASE WHEN source IS NULL OR source = '' THEN '[]' ELSE '["' || source || '"]' END,
+        discovered_at, discovered_at
+    FROM tracked_wallets WHERE kol_id IS NOT NULL;
+INSERT INTO wallet_kol_links (wallet_id, kol_id, confidence)
+    SELECT w.id, a.kol_id, MAX(a.confidence)
+    FROM wallet_attributions a JOIN tracked_wallets w ON w.address = a.address AND w.chain = a.chain
+    WHERE a.kol_id IS NOT NULL AND a.kol_id > 0
+    GROUP BY w.id, a.kol_id
+    ON CONFLICT DO NOTHING;`,
+		Down: `DROP TABLE IF EXISTS wallet_kol_links;`,
+	},
+	{
+		// Supports retention pruning and keeps candidate listings fast.
+		Version: 4,
+		Name:    "retention_indexes",
+		Up: `
+CREATE INDEX IF NOT EXISTS idx_post_time ON social_posts(posted_at);
+CREATE INDEX IF NOT EXISTS idx_wash_status ON wash_wallet_candidates(status, confidence_score);`,
+		Down: `
+DROP INDEX IF EXISTS idx_post_time;
+DROP INDEX IF EXISTS idx_wash_status;`,
+	},
+	{
+		// Fresh-buyer watches survive restarts; checked holds a JSON array of
+		// buyer addresses already analyzed for the watch.
+		Version: 5,
+		Name:    "token_watches",
+		Up: `
+CREATE TABLE IF NOT EXISTS token_watches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kol_id INTEGER NOT NULL REFERENCES kol_profiles(id),
+    token_address TEXT NOT NULL,
+    chain TEXT NOT NULL,
+    mention_time TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    checked TEXT DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(kol_id, token_address)
+);
+CREATE INDEX IF NOT EXISTS idx_watch_expires ON token_watches(expires_at);`,
+		Down: `DROP TABLE IF EXISTS token_watches;`,
+	},
+	{
+		// Per-wallet scan positions so each pass only fetches new activity.
+		// stream names the fetch (e.g. "helius:SWAP", "etherscan:tokentx");
+		// cursor is a signature or block number.
+		Version: 6,
+		Name:    "scan_cursors",
+		Up: `
+CREATE TABLE IF NOT EXISTS scan_cursors (
+    wallet_id INTEGER NOT NULL REFERENCES tracked_wallets(id),
+    stream TEXT NOT NULL,
+    cursor TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, stream)
+);`,
+		Down: `DROP TABLE IF EXISTS scan_cursors;`,
+	},
+	{
+		// Historical USD prices for valuing trades at their own time.
+		// open_time is unix seconds; resolution is the bar length in seconds.
+		Version: 7,
+		Name:    "price_candles",
+		Up: `
+CREATE TABLE IF NOT EXISTS price_candles (
+    asset TEXT NOT NULL,
+    resolution INTEGER NOT NULL,
+    open_time INTEGER NOT NULL,
+    open REAL,
+    high REAL,
+    low REAL,
+    close REAL NOT NULL,
+    source TEXT,
+    PRIMARY KEY (asset, resolution, open_time)
+);`,
+		Down: `DROP TABLE IF EXISTS price_candles;`,
+	},
+	{
+		// FIFO position ledger, one row per wallet and token. exits holds a
+		// JSON array of the sells that reduced the position.
+		Version: 8,
+		Name:    "positions",
+		Up: `
+CREATE TABLE IF NOT EXISTS positions (
+    wallet_id INTEGER NOT NULL REFERENCES tracked_wallets(id),
+    chain TEXT NOT NULL,
+    token_address TEXT NOT NULL,
+    token_symbol TEXT,
+    buys INTEGER DEFAULT 0,
+    bought REAL DEFAULT 0,
+    sold REAL DEFAULT 0,
+    held REAL DEFAULT 0,
+    cost_usd REAL DEFAULT 0,
+    proceeds_usd REAL DEFAULT 0,
+    open_cost_usd REAL DEFAULT 0,
+    realized_pnl REAL DEFAULT 0,
+    unrealized_pnl REAL DEFAULT 0,
+    exits TEXT DEFAULT '[]',
+    first_buy_at TIMESTAMP,
+    last_trade_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, chain, token_address)
+);`,
+		Down: `DROP TABLE IF EXISTS positions;`,
+	},
+	{
+		// Deep funding traces: one row per hop, keyed by the traced wallet
+		// (address, chain). A trace replaces the wallet's earlier hops.
+		// Wash candidates keep the trace's verdict.
+		Version: 9,
+		Name:    "funding_hops",
+		Up: `
+CREATE TABLE IF NOT EXISTS funding_hops (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    address TEXT NOT NULL,
+    chain TEXT NOT NULL,
+    from_address TEXT NOT NULL,
+    to_address TEXT NOT NULL,
+    hop_chain TEXT NOT NULL,
+    amount REAL,
+    token TEXT,
+    tx_hash TEXT,
+    hop_type TEXT,
+    service_name TEXT,
+    depth INTEGER DEFAULT 0,
+    timestamp TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_hops_wallet ON funding_hops(address, chain);
+ALTER TABLE wash_wallet_candidates ADD COLUMN suspicion_level TEXT;
+ALTER TABLE wash_wallet_candidates ADD COLUMN origin_type TEXT;`,
+		Down: `
+DROP TABLE IF EXISTS funding_hops;
+ALTER TABLE wash_wallet_candidates DROP COLUMN suspicion_level;
+ALTER TABLE wash_wallet_candidates DROP COLUMN origin_type;`,
+	},
+	{
+		// Matching passes re-run on a schedule; keep one row per pairing of
+		// outgoing tx and funded wallet.
+		Version: 10,
+		Name:    "funding_match_dedupe",
+		Up: `
+DELETE FROM funding_flow_matches WHERE id NOT IN (
+    SELECT MIN(id) FROM funding_flow_matches GROUP BY source_tx, dest_address, dest_chain, service);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_match_pair ON funding_flow_matches(source_tx, dest_address, dest_chain, service);`,
+		Down: `DROP INDEX IF EXISTS idx_match_pair;`,
+	},
+	{
+		// Failed funding traces, so retries back off instead of holding the
+		// front of the trace queue.
+		Version: 11,
+		Name:    "trace_attempts",
+		Up: `
+ALTER TABLE wash_wallet_candidates ADD COLUMN trace_failures INTEGER DEFAULT 0;
+ALTER TABLE wash_wallet_candidates ADD COLUMN trace_attempted_at TIMESTAMP;`,
+		Down: `
+ALTER TABLE wash_wallet_candidates DROP COLUMN trace_failures;
+ALTER TABLE wash_wallet_candidates DROP COLUMN trace_attempted_at;`,
+	},
+}
+
+const schemaVersionTable = `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+// Migrations returns the list of known migrations in version order.
+func Migrations() []Migration {
+	return migrations
+}
+
+// LatestVersion is the highest migration version known to this build.
+func LatestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func (s *SQLStore) ensureVersionTable() error {
+	_, err := s.db.Exec(s.dialect.ddl(schemaVersionTable))
+	return err
+}
+
+// SchemaVersion returns the highest applied migration version (0 if none).
+func (s *SQLStore) SchemaVersion() (int, error) {
+	if err := s.ensureVersionTable(); err != nil {
+		return 0, err
+	}
+	var v sql.NullInt64
+	if err := s.db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&v); err != nil {
+		return 0, err
+	}
+	return int(v.Int64), nil
+}
+
+// MigrationStatus lists every known migration and whether it has been applied.
+func (s *SQLStore) MigrationStatus() ([]MigrationStatus, error) {
+	if err := s.ensureVersionTable(); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []MigrationStatus
+	for _, m := range migrations {
+		st := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// MigrateUp applies pending migrations up to and including target.
+// A target of 0 means "latest".
+func (s *SQLStore) MigrateUp(target int) error {
+	if target <= 0 {
+		target = LatestVersion()
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+		if err := s.applyMigration(m, true); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown rolls back applied migrations until the schema is at target.
+func (s *SQLStore) MigrateDown(target int) error {
+	if target < 0 {
+		target = 0
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		if err := s.applyMigration(m, false); err != nil {
+			return fmt.Errorf("rollback %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) applyMigration(m Migration, up bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Several tracker instances may share one Postgres database and start at
+	// the same time; serialize them and re-check under the lock.
+	if s.dialect == dialectPostgres {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(73150001)"); err != nil {
+			return err
+		}
+	}
+	var n int
+	if err := tx.QueryRow(s.dialect.rebind("SELECT COUNT(*) FROM schema_version WHERE version=?"), m.Version).Scan(&n); err != nil {
+		return err
+	}
+	if (n > 0) == up {
+		return nil // another instance got here first
+	}
+
+	stmt := m.Down
+	if up {
+		stmt = m.Up
+	}
+	if _, err := tx.Exec(s.dialect.ddl(stmt)); err != nil {
+		return err
+	}
+
+	if up {
+		_, err = tx.Exec(s.dialect.rebind("INSERT INTO schema_version (version, name) VALUES (?, ?)"), m.Version, m.Name)
+	} else {
+		_, err = tx.Exec(s.dialect.rebind("DELETE FROM schema_version WHERE version=?"), m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -29,6 +29,35 @@ type TrackedWallet struct {
 	Metadata     string       `json:"metadata"`      // JSON
 }
 
+// WalletAttribution is one entry in the append-only wallet_attributions log.
+// Every UpsertWallet call records who linked the address, with what label and
+// confidence; TrackedWallet is derived from these via MergeAttributions.
+type WalletAttribution struct {
+	ID         int64        `json:"id"`
+	Address    string       `json:"address"`
+	Chain      config.Chain `json:"chain"`
+	KOLID      int64        `json:"kol_id"`
+	Label      string       `json:"label"`
+	Confidence float64      `json:"confidence"`
+	Source     string       `json:"source"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// WalletKOLLink ties a tracked wallet to one KOL. A wallet can be linked to
+// several KOLs (shared wash wallets, insider clusters); each link carries its
+// own confidence and the attribution sources that support it.
+type WalletKOLLink struct {
+	WalletID   int64        `json:"wallet_id"`
+	KOLID      int64        `json:"kol_id"`
+	Address    string       `json:"address"`
+	Chain      config.Chain `json:"chain"`
+	Label      string       `json:"label"`
+	Confidence float64      `json:"confidence"`
+	Evidence   []string     `json:"evidence"` // attribution sources, e.g. "tweet:123","fresh_buyer:<token>"
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
 type SocialPost struct {
 	ID               int64     `json:"id"`
 	KOLID            int64     `json:"kol_id"`
@@ -55,6 +84,70 @@ type TokenMention struct {
 	PostBuyDetected bool       `json:"post_buy_detected"`
 }
 
+// TokenWatch is a persisted fresh-buyer watch: a token a KOL mentioned and
+// the buyers already analyzed for it.
+type TokenWatch struct {
+	KOLID        int64        `json:"kol_id"`
+	TokenAddress string       `json:"token_address"`
+	Chain        config.Chain `json:"chain"`
+	MentionTime  time.Time    `json:"mention_time"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	Checked      []string     `json:"checked"`
+}
+
+// PriceCandle is one OHLC bar of an asset's USD price. Asset is
+// "native:<SYMBOL>" for a chain's coin or "<chain>:<token address>";
+// Resolution is the bar length in seconds.
+type PriceCandle struct {
+	Asset      string    `json:"asset"`
+	Resolution int       `json:"resolution"`
+	OpenTime   time.Time `json:"open_time"`
+	Open       float64   `json:"open"`
+	High       float64   `json:"high"`
+	Low        float64   `json:"low"`
+	Close      float64   `json:"close"`
+	Source     string    `json:"source"`
+}
+
+// Position is a wallet's holding of one token, rebuilt from its swaps with
+// FIFO lots. Amounts are in token units; cost and proceeds are USD at trade
+// time, UnrealizedPnL is marked at UpdatedAt.
+type Position struct {
+	WalletID      int64          `json:"wallet_id"`
+	Chain         config.Chain   `json:"chain"`
+	TokenAddress  string         `json:"token_address"`
+	TokenSymbol   string         `json:"token_symbol"`
+	Buys          int            `json:"buys"`
+	Bought        float64        `json:"bought"`
+	Sold          float64        `json:"sold"`
+	Held          float64        `json:"held"`
+	CostUSD       float64        `json:"cost_usd"`
+	ProceedsUSD   float64        `json:"proceeds_usd"`
+	OpenCostUSD   float64        `json:"open_cost_usd"`
+	RealizedPnL   float64        `json:"realized_pnl"`
+	UnrealizedPnL float64        `json:"unrealized_pnl"`
+	Exits         []PositionExit `json:"exits"`
+	FirstBuyAt    time.Time      `json:"first_buy_at"`
+	LastTradeAt   time.Time      `json:"last_trade_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// PositionExit is one sell out of a position. PctSold is the share of the
+// holding at that moment; CostUSD and HoldSec are the basis and the
+// amount-weighted age of the lots it consumed. An Unpriced sell (no USD
+// value known for it or for a lot it consumed) still consumes lots but is
+// left out of realized PnL.
+type PositionExit struct {
+	TxHash   string    `json:"tx_hash"`
+	At       time.Time `json:"at"`
+	Amount   float64   `json:"amount"`
+	USD      float64   `json:"usd"`
+	CostUSD  float64   `json:"cost_usd"`
+	PctSold  float64   `json:"pct_sold"`
+	HoldSec  float64   `json:"hold_sec"`
+	Unpriced bool      `json:"unpriced,omitempty"`
+}
+
 type WalletTransaction struct {
 	ID            int64        `json:"id"`
 	WalletID      int64        `json:"wallet_id"`
@@ -96,6 +189,29 @@ type WashWalletCandidate struct {
 	Status      string `json:"status"` // "candidate","confirmed","dismissed"
 	Notes       string `json:"notes"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// From the last deep funding trace; empty until the wallet is traced.
+	SuspicionLevel string `json:"suspicion_level"` // "clean","low","medium","high","critical"
+	OriginType     string `json:"origin_type"`     // "fixedfloat","bridge","mixer","cex",…
+	// Failed trace attempts since the last successful one, and when the
+	// latest attempt failed.
+	TraceFailures    int       `json:"trace_failures,omitempty"`
+	TraceAttemptedAt time.Time `json:"trace_attempted_at,omitempty"`
+}
+
+// FundingHop is one edge of a funding trace: money moving from FromAddress
+// to ToAddress, Depth hops back from the traced wallet.
+type FundingHop struct {
+	FromAddress string       `json:"from_address"`
+	ToAddress   string       `json:"to_address"`
+	Amount      float64      `json:"amount"`
+	Token       string       `json:"token"`
+	TxHash      string       `json:"tx_hash"`
+	Chain       config.Chain `json:"chain"`
+	HopType     string       `json:"hop_type"` // "direct","fixedfloat","bridge","mixer","cex_withdraw","unknown"
+	ServiceName string       `json:"service_name"`
+	Timestamp   time.Time    `json:"timestamp"`
+	Depth       int          `json:"depth"`
 }
 
 type TradingPattern struct {
@@ -142,6 +258,8 @@ type Alert struct {
 type ExtractionResult struct {
 	SolanaAddresses []string `json:"solana_addresses"`
 	EVMAddresses    []string `json:"evm_addresses"`
+	TronAddresses   []string `json:"tron_addresses"`
+	BitcoinAddresses []string `json:"bitcoin_addresses"` // wallets only, never CAs
 	TokenSymbols    []string `json:"token_symbols"`    // $TICKER mentions
 	ContractAddrs   []string `json:"contract_addrs"`   // Explicit CAs in text
 	TokenCAsFromLinks []string `json:"token_cas_from_links"` // CAs extracted from dex links
@@ -177,11 +295,13 @@ func (e *ExtractionResult) AllAddresses() []string {
 	var result []string
 	result = append(result, e.SolanaAddresses...)
 	result = append(result, e.EVMAddresses...)
+	result = append(result, e.TronAddresses...)
+	result = append(result, e.BitcoinAddresses...)
 	return result
 }
 
 func (e *ExtractionResult) HasContent() bool {
-	return len(e.SolanaAddresses) > 0 || len(e.EVMAddresses) > 0 ||
+	return len(e.SolanaAddresses) > 0 || len(e.EVMAddresses) > 0 || len(e.TronAddresses) > 0 || len(e.BitcoinAddresses) > 0 ||
 		len(e.TokenSymbols) > 0 || len(e.AllTokenCAs()) > 0
 }
 
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// GetSwapsForWallet returns every swap_buy and swap_sell of a wallet in the
+// order they happened, for rebuilding its positions.
+func (s *SQLStore) GetSwapsForWallet(walletID int64) ([]WalletTransaction, error) {
+	rows, err := s.query(`
+		SELECT id, wallet_id, tx_hash, chain, tx_type, COALESCE(token_address,''),
+			   COALESCE(token_symbol,''), COALESCE(amount_token,0), COALESCE(amount_usd,0), timestamp
+		FROM wallet_transactions
+		WHERE wallet_id=? AND tx_type IN ('swap_buy','swap_sell')
+		ORDER BY timestamp, id`, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []WalletTransaction
+	for rows.Next() {
+		var t WalletTransaction
+		var chain string
+		if err := rows.Scan(&t.ID, &t.WalletID, &t.TxHash, &chain, &t.TxType, &t.TokenAddress,
+			&t.TokenSymbol, &t.AmountToken, &t.AmountUSD, &t.Timestamp); err != nil {
+			return nil, err
+		}
+		t.Chain = config.Chain(chain)
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+// ReplacePositions swaps a wallet's stored positions for a freshly rebuilt
+// set, so tokens that no longer have swaps don't linger.
+func (s *SQLStore) ReplacePositions(walletID int64, positions []Position) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(s.dialect.rebind(`DELETE FROM positions WHERE wallet_id=?`), walletID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(s.dialect.rebind(`
+		INSERT INTO positions (wallet_id, chain, token_address, token_symbol, buys, bought, sold, held,
+			cost_usd, proceeds_usd, open_cost_usd, realized_pnl, unrealized_pnl, exits,
+			first_buy_at, last_trade_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, p := range positions {
+		exits := p.Exits
+		if exits == nil {
+			exits = []PositionExit{}
+		}
+		ej, _ := json.Marshal(exits)
+		var first interface{}
+		if !p.FirstBuyAt.IsZero() {
+			first = p.FirstBuyAt.UTC()
+		}
+		if _, err := stmt.Exec(walletID, string(p.Chain), p.TokenAddress, p.TokenSymbol, p.Buys, p.Bought, p.Sold, p.Held,
+			p.CostUSD, p.ProceedsUSD, p.OpenCostUSD, p.RealizedPnL, p.UnrealizedPnL, string(ej),
+			first, p.LastTradeAt.UTC(), p.UpdatedAt.UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPositionsForWallet returns a wallet's stored positions, largest realized
+// PnL first.
+func (s *SQLStore) GetPositionsForWallet(walletID int64) ([]Position, error) {
+	rows, err := s.query(`
+		SELECT wallet_id, chain, token_address, COALESCE(token_symbol,''), buys, bought, sold, held,
+			   cost_usd, proceeds_usd, open_cost_usd, realized_pnl, unrealized_pnl, COALESCE(exits,'[]'),
+			   first_buy_at, last_trade_at, updated_at
+		FROM positions WHERE wallet_id=? ORDER BY realized_pnl DESC`, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Position
+	for rows.Next() {
+		var p Position
+		var chain, ej string
+		var first, last, updated sql.NullTime
+		if err := rows.Scan(&p.WalletID, &chain, &p.TokenAddress, &p.TokenSymbol, &p.Buys, &p.Bought, &p.Sold, &p.Held,
+			&p.CostUSD, &p.ProceedsUSD, &p.OpenCostUSD, &p.RealizedPnL, &p.UnrealizedPnL, &ej,
+			&first, &last, &updated); err != nil {
+			return nil, err
+		}
+		p.Chain = config.Chain(chain)
+		json.Unmarshal([]byte(ej), &p.Exits)
+		p.FirstBuyAt, p.LastTradeAt, p.UpdatedAt = first.Time, last.Time, updated.Time
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// UpsertCandles stores price bars, replacing any already stored for the same
+// asset, resolution and open time.
+func (s *SQLStore) UpsertCandles(candles []PriceCandle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(s.dialect.rebind(`
+		INSERT INTO price_candles (asset, resolution, open_time, open, high, low, close, source)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(asset, resolution, open_time) DO UPDATE SET
+			open = excluded.open, high = excluded.high, low = excluded.low,
+			close = excluded.close, source = excluded.source`))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, c := range candles {
+		if _, err := stmt.Exec(c.Asset, c.Resolution, c.OpenTime.Unix(), c.Open, c.High, c.Low, c.Close, c.Source); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCandleAt returns the latest bar of asset at resolution that opened at or
+// before at and no more than maxAge before it, or nil if there is none.
+func (s *SQLStore) GetCandleAt(asset string, resolution int, at time.Time, maxAge time.Duration) (*PriceCandle, error) {
+	c := PriceCandle{Asset: asset, Resolution: resolution}
+	var open int64
+	var src sql.NullString
+	err := s.queryRow(`
+		SELECT open_time, COALESCE(open,0), COALESCE(high,0), COALESCE(low,0), close, source
+		FROM price_candles
+		WHERE asset=? AND resolution=? AND open_time<=? AND open_time>=?
+		ORDER BY open_time DESC LIMIT 1`,
+		asset, resolution, at.Unix(), at.Add(-maxAge).Unix()).Scan(&open, &c.Open, &c.High, &c.Low, &c.Close, &src)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.OpenTime = time.Unix(open, 0).UTC()
+	c.Source = src.String
+	return &c, nil
+}
+
+// GetTransactionsAfter pages through transactions in id order: up to limit
+// rows with id > afterID and a timestamp at or after since.
+func (s *SQLStore) GetTransactionsAfter(afterID int64, since time.Time, limit int) ([]WalletTransaction, error) {
+	rows, err := s.query(`
+		SELECT id, wallet_id, tx_hash, chain, COALESCE(tx_type,''), COALESCE(token_address,''),
+			   COALESCE(token_symbol,''), COALESCE(amount_token,0), COALESCE(amount_usd,0), timestamp,
+			   COALESCE(platform,''), COALESCE(metadata,'')
+		FROM wallet_transactions WHERE id>? AND timestamp>=? ORDER BY id LIMIT ?`, afterID, since.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []WalletTransaction
+	for rows.Next() {
+		var t WalletTransaction
+		var chain string
+		if err := rows.Scan(&t.ID, &t.WalletID, &t.TxHash, &chain, &t.TxType, &t.TokenAddress,
+			&t.TokenSymbol, &t.AmountToken, &t.AmountUSD, &t.Timestamp, &t.Platform, &t.Metadata); err != nil {
+			return nil, err
+		}
+		t.Chain = config.Chain(chain)
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+// UpdateTransactionUSD sets a transaction's USD value, e.g. after repricing.
+func (s *SQLStore) UpdateTransactionUSD(id int64, amountUSD float64) error {
+	_, err := s.exec(`UPDATE wallet_transactions SET amount_usd=? WHERE id=?`, amountUSD, id)
+	return err
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy prunes rows of Table older than MaxAge. MaxAge <= 0 keeps
+// everything.
+type RetentionPolicy struct {
+	Table  string
+	MaxAge time.Duration
+}
+
+// PruneResult reports what one policy did (or would do, on a dry run).
+type PruneResult struct {
+	Table       string `json:"table"`
+	Deleted     int64  `json:"deleted"`
+	ArchiveFile string `json:"archive_file,omitempty"`
+}
+
+// retentionSpec describes how a prunable table is aged and which rows are
+// pinned. Pinned rows are never pruned regardless of age.
+type retentionSpec struct {
+	timeCol string
+	pinned  string // SQL condition; rows matching it are kept
+	// onDelete runs before the rows are deleted, with the same id list.
+	onDelete string
+}
+
+const confirmedWash = `SELECT address FROM wash_wallet_candidates WHERE status='confirmed'`
+
+// confirmedWashPosts are the posts behind a confirmed candidate's token and
+// timing evidence: its linked KOL's mentions of tokens the wallet bought.
+const confirmedWashPosts = `SELECT tm.post_id FROM token_mentions tm
+	JOIN wash_wallet_candidates wc ON wc.linked_kol_id = tm.kol_id
+	WHERE wc.status='confirmed' AND tm.post_id IS NOT NULL AND tm.token_address IN (
+		SELECT wt.token_address FROM wallet_transactions wt JOIN tracked_wallets tw ON tw.id = wt.wallet_id
+		WHERE tw.address = wc.address AND tw.chain = wc.chain AND wt.tx_type='swap_buy')`
+
+var retentionTables = map[string]retentionSpec{
+	"wallet_transactions": {
+		timeCol: "timestamp",
+		pinned: `wallet_id IN (SELECT tw.id FROM tracked_wallets tw JOIN wash_wallet_candidates wc
+			ON wc.address = tw.address AND wc.chain = tw.chain WHERE wc.status='confirmed')
+			OR from_address IN (` + confirmedWash + `) OR to_address IN (` + confirmedWash + `)`,
+	},
+	"social_posts": {
+		timeCol: "COALESCE(posted_at, created_at)",
+		pinned:  "processed = FALSE OR id IN (" + confirmedWashPosts + ")",
+		// token mentions outlive their posts; they feed timing analysis
+		onDelete: "UPDATE token_mentions SET post_id = NULL WHERE post_id IN (%s)",
+	},
+	"alerts": {
+		timeCol: "created_at",
+		pinned:  "related_wallet IN (" + confirmedWash + ")",
+	},
+}
+
+// RetentionTables lists the tables Prune accepts.
+func RetentionTables() []string {
+	return []string{"wallet_transactions", "social_posts", "alerts"}
+}
+
+const pruneBatch = 1000
+
+// Prune deletes rows past their retention age, archiving each batch to
+// archiveDir/<table>-<timestamp>.jsonl.gz before it is deleted. An empty
+// archiveDir deletes without archiving. With dryRun nothing is written;
+// Deleted reports how many rows would go.
+func (s *SQLStore) Prune(policies []RetentionPolicy, archiveDir string, dryRun bool) ([]PruneResult, error) {
+	var results []PruneResult
+	for _, p := range policies {
+		if p.MaxAge <= 0 {
+			continue
+		}
+		spec, ok := retentionTables[p.Table]
+		if !ok {
+			return results, fmt.Errorf("table %q has no retention support", p.Table)
+		}
+		cutoff := time.Now().UTC().Add(-p.MaxAge)
+		where := fmt.Sprintf("%s < ? AND NOT (%s)", spec.timeCol, spec.pinned)
+
+		if dryRun {
+			var n int64
+			if err := s.queryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", p.Table, where), cutoff).Scan(&n); err != nil {
+				return results, fmt.Errorf("count %s: %w", p.Table, err)
+			}
+			results = append(results, PruneResult{Table: p.Table, Deleted: n})
+			continue
+		}
+
+		res, err := s.pruneTable(p.Table, spec, where, cutoff, archiveDir)
+		results = append(results, res)
+		if err != nil {
+			return results, fmt.Errorf("prune %s: %w", p.Table, err)
+		}
+	}
+	return results, nil
+}
+
+func (s *SQLStore) pruneTable(table string, spec retentionSpec, where string, cutoff time.Time, archiveDir string) (PruneResult, error) {
+	res := PruneResult{Table: table}
+	var arc *archiveWriter
+	defer func() {
+		if arc != nil {
+			arc.Close()
+		}
+	}()
+
+	for {
+		rows, err := s.query(fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY id LIMIT %d", table, where, pruneBatch), cutoff)
+		if err != nil {
+			return res, err
+		}
+		records, ids, err := scanRecords(rows)
+		rows.Close()
+		if err != nil {
+			return res, err
+		}
+		if len(ids) == 0 {
+			return res, nil
+		}
+
+		if archiveDir != "" {
+			if arc == nil {
+				if arc, err = newArchiveWriter(archiveDir, table); err != nil {
+					return res, err
+				}
+				res.ArchiveFile = arc.path
+			}
+			if err := arc.Write(records); err != nil {
+				return res, fmt.Errorf("archive: %w", err)
+			}
+		}
+
+		n, err := s.deleteIDs(table, spec, ids)
+		res.Deleted += n
+		if err != nil {
+			return res, err
+		}
+	}
+}
+
+func (s *SQLStore) deleteIDs(table string, spec retentionSpec, ids []interface{}) (int64, error) {
+	in := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if spec.onDelete != "" {
+		if _, err := tx.Exec(s.dialect.rebind(fmt.Sprintf(spec.onDelete, in)), ids...); err != nil {
+			return 0, err
+		}
+	}
+	r, err := tx.Exec(s.dialect.rebind(fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", table, in)), ids...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := r.RowsAffected()
+	return n, tx.Commit()
+}
+
+// scanRecords reads arbitrary rows into column→value maps and collects ids.
+func scanRecords(rows *sql.Rows) ([]map[string]interface{}, []interface{}, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []map[string]interface{}
+	var ids []interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+		rec := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			if b, ok := vals[i].([]byte); ok {
+				vals[i] = string(b)
+			}
+			rec[c] = vals[i]
+			if c == "id" {
+				ids = append(ids, vals[i])
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, ids, rows.Err()
+}
+
+// archiveWriter appends JSON lines to a gzip file. Each batch is flushed and
+// synced before the caller deletes the rows it holds.
+type archiveWriter struct {
+	path string
+	f    *os.File
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+func newArchiveWriter(dir, table string) (*archiveWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.jsonl.gz", table, time.Now().UTC().Format("20060102T150405Z")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	return &archiveWriter{path: path, f: f, gz: gz, buf: bufio.NewWriter(gz)}, nil
+}
+
+func (a *archiveWriter) Write(records []map[string]interface{}) error {
+	enc := json.NewEncoder(a.buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	if err := a.buf.Flush(); err != nil {
+		return err
+	}
+	if err := a.gz.Flush(); err != nil {
+		return err
+	}
+	return a.f.Sync()
+}
+
+func (a *archiveWriter) Close() error {
+	a.buf.Flush()
+	a.gz.Close()
+	return a.f.Close()
+}
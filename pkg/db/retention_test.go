@@ -0,0 +1,88 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+func TestPruneKeepsConfirmedWashPosts(t *testing.T) {
+	s, err := Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	old := time.Now().UTC().AddDate(0, 0, -90)
+	kolID, err := s.UpsertKOL("kol", "kol", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func(id, token string) int64 {
+		t.Helper()
+		pid, err := s.InsertPost(kolID, "twitter", id, "buy "+token, old, []string{token}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.MarkPostProcessed(pid); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.InsertTokenMention(kolID, pid, token, "", config.ChainSolana, old); err != nil {
+			t.Fatal(err)
+		}
+		return pid
+	}
+	evidence := post("1", "WashedToken")
+	post("2", "OtherToken")
+
+	const wash = "WashWa11et"
+	walletID, err := s.UpsertWallet(0, wash, config.ChainSolana, "wash", 0.5, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertTransaction(WalletTransaction{WalletID: walletID, TxHash: "sig1", Chain: config.ChainSolana,
+		TxType: "swap_buy", TokenAddress: "WashedToken", AmountToken: 1, Timestamp: old}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpsertWashCandidate(WashWalletCandidate{Address: wash, Chain: config.ChainSolana, LinkedKOLID: kolID}); err != nil {
+		t.Fatal(err)
+	}
+
+	prunePosts := func() int64 {
+		t.Helper()
+		res, err := s.Prune([]RetentionPolicy{{Table: "social_posts", MaxAge: 24 * time.Hour}}, "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res[0].Deleted
+	}
+	if n := prunePosts(); n != 2 {
+		t.Fatalf("before confirming, %d posts would go, want 2", n)
+	}
+	if err := s.SetWashStatus(wash, config.ChainSolana, "confirmed"); err != nil {
+		t.Fatal(err)
+	}
+	if n := prunePosts(); n != 1 {
+		t.Fatalf("after confirming, %d posts would go, want 1", n)
+	}
+
+	if _, err := s.Prune([]RetentionPolicy{{Table: "social_posts", MaxAge: 24 * time.Hour}}, "", false); err != nil {
+		t.Fatal(err)
+	}
+	var left []int64
+	rows, err := s.query(`SELECT id FROM social_posts`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		rows.Scan(&id)
+		left = append(left, id)
+	}
+	if len(left) != 1 || left[0] != evidence {
+		t.Errorf("posts left = %v, want only the evidence post %d", left, evidence)
+	}
+}
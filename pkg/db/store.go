@@ -10,7 +10,10 @@ import (
 	"github.com/kol-tracker/pkg/config"
 )
 
-const schema = `
+// baselineSchema is the original table layout. It is applied as migration 1
+// (see migrations.go) so existing databases created before versioning
+// adopt it as a no-op thanks to IF NOT EXISTS.
+const baselineSchema = `
 CREATE TABLE IF NOT EXISTS kol_profiles (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
@@ -155,43 +158,54 @@ CREATE INDEX IF NOT EXISTS idx_post_kol ON social_posts(kol_id);
 CREATE INDEX IF NOT EXISTS idx_alert_time ON alerts(created_at);
 `
 
-type Store struct {
-	db *sql.DB
+// SQLStore implements Store on top of database/sql. The same query set is
+// shared by the SQLite and PostgreSQL backends; dialect differences are
+// handled by rebind/ddl in backend.go.
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
 }
 
-func NewStore(dbPath string) (*Store, error) {
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
+// NewStore opens (or creates) a SQLite database and applies pending migrations.
+func NewStore(dbPath string) (*SQLStore, error) {
+	s, err := openSQLite(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("open db: %w", err)
+		return nil, err
 	}
-
-	if _, err := db.Exec(schema); err != nil {
+	if err := s.MigrateUp(0); err != nil {
+		s.db.Close()
 		return nil, fmt.Errorf("init schema: %w", err)
 	}
+	return s, nil
+}
 
-	return &Store{db: db}, nil
+func openSQLite(dbPath string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	return &SQLStore{db: db, dialect: dialectSQLite}, nil
 }
 
-func (s *Store) Close() error {
+func (s *SQLStore) Close() error {
 	return s.db.Close()
 }
 
 // ---- KOL Profiles ----
 
-func (s *Store) UpsertKOL(name, twitterHandle, telegramChannel string) (int64, error) {
-	res, err := s.db.Exec(`
+func (s *SQLStore) UpsertKOL(name, twitterHandle, telegramChannel string) (int64, error) {
+	var id int64
+	err := s.queryRow(`
 		INSERT INTO kol_profiles (name, twitter_handle, telegram_channel)
 		VALUES (?, ?, ?)
-		ON CONFLICT DO UPDATE SET name=excluded.name`,
-		name, twitterHandle, telegramChannel)
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
+		ON CONFLICT(id) DO UPDATE SET name=excluded.name
+		RETURNING id`,
+		name, twitterHandle, telegramChannel).Scan(&id)
+	return id, err
 }
 
-func (s *Store) GetKOLs() ([]KOLProfile, error) {
-	rows, err := s.db.Query("SELECT id, name, twitter_handle, telegram_channel, COALESCE(notes,''), created_at FROM kol_profiles")
+func (s *SQLStore) GetKOLs() ([]KOLProfile, error) {
+	rows, err := s.query("SELECT id, name, twitter_handle, telegram_channel, COALESCE(notes,''), created_at FROM kol_profiles")
 	if err != nil {
 		return nil, err
 	}
@@ -208,9 +222,9 @@ func (s *Store) GetKOLs() ([]KOLProfile, error) {
 	return kols, nil
 }
 
-func (s *Store) GetKOLByHandle(handle string) (*KOLProfile, error) {
+func (s *SQLStore) GetKOLByHandle(handle string) (*KOLProfile, error) {
 	var k KOLProfile
-	err := s.db.QueryRow(
+	err := s.queryRow(
 		"SELECT id, name, twitter_handle, telegram_channel, COALESCE(notes,''), created_at FROM kol_profiles WHERE twitter_handle=? OR telegram_channel=?",
 		handle, handle).Scan(&k.ID, &k.Name, &k.TwitterHandle, &k.TelegramChannel, &k.Notes, &k.CreatedAt)
 	if err != nil {
@@ -219,26 +233,54 @@ func (s *Store) GetKOLByHandle(handle string) (*KOLProfile, error) {
 	return &k, nil
 }
 
-// ---- Tracked Wallets ----
-
-func (s *Store) UpsertWallet(kolID int64, address string, chain config.Chain, label string, confidence float64, source string) (int64, error) {
-	res, err := s.db.Exec(`
-		INSERT INTO tracked_wallets (kol_id, address, chain, label, confidence, source)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(address, chain) DO UPDATE SET
-			confidence = MAX(tracked_wallets.confidence, excluded.confidence),
-			label = CASE WHEN excluded.confidence > tracked_wallets.confidence THEN excluded.label ELSE tracked_wallets.label END`,
-		kolID, address, string(chain), label, confidence, source)
+// DeleteKOL removes a KOL profile together with its wallet links, trading
+// patterns and fresh-buyer watches. Evidence (posts, mentions, alerts, wash candidates, attribution
+// history) is kept but detached; wallets it owned pass to their next most
+// confident linked KOL, or become unowned.
+func (s *SQLStore) DeleteKOL(id int64) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return 0, err
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`DELETE FROM wallet_kol_links WHERE kol_id=?`,
+		`DELETE FROM trading_patterns WHERE kol_id=?`,
+		`DELETE FROM token_watches WHERE kol_id=?`,
+		`UPDATE tracked_wallets SET kol_id = (
+			SELECT l.kol_id FROM wallet_kol_links l WHERE l.wallet_id = tracked_wallets.id
+			ORDER BY l.confidence DESC LIMIT 1) WHERE kol_id=?`,
+		`UPDATE social_posts SET kol_id=NULL WHERE kol_id=?`,
+		`UPDATE token_mentions SET kol_id=NULL WHERE kol_id=?`,
+		`UPDATE alerts SET kol_id=NULL WHERE kol_id=?`,
+		`UPDATE wash_wallet_candidates SET linked_kol_id=NULL WHERE linked_kol_id=?`,
+		`UPDATE wallet_attributions SET kol_id=NULL WHERE kol_id=?`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(s.dialect.rebind(q), id); err != nil {
+			return fmt.Errorf("delete kol %d: %w", id, err)
+		}
+	}
+	r, err := tx.Exec(s.dialect.rebind(`DELETE FROM kol_profiles WHERE id=?`), id)
+	if err != nil {
+		return err
 	}
-	return res.LastInsertId()
+	if n, _ := r.RowsAffected(); n == 0 {
+		return fmt.Errorf("no KOL with id %d", id)
+	}
+	return tx.Commit()
 }
 
-func (s *Store) GetWalletsForKOL(kolID int64) ([]TrackedWallet, error) {
-	rows, err := s.db.Query(`
-		SELECT id, kol_id, address, chain, COALESCE(label,''), confidence, COALESCE(source,''), discovered_at, COALESCE(metadata,'{}')
-		FROM tracked_wallets WHERE kol_id=? ORDER BY confidence DESC`, kolID)
+// ---- Tracked Wallets ----
+
+// GetWalletsForKOL returns every wallet linked to the KOL, including wallets
+// shared with other KOLs. Label and Confidence are those of this KOL's link.
+func (s *SQLStore) GetWalletsForKOL(kolID int64) ([]TrackedWallet, error) {
+	rows, err := s.query(`
+		SELECT w.id, l.kol_id, w.address, w.chain, COALESCE(l.label,''), l.confidence, COALESCE(w.source,''), w.discovered_at, COALESCE(w.metadata,'{}')
+		FROM wallet_kol_links l JOIN tracked_wallets w ON w.id = l.wallet_id
+		WHERE l.kol_id=? ORDER BY l.confidence DESC`, kolID)
 	if err != nil {
 		return nil, err
 	}
@@ -257,8 +299,8 @@ func (s *Store) GetWalletsForKOL(kolID int64) ([]TrackedWallet, error) {
 	return wallets, nil
 }
 
-func (s *Store) GetAllTrackedAddresses() ([]TrackedWallet, error) {
-	rows, err := s.db.Query(`SELECT id, kol_id, address, chain, COALESCE(label,''), confidence, COALESCE(source,''), discovered_at, COALESCE(metadata,'{}') FROM tracked_wallets`)
+func (s *SQLStore) GetAllTrackedAddresses() ([]TrackedWallet, error) {
+	rows, err := s.query(`SELECT id, COALESCE(kol_id,0), address, chain, COALESCE(label,''), confidence, COALESCE(source,''), discovered_at, COALESCE(metadata,'{}') FROM tracked_wallets`)
 	if err != nil {
 		return nil, err
 	}
@@ -277,10 +319,10 @@ func (s *Store) GetAllTrackedAddresses() ([]TrackedWallet, error) {
 	return wallets, nil
 }
 
-func (s *Store) GetWalletByAddress(address string, chain config.Chain) (*TrackedWallet, error) {
+func (s *SQLStore) GetWalletByAddress(address string, chain config.Chain) (*TrackedWallet, error) {
 	var w TrackedWallet
 	var ch string
-	err := s.db.QueryRow(`SELECT id, kol_id, address, chain, COALESCE(label,''), confidence FROM tracked_wallets WHERE address=? AND chain=?`,
+	err := s.queryRow(`SELECT id, COALESCE(kol_id,0), address, chain, COALESCE(label,''), confidence FROM tracked_wallets WHERE address=? AND chain=?`,
 		address, string(chain)).Scan(&w.ID, &w.KOLID, &w.Address, &ch, &w.Label, &w.Confidence)
 	if err != nil {
 		return nil, err
@@ -291,23 +333,26 @@ func (s *Store) GetWalletByAddress(address string, chain config.Chain) (*Tracked
 
 // ---- Social Posts ----
 
-func (s *Store) InsertPost(kolID int64, platform, postID, content string, postedAt time.Time, tokens, wallets, links []string) (int64, error) {
+func (s *SQLStore) InsertPost(kolID int64, platform, postID, content string, postedAt time.Time, tokens, wallets, links []string) (int64, error) {
 	tokensJSON, _ := json.Marshal(tokens)
 	walletsJSON, _ := json.Marshal(wallets)
 	linksJSON, _ := json.Marshal(links)
 
-	res, err := s.db.Exec(`
+	// Duplicate posts are ignored and report id 0.
+	var id int64
+	err := s.queryRow(`
 		INSERT OR IGNORE INTO social_posts (kol_id, platform, post_id, content, posted_at, extracted_tokens, extracted_wallets, extracted_links)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		kolID, platform, postID, content, postedAt, string(tokensJSON), string(walletsJSON), string(linksJSON))
-	if err != nil {
-		return 0, err
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		kolID, platform, postID, content, postedAt, string(tokensJSON), string(walletsJSON), string(linksJSON)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
 	}
-	return res.LastInsertId()
+	return id, err
 }
 
-func (s *Store) GetUnprocessedPosts() ([]SocialPost, error) {
-	rows, err := s.db.Query(`SELECT id, kol_id, platform, post_id, content, posted_at, extracted_tokens, extracted_wallets, extracted_links FROM social_posts WHERE processed=FALSE ORDER BY posted_at ASC`)
+func (s *SQLStore) GetUnprocessedPosts() ([]SocialPost, error) {
+	rows, err := s.query(`SELECT id, COALESCE(kol_id,0), platform, post_id, content, posted_at, extracted_tokens, extracted_wallets, extracted_links FROM social_posts WHERE processed=FALSE ORDER BY posted_at ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -324,24 +369,24 @@ func (s *Store) GetUnprocessedPosts() ([]SocialPost, error) {
 	return posts, nil
 }
 
-func (s *Store) MarkPostProcessed(id int64) error {
-	_, err := s.db.Exec("UPDATE social_posts SET processed=TRUE WHERE id=?", id)
+func (s *SQLStore) MarkPostProcessed(id int64) error {
+	_, err := s.exec("UPDATE social_posts SET processed=TRUE WHERE id=?", id)
 	return err
 }
 
 // ---- Token Mentions ----
 
-func (s *Store) InsertTokenMention(kolID, postID int64, tokenAddr, tokenSymbol string, chain config.Chain, mentionedAt time.Time) error {
-	_, err := s.db.Exec(`INSERT INTO token_mentions (kol_id, post_id, token_address, token_symbol, chain, mentioned_at) VALUES (?,?,?,?,?,?)`,
+func (s *SQLStore) InsertTokenMention(kolID, postID int64, tokenAddr, tokenSymbol string, chain config.Chain, mentionedAt time.Time) error {
+	_, err := s.exec(`INSERT INTO token_mentions (kol_id, post_id, token_address, token_symbol, chain, mentioned_at) VALUES (?,?,?,?,?,?)`,
 		kolID, postID, tokenAddr, tokenSymbol, string(chain), mentionedAt)
 	return err
 }
 
-func (s *Store) GetRecentTokenMentions(hours int) ([]TokenMention, error) {
-	rows, err := s.db.Query(`
-		SELECT id, kol_id, post_id, COALESCE(token_address,''), COALESCE(token_symbol,''), chain, mentioned_at
-		FROM token_mentions WHERE mentioned_at > datetime('now', ?)
-		ORDER BY mentioned_at DESC`, fmt.Sprintf("-%d hours", hours))
+func (s *SQLStore) GetRecentTokenMentions(hours int) ([]TokenMention, error) {
+	rows, err := s.query(`
+		SELECT id, COALESCE(kol_id,0), COALESCE(post_id,0), COALESCE(token_address,''), COALESCE(token_symbol,''), chain, mentioned_at
+		FROM token_mentions WHERE mentioned_at > ?
+		ORDER BY mentioned_at DESC`, time.Now().UTC().Add(-time.Duration(hours)*time.Hour))
 	if err != nil {
 		return nil, err
 	}
@@ -362,8 +407,8 @@ func (s *Store) GetRecentTokenMentions(hours int) ([]TokenMention, error) {
 
 // ---- Wallet Transactions ----
 
-func (s *Store) InsertTransaction(tx WalletTransaction) error {
-	_, err := s.db.Exec(`
+func (s *SQLStore) InsertTransaction(tx WalletTransaction) error {
+	_, err := s.exec(`
 		INSERT OR IGNORE INTO wallet_transactions
 		(wallet_id, tx_hash, chain, tx_type, token_address, token_symbol, amount_token, amount_usd,
 		 from_address, to_address, timestamp, block_number, platform, priority_fee, metadata)
@@ -374,8 +419,18 @@ func (s *Store) InsertTransaction(tx WalletTransaction) error {
 	return err
 }
 
-func (s *Store) GetTransactionsForWallet(walletID int64, limit int) ([]WalletTransaction, error) {
-	rows, err := s.db.Query(`
+// DeleteTransaction removes a tx a reorg dropped from chain. Rows are one per
+// tx, so every transfer it held goes with it.
+func (s *SQLStore) DeleteTransaction(txHash string, chain config.Chain) (int64, error) {
+	r, err := s.exec(`DELETE FROM wallet_transactions WHERE tx_hash=? AND chain=?`, txHash, string(chain))
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) GetTransactionsForWallet(walletID int64, limit int) ([]WalletTransaction, error) {
+	rows, err := s.query(`
 		SELECT id, wallet_id, tx_hash, chain, COALESCE(tx_type,''), COALESCE(token_address,''),
 			   COALESCE(token_symbol,''), amount_token, amount_usd, COALESCE(from_address,''),
 			   COALESCE(to_address,''), timestamp, block_number, COALESCE(platform,''), priority_fee
@@ -400,8 +455,8 @@ func (s *Store) GetTransactionsForWallet(walletID int64, limit int) ([]WalletTra
 	return txs, nil
 }
 
-func (s *Store) GetBuyTransactionsForAddress(address string) ([]WalletTransaction, error) {
-	rows, err := s.db.Query(`
+func (s *SQLStore) GetBuyTransactionsForAddress(address string) ([]WalletTransaction, error) {
+	rows, err := s.query(`
 		SELECT wt.id, wt.wallet_id, wt.tx_hash, wt.chain, wt.tx_type, COALESCE(wt.token_address,''),
 			   COALESCE(wt.token_symbol,''), wt.amount_token, wt.amount_usd, wt.timestamp, COALESCE(wt.platform,''), wt.priority_fee
 		FROM wallet_transactions wt
@@ -429,20 +484,20 @@ func (s *Store) GetBuyTransactionsForAddress(address string) ([]WalletTransactio
 
 // ---- Wash Wallet Candidates ----
 
-func (s *Store) UpsertWashCandidate(wc WashWalletCandidate) error {
-	_, err := s.db.Exec(`
+func (s *SQLStore) UpsertWashCandidate(wc WashWalletCandidate) error {
+	_, err := s.exec(fmt.Sprintf(`
 		INSERT INTO wash_wallet_candidates
 		(address, chain, funded_by, funding_source_type, funding_amount, funding_token, funding_tx, confidence_score, linked_kol_id, notes)
 		VALUES (?,?,?,?,?,?,?,?,?,?)
 		ON CONFLICT(address, chain) DO UPDATE SET
-			confidence_score = MAX(wash_wallet_candidates.confidence_score, excluded.confidence_score),
-			funding_source_type = COALESCE(excluded.funding_source_type, wash_wallet_candidates.funding_source_type)`,
+			confidence_score = %s(wash_wallet_candidates.confidence_score, excluded.confidence_score),
+			funding_source_type = COALESCE(excluded.funding_source_type, wash_wallet_candidates.funding_source_type)`, s.dialect.greatest()),
 		wc.Address, string(wc.Chain), wc.FundedBy, wc.FundingSourceType, wc.FundingAmount,
-		wc.FundingToken, wc.FundingTx, wc.ConfidenceScore, wc.LinkedKOLID, wc.Notes)
+		wc.FundingToken, wc.FundingTx, wc.ConfidenceScore, nullID(wc.LinkedKOLID), wc.Notes)
 	return err
 }
 
-func (s *Store) UpdateWashScore(address string, chain config.Chain, score float64, signals map[string]bool) error {
+func (s *SQLStore) UpdateWashScore(address string, chain config.Chain, score float64, signals map[string]bool) error {
 	setClauses := "confidence_score=?"
 	args := []interface{}{score}
 
@@ -464,16 +519,36 @@ func (s *Store) UpdateWashScore(address string, chain config.Chain, score float6
 	}
 
 	args = append(args, address, string(chain))
-	_, err := s.db.Exec(fmt.Sprintf("UPDATE wash_wallet_candidates SET %s WHERE address=? AND chain=?", setClauses), args...)
+	_, err := s.exec(fmt.Sprintf("UPDATE wash_wallet_candidates SET %s WHERE address=? AND chain=?", setClauses), args...)
 	return err
 }
 
-func (s *Store) GetWashCandidates(minScore float64) ([]WashWalletCandidate, error) {
-	rows, err := s.db.Query(`
+// SetWashStatus moves a candidate to "candidate", "confirmed" or "dismissed".
+// Rows tied to confirmed candidates are pinned against retention pruning.
+func (s *SQLStore) SetWashStatus(address string, chain config.Chain, status string) error {
+	switch status {
+	case "candidate", "confirmed", "dismissed":
+	default:
+		return fmt.Errorf("invalid wash status %q", status)
+	}
+	r, err := s.exec(`UPDATE wash_wallet_candidates SET status=? WHERE address=? AND chain=?`, status, address, string(chain))
+	if err != nil {
+		return err
+	}
+	if n, _ := r.RowsAffected(); n == 0 {
+		return fmt.Errorf("no wash candidate %s on %s", address, chain)
+	}
+	return nil
+}
+
+func (s *SQLStore) GetWashCandidates(minScore float64) ([]WashWalletCandidate, error) {
+	rows, err := s.query(`
 		SELECT id, address, chain, COALESCE(funded_by,''), COALESCE(funding_source_type,'unknown'),
 			   funding_amount, COALESCE(funding_token,''), COALESCE(funding_tx,''), first_seen,
 			   bought_same_token, timing_match, amount_pattern_match, bot_signature_match,
-			   confidence_score, linked_kol_id, status, COALESCE(notes,'')
+			   confidence_score, COALESCE(linked_kol_id,0), status, COALESCE(notes,''),
+			   COALESCE(suspicion_level,''), COALESCE(origin_type,''),
+			   COALESCE(trace_failures,0), trace_attempted_at
 		FROM wash_wallet_candidates
 		WHERE confidence_score >= ? AND status='candidate'
 		ORDER BY confidence_score DESC`, minScore)
@@ -486,13 +561,16 @@ func (s *Store) GetWashCandidates(minScore float64) ([]WashWalletCandidate, erro
 	for rows.Next() {
 		var c WashWalletCandidate
 		var chain string
+		var attempted sql.NullTime
 		if err := rows.Scan(&c.ID, &c.Address, &chain, &c.FundedBy, &c.FundingSourceType,
 			&c.FundingAmount, &c.FundingToken, &c.FundingTx, &c.FirstSeen,
 			&c.BoughtSameToken, &c.TimingMatch, &c.AmountPatternMatch, &c.BotSignatureMatch,
-			&c.ConfidenceScore, &c.LinkedKOLID, &c.Status, &c.Notes); err != nil {
+			&c.ConfidenceScore, &c.LinkedKOLID, &c.Status, &c.Notes,
+			&c.SuspicionLevel, &c.OriginType, &c.TraceFailures, &attempted); err != nil {
 			continue
 		}
 		c.Chain = config.Chain(chain)
+		c.TraceAttemptedAt = attempted.Time
 		candidates = append(candidates, c)
 	}
 	return candidates, nil
@@ -500,9 +578,9 @@ func (s *Store) GetWashCandidates(minScore float64) ([]WashWalletCandidate, erro
 
 // ---- Trading Patterns ----
 
-func (s *Store) UpsertPattern(kolID int64, patternType string, data interface{}, sampleCount int) error {
+func (s *SQLStore) UpsertPattern(kolID int64, patternType string, data interface{}, sampleCount int) error {
 	jsonData, _ := json.Marshal(data)
-	_, err := s.db.Exec(`
+	_, err := s.exec(`
 		INSERT INTO trading_patterns (kol_id, pattern_type, pattern_data, sample_count, last_updated)
 		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT(kol_id, pattern_type) DO UPDATE SET
@@ -511,8 +589,8 @@ func (s *Store) UpsertPattern(kolID int64, patternType string, data interface{},
 	return err
 }
 
-func (s *Store) GetPatternsForKOL(kolID int64) ([]TradingPattern, error) {
-	rows, err := s.db.Query("SELECT id, kol_id, pattern_type, pattern_data, sample_count, last_updated FROM trading_patterns WHERE kol_id=?", kolID)
+func (s *SQLStore) GetPatternsForKOL(kolID int64) ([]TradingPattern, error) {
+	rows, err := s.query("SELECT id, kol_id, pattern_type, pattern_data, sample_count, last_updated FROM trading_patterns WHERE kol_id=?", kolID)
 	if err != nil {
 		return nil, err
 	}
@@ -531,14 +609,14 @@ func (s *Store) GetPatternsForKOL(kolID int64) ([]TradingPattern, error) {
 
 // ---- Alerts ----
 
-func (s *Store) InsertAlert(kolID int64, alertType, severity, title, description, wallet, token string) error {
-	_, err := s.db.Exec(`INSERT INTO alerts (kol_id, alert_type, severity, title, description, related_wallet, related_token) VALUES (?,?,?,?,?,?,?)`,
-		kolID, alertType, severity, title, description, wallet, token)
+func (s *SQLStore) InsertAlert(kolID int64, alertType, severity, title, description, wallet, token string) error {
+	_, err := s.exec(`INSERT INTO alerts (kol_id, alert_type, severity, title, description, related_wallet, related_token) VALUES (?,?,?,?,?,?,?)`,
+		nullID(kolID), alertType, severity, title, description, wallet, token)
 	return err
 }
 
-func (s *Store) GetRecentAlerts(limit int) ([]Alert, error) {
-	rows, err := s.db.Query(`SELECT id, kol_id, alert_type, severity, title, COALESCE(description,''), COALESCE(related_wallet,''), COALESCE(related_token,''), created_at FROM alerts ORDER BY created_at DESC LIMIT ?`, limit)
+func (s *SQLStore) GetRecentAlerts(limit int) ([]Alert, error) {
+	rows, err := s.query(`SELECT id, COALESCE(kol_id,0), alert_type, severity, title, COALESCE(description,''), COALESCE(related_wallet,''), COALESCE(related_token,''), created_at FROM alerts ORDER BY created_at DESC LIMIT ?`, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -557,16 +635,16 @@ func (s *Store) GetRecentAlerts(limit int) ([]Alert, error) {
 
 // ---- Funding Flow Matches ----
 
-func (s *Store) InsertFundingMatch(fm FundingFlowMatch) error {
-	_, err := s.db.Exec(`INSERT INTO funding_flow_matches (source_tx, source_chain, source_amount, source_token, dest_address, dest_chain, dest_amount, dest_token, service, amount_diff_pct, time_diff_seconds, match_confidence) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
+func (s *SQLStore) InsertFundingMatch(fm FundingFlowMatch) error {
+	_, err := s.exec(`INSERT INTO funding_flow_matches (source_tx, source_chain, source_amount, source_token, dest_address, dest_chain, dest_amount, dest_token, service, amount_diff_pct, time_diff_seconds, match_confidence) VALUES (?,?,?,?,?,?,?,?,?,?,?,?) ON CONFLICT DO NOTHING`,
 		fm.SourceTx, string(fm.SourceChain), fm.SourceAmount, fm.SourceToken, fm.DestAddress,
 		string(fm.DestChain), fm.DestAmount, fm.DestToken, fm.Service, fm.AmountDiffPct,
 		fm.TimeDiffSeconds, fm.MatchConfidence)
 	return err
 }
 
-func (s *Store) GetFundingMatches(limit int) ([]FundingFlowMatch, error) {
-	rows, err := s.db.Query(`SELECT id, source_tx, source_chain, source_amount, source_token,
+func (s *SQLStore) GetFundingMatches(limit int) ([]FundingFlowMatch, error) {
+	rows, err := s.query(`SELECT id, source_tx, source_chain, source_amount, source_token,
 		dest_address, dest_chain, dest_amount, dest_token, service,
 		amount_diff_pct, time_diff_seconds, match_confidence, created_at
 		FROM funding_flow_matches ORDER BY created_at DESC LIMIT ?`, limit)
@@ -591,29 +669,29 @@ func (s *Store) GetFundingMatches(limit int) ([]FundingFlowMatch, error) {
 
 // ---- Stats ----
 
-func (s *Store) GetStats() (map[string]int64, error) {
+func (s *SQLStore) GetStats() (map[string]int64, error) {
 	stats := map[string]int64{}
 	tables := []string{"kol_profiles", "tracked_wallets", "social_posts", "token_mentions",
 		"wallet_transactions", "wash_wallet_candidates", "alerts"}
 
 	for _, t := range tables {
 		var count int64
-		if err := s.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", t)).Scan(&count); err == nil {
+		if err := s.queryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", t)).Scan(&count); err == nil {
 			stats[t] = count
 		}
 	}
 
 	// High-confidence wash candidates
 	var wc int64
-	s.db.QueryRow("SELECT COUNT(*) FROM wash_wallet_candidates WHERE confidence_score >= 0.5").Scan(&wc)
+	s.queryRow("SELECT COUNT(*) FROM wash_wallet_candidates WHERE confidence_score >= 0.5").Scan(&wc)
 	stats["high_confidence_wash"] = wc
 
 	return stats, nil
 }
 
-func (s *Store) GetKOLByID(id int64) (*KOLProfile, error) {
+func (s *SQLStore) GetKOLByID(id int64) (*KOLProfile, error) {
 	var k KOLProfile
-	err := s.db.QueryRow(
+	err := s.queryRow(
 		"SELECT id, name, COALESCE(twitter_handle,''), COALESCE(telegram_channel,''), COALESCE(notes,''), created_at FROM kol_profiles WHERE id=?",
 		id).Scan(&k.ID, &k.Name, &k.TwitterHandle, &k.TelegramChannel, &k.Notes, &k.CreatedAt)
 	if err != nil {
@@ -622,8 +700,8 @@ func (s *Store) GetKOLByID(id int64) (*KOLProfile, error) {
 	return &k, nil
 }
 
-func (s *Store) GetPostsForKOL(kolID int64, limit int) ([]SocialPost, error) {
-	rows, err := s.db.Query(`SELECT id, kol_id, platform, post_id, content, posted_at, extracted_tokens, extracted_wallets, extracted_links, processed FROM social_posts WHERE kol_id=? ORDER BY posted_at DESC LIMIT ?`, kolID, limit)
+func (s *SQLStore) GetPostsForKOL(kolID int64, limit int) ([]SocialPost, error) {
+	rows, err := s.query(`SELECT id, kol_id, platform, post_id, content, posted_at, extracted_tokens, extracted_wallets, extracted_links, processed FROM social_posts WHERE kol_id=? ORDER BY posted_at DESC LIMIT ?`, kolID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -639,8 +717,8 @@ func (s *Store) GetPostsForKOL(kolID int64, limit int) ([]SocialPost, error) {
 	return posts, nil
 }
 
-func (s *Store) GetWashCandidatesForKOL(kolID int64) ([]WashWalletCandidate, error) {
-	rows, err := s.db.Query(`SELECT id, address, chain, COALESCE(funded_by,''), COALESCE(funding_source_type,'unknown'), funding_amount, COALESCE(funding_token,''), COALESCE(funding_tx,''), first_seen, bought_same_token, timing_match, amount_pattern_match, bot_signature_match, confidence_score, linked_kol_id, status, COALESCE(notes,'') FROM wash_wallet_candidates WHERE linked_kol_id=? ORDER BY confidence_score DESC`, kolID)
+func (s *SQLStore) GetWashCandidatesForKOL(kolID int64) ([]WashWalletCandidate, error) {
+	rows, err := s.query(`SELECT id, address, chain, COALESCE(funded_by,''), COALESCE(funding_source_type,'unknown'), funding_amount, COALESCE(funding_token,''), COALESCE(funding_tx,''), first_seen, bought_same_token, timing_match, amount_pattern_match, bot_signature_match, confidence_score, linked_kol_id, status, COALESCE(notes,''), COALESCE(suspicion_level,''), COALESCE(origin_type,'') FROM wash_wallet_candidates WHERE linked_kol_id=? ORDER BY confidence_score DESC`, kolID)
 	if err != nil {
 		return nil, err
 	}
@@ -649,7 +727,7 @@ func (s *Store) GetWashCandidatesForKOL(kolID int64) ([]WashWalletCandidate, err
 	for rows.Next() {
 		var c WashWalletCandidate
 		var chain string
-		if err := rows.Scan(&c.ID, &c.Address, &chain, &c.FundedBy, &c.FundingSourceType, &c.FundingAmount, &c.FundingToken, &c.FundingTx, &c.FirstSeen, &c.BoughtSameToken, &c.TimingMatch, &c.AmountPatternMatch, &c.BotSignatureMatch, &c.ConfidenceScore, &c.LinkedKOLID, &c.Status, &c.Notes); err != nil {
+		if err := rows.Scan(&c.ID, &c.Address, &chain, &c.FundedBy, &c.FundingSourceType, &c.FundingAmount, &c.FundingToken, &c.FundingTx, &c.FirstSeen, &c.BoughtSameToken, &c.TimingMatch, &c.AmountPatternMatch, &c.BotSignatureMatch, &c.ConfidenceScore, &c.LinkedKOLID, &c.Status, &c.Notes, &c.SuspicionLevel, &c.OriginType); err != nil {
 			continue
 		}
 		c.Chain = config.Chain(chain)
@@ -657,3 +735,42 @@ func (s *Store) GetWashCandidatesForKOL(kolID int64) ([]WashWalletCandidate, err
 	}
 	return candidates, nil
 }
+
+// GetLastActivity returns each wallet's most recent transaction time.
+func (s *SQLStore) GetLastActivity() (map[int64]time.Time, error) {
+	rows, err := s.query(`SELECT wallet_id, MAX(timestamp) FROM wallet_transactions GROUP BY wallet_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]time.Time{}
+	for rows.Next() {
+		var id int64
+		var ts interface{}
+		if err := rows.Scan(&id, &ts); err != nil {
+			continue
+		}
+		if t := asTime(ts); !t.IsZero() {
+			out[id] = t
+		}
+	}
+	return out, rows.Err()
+}
+
+// asTime converts an aggregate timestamp, which SQLite returns as text since
+// MAX() loses the column type.
+func asTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case []byte:
+		return asTime(string(t))
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano, "2006-01-02 15:04:05"} {
+			if p, err := time.Parse(layout, t); err == nil {
+				return p
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// ReplaceFundingHops stores a fresh funding trace of a wallet, dropping the
+// hops of any earlier trace.
+func (s *SQLStore) ReplaceFundingHops(address string, chain config.Chain, hops []FundingHop) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(s.dialect.rebind(`DELETE FROM funding_hops WHERE address=? AND chain=?`), address, string(chain)); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(s.dialect.rebind(`
+		INSERT INTO funding_hops (address, chain, from_address, to_address, hop_chain, amount, token,
+			tx_hash, hop_type, service_name, depth, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, h := range hops {
+		var at interface{}
+		if !h.Timestamp.IsZero() {
+			at = h.Timestamp.UTC()
+		}
+		if _, err := stmt.Exec(address, string(chain), h.FromAddress, h.ToAddress, string(h.Chain), h.Amount, h.Token,
+			h.TxHash, h.HopType, h.ServiceName, h.Depth, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetFundingHops returns the stored trace of a wallet, nearest hops first.
+func (s *SQLStore) GetFundingHops(address string, chain config.Chain) ([]FundingHop, error) {
+	rows, err := s.query(`
+		SELECT from_address, to_address, hop_chain, COALESCE(amount,0), COALESCE(token,''), COALESCE(tx_hash,''),
+			   COALESCE(hop_type,''), COALESCE(service_name,''), depth, timestamp
+		FROM funding_hops WHERE address=? AND chain=? ORDER BY depth, id`, address, string(chain))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hops []FundingHop
+	for rows.Next() {
+		var h FundingHop
+		var ch string
+		var at sql.NullTime
+		if err := rows.Scan(&h.FromAddress, &h.ToAddress, &ch, &h.Amount, &h.Token, &h.TxHash,
+			&h.HopType, &h.ServiceName, &h.Depth, &at); err != nil {
+			return nil, err
+		}
+		h.Chain = config.Chain(ch)
+		h.Timestamp = at.Time
+		hops = append(hops, h)
+	}
+	return hops, rows.Err()
+}
+
+// SetWashTrace records a funding trace's verdict on a wash candidate. An
+// address that isn't a candidate is left alone.
+func (s *SQLStore) SetWashTrace(address string, chain config.Chain, suspicionLevel, originType string) error {
+	_, err := s.exec(`UPDATE wash_wallet_candidates SET suspicion_level=?, origin_type=?, trace_failures=0 WHERE address=? AND chain=?`,
+		suspicionLevel, originType, address, string(chain))
+	return err
+}
+
+// RecordTraceFailure counts a failed funding trace of a wash candidate and
+// stamps the attempt, for the retry backoff.
+func (s *SQLStore) RecordTraceFailure(address string, chain config.Chain) error {
+	_, err := s.exec(`UPDATE wash_wallet_candidates SET trace_failures=COALESCE(trace_failures,0)+1, trace_attempted_at=? WHERE address=? AND chain=?`,
+		time.Now().UTC(), address, string(chain))
+	return err
+}
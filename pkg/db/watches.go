@@ -0,0 +1,71 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// UpsertTokenWatch starts (or restarts, on a repeat mention) a fresh-buyer
+// watch. A restart resets the checked set, like the in-memory monitor does.
+func (s *SQLStore) UpsertTokenWatch(w TokenWatch) error {
+	checked := w.Checked
+	if checked == nil {
+		checked = []string{}
+	}
+	cj, _ := json.Marshal(checked)
+	now := time.Now().UTC()
+	_, err := s.exec(`
+		INSERT INTO token_watches (kol_id, token_address, chain, mention_time, expires_at, checked, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(kol_id, token_address) DO UPDATE SET
+			chain = excluded.chain,
+			mention_time = excluded.mention_time,
+			expires_at = excluded.expires_at,
+			checked = excluded.checked,
+			updated_at = excluded.updated_at`,
+		w.KOLID, w.TokenAddress, string(w.Chain), w.MentionTime.UTC(), w.ExpiresAt.UTC(), string(cj), now, now)
+	return err
+}
+
+// SetTokenWatchChecked replaces the set of buyers already analyzed for a watch.
+func (s *SQLStore) SetTokenWatchChecked(kolID int64, tokenAddr string, checked []string) error {
+	cj, _ := json.Marshal(checked)
+	_, err := s.exec(`UPDATE token_watches SET checked=?, updated_at=? WHERE kol_id=? AND token_address=?`,
+		string(cj), time.Now().UTC(), kolID, tokenAddr)
+	return err
+}
+
+// GetActiveTokenWatches returns the watches that have not expired at now.
+func (s *SQLStore) GetActiveTokenWatches(now time.Time) ([]TokenWatch, error) {
+	rows, err := s.query(`
+		SELECT kol_id, token_address, chain, mention_time, expires_at, COALESCE(checked,'[]')
+		FROM token_watches WHERE expires_at > ? ORDER BY mention_time`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TokenWatch
+	for rows.Next() {
+		var w TokenWatch
+		var ch, cj string
+		if err := rows.Scan(&w.KOLID, &w.TokenAddress, &ch, &w.MentionTime, &w.ExpiresAt, &cj); err != nil {
+			continue
+		}
+		w.Chain = config.Chain(ch)
+		json.Unmarshal([]byte(cj), &w.Checked)
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// DeleteExpiredTokenWatches drops watches that expired at or before now.
+func (s *SQLStore) DeleteExpiredTokenWatches(now time.Time) (int64, error) {
+	r, err := s.exec(`DELETE FROM token_watches WHERE expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
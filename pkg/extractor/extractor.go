@@ -1,6 +1,9 @@
 package extractor
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
 	"regexp"
 	"strings"
 
@@ -12,6 +15,7 @@ var (
 	// Address patterns
 	solanaAddrRe = regexp.MustCompile(`\b([1-9A-HJ-NP-Za-km-z]{32,44})\b`)
 	evmAddrRe    = regexp.MustCompile(`\b(0x[a-fA-F0-9]{40})\b`)
+	btcAddrRe    = regexp.MustCompile(`\b(bc1[02-9ac-hj-np-z]{11,71}|[13][1-9A-HJ-NP-Za-km-z]{25,34})\b`)
 	tickerRe     = regexp.MustCompile(`\$([A-Za-z][A-Za-z0-9]{1,10})\b`)
 
 	// DEX/tool link patterns
@@ -110,10 +114,21 @@ func Extract(text string) *db.ExtractionResult {
 		r.EVMAddresses = appendUnique(r.EVMAddresses, addr)
 	}
 
-	// 3. Extract Solana addresses (filter aggressively)
+	// 3. Extract Bitcoin, Solana and Tron addresses (filter aggressively).
+	// Legacy Bitcoin and Tron addresses are base58 like Solana's but carry a
+	// checksum, so check for those first.
+	for _, addr := range btcAddrRe.FindAllString(cleanText, -1) {
+		if IsBitcoinAddress(addr) {
+			r.BitcoinAddresses = appendUnique(r.BitcoinAddresses, addr)
+		}
+	}
 	solMatches := solanaAddrRe.FindAllString(cleanText, -1)
 	for _, addr := range solMatches {
-		if isValidSolanaAddress(addr) {
+		if IsBitcoinAddress(addr) {
+			continue
+		} else if IsTronAddress(addr) {
+			r.TronAddresses = appendUnique(r.TronAddresses, addr)
+		} else if isValidSolanaAddress(addr) {
 			r.SolanaAddresses = appendUnique(r.SolanaAddresses, addr)
 		}
 	}
@@ -130,7 +145,7 @@ func Extract(text string) *db.ExtractionResult {
 	}
 
 	// 5. All standalone addresses are potential CAs too
-	r.ContractAddrs = append(r.SolanaAddresses, r.EVMAddresses...)
+	r.ContractAddrs = concat(r.SolanaAddresses, r.EVMAddresses, r.TronAddresses)
 
 	// 6. Detect trading bot mentions
 	for name, re := range botPatterns {
@@ -151,9 +166,112 @@ func ClassifyAddress(addr string) config.Chain {
 	if strings.HasPrefix(addr, "0x") && len(addr) == 42 {
 		return config.ChainEthereum // default EVM, caller can refine
 	}
+	if IsTronAddress(addr) {
+		return config.ChainTron
+	}
+	if IsBitcoinAddress(addr) {
+		return config.ChainBitcoin
+	}
 	return config.ChainSolana
 }
 
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// IsTronAddress reports whether addr is a Tron base58check address: 34
+// characters starting with T, decoding to 0x41 and 20 address bytes.
+func IsTronAddress(addr string) bool {
+	if len(addr) != 34 || addr[0] != 'T' {
+		return false
+	}
+	b := base58Check(addr)
+	return len(b) == 21 && b[0] == 0x41
+}
+
+// IsBitcoinAddress reports whether addr is a mainnet Bitcoin address: legacy
+// P2PKH (1...) or P2SH (3...) base58check, or segwit bech32/bech32m (bc1...).
+func IsBitcoinAddress(addr string) bool {
+	if strings.HasPrefix(addr, "bc1") {
+		return isBech32("bc", addr)
+	}
+	if len(addr) < 26 || len(addr) > 34 || (addr[0] != '1' && addr[0] != '3') {
+		return false
+	}
+	b := base58Check(addr)
+	return len(b) == 21 && (b[0] == 0x00 || b[0] == 0x05)
+}
+
+// base58Check decodes s and returns the version byte and payload if its
+// 4-byte double-SHA256 checksum matches, nil otherwise.
+func base58Check(s string) []byte {
+	n := new(big.Int)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	b := append(make([]byte, zeros), n.Bytes()...)
+	if len(b) < 5 {
+		return nil
+	}
+	h := sha256.Sum256(b[:len(b)-4])
+	h = sha256.Sum256(h[:])
+	if !bytes.Equal(h[:4], b[len(b)-4:]) {
+		return nil
+	}
+	return b[:len(b)-4]
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// isBech32 checks a lowercase segwit address against hrp: bech32 checksum
+// for witness version 0, bech32m (BIP-350) for later versions.
+func isBech32(hrp, addr string) bool {
+	if len(addr) > 90 || !strings.HasPrefix(addr, hrp+"1") {
+		return false
+	}
+	data := addr[len(hrp)+1:]
+	if len(data) < 7 {
+		return false
+	}
+	values := make([]int, 0, len(hrp)*2+1+len(data))
+	for _, c := range hrp {
+		values = append(values, int(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, int(c)&31)
+	}
+	for _, c := range data {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return false
+		}
+		values = append(values, i)
+	}
+	chk := 1
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ v
+		for i, g := range []int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3} {
+			if top>>i&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	version := strings.IndexByte(bech32Charset, data[0])
+	if version == 0 {
+		return chk == 1
+	}
+	return version <= 16 && chk == 0x2bc830a3
+}
+
 func isValidSolanaAddress(addr string) bool {
 	if len(addr) < 32 || len(addr) > 44 {
 		return false
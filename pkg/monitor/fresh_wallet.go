@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,9 +20,10 @@ import (
 // a KOL posts about them. This is the real-time catch mechanism.
 type FreshWalletMonitor struct {
 	cfg      *config.Config
-	store    *db.Store
+	store    db.Store
 	scanner  *scanner.Scanner
 	analyzer *analyzer.Analyzer
+	tracer   *scanner.DeepFundingTracer // optional; traces new candidates
 
 	mu       sync.RWMutex
 	watches  map[string]*TokenWatch // "kolID:tokenAddr" -> watch
@@ -33,26 +35,61 @@ type TokenWatch struct {
 	Chain        config.Chain
 	MentionTime  time.Time
 	Expires      time.Time
-	Checked      map[string]bool // addresses already analyzed
+	Checked      map[string]bool // addresses already analyzed (or in progress)
+	analyzed     []string        // finished analyses, persisted to token_watches
 }
 
-func NewFreshWalletMonitor(cfg *config.Config, store *db.Store, sc *scanner.Scanner, an *analyzer.Analyzer) *FreshWalletMonitor {
-	return &FreshWalletMonitor{
+// NewFreshWalletMonitor resumes the unexpired watches persisted by a previous
+// run, so a restart inside the watch window neither misses nor re-analyzes
+// buyers.
+func NewFreshWalletMonitor(cfg *config.Config, store db.Store, sc *scanner.Scanner, an *analyzer.Analyzer) *FreshWalletMonitor {
+	m := &FreshWalletMonitor{
 		cfg:      cfg,
 		store:    store,
 		scanner:  sc,
 		analyzer: an,
 		watches:  make(map[string]*TokenWatch),
 	}
+
+	saved, err := store.GetActiveTokenWatches(time.Now().UTC())
+	if err != nil {
+		log.Warn().Err(err).Msg("could not load saved token watches")
+	}
+	for _, w := range saved {
+		tw := &TokenWatch{
+			KOLID:        w.KOLID,
+			TokenAddress: w.TokenAddress,
+			Chain:        w.Chain,
+			MentionTime:  w.MentionTime,
+			Expires:      w.ExpiresAt,
+			Checked:      make(map[string]bool, len(w.Checked)),
+			analyzed:     w.Checked,
+		}
+		for _, a := range w.Checked {
+			tw.Checked[a] = true
+		}
+		m.watches[watchKey(w.KOLID, w.TokenAddress)] = tw
+	}
+	if len(saved) > 0 {
+		log.Info().Int("watches", len(saved)).Msg("🔍 resumed token watches")
+	}
+	return m
+}
+
+// SetTracer makes every new wash candidate get a deep funding trace.
+func (m *FreshWalletMonitor) SetTracer(t *scanner.DeepFundingTracer) {
+	m.tracer = t
+}
+
+func watchKey(kolID int64, tokenAddr string) string {
+	return fmt.Sprintf("%d:%s", kolID, tokenAddr)
 }
 
 // OnTokenMentioned is called when a KOL mentions a token in social media.
 // Starts watching for fresh wallet buyers.
 func (m *FreshWalletMonitor) OnTokenMentioned(kolID int64, tokenAddr string, chain config.Chain, mentionTime time.Time) {
-	key := fmt.Sprintf("%d:%s", kolID, tokenAddr)
-
-	m.mu.Lock()
-	m.watches[key] = &TokenWatch{
+	key := watchKey(kolID, tokenAddr)
+	watch := &TokenWatch{
 		KOLID:        kolID,
 		TokenAddress: tokenAddr,
 		Chain:        chain,
@@ -60,8 +97,21 @@ func (m *FreshWalletMonitor) OnTokenMentioned(kolID int64, tokenAddr string, cha
 		Expires:      mentionTime.Add(4 * time.Hour),
 		Checked:      make(map[string]bool),
 	}
+
+	m.mu.Lock()
+	m.watches[key] = watch
 	m.mu.Unlock()
 
+	if err := m.store.UpsertTokenWatch(db.TokenWatch{
+		KOLID:        kolID,
+		TokenAddress: tokenAddr,
+		Chain:        chain,
+		MentionTime:  watch.MentionTime,
+		ExpiresAt:    watch.Expires,
+	}); err != nil {
+		log.Warn().Err(err).Str("token", abbrev(tokenAddr)).Msg("failed to persist token watch")
+	}
+
 	log.Info().
 		Int64("kol", kolID).
 		Str("token", abbrev(tokenAddr)).
@@ -80,6 +130,8 @@ func (m *FreshWalletMonitor) Run(ctx context.Context) error {
 			return ctx.Err()
 		case <-ticker.C:
 			m.scanAllWatches(ctx)
+		case <-m.cfg.Reloaded():
+			ticker.Reset(m.cfg.Current().FreshBuyerScanInterval)
 		}
 	}
 }
@@ -94,6 +146,7 @@ func (m *FreshWalletMonitor) scanAllWatches(ctx context.Context) {
 			log.Debug().Str("key", key).Msg("watch expired")
 		}
 	}
+	m.store.DeleteExpiredTokenWatches(now)
 
 	// Copy active watches
 	active := make(map[string]*TokenWatch)
@@ -116,18 +169,165 @@ func (m *FreshWalletMonitor) scanTokenBuyers(ctx context.Context, watch *TokenWa
 		log.Debug().Err(err).Str("token", abbrev(watch.TokenAddress)).Msg("failed to get buyers")
 		return
 	}
+	m.checkBuyers(ctx, watch, buyers)
+}
 
+// checkBuyers analyzes the buyers of watch's token not seen before.
+func (m *FreshWalletMonitor) checkBuyers(ctx context.Context, watch *TokenWatch, buyers []scanner.TokenBuyer) {
+	var wg sync.WaitGroup
+	launched := 0
 	for _, buyer := range buyers {
-		if buyer.Address == "" || watch.Checked[buyer.Address] {
-			continue
-		}
-
+		// the ticker and the streams both feed buyers, so check-and-mark is atomic
 		m.mu.Lock()
-		watch.Checked[buyer.Address] = true
+		seen := buyer.Address == "" || watch.Checked[buyer.Address]
+		if !seen {
+			watch.Checked[buyer.Address] = true
+		}
 		m.mu.Unlock()
+		if seen {
+			continue
+		}
 
 		// Analyze this buyer in background
-		go m.analyzeBuyer(ctx, watch, buyer)
+		wg.Add(1)
+		launched++
+		go func(buyer scanner.TokenBuyer) {
+			defer wg.Done()
+			m.analyzeBuyer(ctx, watch, buyer)
+			if ctx.Err() != nil {
+				return // interrupted by shutdown: leave it for the next run
+			}
+			m.mu.Lock()
+			watch.analyzed = append(watch.analyzed, buyer.Address)
+			m.mu.Unlock()
+		}(buyer)
+	}
+	if launched > 0 {
+		go func() { wg.Wait(); m.saveChecked(ctx, watch) }()
+	}
+}
+
+// OnWalletTrade is called by the real-time streams for each tracked-wallet
+// transaction. A sell of a token the wallet's own KOL is promoting raises a
+// dump alert; another wallet buying a watched token is analyzed as a fresh
+// buyer immediately instead of on the next buyer scan.
+func (m *FreshWalletMonitor) OnWalletTrade(ctx context.Context, ev scanner.TradeEvent) {
+	tx := ev.Tx
+	if tx.TokenAddress == "" || (tx.TxType != "swap_buy" && tx.TxType != "swap_sell") {
+		return
+	}
+	now := time.Now().UTC()
+	var hits []*TokenWatch
+	m.mu.RLock()
+	for _, w := range m.watches {
+		if w.TokenAddress == tx.TokenAddress && w.Chain == tx.Chain && now.Before(w.Expires) {
+			hits = append(hits, w)
+		}
+	}
+	m.mu.RUnlock()
+	if len(hits) == 0 {
+		return
+	}
+
+	owners := map[int64]bool{ev.Wallet.KOLID: true}
+	if links, err := m.store.GetWalletLinks(ev.Wallet.Address, ev.Wallet.Chain); err == nil {
+		for _, l := range links {
+			owners[l.KOLID] = true
+		}
+	}
+	for _, watch := range hits {
+		switch {
+		case owners[watch.KOLID] && tx.TxType == "swap_sell":
+			m.alertDump(watch, ev)
+		case !owners[watch.KOLID] && tx.TxType == "swap_buy":
+			m.checkBuyers(ctx, watch, []scanner.TokenBuyer{{
+				Address:   ev.Wallet.Address,
+				AmountUSD: tx.AmountUSD,
+				TxHash:    tx.TxHash,
+				Timestamp: tx.Timestamp,
+				Source:    "stream",
+				Chain:     tx.Chain,
+			}})
+		}
+	}
+}
+
+// WatchedTokens lists the tokens on chain with an active watch, for the
+// real-time streams to follow.
+func (m *FreshWalletMonitor) WatchedTokens(chain config.Chain) []string {
+	now := time.Now().UTC()
+	seen := map[string]bool{}
+	var out []string
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, w := range m.watches {
+		if w.Chain == chain && now.Before(w.Expires) && !seen[w.TokenAddress] {
+			seen[w.TokenAddress] = true
+			out = append(out, w.TokenAddress)
+		}
+	}
+	return out
+}
+
+// OnTokenBuyer is called by the streams when a watched token lands in a
+// wallet, so the buyer is analyzed within a block of buying.
+func (m *FreshWalletMonitor) OnTokenBuyer(ctx context.Context, chain config.Chain, token string, buyer scanner.TokenBuyer) {
+	now := time.Now().UTC()
+	var hits []*TokenWatch
+	m.mu.RLock()
+	for _, w := range m.watches {
+		if w.Chain == chain && strings.EqualFold(w.TokenAddress, token) && now.Before(w.Expires) {
+			hits = append(hits, w)
+		}
+	}
+	m.mu.RUnlock()
+	for _, watch := range hits {
+		m.checkBuyers(ctx, watch, []scanner.TokenBuyer{buyer})
+	}
+}
+
+// alertDump flags a KOL wallet selling a token after the KOL posted about it.
+func (m *FreshWalletMonitor) alertDump(watch *TokenWatch, ev scanner.TradeEvent) {
+	soldAt := ev.Tx.Timestamp
+	if soldAt.IsZero() {
+		soldAt = time.Now()
+	}
+	after := soldAt.Sub(watch.MentionTime)
+	if after < 0 {
+		return // sold before posting: not a dump on followers
+	}
+	severity := "warning"
+	if after < 30*time.Minute {
+		severity = "critical"
+	}
+	m.store.InsertAlert(watch.KOLID, "kol_dump", severity,
+		fmt.Sprintf("KOL wallet %s sold %s %s after posting", abbrev(ev.Wallet.Address), abbrev(watch.TokenAddress), after.Round(time.Second)),
+		fmt.Sprintf("Sold %.4g tokens ($%.2f) in tx %s", ev.Tx.AmountToken, ev.Tx.AmountUSD, ev.Tx.TxHash),
+		ev.Wallet.Address, watch.TokenAddress)
+
+	log.Warn().
+		Int64("kol", watch.KOLID).
+		Str("wallet", abbrev(ev.Wallet.Address)).
+		Str("token", abbrev(watch.TokenAddress)).
+		Dur("after_post", after).
+		Msg("🚨 KOL wallet dumping after post")
+}
+
+// saveChecked persists the buyers analyzed so far for watch. Only finished
+// analyses are saved, so a restart retries anything that was in flight.
+func (m *FreshWalletMonitor) saveChecked(ctx context.Context, watch *TokenWatch) {
+	if ctx.Err() != nil {
+		return
+	}
+	m.mu.RLock()
+	current := m.watches[watchKey(watch.KOLID, watch.TokenAddress)] == watch
+	analyzed := append([]string(nil), watch.analyzed...)
+	m.mu.RUnlock()
+	if !current || len(analyzed) == 0 {
+		return // expired, or replaced by a newer mention
+	}
+	if err := m.store.SetTokenWatchChecked(watch.KOLID, watch.TokenAddress, analyzed); err != nil {
+		log.Debug().Err(err).Str("token", abbrev(watch.TokenAddress)).Msg("failed to persist checked buyers")
 	}
 }
 
@@ -255,6 +455,12 @@ func (m *FreshWalletMonitor) analyzeBuyer(ctx context.Context, watch *TokenWatch
 		Float64("score", score).
 		Interface("signals", signals).
 		Msg("⚠️ suspicious fresh buyer detected")
+
+	if m.tracer != nil {
+		if _, err := m.tracer.TraceAndSave(ctx, buyer.Address, watch.Chain, m.cfg.Current().FundingTraceDepth); err != nil {
+			log.Warn().Err(err).Str("address", abbrev(buyer.Address)).Msg("funding trace failed")
+		}
+	}
 }
 
 func abbrev(addr string) string {
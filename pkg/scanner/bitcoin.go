@@ -0,0 +1,310 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// ── Bitcoin ─────────────────────────────────────────────────
+
+const (
+	esploraPageSize = 25 // confirmed txs per /txs/chain page, fixed by Esplora
+	satsPerBTC      = 1e8
+	// fundingPageLimit bounds the walk back to a wallet's first txs: 5,000
+	// txs, far more than the fresh wallets whose funding is traced have.
+	fundingPageLimit = 200
+)
+
+// esploraProvider serves Bitcoin from an Esplora API (blockstream.info,
+// mempool.space, or electrs on regtest for testing). Bitcoin matters here as
+// the first leg of a BTC → FixedFloat → SOL/ETH swap: each payment a wallet
+// makes is stored as its own transfer_out row, keyed by outpoint
+// ("txid:vout"), so ScanForFixedFloatPatterns can match it like any other
+// outgoing transfer. Change back to the wallet is not a payment.
+type esploraProvider struct {
+	unsupported
+	*Scanner
+}
+
+func (e *esploraProvider) Name() string { return "esplora" }
+
+func (e *esploraProvider) Supports(chain config.Chain) bool {
+	return chain == config.ChainBitcoin && e.cfg.GetExplorerURL(chain) != ""
+}
+
+type esploraTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		Prevout *struct {
+			Address string `json:"scriptpubkey_address"`
+			Value   int64  `json:"value"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		Address string `json:"scriptpubkey_address"`
+		Value   int64  `json:"value"`
+	} `json:"vout"`
+	Fee    int64 `json:"fee"`
+	Status struct {
+		Confirmed   bool  `json:"confirmed"`
+		BlockHeight int64 `json:"block_height"`
+		BlockTime   int64 `json:"block_time"`
+	} `json:"status"`
+}
+
+// flows returns what address put into the tx (spent outputs) and took out
+// of it, in sats.
+func (tx esploraTx) flows(address string) (spent, received int64) {
+	for _, in := range tx.Vin {
+		if in.Prevout != nil && in.Prevout.Address == address {
+			spent += in.Prevout.Value
+		}
+	}
+	for _, out := range tx.Vout {
+		if out.Address == address {
+			received += out.Value
+		}
+	}
+	return spent, received
+}
+
+// sender is the address of the tx's first input, taken as who paid.
+func (tx esploraTx) sender() string {
+	for _, in := range tx.Vin {
+		if in.Prevout != nil && in.Prevout.Address != "" {
+			return in.Prevout.Address
+		}
+	}
+	return ""
+}
+
+// esploraPage fetches one page of an address's confirmed txs, newest first,
+// older than the after txid (or the newest when empty).
+func (s *Scanner) esploraPage(ctx context.Context, address, after string) ([]esploraTx, error) {
+	path := []string{"address", address, "txs", "chain"}
+	if after != "" {
+		path = append(path, after)
+	}
+	body, err := s.getJSON(ctx, endpoint(s.cfg.GetExplorerURL(config.ChainBitcoin), path, nil))
+	if err != nil {
+		return nil, err
+	}
+	var txs []esploraTx
+	if err := json.Unmarshal(body, &txs); err != nil {
+		return nil, fmt.Errorf("esplora response: %w", err)
+	}
+	return txs, nil
+}
+
+// esploraOldest walks an address's confirmed history back to its first tx
+// and returns the oldest keep pages, newest first like esploraPage. complete
+// is false when the walk stopped at fundingPageLimit before the start.
+func (s *Scanner) esploraOldest(ctx context.Context, address string, keep int) (txs []esploraTx, complete bool, err error) {
+	var window [][]esploraTx
+	after := ""
+	for i := 0; i < fundingPageLimit; i++ {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		page, err := s.esploraPage(ctx, address, after)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(page) > 0 {
+			if window = append(window, page); len(window) > keep {
+				window = window[1:]
+			}
+		}
+		if len(page) < esploraPageSize {
+			complete = true
+			break
+		}
+		after = page[len(page)-1].TxID
+	}
+	for _, page := range window {
+		txs = append(txs, page...)
+	}
+	return txs, complete, nil
+}
+
+func (e *esploraProvider) FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
+	count := 0
+	err := e.scanNewestFirst(walletID, address, "esplora", esploraPageSize, func(before, until string) ([]string, error) {
+		page, err := e.esploraPage(ctx, address, before)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, tx := range page {
+			if tx.TxID == until {
+				break // Esplora has no until; stop at it here
+			}
+			count += e.storeBitcoinTx(ctx, walletID, address, tx)
+			ids = append(ids, tx.TxID)
+		}
+		return ids, nil
+	})
+	if err != nil && count == 0 {
+		return 0, err
+	}
+	log.Info().Str("addr", abbrev(address)).Str("chain", string(chain)).Int("txs", count).Msg("scanned Bitcoin")
+	return count, nil
+}
+
+// storeBitcoinTx stores a tx the wallet paid into as one transfer_out per
+// output to another address, the fee going on the first; a tx it only
+// received from is one transfer_in. Returns the rows stored.
+func (s *Scanner) storeBitcoinTx(ctx context.Context, walletID int64, address string, tx esploraTx) int {
+	spent, received := tx.flows(address)
+	ts := time.Unix(tx.Status.BlockTime, 0)
+	row := db.WalletTransaction{
+		WalletID: walletID, Chain: config.ChainBitcoin, TokenSymbol: "BTC",
+		Timestamp: ts, BlockNumber: tx.Status.BlockHeight,
+	}
+	stored := 0
+	if spent == 0 {
+		if received == 0 {
+			return 0
+		}
+		row.TxHash, row.TxType = tx.TxID, "transfer_in"
+		row.AmountToken = float64(received) / satsPerBTC
+		row.AmountUSD = s.usdAt(ctx, config.ChainBitcoin, "", row.AmountToken, ts)
+		row.FromAddress, row.ToAddress = tx.sender(), address
+		if s.store.InsertTransaction(row) == nil {
+			stored++
+		}
+		return stored
+	}
+
+	fee := float64(tx.Fee) / satsPerBTC
+	for i, out := range tx.Vout {
+		if out.Address == "" || out.Address == address || out.Value == 0 {
+			continue // OP_RETURN or change
+		}
+		row.TxHash, row.TxType = fmt.Sprintf("%s:%d", tx.TxID, i), "transfer_out"
+		row.AmountToken = float64(out.Value) / satsPerBTC
+		row.AmountUSD = s.usdAt(ctx, config.ChainBitcoin, "", row.AmountToken, ts)
+		row.FromAddress, row.ToAddress = address, out.Address
+		row.PriorityFee, fee = fee, 0
+		if s.store.InsertTransaction(row) == nil {
+			stored++
+		}
+	}
+	return stored
+}
+
+// Backfill walks the wallet's confirmed history newest to oldest under the
+// "esplora:backfill" cursor (the last txid read).
+func (e *esploraProvider) Backfill(ctx context.Context, walletID int64, address string, chain config.Chain, maxPages int) (int, bool, error) {
+	stream := "esplora:backfill"
+	after, err := e.store.GetScanCursor(walletID, stream)
+	if err != nil {
+		return 0, false, err
+	}
+	count := 0
+	for i := 0; maxPages <= 0 || i < maxPages; i++ {
+		if ctx.Err() != nil {
+			return count, false, ctx.Err()
+		}
+		page, err := e.esploraPage(ctx, address, after)
+		if err != nil {
+			return count, false, err
+		}
+		for _, tx := range page {
+			count += e.storeBitcoinTx(ctx, walletID, address, tx)
+		}
+		if len(page) == 0 {
+			return count, true, nil
+		}
+		after = page[len(page)-1].TxID
+		if err := e.store.SetScanCursor(walletID, stream, after); err != nil {
+			return count, false, err
+		}
+		if len(page) < esploraPageSize {
+			return count, true, nil
+		}
+	}
+	return count, false, nil
+}
+
+// FetchFunding looks at the payments into the wallet among its oldest txs.
+// Esplora lists newest first, so it pages back to the start of the history
+// and keeps the last two pages.
+func (e *esploraProvider) FetchFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error) {
+	fa := &db.FundingAnalysis{Address: address, Chain: chain, NativeSymbol: "BTC"}
+	txs, complete, err := e.esploraOldest(ctx, address, 2)
+	if err != nil {
+		return fa, err
+	}
+	if !complete {
+		log.Warn().Str("addr", abbrev(address)).Int("pages", fundingPageLimit).
+			Msg("history longer than the funding walk; first funding may be missed")
+	}
+	if len(txs) == 0 {
+		fa.IsNewWallet = true
+		return fa, nil
+	}
+
+	for i := len(txs) - 1; i >= 0; i-- {
+		tx := txs[i]
+		at := time.Unix(tx.Status.BlockTime, 0)
+		if fa.FirstTxTime == nil || at.Before(*fa.FirstTxTime) {
+			fa.FirstTxTime = &at
+		}
+		spent, received := tx.flows(address)
+		if spent > 0 || received == 0 {
+			continue
+		}
+		btc := float64(received) / satsPerBTC
+		fa.TotalFunded += btc
+		from := tx.sender()
+		srcType := e.identifyAddress(ctx, from, chain)
+		if srcType == "unknown" {
+			continue
+		}
+		fa.FundingSources = append(fa.FundingSources, db.FundingSource{
+			SourceAddress: from, Amount: btc, Token: "BTC", TxHash: tx.TxID,
+			SourceType: srcType, Timestamp: at.Unix(), Chain: chain,
+		})
+		log.Warn().Str("wallet", abbrev(address)).Str("chain", string(chain)).
+			Str("source", srcType).Float64("amount", btc).Msg("🚨 suspicious BTC funding")
+	}
+	return fa, nil
+}
+
+func (e *esploraProvider) LinkedWallets(ctx context.Context, address string, chain config.Chain) ([]db.FundingSource, error) {
+	txs, err := e.esploraPage(ctx, address, "")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{address: true}
+	var linked []db.FundingSource
+	for _, tx := range txs {
+		spent, received := tx.flows(address)
+		if spent > 0 {
+			for _, out := range tx.Vout {
+				if out.Address != "" && !seen[out.Address] {
+					seen[out.Address] = true
+					linked = append(linked, db.FundingSource{
+						SourceAddress: out.Address, Amount: float64(out.Value) / satsPerBTC, Token: "BTC",
+						SourceType: "sent_to", Chain: chain,
+					})
+				}
+			}
+		} else if from := tx.sender(); from != "" && !seen[from] {
+			seen[from] = true
+			linked = append(linked, db.FundingSource{
+				SourceAddress: from, Amount: float64(received) / satsPerBTC, Token: "BTC",
+				SourceType: "received_from", Chain: chain,
+			})
+		}
+	}
+	return linked, nil
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// fakeEsplora serves /address/:addr/txs/chain[/:last_seen] from txs, which
+// are newest first like Esplora's.
+func fakeEsplora(t *testing.T, address string, txs []esploraTx) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/address/" + address + "/txs/chain"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		start := 0
+		if after := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/"); after != "" {
+			for i, tx := range txs {
+				if tx.TxID == after {
+					start = i + 1
+				}
+			}
+		}
+		end := start + esploraPageSize
+		if end > len(txs) {
+			end = len(txs)
+		}
+		json.NewEncoder(w).Encode(txs[start:end])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// btcTx builds a confirmed tx paying value sats from one address to another.
+func btcTx(id string, from, to string, value int64, at time.Time) esploraTx {
+	var tx esploraTx
+	raw := fmt.Sprintf(`{"txid":%q,"vin":[{"prevout":{"scriptpubkey_address":%q,"value":%d}}],
+		"vout":[{"scriptpubkey_address":%q,"value":%d}],"fee":500,
+		"status":{"confirmed":true,"block_height":100,"block_time":%d}}`, id, from, value+500, to, value, at.Unix())
+	if err := json.Unmarshal([]byte(raw), &tx); err != nil {
+		panic(err)
+	}
+	return tx
+}
+
+func TestEsploraFundingPagesToOldest(t *testing.T) {
+	const wallet = "bcrt1qwallet"
+	start := time.Unix(1700000000, 0)
+	// 60 txs: the first funds the wallet, every later one is the wallet paying out
+	var txs []esploraTx
+	for i := 59; i > 0; i-- {
+		txs = append(txs, btcTx(fmt.Sprintf("tx%03d", i), wallet, "bcrt1qpayee", 1000, start.Add(time.Duration(i)*time.Hour)))
+	}
+	txs = append(txs, btcTx("tx000", "bcrt1qfunder", wallet, 50_000_000, start))
+
+	s, _ := newTestScanner(t)
+	useExplorer(t, config.ChainBitcoin, fakeEsplora(t, wallet, txs).URL)
+	e := &esploraProvider{Scanner: s}
+
+	oldest, complete, err := s.esploraOldest(context.Background(), wallet, 2)
+	if err != nil || !complete {
+		t.Fatalf("esploraOldest: complete=%v err=%v", complete, err)
+	}
+	if len(oldest) != 35 || oldest[len(oldest)-1].TxID != "tx000" || oldest[0].TxID != "tx034" {
+		t.Fatalf("got %d txs %s..%s, want the 35 oldest tx034..tx000", len(oldest), oldest[0].TxID, oldest[len(oldest)-1].TxID)
+	}
+
+	fa, err := e.FetchFunding(context.Background(), wallet, config.ChainBitcoin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fa.TotalFunded != 0.5 {
+		t.Errorf("TotalFunded = %v, want 0.5", fa.TotalFunded)
+	}
+	if fa.FirstTxTime == nil || !fa.FirstTxTime.Equal(start) {
+		t.Errorf("FirstTxTime = %v, want %v", fa.FirstTxTime, start)
+	}
+}
+
+// TestEsploraRegtest runs the Bitcoin provider against a live Esplora, e.g.
+// electrs on a regtest node: set TEST_ESPLORA_URL to its HTTP API and
+// TEST_ESPLORA_ADDRESS to an address that has received at least one payment.
+func TestEsploraRegtest(t *testing.T) {
+	base, address := os.Getenv("TEST_ESPLORA_URL"), os.Getenv("TEST_ESPLORA_ADDRESS")
+	if base == "" || address == "" {
+		t.Skip("TEST_ESPLORA_URL and TEST_ESPLORA_ADDRESS not set")
+	}
+	s, store := newTestScanner(t)
+	useExplorer(t, config.ChainBitcoin, base)
+	e := &esploraProvider{Scanner: s}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fa, err := e.FetchFunding(ctx, address, config.ChainBitcoin)
+	if err != nil {
+		t.Fatalf("FetchFunding: %v", err)
+	}
+	if fa.IsNewWallet || fa.FirstTxTime == nil || fa.TotalFunded <= 0 {
+		t.Errorf("funding = %+v, want a funded wallet with a first tx", fa)
+	}
+
+	kolID, err := store.UpsertKOL("regtest", "regtest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	walletID, err := store.UpsertWallet(kolID, address, config.ChainBitcoin, "regtest", 1, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := e.FetchTransactions(ctx, walletID, address, config.ChainBitcoin)
+	if err != nil {
+		t.Fatalf("FetchTransactions: %v", err)
+	}
+	if n == 0 {
+		t.Error("no transactions stored")
+	}
+	if cur, _ := store.GetScanCursor(walletID, "esplora"); cur == "" {
+		t.Error("scan cursor not set")
+	}
+}
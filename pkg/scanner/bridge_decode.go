@@ -0,0 +1,902 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// ── Bridge messages ─────────────────────────────────────────
+// A bridge transfer has a tx on each chain: the deposit on the source and
+// the fill, redeem or delivery on the destination. The decoders below start
+// from either one and resolve the other side, so a trace can cross chains
+// even when the recipient is a Solana wallet or a fresh EVM address.
+
+const (
+	wormholescanAPI = "https://api.wormholescan.io/api/v1"
+	dlnStatsAPI     = "https://stats-api.dln.trade/api"
+	mayanAPI        = "https://explorer-api.mayan.finance/v3"
+	acrossAPI       = "https://app.across.to/api"
+	layerZeroAPI    = "https://scan.layerzero-api.com/v1"
+)
+
+// Across spoke pool events. The bytes32 versions replaced the V3 ones so
+// that deposits can name Solana addresses.
+const (
+	// V3FundsDeposited(address,address,uint256,uint256,uint256,uint32,uint32,uint32,uint32,address,address,address,bytes)
+	acrossV3DepositTopic = "0xa123dc29aebf7d0c3322c8eeb5b999e859f39937950ed31056532713d0de396f"
+	// FundsDeposited(bytes32,bytes32,uint256,uint256,uint256,uint256,uint32,uint32,uint32,bytes32,bytes32,bytes32,bytes)
+	acrossDepositTopic = "0x32ed1a409ef04c7b0227189c3a103dc5ac10e775a15b785dcc510201f7c25ad3"
+	// FilledV3Relay(address,address,uint256,uint256,uint256,uint256,uint32,uint32,uint32,address,address,address,address,bytes,(address,bytes,uint256,uint8))
+	acrossV3FillTopic = "0x571749edf1d5c9599318cdbc4e28a6475d65e87fd3b2ddbe1e9a8d5e7a0f0ff7"
+	// FilledRelay(bytes32,bytes32,uint256,uint256,uint256,uint256,uint256,uint32,uint32,bytes32,bytes32,bytes32,bytes32,bytes32,(bytes32,bytes32,uint256,uint8))
+	acrossFillTopic = "0x44b559f101f8fbcc8a0ea43fa91a05a729a5ea6e14a7c75aa750374690137208"
+)
+
+// LayerZero v2 events: OFTSent and OFTReceived come from Stargate pools and
+// OFT tokens, PacketSent from the endpoint.
+const (
+	oftSentTopic      = "0x85496b760a4b7f8d66384b9df21b381f5d1b1e79f229a47aaf4c232edc2fe59a" // OFTSent(bytes32,uint32,address,uint256,uint256)
+	oftReceivedTopic  = "0xefed6d3500546b29533b128a29e3a94d70788727f0507505ac12eaf2e578fd9c" // OFTReceived(bytes32,uint32,address,uint256)
+	lzPacketSentTopic = "0x1ab700d4ced0c005b164c0f789fd09fcbb0156d4c2041b8a3bfbcd961cd1567f" // PacketSent(bytes,bytes,address)
+)
+
+// bridgeEvents maps the events that mark a bridge transfer to its protocol.
+var bridgeEvents = map[string]string{
+	acrossV3DepositTopic: "across",
+	acrossDepositTopic:   "across",
+	acrossV3FillTopic:    "across",
+	acrossFillTopic:      "across",
+	oftSentTopic:         "stargate",
+	oftReceivedTopic:     "stargate",
+}
+
+// bridgeNames are the protocols' display names.
+var bridgeNames = map[string]string{
+	"wormhole": "Wormhole",
+	"debridge": "deBridge",
+	"mayan":    "Mayan",
+	"across":   "Across",
+	"stargate": "Stargate",
+}
+
+// decodedBridge returns the protocol of a bridge contract or program on
+// chain when a decoder below handles it, else "". Rollup bridges and
+// unlabelled ones are left to the funding trace.
+func decodedBridge(chain config.Chain, address string) string {
+	p := config.BridgeProtocol(chain, address)
+	if _, ok := bridgeNames[p]; !ok {
+		return ""
+	}
+	return p
+}
+
+// BridgeTransfer is one cross-chain transfer as the bridge recorded it.
+// Addresses are in their chain's own format; a Solana recipient is the
+// wallet, not its token account. Amount is what the recipient got, in Token.
+type BridgeTransfer struct {
+	Protocol    string       `json:"protocol"`
+	SourceChain config.Chain `json:"source_chain"`
+	SourceTx    string       `json:"source_tx"`
+	Sender      string       `json:"sender"`
+	DestChain   config.Chain `json:"dest_chain"`
+	DestTx      string       `json:"dest_tx"`
+	Recipient   string       `json:"recipient"`
+	Token       string       `json:"token,omitempty"`
+	Amount      float64      `json:"amount,omitempty"`
+}
+
+// bridgeTx is the tx a decoder starts from, with the protocols it touches.
+type bridgeTx struct {
+	Chain     config.Chain
+	Hash      string
+	Receipt   *evmReceipt // EVM txs only
+	protocols map[string]bool
+}
+
+func (tx *bridgeTx) mark(address string) {
+	if p := decodedBridge(tx.Chain, address); p != "" {
+		tx.protocols[p] = true
+	}
+}
+
+// bridgeDecoder resolves a transfer of one protocol from either of its txs.
+// It returns nil when the tx holds none.
+type bridgeDecoder interface {
+	Protocol() string
+	Decode(ctx context.Context, tx *bridgeTx) (*BridgeTransfer, error)
+}
+
+// bridgeDecoders lists the decoders in the order they are tried. Mayan and
+// deBridge orders can settle over Wormhole, so they go before it.
+func (s *Scanner) bridgeDecoders() []bridgeDecoder {
+	return []bridgeDecoder{
+		mayanDecoder{s}, debridgeDecoder{s}, acrossDecoder{s}, stargateDecoder{s}, wormholeDecoder{s},
+	}
+}
+
+// DecodeBridgeTx decodes the bridge transfer hash is part of, from its source
+// or its destination side. It returns nil when hash touches no known bridge.
+func (s *Scanner) DecodeBridgeTx(ctx context.Context, chain config.Chain, hash string) (*BridgeTransfer, error) {
+	tx, err := s.loadBridgeTx(ctx, chain, hash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, d := range s.bridgeDecoders() {
+		if !tx.protocols[d.Protocol()] {
+			continue
+		}
+		bt, err := d.Decode(ctx, tx)
+		if err != nil {
+			log.Debug().Err(err).Str("bridge", d.Protocol()).Str("tx", abbrev(hash)).Msg("bridge decode failed")
+			lastErr = err
+			continue
+		}
+		if bt == nil {
+			continue
+		}
+		bt.Protocol = d.Protocol()
+		s.completeBridgeTransfer(ctx, bt, tx)
+		return bt, nil
+	}
+	return nil, lastErr
+}
+
+// loadBridgeTx fetches hash and notes the bridges it touches: EVM log
+// emitters, events and the called contract, or Solana programs and
+// accounts. It returns nil for a failed tx or one no bridge is part of.
+func (s *Scanner) loadBridgeTx(ctx context.Context, chain config.Chain, hash string) (*bridgeTx, error) {
+	tx := &bridgeTx{Chain: chain, Hash: hash, protocols: map[string]bool{}}
+	switch {
+	case chain.IsEVM():
+		rpcURL := s.cfg.EVMRPC[chain]
+		if rpcURL == "" {
+			return nil, fmt.Errorf("no RPC for %s", chain)
+		}
+		rc, err := s.getReceipt(ctx, rpcURL, hash)
+		if err != nil {
+			return nil, err
+		}
+		if rc.Status != "0x1" {
+			return nil, nil
+		}
+		tx.Receipt = rc
+		tx.mark(rc.To)
+		for _, l := range rc.Logs {
+			tx.mark(l.Address)
+			if len(l.Topics) > 0 && bridgeEvents[l.Topics[0]] != "" {
+				tx.protocols[bridgeEvents[l.Topics[0]]] = true
+			}
+		}
+	case chain == config.ChainSolana:
+		raw, err := s.rpcCall(ctx, s.cfg.SolanaRPCURL, "getTransaction", []interface{}{
+			hash,
+			map[string]interface{}{
+				"encoding":                       "jsonParsed",
+				"maxSupportedTransactionVersion": 0,
+				"commitment":                     "confirmed",
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if string(raw) == "null" {
+			return nil, errTxNotFound
+		}
+		var p solParsedTx
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		if p.Transaction == nil || (p.Meta != nil && p.Meta.Err != nil) {
+			return nil, nil
+		}
+		for _, k := range p.Transaction.Message.AccountKeys {
+			tx.mark(k.Pubkey)
+		}
+	default:
+		return nil, nil
+	}
+	if len(tx.protocols) == 0 {
+		return nil, nil
+	}
+	return tx, nil
+}
+
+// completeBridgeTransfer fills in what the decoder left to the tx itself
+// and resolves a Solana recipient's token account to its owner.
+func (s *Scanner) completeBridgeTransfer(ctx context.Context, bt *BridgeTransfer, tx *bridgeTx) {
+	switch tx.Chain {
+	case bt.SourceChain:
+		if bt.SourceTx == "" {
+			bt.SourceTx = tx.Hash
+		}
+		if bt.Sender == "" && tx.Receipt != nil {
+			bt.Sender = strings.ToLower(tx.Receipt.From)
+		}
+	case bt.DestChain:
+		if bt.DestTx == "" {
+			bt.DestTx = tx.Hash
+		}
+	}
+	if bt.DestChain == config.ChainSolana && bt.Recipient != "" {
+		bt.Recipient = s.solanaOwner(ctx, bt.Recipient)
+	}
+}
+
+// getReceipt fetches a tx receipt; errTxNotFound while it is unknown.
+func (s *Scanner) getReceipt(ctx context.Context, rpcURL, hash string) (*evmReceipt, error) {
+	raw, err := s.rpcCall(ctx, rpcURL, "eth_getTransactionReceipt", []interface{}{hash})
+	if err != nil {
+		return nil, err
+	}
+	if string(raw) == "null" {
+		return nil, errTxNotFound
+	}
+	var rc evmReceipt
+	if err := json.Unmarshal(raw, &rc); err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+// solanaOwner returns the wallet owning a token account, or account itself
+// when it is not a token account.
+func (s *Scanner) solanaOwner(ctx context.Context, account string) string {
+	raw, err := s.rpcCall(ctx, s.cfg.SolanaRPCURL, "getAccountInfo", []interface{}{
+		account, map[string]string{"encoding": "jsonParsed"},
+	})
+	if err != nil {
+		return account
+	}
+	var res struct {
+		Value *struct {
+			Data struct {
+				Parsed struct {
+					Type string `json:"type"`
+					Info struct {
+						Owner string `json:"owner"`
+					} `json:"info"`
+				} `json:"parsed"`
+			} `json:"data"`
+		} `json:"value"`
+	}
+	// accounts the node can't parse come back as base64, which fails here
+	if json.Unmarshal(raw, &res) != nil || res.Value == nil ||
+		res.Value.Data.Parsed.Type != "account" || res.Value.Data.Parsed.Info.Owner == "" {
+		return account
+	}
+	return res.Value.Data.Parsed.Info.Owner
+}
+
+// bridgeTokenValue converts a raw amount of an EVM token on chain, when
+// there is an RPC to look the token up.
+func (s *Scanner) bridgeTokenValue(ctx context.Context, chain config.Chain, token string, amount *big.Int) (string, float64, bool) {
+	rpcURL := s.cfg.EVMRPC[chain]
+	if rpcURL == "" || token == "" || amount == nil {
+		return "", 0, false
+	}
+	info := s.evmTokenInfo(ctx, rpcURL, chain, token, map[string]tokenInfo{})
+	return info.symbol, tokenValueBig(amount, info.decimals), true
+}
+
+// bridgeAddress formats a 32-byte address from a bridge message for chain:
+// base58 on Solana, base58check on Tron and the low 20 bytes elsewhere. The
+// zero address is "".
+func bridgeAddress(chain config.Chain, b []byte) string {
+	if len(b) != 32 || new(big.Int).SetBytes(b).Sign() == 0 {
+		return ""
+	}
+	switch chain {
+	case config.ChainSolana:
+		return base58Encode(b)
+	case config.ChainTron:
+		return tronBase58("41" + hex.EncodeToString(b[12:]))
+	}
+	return "0x" + hex.EncodeToString(b[12:])
+}
+
+// normalizeBridgeAddress tidies an address from a bridge API: 32-byte hex is
+// formatted for chain and EVM addresses are lowercased.
+func normalizeBridgeAddress(chain config.Chain, address string) string {
+	if h := strings.TrimPrefix(address, "0x"); len(h) == 64 && h != address {
+		if b, err := hex.DecodeString(h); err == nil {
+			return bridgeAddress(chain, b)
+		}
+	}
+	if chain.IsEVM() {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+func topicBytes(topic string) []byte {
+	b, _ := hex.DecodeString(strings.TrimPrefix(topic, "0x"))
+	return b
+}
+
+func logData(l evmLog) []byte {
+	b, _ := hex.DecodeString(strings.TrimPrefix(l.Data, "0x"))
+	return b
+}
+
+// apiNumber is a number an API may send bare or quoted.
+type apiNumber float64
+
+func (n *apiNumber) UnmarshalJSON(b []byte) error {
+	v, _ := strconv.ParseFloat(strings.Trim(string(b), `"`), 64)
+	*n = apiNumber(v)
+	return nil
+}
+
+// ── Wormhole ────────────────────────────────────────────────
+
+type wormholeDecoder struct{ *Scanner }
+
+func (wormholeDecoder) Protocol() string { return "wormhole" }
+
+// wormholeOp is a Wormholescan operation: a signed message (VAA) with the
+// txs that emitted and redeemed it.
+type wormholeOp struct {
+	VAA *struct {
+		Raw []byte `json:"raw"` // base64
+	} `json:"vaa"`
+	Content struct {
+		StandarizedProperties struct {
+			FromChain   int64  `json:"fromChain"`
+			FromAddress string `json:"fromAddress"`
+			ToChain     int64  `json:"toChain"`
+			ToAddress   string `json:"toAddress"`
+		} `json:"standarizedProperties"`
+	} `json:"content"`
+	SourceChain *struct {
+		From        string `json:"from"`
+		Transaction struct {
+			TxHash string `json:"txHash"`
+		} `json:"transaction"`
+	} `json:"sourceChain"`
+	TargetChain *struct {
+		Transaction struct {
+			TxHash string `json:"txHash"`
+		} `json:"transaction"`
+	} `json:"targetChain"`
+	Data *struct {
+		Symbol      string `json:"symbol"`
+		TokenAmount string `json:"tokenAmount"`
+	} `json:"data"`
+}
+
+func (d wormholeDecoder) Decode(ctx context.Context, tx *bridgeTx) (*BridgeTransfer, error) {
+	body, err := d.getJSON(ctx, endpoint(wormholescanAPI, []string{"operations"}, url.Values{"txHash": {tx.Hash}}))
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Operations []wormholeOp `json:"operations"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("wormholescan: %w", err)
+	}
+	if len(res.Operations) == 0 {
+		return nil, nil
+	}
+	op := res.Operations[0]
+	props := op.Content.StandarizedProperties
+
+	fromID, toID := props.FromChain, props.ToChain
+	var tt *wormholeTransfer
+	if op.VAA != nil {
+		if vaa, err := parseVAA(op.VAA.Raw); err == nil {
+			fromID = int64(vaa.EmitterChain)
+			if tt = vaa.tokenTransfer(); tt != nil {
+				toID = int64(tt.ToChain)
+			}
+		}
+	}
+	src, ok := config.ChainByBridgeID("wormhole", fromID)
+	if !ok {
+		return nil, fmt.Errorf("wormhole chain %d not registered", fromID)
+	}
+	dst, ok := config.ChainByBridgeID("wormhole", toID)
+	if !ok {
+		return nil, fmt.Errorf("wormhole chain %d not registered", toID)
+	}
+
+	bt := &BridgeTransfer{SourceChain: src, DestChain: dst, Recipient: normalizeBridgeAddress(dst, props.ToAddress)}
+	if tt != nil {
+		// a plain transfer's VAA names the recipient; one with a payload
+		// names the contract acting on it, which Wormholescan resolves to the
+		// end recipient for the protocols it knows
+		if tt.PayloadID == 1 || bt.Recipient == "" {
+			bt.Recipient = bridgeAddress(dst, tt.To)
+		}
+		bt.Amount = tokenValueBig(tt.Amount, 8) // the token bridge normalizes to 8 decimals
+	}
+	if op.SourceChain != nil {
+		bt.Sender = normalizeBridgeAddress(src, op.SourceChain.From)
+		bt.SourceTx = op.SourceChain.Transaction.TxHash
+	}
+	if bt.Sender == "" {
+		bt.Sender = normalizeBridgeAddress(src, props.FromAddress)
+	}
+	if op.TargetChain != nil {
+		bt.DestTx = op.TargetChain.Transaction.TxHash
+	}
+	if op.Data != nil {
+		bt.Token = op.Data.Symbol
+		if v, err := strconv.ParseFloat(op.Data.TokenAmount, 64); err == nil && v > 0 {
+			bt.Amount = v
+		}
+	}
+	return bt, nil
+}
+
+// wormholeVAA is the body of a signed Wormhole message.
+type wormholeVAA struct {
+	EmitterChain   uint16
+	EmitterAddress []byte
+	Sequence       uint64
+	Payload        []byte
+}
+
+// parseVAA reads a serialized v1 VAA: version, guardian set index and the
+// 66-byte guardian signatures, then the body (timestamp, nonce, emitter
+// chain and address, sequence, consistency level, payload).
+func parseVAA(b []byte) (*wormholeVAA, error) {
+	if len(b) < 6 || b[0] != 1 {
+		return nil, errors.New("not a v1 VAA")
+	}
+	off := 6 + int(b[5])*66
+	if len(b) < off+51 {
+		return nil, errors.New("short VAA")
+	}
+	body := b[off:]
+	return &wormholeVAA{
+		EmitterChain:   binary.BigEndian.Uint16(body[8:10]),
+		EmitterAddress: body[10:42],
+		Sequence:       binary.BigEndian.Uint64(body[42:50]),
+		Payload:        body[51:],
+	}, nil
+}
+
+// wormholeTransfer is a token bridge payload: 1 is a plain transfer, 3 a
+// transfer with a payload for the recipient contract.
+type wormholeTransfer struct {
+	PayloadID  byte
+	Amount     *big.Int // normalized to 8 decimals
+	Token      []byte
+	TokenChain uint16
+	To         []byte
+	ToChain    uint16
+}
+
+func (v *wormholeVAA) tokenTransfer() *wormholeTransfer {
+	p := v.Payload
+	if len(p) < 101 || (p[0] != 1 && p[0] != 3) {
+		return nil
+	}
+	return &wormholeTransfer{
+		PayloadID:  p[0],
+		Amount:     new(big.Int).SetBytes(p[1:33]),
+		Token:      p[33:65],
+		TokenChain: binary.BigEndian.Uint16(p[65:67]),
+		To:         p[67:99],
+		ToChain:    binary.BigEndian.Uint16(p[99:101]),
+	}
+}
+
+// ── deBridge ────────────────────────────────────────────────
+
+type debridgeDecoder struct{ *Scanner }
+
+func (debridgeDecoder) Protocol() string { return "debridge" }
+
+// dlnValue is how the DLN API wraps ids, addresses and amounts.
+type dlnValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type dlnOffer struct {
+	ChainID  dlnValue `json:"chainId"`
+	Amount   dlnValue `json:"amount"`
+	Metadata struct {
+		Decimals int    `json:"decimals"`
+		Symbol   string `json:"symbol"`
+	} `json:"metadata"`
+}
+
+// dlnOrder is a DLN order: the maker gives one offer on the source chain and
+// a taker fills the other to the receiver on the destination.
+type dlnOrder struct {
+	Give        dlnOffer `json:"giveOfferWithMetadata"`
+	Take        dlnOffer `json:"takeOfferWithMetadata"`
+	MakerSrc    dlnValue `json:"makerSrc"`
+	ReceiverDst dlnValue `json:"receiverDst"`
+	CreateTx    dlnValue `json:"createEventTransactionHash"`
+	Fulfilled   struct {
+		TransactionHash dlnValue `json:"transactionHash"`
+	} `json:"fulfilledDstEventMetadata"`
+}
+
+func (d debridgeDecoder) Decode(ctx context.Context, tx *bridgeTx) (*BridgeTransfer, error) {
+	body, err := d.getJSON(ctx, endpoint(dlnStatsAPI, []string{"Transaction", tx.Hash, "orderIds"}, nil))
+	if err != nil {
+		return nil, err
+	}
+	var ids struct {
+		OrderIDs []dlnValue `json:"orderIds"`
+	}
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return nil, fmt.Errorf("dln order ids: %w", err)
+	}
+	if len(ids.OrderIDs) == 0 {
+		return nil, nil
+	}
+	body, err = d.getJSON(ctx, endpoint(dlnStatsAPI, []string{"Orders", ids.OrderIDs[0].StringValue}, nil))
+	if err != nil {
+		return nil, err
+	}
+	var o dlnOrder
+	if err := json.Unmarshal(body, &o); err != nil {
+		return nil, fmt.Errorf("dln order: %w", err)
+	}
+	src, ok := config.ChainByBridgeID("debridge", parseInt64(o.Give.ChainID.StringValue))
+	if !ok {
+		return nil, fmt.Errorf("deBridge chain %s not registered", o.Give.ChainID.StringValue)
+	}
+	dst, ok := config.ChainByBridgeID("debridge", parseInt64(o.Take.ChainID.StringValue))
+	if !ok {
+		return nil, fmt.Errorf("deBridge chain %s not registered", o.Take.ChainID.StringValue)
+	}
+	return &BridgeTransfer{
+		SourceChain: src,
+		SourceTx:    o.CreateTx.StringValue,
+		Sender:      normalizeBridgeAddress(src, o.MakerSrc.StringValue),
+		DestChain:   dst,
+		DestTx:      o.Fulfilled.TransactionHash.StringValue,
+		Recipient:   normalizeBridgeAddress(dst, o.ReceiverDst.StringValue),
+		Token:       o.Take.Metadata.Symbol,
+		Amount:      tokenValue(o.Take.Amount.StringValue, o.Take.Metadata.Decimals),
+	}, nil
+}
+
+// ── Mayan ───────────────────────────────────────────────────
+
+type mayanDecoder struct{ *Scanner }
+
+func (mayanDecoder) Protocol() string { return "mayan" }
+
+// mayanSwap is a swap from the Mayan explorer. Chains are Wormhole ids.
+type mayanSwap struct {
+	SourceChain   apiNumber `json:"sourceChain"`
+	SourceTxHash  string    `json:"sourceTxHash"`
+	Trader        string    `json:"trader"`
+	DestChain     apiNumber `json:"destChain"`
+	DestAddress   string    `json:"destAddress"`
+	FulfillTxHash string    `json:"fulfillTxHash"`
+	RedeemTxHash  string    `json:"redeemTxHash"`
+	ToTokenSymbol string    `json:"toTokenSymbol"`
+	ToAmount      apiNumber `json:"toAmount"`
+}
+
+func (d mayanDecoder) Decode(ctx context.Context, tx *bridgeTx) (*BridgeTransfer, error) {
+	body, err := d.getJSON(ctx, endpoint(mayanAPI, []string{"swap", "trx", tx.Hash}, nil))
+	if err != nil {
+		return nil, err
+	}
+	var sw mayanSwap
+	if err := json.Unmarshal(body, &sw); err != nil {
+		return nil, fmt.Errorf("mayan swap: %w", err)
+	}
+	if sw.SourceTxHash == "" {
+		return nil, nil
+	}
+	src, ok := config.ChainByBridgeID("wormhole", int64(sw.SourceChain))
+	if !ok {
+		return nil, fmt.Errorf("mayan chain %v not registered", sw.SourceChain)
+	}
+	dst, ok := config.ChainByBridgeID("wormhole", int64(sw.DestChain))
+	if !ok {
+		return nil, fmt.Errorf("mayan chain %v not registered", sw.DestChain)
+	}
+	destTx := sw.FulfillTxHash
+	if destTx == "" {
+		destTx = sw.RedeemTxHash
+	}
+	return &BridgeTransfer{
+		SourceChain: src,
+		SourceTx:    sw.SourceTxHash,
+		Sender:      normalizeBridgeAddress(src, sw.Trader),
+		DestChain:   dst,
+		DestTx:      destTx,
+		Recipient:   normalizeBridgeAddress(dst, sw.DestAddress),
+		Token:       sw.ToTokenSymbol,
+		Amount:      float64(sw.ToAmount),
+	}, nil
+}
+
+// ── Across ──────────────────────────────────────────────────
+
+type acrossDecoder struct{ *Scanner }
+
+func (acrossDecoder) Protocol() string { return "across" }
+
+// Decode reads a deposit or fill event. Both name the depositor and the
+// recipient, so the Across API is only asked for the other side's tx.
+func (d acrossDecoder) Decode(ctx context.Context, tx *bridgeTx) (*BridgeTransfer, error) {
+	if tx.Receipt == nil {
+		return nil, nil
+	}
+	for _, l := range tx.Receipt.Logs {
+		if len(l.Topics) < 4 {
+			continue
+		}
+		data := logData(l)
+		switch l.Topics[0] {
+		case acrossV3DepositTopic, acrossDepositTopic:
+			// data: inputToken, outputToken, inputAmount, outputAmount,
+			// quoteTimestamp, fillDeadline, exclusivityDeadline, recipient, …
+			// topics: destinationChainId, depositId, depositor
+			if len(data) < 8*32 {
+				continue
+			}
+			dst, ok := config.ChainByBridgeID("across", hexBig(l.Topics[1]).Int64())
+			if !ok {
+				return nil, fmt.Errorf("across chain %s not registered", hexBig(l.Topics[1]))
+			}
+			bt := &BridgeTransfer{
+				SourceChain: tx.Chain,
+				SourceTx:    tx.Hash,
+				Sender:      bridgeAddress(tx.Chain, topicBytes(l.Topics[3])),
+				DestChain:   dst,
+				Recipient:   bridgeAddress(dst, word(data, 7)),
+			}
+			// value the output where the destination can be asked, else the input
+			var valued bool
+			if dst.IsEVM() {
+				bt.Token, bt.Amount, valued = d.bridgeTokenValue(ctx, dst, bridgeAddress(dst, word(data, 1)), wordBig(data, 3))
+			}
+			if !valued {
+				bt.Token, bt.Amount, _ = d.bridgeTokenValue(ctx, tx.Chain, bridgeAddress(tx.Chain, word(data, 0)), wordBig(data, 2))
+			}
+			if ci, _ := config.LookupChain(tx.Chain); ci.ChainID != 0 {
+				_, bt.DestTx = d.status(ctx, ci.ChainID, hexBig(l.Topics[2]))
+			}
+			return bt, nil
+
+		case acrossV3FillTopic, acrossFillTopic:
+			// data: inputToken, outputToken, inputAmount, outputAmount,
+			// repaymentChainId, fillDeadline, exclusivityDeadline,
+			// exclusiveRelayer, depositor, recipient, message, then the
+			// execution info (updatedRecipient, …, updatedOutputAmount, …)
+			// topics: originChainId, depositId, relayer
+			if len(data) < 11*32 {
+				continue
+			}
+			originID := hexBig(l.Topics[1]).Int64()
+			src, ok := config.ChainByBridgeID("across", originID)
+			if !ok {
+				return nil, fmt.Errorf("across chain %d not registered", originID)
+			}
+			recipient, amount := word(data, 9), wordBig(data, 3)
+			var info []byte
+			if l.Topics[0] == acrossFillTopic {
+				info = data[11*32:]
+			} else if off, ok := dynOffset(data, 11); ok {
+				info = data[off:]
+			}
+			// a speed-up can redirect the fill and change its amount
+			if r := word(info, 0); r != nil && bridgeAddress(tx.Chain, r) != "" {
+				recipient = r
+			}
+			if a := wordBig(info, 2); a != nil && a.Sign() > 0 {
+				amount = a
+			}
+			bt := &BridgeTransfer{
+				SourceChain: src,
+				Sender:      bridgeAddress(src, word(data, 8)),
+				DestChain:   tx.Chain,
+				DestTx:      tx.Hash,
+				Recipient:   bridgeAddress(tx.Chain, recipient),
+			}
+			bt.Token, bt.Amount, _ = d.bridgeTokenValue(ctx, tx.Chain, bridgeAddress(tx.Chain, word(data, 1)), amount)
+			bt.SourceTx, _ = d.status(ctx, originID, hexBig(l.Topics[2]))
+			return bt, nil
+		}
+	}
+	return nil, nil
+}
+
+// status asks the Across API for a deposit's txs. Either may be "".
+func (d acrossDecoder) status(ctx context.Context, originID int64, depositID *big.Int) (depositTx, fillTx string) {
+	body, err := d.getJSON(ctx, endpoint(acrossAPI, []string{"deposit", "status"}, url.Values{
+		"originChainId": {strconv.FormatInt(originID, 10)}, "depositId": {depositID.String()},
+	}))
+	if err != nil {
+		return "", ""
+	}
+	var st struct {
+		DepositTxHash string `json:"depositTxHash"`
+		FillTx        string `json:"fillTx"`
+	}
+	json.Unmarshal(body, &st)
+	return st.DepositTxHash, st.FillTx
+}
+
+// ── Stargate / LayerZero ────────────────────────────────────
+
+type stargateDecoder struct{ *Scanner }
+
+func (stargateDecoder) Protocol() string { return "stargate" }
+
+// lzMessage is a LayerZero Scan message: the txs that sent and delivered a
+// packet.
+type lzMessage struct {
+	Source struct {
+		Tx struct {
+			TxHash string `json:"txHash"`
+			From   string `json:"from"`
+		} `json:"tx"`
+	} `json:"source"`
+	Destination struct {
+		Tx struct {
+			TxHash string `json:"txHash"`
+		} `json:"tx"`
+	} `json:"destination"`
+}
+
+// Decode reads an OFTSent or OFTReceived event of a Stargate pool or OFT.
+// Each event names only its own side's wallet; the other comes from the
+// packet, or from the other side's event found through LayerZero Scan.
+func (d stargateDecoder) Decode(ctx context.Context, tx *bridgeTx) (*BridgeTransfer, error) {
+	if tx.Receipt == nil {
+		return nil, nil
+	}
+	for _, l := range tx.Receipt.Logs {
+		if len(l.Topics) < 3 {
+			continue
+		}
+		data, guid := logData(l), l.Topics[1]
+		switch l.Topics[0] {
+		case oftSentTopic:
+			// data: dstEid, amountSentLD, amountReceivedLD; topics: guid, fromAddress
+			if len(data) < 3*32 {
+				continue
+			}
+			dst, ok := config.ChainByBridgeID("layerzero", wordBig(data, 0).Int64())
+			if !ok {
+				return nil, fmt.Errorf("layerzero eid %s not registered", wordBig(data, 0))
+			}
+			bt := &BridgeTransfer{
+				SourceChain: tx.Chain,
+				SourceTx:    tx.Hash,
+				Sender:      bridgeAddress(tx.Chain, topicBytes(l.Topics[2])),
+				DestChain:   dst,
+				Recipient:   bridgeAddress(dst, lzPacketRecipient(tx.Receipt, guid)),
+			}
+			bt.Token, bt.Amount = d.oftValue(ctx, tx.Chain, l.Address, wordBig(data, 2))
+			if msg := d.lzMessage(ctx, guid); msg != nil {
+				bt.DestTx = msg.Destination.Tx.TxHash
+				if bt.Recipient == "" {
+					bt.Recipient = d.oftParty(ctx, dst, bt.DestTx, oftReceivedTopic, guid)
+				}
+			}
+			return bt, nil
+
+		case oftReceivedTopic:
+			// data: srcEid, amountReceivedLD; topics: guid, toAddress
+			if len(data) < 2*32 {
+				continue
+			}
+			src, ok := config.ChainByBridgeID("layerzero", wordBig(data, 0).Int64())
+			if !ok {
+				return nil, fmt.Errorf("layerzero eid %s not registered", wordBig(data, 0))
+			}
+			bt := &BridgeTransfer{
+				SourceChain: src,
+				DestChain:   tx.Chain,
+				DestTx:      tx.Hash,
+				Recipient:   bridgeAddress(tx.Chain, topicBytes(l.Topics[2])),
+			}
+			bt.Token, bt.Amount = d.oftValue(ctx, tx.Chain, l.Address, wordBig(data, 1))
+			if msg := d.lzMessage(ctx, guid); msg != nil {
+				bt.SourceTx = msg.Source.Tx.TxHash
+				bt.Sender = d.oftParty(ctx, src, bt.SourceTx, oftSentTopic, guid)
+				if bt.Sender == "" {
+					bt.Sender = normalizeBridgeAddress(src, msg.Source.Tx.From)
+				}
+			}
+			return bt, nil
+		}
+	}
+	return nil, nil
+}
+
+// lzPacketRecipient reads the OFT recipient from the PacketSent carrying
+// guid. An encoded packet is an 81-byte header (version, nonce, source eid,
+// sender, destination eid, receiver), the guid, then the message, which for
+// an OFT starts with the recipient. Stargate bus rides send no packet of
+// their own.
+func lzPacketRecipient(rc *evmReceipt, guid string) []byte {
+	g := topicBytes(guid)
+	for _, l := range rc.Logs {
+		if len(l.Topics) == 0 || l.Topics[0] != lzPacketSentTopic {
+			continue
+		}
+		if pkt := dynBytes(logData(l), 0); len(pkt) >= 145 && bytes.Equal(pkt[81:113], g) {
+			return pkt[113:145]
+		}
+	}
+	return nil
+}
+
+func (d stargateDecoder) lzMessage(ctx context.Context, guid string) *lzMessage {
+	if hexBig(guid).Sign() == 0 {
+		return nil
+	}
+	body, err := d.getJSON(ctx, endpoint(layerZeroAPI, []string{"messages", "guid", guid}, nil))
+	if err != nil {
+		return nil
+	}
+	var res struct {
+		Data []lzMessage `json:"data"`
+	}
+	if json.Unmarshal(body, &res) != nil || len(res.Data) == 0 {
+		return nil
+	}
+	return &res.Data[0]
+}
+
+// oftParty reads the wallet in the OFTSent or OFTReceived event carrying
+// guid in another chain's tx, when that chain has an EVM RPC.
+func (d stargateDecoder) oftParty(ctx context.Context, chain config.Chain, hash, topic, guid string) string {
+	rpcURL := d.cfg.EVMRPC[chain]
+	if hash == "" || rpcURL == "" {
+		return ""
+	}
+	rc, err := d.getReceipt(ctx, rpcURL, hash)
+	if err != nil {
+		return ""
+	}
+	for _, l := range rc.Logs {
+		if len(l.Topics) >= 3 && l.Topics[0] == topic && strings.EqualFold(l.Topics[1], guid) {
+			return bridgeAddress(chain, topicBytes(l.Topics[2]))
+		}
+	}
+	return ""
+}
+
+// oftValue converts an amount a Stargate pool or OFT sent or received.
+// token() names the underlying token: zero for native pools, the OFT itself
+// for OFT tokens.
+func (d stargateDecoder) oftValue(ctx context.Context, chain config.Chain, emitter string, amount *big.Int) (string, float64) {
+	token := strings.ToLower(emitter)
+	raw, err := d.rpcCall(ctx, d.cfg.EVMRPC[chain], "eth_call", []interface{}{
+		map[string]string{"to": emitter, "data": "0xfc0c546a"}, // token()
+		"latest",
+	})
+	if err == nil {
+		var hexData string
+		json.Unmarshal(raw, &hexData)
+		if b := topicBytes(hexData); len(b) == 32 {
+			token = bridgeAddress(chain, b)
+			if token == "" {
+				token = evmNative
+			}
+		}
+	}
+	symbol, v, _ := d.bridgeTokenValue(ctx, chain, token, amount)
+	return symbol, v
+}
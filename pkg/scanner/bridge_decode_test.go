@@ -0,0 +1,319 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// fakeAPIs answers GETs by URL without query, from canned JSON bodies.
+type fakeAPIs map[string]string
+
+func (f fakeAPIs) RoundTrip(r *http.Request) (*http.Response, error) {
+	u := *r.URL
+	u.RawQuery = ""
+	body, ok := f[u.String()]
+	status := http.StatusOK
+	if !ok {
+		status, body = http.StatusNotFound, "{}"
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+}
+
+func useAPIs(t *testing.T, s *Scanner, apis fakeAPIs) {
+	t.Helper()
+	s.client = &http.Client{Transport: apis}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// word32 right-aligns b in 32 bytes, like an address or amount in a payload.
+func word32(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+// buildVAA serializes a v1 VAA with sigs guardian signatures.
+func buildVAA(sigs int, emitterChain uint16, seq uint64, payload []byte) []byte {
+	var b bytes.Buffer
+	b.WriteByte(1)
+	binary.Write(&b, binary.BigEndian, uint32(4)) // guardian set
+	b.WriteByte(byte(sigs))
+	b.Write(make([]byte, sigs*66))
+	binary.Write(&b, binary.BigEndian, uint32(1700000000)) // timestamp
+	binary.Write(&b, binary.BigEndian, uint32(7))          // nonce
+	binary.Write(&b, binary.BigEndian, emitterChain)
+	b.Write(word32([]byte{0xee}))
+	binary.Write(&b, binary.BigEndian, seq)
+	b.WriteByte(1) // consistency level
+	b.Write(payload)
+	return b.Bytes()
+}
+
+// transferPayload is a token bridge transfer of amount (8 decimals) to to.
+func transferPayload(id byte, amount int64, to []byte, toChain uint16) []byte {
+	var b bytes.Buffer
+	b.WriteByte(id)
+	b.Write(word32(big.NewInt(amount).Bytes()))
+	b.Write(word32([]byte{0xaa})) // token
+	binary.Write(&b, binary.BigEndian, uint16(2))
+	b.Write(word32(to))
+	binary.Write(&b, binary.BigEndian, toChain)
+	b.Write(make([]byte, 32)) // fee
+	return b.Bytes()
+}
+
+func TestParseVAA(t *testing.T) {
+	recipient := bytes.Repeat([]byte{0x11}, 20)
+	tests := []struct {
+		name      string
+		raw       []byte
+		wantErr   bool
+		wantChain uint16
+		wantSeq   uint64
+		transfer  *wormholeTransfer
+	}{
+		{name: "not a VAA", raw: []byte{2, 0, 0, 0, 0, 0}, wantErr: true},
+		{name: "short", raw: buildVAA(13, 2, 1, nil)[:6+13*66+20], wantErr: true},
+		{
+			name: "plain transfer", raw: buildVAA(13, 2, 42, transferPayload(1, 150_000_000, recipient, 30)),
+			wantChain: 2, wantSeq: 42,
+			transfer: &wormholeTransfer{PayloadID: 1, Amount: big.NewInt(150_000_000), TokenChain: 2, ToChain: 30},
+		},
+		{
+			name: "transfer with payload", raw: buildVAA(19, 1, 9, transferPayload(3, 5, recipient, 2)),
+			wantChain: 1, wantSeq: 9,
+			transfer: &wormholeTransfer{PayloadID: 3, Amount: big.NewInt(5), TokenChain: 2, ToChain: 2},
+		},
+		{name: "attestation", raw: buildVAA(1, 2, 3, append([]byte{2}, make([]byte, 120)...)), wantChain: 2, wantSeq: 3},
+		{name: "no guardians, empty payload", raw: buildVAA(0, 6, 0, nil), wantChain: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseVAA(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.EmitterChain != tt.wantChain || v.Sequence != tt.wantSeq {
+				t.Errorf("emitter chain %d seq %d, want %d %d", v.EmitterChain, v.Sequence, tt.wantChain, tt.wantSeq)
+			}
+			got := v.tokenTransfer()
+			if tt.transfer == nil {
+				if got != nil {
+					t.Errorf("tokenTransfer = %+v, want none", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("no token transfer")
+			}
+			if got.PayloadID != tt.transfer.PayloadID || got.Amount.Cmp(tt.transfer.Amount) != 0 ||
+				got.TokenChain != tt.transfer.TokenChain || got.ToChain != tt.transfer.ToChain {
+				t.Errorf("tokenTransfer = %+v, want %+v", got, tt.transfer)
+			}
+			if !bytes.Equal(got.To, word32(recipient)) {
+				t.Errorf("to = %x", got.To)
+			}
+		})
+	}
+}
+
+func TestWormholeDecode(t *testing.T) {
+	evmRecipient := bytes.Repeat([]byte{0x22}, 20)
+	op := func(raw []byte, toAddress string) string {
+		b, _ := json.Marshal(map[string]interface{}{"operations": []interface{}{map[string]interface{}{
+			"vaa": map[string]interface{}{"raw": base64.StdEncoding.EncodeToString(raw)},
+			"content": map[string]interface{}{"standarizedProperties": map[string]interface{}{
+				"fromChain": 1, "toChain": 30, "toAddress": toAddress,
+			}},
+			"sourceChain": map[string]interface{}{"from": "SoLSender111", "transaction": map[string]string{"txHash": "srcsig"}},
+			"targetChain": map[string]interface{}{"transaction": map[string]string{"txHash": "0xdest"}},
+		}}})
+		return string(b)
+	}
+	relayer := "0x" + strings.Repeat("0", 24) + strings.Repeat("ab", 20)
+	tests := []struct {
+		name          string
+		body          string
+		wantNil       bool
+		wantErr       bool
+		wantRecipient string
+		wantAmount    float64
+	}{
+		{name: "no operation", body: `{"operations":[]}`, wantNil: true},
+		{
+			name:          "plain transfer pays the VAA recipient",
+			body:          op(buildVAA(13, 1, 5, transferPayload(1, 250_000_000, evmRecipient, 30)), relayer),
+			wantRecipient: "0x" + strings.Repeat("22", 20), wantAmount: 2.5,
+		},
+		{
+			name:          "payload transfer keeps the resolved recipient",
+			body:          op(buildVAA(13, 1, 6, transferPayload(3, 100_000_000, evmRecipient, 30)), relayer),
+			wantRecipient: "0x" + strings.Repeat("ab", 20), wantAmount: 1,
+		},
+		{
+			name:    "unregistered chain",
+			body:    op(buildVAA(13, 1, 7, transferPayload(1, 1, evmRecipient, 9999)), relayer),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestScanner(t)
+			useAPIs(t, s, fakeAPIs{wormholescanAPI + "/operations": tt.body})
+			bt, err := wormholeDecoder{s}.Decode(context.Background(), &bridgeTx{Chain: config.ChainSolana, Hash: "srcsig"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want an error, got %+v", bt)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if bt != nil {
+					t.Fatalf("got %+v, want nil", bt)
+				}
+				return
+			}
+			if bt.SourceChain != config.ChainSolana || bt.DestChain != config.ChainBase {
+				t.Errorf("route %s -> %s, want solana -> base", bt.SourceChain, bt.DestChain)
+			}
+			if bt.Recipient != tt.wantRecipient || !near(bt.Amount, tt.wantAmount) {
+				t.Errorf("recipient %s amount %v, want %s %v", bt.Recipient, bt.Amount, tt.wantRecipient, tt.wantAmount)
+			}
+			if bt.Sender != "SoLSender111" || bt.SourceTx != "srcsig" || bt.DestTx != "0xdest" {
+				t.Errorf("sender %s txs %s -> %s", bt.Sender, bt.SourceTx, bt.DestTx)
+			}
+		})
+	}
+}
+
+func TestDebridgeDecode(t *testing.T) {
+	solWallet := bytes.Repeat([]byte{0x07}, 32)
+	order := func(giveChain, takeChain, receiver string) string {
+		return `{"giveOfferWithMetadata":{"chainId":{"stringValue":"` + giveChain + `"},"amount":{"stringValue":"1000000000000000000"},"metadata":{"decimals":18,"symbol":"ETH"}},
+			"takeOfferWithMetadata":{"chainId":{"stringValue":"` + takeChain + `"},"amount":{"stringValue":"2500000000"},"metadata":{"decimals":6,"symbol":"USDC"}},
+			"makerSrc":{"stringValue":"0xAbCdEf0000000000000000000000000000000001"},
+			"receiverDst":{"stringValue":"` + receiver + `"},
+			"createEventTransactionHash":{"stringValue":"0xcreate"},
+			"fulfilledDstEventMetadata":{"transactionHash":{"stringValue":"fillsig"}}}`
+	}
+	const hash = "0xcreate"
+	orderIDs := dlnStatsAPI + "/Transaction/" + hash + "/orderIds"
+	tests := []struct {
+		name          string
+		apis          fakeAPIs
+		wantNil       bool
+		wantErr       bool
+		wantDest      config.Chain
+		wantRecipient string
+	}{
+		{name: "no order", apis: fakeAPIs{orderIDs: `{"orderIds":[]}`}, wantNil: true},
+		{
+			name: "ethereum to solana",
+			apis: fakeAPIs{
+				orderIDs:                        `{"orderIds":[{"stringValue":"0xorder"}]}`,
+				dlnStatsAPI + "/Orders/0xorder": order("1", "7565164", "0x"+strings.Repeat("07", 32)),
+			},
+			wantDest: config.ChainSolana, wantRecipient: base58Encode(solWallet),
+		},
+		{
+			name: "ethereum to base",
+			apis: fakeAPIs{
+				orderIDs:                        `{"orderIds":[{"stringValue":"0xorder"}]}`,
+				dlnStatsAPI + "/Orders/0xorder": order("1", "8453", "0x00000000000000000000000000000000000000AB"),
+			},
+			wantDest: config.ChainBase, wantRecipient: "0x00000000000000000000000000000000000000ab",
+		},
+		{
+			name: "unknown chain",
+			apis: fakeAPIs{
+				orderIDs:                        `{"orderIds":[{"stringValue":"0xorder"}]}`,
+				dlnStatsAPI + "/Orders/0xorder": order("1", "424242", "0x01"),
+			},
+			wantErr: true,
+		},
+		{name: "API down", apis: fakeAPIs{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestScanner(t)
+			useAPIs(t, s, tt.apis)
+			bt, err := debridgeDecoder{s}.Decode(context.Background(), &bridgeTx{Chain: config.ChainEthereum, Hash: hash})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want an error, got %+v", bt)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if bt != nil {
+					t.Fatalf("got %+v, want nil", bt)
+				}
+				return
+			}
+			if bt.SourceChain != config.ChainEthereum || bt.DestChain != tt.wantDest {
+				t.Errorf("route %s -> %s, want ethereum -> %s", bt.SourceChain, bt.DestChain, tt.wantDest)
+			}
+			if bt.Recipient != tt.wantRecipient {
+				t.Errorf("recipient = %s, want %s", bt.Recipient, tt.wantRecipient)
+			}
+			if bt.Sender != "0xabcdef0000000000000000000000000000000001" || bt.SourceTx != "0xcreate" || bt.DestTx != "fillsig" {
+				t.Errorf("sender %s txs %s -> %s", bt.Sender, bt.SourceTx, bt.DestTx)
+			}
+			if bt.Token != "USDC" || !near(bt.Amount, 2500) {
+				t.Errorf("took %v %s, want 2500 USDC", bt.Amount, bt.Token)
+			}
+		})
+	}
+}
+
+func TestBridgeRegistry(t *testing.T) {
+	tr := &DeepFundingTracer{}
+	tests := []struct {
+		chain       config.Chain
+		address     string
+		wantDecoded string
+		wantName    string
+	}{
+		{config.ChainEthereum, "0x98F3C9E6E3FACE36BAAD05FE09D375EF1464288B", "wormhole", "Wormhole"},
+		{config.ChainBSC, "0x98f3c9e6e3face36baad05fe09d375ef1464288b", "wormhole", "Wormhole"},
+		{config.ChainBase, "0x98f3c9e6e3face36baad05fe09d375ef1464288b", "", "Bridge (base)"},
+		{config.ChainSolana, "dst5MGcFPoBeREFAA5E3tU5ij8m5uVYwkzkSAbsLbNo", "debridge", "deBridge"},
+		{config.ChainSolana, "DST5MGCFPOBEREFAA5E3TU5IJ8M5UVYWKZKSABSLBNO", "", "Bridge (solana)"},
+		{config.ChainArbitrum, "0xC38e4e6A15593f908255214653d3D947CA1c2338", "mayan", "Mayan"},
+		{config.ChainEthereum, "0x49048044d57e1c92a77f79988d21fa8faf74e97e", "", "Base Bridge"},
+		{config.ChainOptimism, "0x4200000000000000000000000000000000000010", "", "Optimism Bridge"},
+		{config.ChainArbitrum, "0x5288c571fd7ad117bea99bf60fe0846c4e84f933", "", "Arbitrum Bridge"},
+	}
+	for _, tt := range tests {
+		if got := decodedBridge(tt.chain, tt.address); got != tt.wantDecoded {
+			t.Errorf("decodedBridge(%s, %s) = %q, want %q", tt.chain, tt.address, got, tt.wantDecoded)
+		}
+		if got := tr.identifyBridge(tt.address, tt.chain); got != tt.wantName {
+			t.Errorf("identifyBridge(%s, %s) = %q, want %q", tt.address, tt.chain, got, tt.wantName)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// ── Scan cursors ────────────────────────────────────────────
+// Each fetch stream of a wallet ("helius:SWAP", "solana_rpc",
+// "etherscan:txlist", "rpc:logs", ...) keeps a cursor in scan_cursors so a
+// pass only downloads activity newer than the last one. Cursors advance only
+// after the fetched transactions are stored. BackfillWallet walks the full
+// history separately, under "<stream>:backfill" cursors.
+
+const (
+	// incrementalPages bounds one pass per stream. Etherscan streams catch up
+	// over later passes; newest-first streams (Helius, Solana RPC, Esplora)
+	// read the rest through a gap cursor, see scanNewestFirst.
+	incrementalPages  = 10
+	heliusPageSize    = 100
+	etherscanPageSize = 1000
+)
+
+// heliusPage fetches one page of parsed transactions, newest first, strictly
+// between until and before (either may be empty).
+func (s *Scanner) heliusPage(ctx context.Context, address, txType, before, until string) ([]json.RawMessage, error) {
+	q := url.Values{"api-key": {s.cfg.HeliusAPIKey}, "type": {txType}, "limit": {strconv.Itoa(heliusPageSize)}}
+	if before != "" {
+		q.Set("before", before)
+	}
+	if until != "" {
+		q.Set("until", until)
+	}
+	body, err := s.getJSON(ctx, endpoint(heliusAPI, []string{"addresses", address, "transactions"}, q))
+	if err != nil {
+		return nil, err
+	}
+	var txs []json.RawMessage
+	if err := json.Unmarshal(body, &txs); err != nil {
+		return nil, fmt.Errorf("helius response: %w", err)
+	}
+	return txs, nil
+}
+
+func signatureOf(raw json.RawMessage) string {
+	var p struct {
+		Signature string `json:"signature"`
+	}
+	json.Unmarshal(raw, &p)
+	return p.Signature
+}
+
+// newestFirstPage reads and stores one page of a stream the API lists newest
+// first, strictly between until and before (either may be empty), and
+// returns the ids it read, newest first.
+type newestFirstPage func(before, until string) ([]string, error)
+
+// walkNewestFirst pages back from before until a short page (the stream got
+// down to until, or to its first item) or the page cap. It returns the newest
+// and oldest ids read and whether it got all the way down.
+func walkNewestFirst(page newestFirstPage, size, pages int, before, until string) (newest, oldest string, done bool, err error) {
+	oldest = before
+	for i := 0; i < pages; i++ {
+		ids, err := page(oldest, until)
+		if err != nil {
+			return newest, oldest, false, err
+		}
+		if len(ids) > 0 {
+			if newest == "" {
+				newest = ids[0]
+			}
+			oldest = ids[len(ids)-1]
+		}
+		if len(ids) < size {
+			return newest, oldest, true, nil
+		}
+	}
+	return newest, oldest, false, nil
+}
+
+// scanNewestFirst is one incremental pass over a newest-first stream (Helius,
+// Solana RPC, Esplora). These can only be paged backwards from the newest
+// item, so a pass that hits incrementalPages before reaching the cursor
+// leaves a stretch unread. It is recorded under "<stream>:gap" as
+// "before:until" and read down to until on the following passes; new
+// activity waits until the gap is closed. Without a cursor only the latest
+// page is read and older history is left to backfill.
+func (s *Scanner) scanNewestFirst(walletID int64, address, stream string, size int, page newestFirstPage) error {
+	gapStream := stream + ":gap"
+	gap, err := s.store.GetScanCursor(walletID, gapStream)
+	if err != nil {
+		return err
+	}
+	if before, until, ok := strings.Cut(gap, ":"); ok {
+		_, oldest, done, err := walkNewestFirst(page, size, incrementalPages, before, until)
+		switch {
+		case done:
+			s.store.SetScanCursor(walletID, gapStream, "")
+		case oldest != before:
+			s.store.SetScanCursor(walletID, gapStream, oldest+":"+until)
+		}
+		if err != nil || !done {
+			return err
+		}
+	}
+
+	cursor, err := s.store.GetScanCursor(walletID, stream)
+	if err != nil {
+		return err
+	}
+	pages := incrementalPages
+	if cursor == "" {
+		pages = 1
+	}
+	newest, oldest, done, err := walkNewestFirst(page, size, pages, "", cursor)
+	if newest == "" {
+		return err
+	}
+	if !done && cursor != "" {
+		// the gap goes in before the cursor moves past it
+		s.store.SetScanCursor(walletID, gapStream, oldest+":"+cursor)
+		log.Info().Str("addr", abbrev(address)).Str("stream", stream).
+			Msg("more new transactions than one pass fetches; reading the rest over the next passes")
+	}
+	s.store.SetScanCursor(walletID, stream, newest)
+	return err
+}
+
+type solSignature struct {
+	Signature string      `json:"signature"`
+	Slot      int64       `json:"slot"`
+	BlockTime *int64      `json:"blockTime"`
+	Err       interface{} `json:"err"`
+}
+
+// signaturesPage calls getSignaturesForAddress, newest first.
+func (s *Scanner) signaturesPage(ctx context.Context, rpcURL, address, before, until string, limit int) ([]solSignature, error) {
+	opts := map[string]interface{}{"limit": limit}
+	if before != "" {
+		opts["before"] = before
+	}
+	if until != "" {
+		opts["until"] = until
+	}
+	result, err := s.rpcCall(ctx, rpcURL, "getSignaturesForAddress", []interface{}{address, opts})
+	if err != nil {
+		return nil, fmt.Errorf("getSignaturesForAddress: %w", err)
+	}
+	var sigs []solSignature
+	if err := json.Unmarshal(result, &sigs); err != nil {
+		return nil, fmt.Errorf("getSignaturesForAddress: %w", err)
+	}
+	return sigs, nil
+}
+
+// etherscanPage fetches one page of an account list action. Unlike
+// etherscanList, an empty history is not an error.
+func (s *Scanner) etherscanPage(ctx context.Context, apiURL, apiKey, address, action string, startBlock int64, offset int, sort string) ([]etherscanResult, error) {
+	body, err := s.getJSON(ctx, endpoint(apiURL, nil, url.Values{
+		"module": {"account"}, "action": {action}, "address": {address},
+		"startblock": {strconv.FormatInt(startBlock, 10)}, "endblock": {"99999999"},
+		"page": {"1"}, "offset": {strconv.Itoa(offset)}, "sort": {sort}, "apikey": {apiKey},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("etherscan response: %w", err)
+	}
+	if result.Status != "1" {
+		if result.Message == "No transactions found" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("etherscan %s: %s %s", action, result.Message, string(result.Result))
+	}
+	var rows []etherscanResult
+	if err := json.Unmarshal(result.Result, &rows); err != nil {
+		return nil, fmt.Errorf("etherscan %s: %w", action, err)
+	}
+	return rows, nil
+}
+
+// etherscanWalk pages through an action in ascending block order from start,
+// calling fn with each page and the highest block in it. The last block of a
+// page is re-read by the next one since its remaining txs may spill over;
+// duplicates are ignored on insert. Reports whether the end was reached.
+func (s *Scanner) etherscanWalk(ctx context.Context, apiURL, apiKey, address, action string, start int64, maxPages int,
+	fn func(page []etherscanResult, last int64)) (bool, error) {
+	for i := 0; maxPages <= 0 || i < maxPages; i++ {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		page, err := s.etherscanPage(ctx, apiURL, apiKey, address, action, start, etherscanPageSize, "asc")
+		if err != nil {
+			return false, err
+		}
+		last := start
+		if len(page) > 0 {
+			last = parseInt64(str(page[len(page)-1], "blockNumber"))
+		}
+		fn(page, last)
+		if len(page) < etherscanPageSize {
+			return true, nil
+		}
+		if last <= start {
+			last = start + 1 // one block filled a whole page; don't spin on it
+		}
+		start = last
+	}
+	return false, nil
+}
+
+// etherscanSince returns an action's results from the cursor block on, and
+// the next cursor. Without a cursor only the latest page is fetched.
+func (s *Scanner) etherscanSince(ctx context.Context, apiURL, apiKey, address, action, cursor string) ([]etherscanResult, string, error) {
+	if cursor == "" {
+		res, err := s.etherscanPage(ctx, apiURL, apiKey, address, action, 0, 100, "desc")
+		if err != nil || len(res) == 0 {
+			return nil, "", err
+		}
+		return res, str(res[0], "blockNumber"), nil
+	}
+	var all []etherscanResult
+	next := parseInt64(cursor)
+	_, err := s.etherscanWalk(ctx, apiURL, apiKey, address, action, next, incrementalPages, func(page []etherscanResult, last int64) {
+		all = append(all, page...)
+		next = last
+	})
+	// pages are in block order, so whatever arrived before an error is complete
+	return all, strconv.FormatInt(next, 10), err
+}
+
+// BackfillWallet walks a wallet's full history and stores it, independent of
+// the incremental cursors. Progress is saved after every page so an
+// interrupted backfill resumes where it stopped. maxPages bounds each stream
+// per call (0 = no limit). Reports whether every stream reached the end.
+//
+// The first provider in priority order that supports the chain and can walk
+// history does the backfill. There is no fallback: its progress lives in its
+// own cursors, so a failed backfill is simply re-run.
+func (s *Scanner) BackfillWallet(ctx context.Context, walletID int64, address string, chain config.Chain, maxPages int) (int, bool, error) {
+	for _, p := range s.providers {
+		if b, ok := p.(historyBackfiller); ok && p.Supports(chain) {
+			return b.Backfill(ctx, walletID, address, chain, maxPages)
+		}
+	}
+	return 0, false, fmt.Errorf("backfill on %s: %w", chain, errNoProvider)
+}
+
+func (h *heliusProvider) Backfill(ctx context.Context, walletID int64, address string, chain config.Chain, maxPages int) (int, bool, error) {
+	count, done := 0, true
+	for _, txType := range []string{"SWAP", "TRANSFER"} {
+		stream := "helius:" + txType + ":backfill"
+		before, err := h.store.GetScanCursor(walletID, stream)
+		if err != nil {
+			return count, false, err
+		}
+		complete := false
+		for i := 0; maxPages <= 0 || i < maxPages; i++ {
+			if ctx.Err() != nil {
+				return count, false, ctx.Err()
+			}
+			page, err := h.heliusPage(ctx, address, txType, before, "")
+			if err != nil {
+				return count, false, fmt.Errorf("helius %s: %w", txType, err)
+			}
+			for _, raw := range page {
+				if h.storeHeliusTx(ctx, walletID, address, txType, raw) {
+					count++
+				}
+			}
+			if len(page) == 0 {
+				complete = true
+				break
+			}
+			before = signatureOf(page[len(page)-1])
+			if err := h.store.SetScanCursor(walletID, stream, before); err != nil {
+				return count, false, err
+			}
+			if len(page) < heliusPageSize {
+				complete = true
+				break
+			}
+		}
+		done = done && complete
+	}
+	return count, done, nil
+}
+
+func (r *solanaRPCProvider) Backfill(ctx context.Context, walletID int64, address string, chain config.Chain, maxPages int) (int, bool, error) {
+	const limit = 1000
+	rpcURL := r.cfg.SolanaRPCURL
+	if rpcURL == "" {
+		return 0, false, fmt.Errorf("no Solana RPC configured")
+	}
+	stream := "solana_rpc:backfill"
+	before, err := r.store.GetScanCursor(walletID, stream)
+	if err != nil {
+		return 0, false, err
+	}
+	count := 0
+	for i := 0; maxPages <= 0 || i < maxPages; i++ {
+		page, err := r.signaturesPage(ctx, rpcURL, address, before, "", limit)
+		if err != nil {
+			return count, false, err
+		}
+		for _, sig := range page {
+			if ctx.Err() != nil {
+				return count, false, ctx.Err()
+			}
+			if sig.Err == nil && r.storeSolanaRPCTx(ctx, rpcURL, walletID, address, sig.Signature) {
+				count++
+			}
+		}
+		if len(page) == 0 {
+			return count, true, nil
+		}
+		before = page[len(page)-1].Signature
+		if err := r.store.SetScanCursor(walletID, stream, before); err != nil {
+			return count, false, err
+		}
+		if len(page) < limit {
+			return count, true, nil
+		}
+	}
+	return count, false, nil
+}
+
+func (e *etherscanProvider) Backfill(ctx context.Context, walletID int64, address string, chain config.Chain, maxPages int) (int, bool, error) {
+	apiURL := e.cfg.GetExplorerURL(chain)
+	apiKey := e.cfg.GetExplorerKey(chain)
+	if apiURL == "" || apiKey == "" {
+		return 0, false, fmt.Errorf("backfill on %s needs an explorer API key", chain)
+	}
+	native := nativeSymbol(chain)
+	count, done := 0, true
+	pass := e.newSwapPass(walletID, address, chain, map[string]tokenInfo{})
+	for _, action := range []string{"txlist", "tokentx", "txlistinternal"} {
+		stream := "etherscan:" + action + ":backfill"
+		cur, err := e.store.GetScanCursor(walletID, stream)
+		if err != nil {
+			return count, false, err
+		}
+		var saveErr error
+		complete, err := e.etherscanWalk(ctx, apiURL, apiKey, address, action, parseInt64(cur), maxPages, func(page []etherscanResult, last int64) {
+			for _, etx := range page {
+				if swap, stored := pass.storeRow(ctx, action, etx); swap {
+					if stored {
+						count++
+					}
+					continue
+				}
+				if e.storeEtherscanTx(ctx, action, walletID, address, chain, etx, native) {
+					count++
+				}
+			}
+			if len(page) > 0 && saveErr == nil {
+				saveErr = e.store.SetScanCursor(walletID, stream, strconv.FormatInt(last, 10))
+			}
+		})
+		if err == nil {
+			err = saveErr
+		}
+		if err != nil {
+			return count, false, fmt.Errorf("%s: %w", action, err)
+		}
+		done = done && complete
+	}
+	return count, done, nil
+}
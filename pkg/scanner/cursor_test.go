@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeStream is a newest-first history: ids[0] is the oldest item.
+type fakeStream struct {
+	ids  []string
+	seen map[string]bool
+}
+
+func (f *fakeStream) add(n int) {
+	for i := 0; i < n; i++ {
+		f.ids = append(f.ids, fmt.Sprintf("sig%05d", len(f.ids)))
+	}
+}
+
+// page serves up to size ids strictly between until and before, newest first.
+func (f *fakeStream) page(size int) newestFirstPage {
+	return func(before, until string) ([]string, error) {
+		var out []string
+		inRange := before == ""
+		for i := len(f.ids) - 1; i >= 0 && len(out) < size; i-- {
+			id := f.ids[i]
+			if id == until {
+				break
+			}
+			if inRange {
+				out = append(out, id)
+				f.seen[id] = true
+			}
+			if id == before {
+				inRange = true
+			}
+		}
+		return out, nil
+	}
+}
+
+func TestScanNewestFirstClosesGap(t *testing.T) {
+	const size = 100
+	s, store := newTestScanner(t)
+	f := &fakeStream{seen: map[string]bool{}}
+	pass := func() {
+		t.Helper()
+		if err := s.scanNewestFirst(1, "wallet", "fake", size, f.page(size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cursors := func() (string, string) {
+		cur, _ := store.GetScanCursor(1, "fake")
+		gap, _ := store.GetScanCursor(1, "fake:gap")
+		return cur, gap
+	}
+
+	f.add(150)
+	pass() // first scan: latest page only
+	if cur, gap := cursors(); cur != "sig00149" || gap != "" {
+		t.Fatalf("after first pass cursor=%q gap=%q", cur, gap)
+	}
+
+	f.add(2500) // sig00150..sig02649, more than one pass reads
+	pass()
+	if cur, gap := cursors(); cur != "sig02649" || gap != "sig01650:sig00149" {
+		t.Fatalf("after capped pass cursor=%q gap=%q", cur, gap)
+	}
+
+	f.add(10) // new activity waits for the gap
+	pass()
+	if cur, gap := cursors(); cur != "sig02649" || gap != "sig00650:sig00149" {
+		t.Fatalf("while draining cursor=%q gap=%q", cur, gap)
+	}
+
+	pass() // closes the gap, then reads what is new
+	if cur, gap := cursors(); cur != "sig02659" || gap != "" {
+		t.Fatalf("after draining cursor=%q gap=%q", cur, gap)
+	}
+	for _, id := range f.ids[50:] {
+		if !f.seen[id] {
+			t.Fatalf("%s never read", id)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -15,15 +16,19 @@ import (
 
 // DeepFundingTracer performs multi-hop tracing of funds through privacy services.
 // It follows: KOL wallet → FixedFloat/Bridge/Mixer → Fresh wallet
-// Also traces cross-chain flows where funds leave on one chain and arrive on another.
+// Also traces cross-chain flows where funds leave on one chain and arrive on another,
+// decoding the bridge message to find the wallet on the other side.
 type DeepFundingTracer struct {
 	scanner *Scanner
-	store   *db.Store
+	store   db.Store
 	cfg     *config.Config
+
+	mu       sync.Mutex
+	followed map[string]bool // bridge deposits FollowBridgeTransfers has decoded
 }
 
-func NewDeepFundingTracer(sc *Scanner, store *db.Store, cfg *config.Config) *DeepFundingTracer {
-	return &DeepFundingTracer{scanner: sc, store: store, cfg: cfg}
+func NewDeepFundingTracer(sc *Scanner, store db.Store, cfg *config.Config) *DeepFundingTracer {
+	return &DeepFundingTracer{scanner: sc, store: store, cfg: cfg, followed: map[string]bool{}}
 }
 
 // TraceWalletFunding performs deep analysis of how a wallet was funded.
@@ -32,17 +37,43 @@ func (t *DeepFundingTracer) TraceWalletFunding(ctx context.Context, address stri
 	trace := &FundingTrace{
 		Address: address,
 		Chain:   chain,
-		Hops:    []FundingHop{},
+		Hops:    []db.FundingHop{},
 	}
 
-	visited := map[string]bool{address: true}
+	visited := map[string]bool{string(chain) + ":" + address: true}
 	return t.traceRecursive(ctx, trace, address, chain, 0, maxDepth, visited)
 }
 
+// TraceAndSave traces a wallet like TraceWalletFunding and stores the result:
+// the hops replace the wallet's earlier trace, and a wash candidate at the
+// address gets the trace's suspicion level and origin type. A trace cut short
+// by ctx is not stored.
+func (t *DeepFundingTracer) TraceAndSave(ctx context.Context, address string, chain config.Chain, maxDepth int) (*FundingTrace, error) {
+	trace, err := t.TraceWalletFunding(ctx, address, chain, maxDepth)
+	if err != nil {
+		return trace, err
+	}
+	if ctx.Err() != nil {
+		return trace, ctx.Err()
+	}
+	if err := t.store.ReplaceFundingHops(address, chain, trace.Hops); err != nil {
+		return trace, fmt.Errorf("store funding hops: %w", err)
+	}
+	if err := t.store.SetWashTrace(address, chain, trace.SuspicionLevel, trace.OriginType); err != nil {
+		return trace, fmt.Errorf("store trace verdict: %w", err)
+	}
+	if trace.SuspicionLevel != "clean" {
+		log.Info().Str("wallet", abbrev(address)).Str("chain", string(chain)).
+			Str("suspicion", trace.SuspicionLevel).Str("origin", trace.OriginType).
+			Int("hops", len(trace.Hops)).Msg("🧭 funding traced")
+	}
+	return trace, nil
+}
+
 type FundingTrace struct {
 	Address          string       `json:"address"`
 	Chain            config.Chain `json:"chain"`
-	Hops             []FundingHop `json:"hops"`
+	Hops             []db.FundingHop `json:"hops"`
 	OriginType       string       `json:"origin_type"`      // "fixedfloat","bridge","mixer","cex","wallet","unknown"
 	OriginAddress    string       `json:"origin_address"`
 	OriginChain      config.Chain `json:"origin_chain"`
@@ -51,19 +82,6 @@ type FundingTrace struct {
 	SuspicionLevel   string       `json:"suspicion_level"`   // "clean","low","medium","high","critical"
 }
 
-type FundingHop struct {
-	FromAddress string       `json:"from_address"`
-	ToAddress   string       `json:"to_address"`
-	Amount      float64      `json:"amount"`
-	Token       string       `json:"token"`
-	TxHash      string       `json:"tx_hash"`
-	Chain       config.Chain `json:"chain"`
-	HopType     string       `json:"hop_type"`  // "direct","fixedfloat","bridge","mixer","cex_withdraw","unknown"
-	ServiceName string       `json:"service_name"`
-	Timestamp   time.Time    `json:"timestamp"`
-	Depth       int          `json:"depth"`
-}
-
 func (t *DeepFundingTracer) traceRecursive(ctx context.Context, trace *FundingTrace, address string, chain config.Chain, depth, maxDepth int, visited map[string]bool) (*FundingTrace, error) {
 	if depth >= maxDepth || ctx.Err() != nil {
 		return trace, nil
@@ -79,7 +97,7 @@ func (t *DeepFundingTracer) traceRecursive(ctx context.Context, trace *FundingTr
 	}
 
 	for _, src := range funding.FundingSources {
-		hop := FundingHop{
+		hop := db.FundingHop{
 			FromAddress: src.SourceAddress,
 			ToAddress:   address,
 			Amount:      src.Amount,
@@ -108,9 +126,26 @@ func (t *DeepFundingTracer) traceRecursive(ctx context.Context, trace *FundingTr
 			trace.SuspicionLevel = maxSuspicion(trace.SuspicionLevel, "high")
 		}
 
+		// A bridge pays out from a pool or a relayer, which also shows up as
+		// an unknown source; the bridge message names who actually paid.
+		var bt *BridgeTransfer
+		if src.SourceType == "bridge" || src.SourceType == "unknown" {
+			bt = t.decodeArrival(ctx, src.TxHash, chain)
+		}
+		if bt != nil {
+			hop.HopType = "bridge"
+			hop.ServiceName = bridgeNames[bt.Protocol]
+			trace.SuspicionLevel = maxSuspicion(trace.SuspicionLevel, "medium")
+		}
+
 		trace.Hops = append(trace.Hops, hop)
 		trace.TotalAmount += src.Amount
 
+		if bt != nil {
+			t.followBridge(ctx, trace, bt, address, depth, maxDepth, visited)
+			continue
+		}
+
 		// If source is identifiable service, that's our origin
 		if src.SourceType != "unknown" && src.SourceType != "" {
 			trace.OriginType = src.SourceType
@@ -119,8 +154,9 @@ func (t *DeepFundingTracer) traceRecursive(ctx context.Context, trace *FundingTr
 		}
 
 		// Recursively trace the source (only for unknown/direct transfers)
-		if src.SourceType == "unknown" && !visited[src.SourceAddress] {
-			visited[src.SourceAddress] = true
+		key := string(chain) + ":" + src.SourceAddress
+		if src.SourceType == "unknown" && !visited[key] {
+			visited[key] = true
 			t.traceRecursive(ctx, trace, src.SourceAddress, chain, depth+1, maxDepth, visited)
 		}
 	}
@@ -128,11 +164,11 @@ func (t *DeepFundingTracer) traceRecursive(ctx context.Context, trace *FundingTr
 	// Also check cross-chain: look for bridge transfers arriving on OTHER chains
 	if depth == 0 {
 		for _, otherChain := range config.AllChains() {
-			if otherChain == chain {
+			if otherChain == chain || !otherChain.IsEVM() {
 				continue
 			}
 			// Check if there's a wallet with matching address pattern on other chain
-			t.checkCrossChainFunding(ctx, trace, address, chain, otherChain)
+			t.checkCrossChainFunding(ctx, trace, address, chain, otherChain, maxDepth, visited)
 		}
 	}
 
@@ -143,7 +179,7 @@ func (t *DeepFundingTracer) traceRecursive(ctx context.Context, trace *FundingTr
 	return trace, nil
 }
 
-func (t *DeepFundingTracer) checkCrossChainFunding(ctx context.Context, trace *FundingTrace, address string, sourceChain, destChain config.Chain) {
+func (t *DeepFundingTracer) checkCrossChainFunding(ctx context.Context, trace *FundingTrace, address string, sourceChain, destChain config.Chain, maxDepth int, visited map[string]bool) {
 	// For EVM chains, the same address might exist on multiple chains
 	if strings.HasPrefix(address, "0x") {
 		otherFunding, err := t.scanner.CheckFunding(ctx, address, destChain)
@@ -153,7 +189,7 @@ func (t *DeepFundingTracer) checkCrossChainFunding(ctx context.Context, trace *F
 		for _, src := range otherFunding.FundingSources {
 			if src.SourceType == "bridge" {
 				trace.CrossChainFlow = true
-				trace.Hops = append(trace.Hops, FundingHop{
+				hop := db.FundingHop{
 					FromAddress: src.SourceAddress,
 					ToAddress:   address,
 					Amount:      src.Amount,
@@ -164,22 +200,155 @@ func (t *DeepFundingTracer) checkCrossChainFunding(ctx context.Context, trace *F
 					ServiceName: t.identifyBridge(src.SourceAddress, destChain),
 					Timestamp:   time.Unix(src.Timestamp, 0),
 					Depth:       0,
-				})
+				}
+				bt := t.decodeArrival(ctx, src.TxHash, destChain)
+				if bt != nil {
+					hop.ServiceName = bridgeNames[bt.Protocol]
+				}
+				trace.Hops = append(trace.Hops, hop)
 				trace.SuspicionLevel = maxSuspicion(trace.SuspicionLevel, "medium")
+				if bt != nil {
+					t.followBridge(ctx, trace, bt, address, 0, maxDepth, visited)
+				}
+			}
+		}
+	}
+}
+
+// decodeArrival decodes the bridge transfer that paid out in hash on chain.
+// It returns nil when hash isn't one, or the sender can't be resolved.
+func (t *DeepFundingTracer) decodeArrival(ctx context.Context, hash string, chain config.Chain) *BridgeTransfer {
+	if hash == "" {
+		return nil
+	}
+	bt, err := t.scanner.DecodeBridgeTx(ctx, chain, hash)
+	if err != nil {
+		log.Debug().Err(err).Str("tx", abbrev(hash)).Str("chain", string(chain)).Msg("bridge arrival not decoded")
+		return nil
+	}
+	if bt == nil || bt.DestChain != chain || bt.Sender == "" {
+		return nil
+	}
+	return bt
+}
+
+// followBridge records the source leg of a decoded bridge arrival, a hop on
+// the source chain at the same depth as the arrival, and keeps tracing from
+// the sender there.
+func (t *DeepFundingTracer) followBridge(ctx context.Context, trace *FundingTrace, bt *BridgeTransfer, address string, depth, maxDepth int, visited map[string]bool) {
+	trace.Hops = append(trace.Hops, db.FundingHop{
+		FromAddress: bt.Sender,
+		ToAddress:   address,
+		Amount:      bt.Amount,
+		Token:       bt.Token,
+		TxHash:      bt.SourceTx,
+		Chain:       bt.SourceChain,
+		HopType:     "bridge",
+		ServiceName: bridgeNames[bt.Protocol],
+		Depth:       depth,
+	})
+	trace.CrossChainFlow = true
+	trace.OriginType = "bridge"
+	trace.OriginAddress = bt.Sender
+	trace.OriginChain = bt.SourceChain
+
+	key := string(bt.SourceChain) + ":" + bt.Sender
+	if !visited[key] {
+		visited[key] = true
+		t.traceRecursive(ctx, trace, bt.Sender, bt.SourceChain, depth+1, maxDepth, visited)
+	}
+}
+
+// FollowBridgeTransfers decodes what a KOL's wallets sent into known bridges
+// and records where it went. Each recipient, often a fresh EVM address or a
+// Solana wallet, is stored as a funding match (service = the bridge) and
+// tracked as a wallet of the KOL; a recipient that wasn't tracked yet raises
+// an alert. Deposits already decoded by this tracer are skipped.
+func (t *DeepFundingTracer) FollowBridgeTransfers(ctx context.Context, kolID int64) ([]BridgeTransfer, error) {
+	wallets, err := t.store.GetWalletsForKOL(kolID)
+	if err != nil {
+		return nil, err
+	}
+	var out []BridgeTransfer
+	for _, w := range wallets {
+		txs, _ := t.store.GetTransactionsForWallet(w.ID, 300)
+		for _, tx := range txs {
+			if ctx.Err() != nil {
+				return out, ctx.Err()
+			}
+			if tx.TxType != "transfer_out" || decodedBridge(w.Chain, tx.ToAddress) == "" || t.wasFollowed(tx.TxHash) {
+				continue
+			}
+			bt, err := t.scanner.DecodeBridgeTx(ctx, w.Chain, tx.TxHash)
+			if err != nil {
+				log.Debug().Err(err).Str("tx", abbrev(tx.TxHash)).Msg("bridge deposit not decoded")
+				continue
 			}
+			t.markFollowed(tx.TxHash)
+			if bt == nil || bt.SourceChain != w.Chain || bt.Recipient == "" {
+				continue
+			}
+			out = append(out, *bt)
+
+			diffPct := 0.0
+			if strings.EqualFold(tx.TokenSymbol, bt.Token) && tx.AmountToken > 0 && bt.Amount > 0 {
+				diffPct = (tx.AmountToken - bt.Amount) / tx.AmountToken * 100
+			}
+			t.store.InsertFundingMatch(db.FundingFlowMatch{
+				SourceTx:        tx.TxHash,
+				SourceChain:     w.Chain,
+				SourceAmount:    tx.AmountToken,
+				SourceToken:     tx.TokenSymbol,
+				DestAddress:     bt.Recipient,
+				DestChain:       bt.DestChain,
+				DestAmount:      bt.Amount,
+				DestToken:       bt.Token,
+				Service:         bt.Protocol,
+				AmountDiffPct:   diffPct,
+				MatchConfidence: 1.0, // read from the bridge's own message
+			})
+
+			known, _ := t.store.GetWalletByAddress(bt.Recipient, bt.DestChain)
+			t.store.UpsertWallet(kolID, bt.Recipient, bt.DestChain, "bridge_recipient", 0.9,
+				fmt.Sprintf("bridge:%s:%s", bt.Protocol, tx.TxHash))
+			if known == nil {
+				t.store.InsertAlert(kolID, "bridge_transfer", "warning",
+					fmt.Sprintf("%s bridged %.4g %s to new wallet %s on %s", abbrev(w.Address), tx.AmountToken, tx.TokenSymbol, abbrev(bt.Recipient), bt.DestChain),
+					fmt.Sprintf("%s %s → %s, source tx %s, destination tx %s", bridgeNames[bt.Protocol], w.Chain, bt.DestChain, bt.SourceTx, bt.DestTx),
+					bt.Recipient, bt.Token)
+			}
+			log.Info().Str("kol_wallet", abbrev(w.Address)).Str("bridge", bt.Protocol).
+				Str("to", abbrev(bt.Recipient)).Str("dest_chain", string(bt.DestChain)).Msg("🌉 bridge transfer followed")
 		}
 	}
+	return out, nil
+}
+
+func (t *DeepFundingTracer) wasFollowed(hash string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.followed[hash]
+}
+
+func (t *DeepFundingTracer) markFollowed(hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.followed[hash] = true
 }
 
 // ScanForFixedFloatPatterns looks for the distinctive pattern of FixedFloat usage:
 // 1. Round-ish amount sent from a wallet
 // 2. Slightly smaller amount (minus ~1-2% fee) arrives at a fresh wallet
 // 3. Time gap of 5-30 minutes typically
+// When the two legs are different assets (USDT sent on Tron, BTC paid from
+// one output of a Bitcoin tx, SOL received) the amounts are compared in USD
+// at the time of each leg.
 func (t *DeepFundingTracer) ScanForFixedFloatPatterns(ctx context.Context, kolID int64) ([]FixedFloatMatch, error) {
 	var matches []FixedFloatMatch
 
 	wallets, _ := t.store.GetWalletsForKOL(kolID)
 	candidates, _ := t.store.GetWashCandidates(0.0)
+	candidateUSD := map[int]float64{}
 
 	for _, w := range wallets {
 		txs, _ := t.store.GetTransactionsForWallet(w.ID, 300)
@@ -190,7 +359,7 @@ func (t *DeepFundingTracer) ScanForFixedFloatPatterns(ctx context.Context, kolID
 			}
 
 			// For each outgoing transfer, look for a matching incoming on a candidate
-			for _, c := range candidates {
+			for i, c := range candidates {
 				if c.FundingAmount <= 0 {
 					continue
 				}
@@ -198,11 +367,24 @@ func (t *DeepFundingTracer) ScanForFixedFloatPatterns(ctx context.Context, kolID
 				// FixedFloat fee is typically 0.5-2.5%
 				feePctLow := 0.3
 				feePctHigh := 3.0
+				sent, received := tx.AmountToken, c.FundingAmount
+				if !strings.EqualFold(tx.TokenSymbol, c.FundingToken) {
+					usd, ok := candidateUSD[i]
+					if !ok {
+						usd = t.scanner.quoteUSDAt(ctx, c.Chain, c.FundingToken, c.FundingAmount, c.FirstSeen)
+						candidateUSD[i] = usd
+					}
+					if tx.AmountUSD <= 0 || usd <= 0 {
+						continue
+					}
+					// prices of two assets drift apart within minutes
+					sent, received, feePctHigh = tx.AmountUSD, usd, 4.0
+				}
 
-				expectedLow := tx.AmountToken * (1 - feePctHigh/100)
-				expectedHigh := tx.AmountToken * (1 - feePctLow/100)
+				expectedLow := sent * (1 - feePctHigh/100)
+				expectedHigh := sent * (1 - feePctLow/100)
 
-				if c.FundingAmount >= expectedLow && c.FundingAmount <= expectedHigh {
+				if received >= expectedLow && received <= expectedHigh {
 					// Amount matches FixedFloat fee range
 					timeDiff := int64(0)
 					if !tx.Timestamp.IsZero() && !c.FirstSeen.IsZero() {
@@ -211,8 +393,8 @@ func (t *DeepFundingTracer) ScanForFixedFloatPatterns(ctx context.Context, kolID
 
 					// FixedFloat typically takes 5-45 minutes
 					if timeDiff >= 0 && timeDiff <= 2700 { // 0-45 min
-						feeAmt := tx.AmountToken - c.FundingAmount
-						feePct := feeAmt / tx.AmountToken * 100
+						feeAmt := sent - received
+						feePct := feeAmt / sent * 100
 
 						match := FixedFloatMatch{
 							KOLWallet:      w.Address,
@@ -285,7 +467,7 @@ type FixedFloatMatch struct {
 	IncomingToken  string       `json:"incoming_token"`
 	IncomingTx     string       `json:"incoming_tx"`
 
-	FeeAmount      float64 `json:"fee_amount"`
+	FeeAmount      float64 `json:"fee_amount"` // in the outgoing token, or USD across assets
 	FeePct         float64 `json:"fee_pct"`
 	TimeDiffSec    int64   `json:"time_diff_sec"`
 	Confidence     float64 `json:"confidence"`
@@ -310,16 +492,17 @@ func calculateFFConfidence(feePct float64, timeDiff int64) float64 {
 }
 
 func (t *DeepFundingTracer) identifyBridge(address string, chain config.Chain) string {
-	addrLower := strings.ToLower(address)
-	knownBridges := map[string]string{
-		"worm2zog2kud4vfxhvjh93uuh596ayrfgq2mgjnmtth": "Wormhole",
-		"0x3ee18b2214aff97000d974cf647e7c347e8fa585":   "Wormhole",
-		"0x4200000000000000000000000000000000000010":     "Base Bridge",
-		"0x49048044d57e1c92a77f79988d21fa8faf74e97e":    "Base Bridge",
-	}
-	if name, ok := knownBridges[addrLower]; ok {
+	p := config.BridgeProtocol(chain, address)
+	if name, ok := bridgeNames[p]; ok {
 		return name
 	}
+	if rollup, ok := strings.CutPrefix(p, "native:"); ok {
+		return strings.ToUpper(rollup[:1]) + rollup[1:] + " Bridge"
+	}
+	// the OP-stack bridge predeploy has the same address on every OP chain
+	if strings.ToLower(address) == "0x4200000000000000000000000000000000000010" {
+		return fmt.Sprintf("%s Bridge", strings.ToUpper(string(chain[:1]))+string(chain[1:]))
+	}
 	return fmt.Sprintf("Bridge (%s)", string(chain))
 }
 
@@ -0,0 +1,559 @@
+package scanner
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// ── EVM swap decoding from receipts ─────────────────────────
+// A swap shows up in tokentx / eth_getLogs as two unrelated transfers (or
+// one, when ETH is on either side). Decoding the receipt's pool Swap events
+// and the router calldata turns it back into one trade with exact input and
+// output, the pool it went through and what was paid for gas.
+
+// Swap event topics
+const (
+	topicSwapV2      = "0xd78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822" // Swap(address,uint256,uint256,uint256,uint256,address)
+	topicSwapV3      = "0xc42079f94a6350d7e6235f29174924f928cc2ac818eb64fed8004e115fbcca67" // Swap(address,address,int256,int256,uint160,uint128,int24)
+	topicSwapPancake = "0x19b47279256b2a23a1665c810c8d55a1758940ee09377d4f8d26497a3577dc83" // PancakeSwap V3: ...,uint128,uint128)
+	topicSwapV4      = "0x40e9cecb9f5f1f1c5b9c97dec2917b7ee92e57ba5563708daca94dd84ad7112f" // PoolManager Swap(bytes32,address,int128,int128,uint160,uint128,int24,uint24)
+	topicWithdrawal  = "0x7fcf532c15f0a6db0bd6d0e038bea71d30d808c7d98cb3bf7268a95bf5081b65" // WETH Withdrawal(address,uint256)
+)
+
+// swapKinds names the pool type behind each Swap event, for when the router
+// isn't a known one.
+var swapKinds = map[string]string{
+	topicSwapV2:      "uniswap_v2",
+	topicSwapV3:      "uniswap_v3",
+	topicSwapPancake: "pancakeswap_v3",
+	topicSwapV4:      "uniswap_v4",
+}
+
+// evmNative stands for the chain's native coin, as 1inch and 0x encode it.
+const evmNative = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+// evmSwap is one decoded DEX trade from the wallet's point of view. It is
+// kept as the transaction's metadata.
+type evmSwap struct {
+	Platform  string  `json:"dex"`
+	Router    string  `json:"router,omitempty"`
+	Pool      string  `json:"pool,omitempty"`
+	TokenIn   string  `json:"token_in"`
+	AmountIn  float64 `json:"amount_in"`
+	TokenOut  string  `json:"token_out"`
+	AmountOut float64 `json:"amount_out"`
+
+	GasUsed           int64   `json:"gas_used"`
+	EffectiveGasPrice float64 `json:"effective_gas_price_gwei"`
+	PriorityTip       float64 `json:"priority_tip_gwei"`
+}
+
+type evmReceipt struct {
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	Status            string   `json:"status"`
+	BlockNumber       string   `json:"blockNumber"`
+	GasUsed           string   `json:"gasUsed"`
+	EffectiveGasPrice string   `json:"effectiveGasPrice"`
+	Logs              []evmLog `json:"logs"`
+}
+
+type evmTxn struct {
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Input                string `json:"input"`
+	Value                string `json:"value"`
+	GasPrice             string `json:"gasPrice"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+}
+
+// routerCall is what a router's calldata says the trade is. Either token
+// may be empty when the call doesn't name it.
+type routerCall struct {
+	tokenIn, tokenOut string
+	amountIn          *big.Int
+}
+
+// evmSwapTx decodes hash as a swap by address. It returns nil if the
+// transaction reverted, has no pool Swap event, or the wallet didn't both
+// pay and receive something in it.
+func (s *Scanner) evmSwapTx(ctx context.Context, rpcURL string, walletID int64, address string, chain config.Chain, hash string, cache map[string]tokenInfo) (*db.WalletTransaction, error) {
+	rc, err := s.getReceipt(ctx, rpcURL, hash)
+	if err != nil {
+		return nil, err
+	}
+	if rc.Status != "0x1" {
+		return nil, nil
+	}
+	var swaps []evmLog
+	for _, l := range rc.Logs {
+		if len(l.Topics) > 0 && swapKinds[l.Topics[0]] != "" {
+			swaps = append(swaps, l)
+		}
+	}
+	if len(swaps) == 0 {
+		return nil, nil
+	}
+
+	raw, err := s.rpcCall(ctx, rpcURL, "eth_getTransactionByHash", []interface{}{hash})
+	if err != nil {
+		return nil, err
+	}
+	var txn evmTxn
+	if err := json.Unmarshal(raw, &txn); err != nil {
+		return nil, err
+	}
+	addr := strings.ToLower(address)
+	router := strings.ToLower(txn.To)
+
+	// the wallet's net change per token; ETH it sent counts as evmNative
+	delta := map[string]*big.Int{}
+	add := func(token string, v *big.Int) {
+		if delta[token] == nil {
+			delta[token] = new(big.Int)
+		}
+		delta[token].Add(delta[token], v)
+	}
+	var order []string // tokens in the order the wallet's transfers happened
+	withdrawn := new(big.Int)
+	for _, l := range rc.Logs {
+		if len(l.Topics) == 0 {
+			continue
+		}
+		switch l.Topics[0] {
+		case erc20TransferTopic:
+			from, to, amount, ok := parseERC20Log(l)
+			if !ok || strings.EqualFold(from, to) {
+				continue
+			}
+			token := strings.ToLower(l.Address)
+			switch {
+			case strings.EqualFold(from, addr):
+				add(token, new(big.Int).Neg(amount))
+			case strings.EqualFold(to, addr):
+				add(token, amount)
+			default:
+				continue
+			}
+			order = append(order, token)
+		case topicWithdrawal:
+			// routers unwrap WETH before paying the wallet out in ETH
+			withdrawn.Add(withdrawn, hexBig(l.Data))
+		}
+	}
+	if strings.EqualFold(txn.From, addr) {
+		if v := hexBig(txn.Value); v.Sign() > 0 {
+			add(evmNative, new(big.Int).Neg(v))
+			order = append([]string{evmNative}, order...)
+		}
+	}
+
+	call := decodeRouterCall(txn.Input)
+	in, out := call.tokenIn, call.tokenOut
+	for _, t := range order {
+		if in == "" && delta[t].Sign() < 0 {
+			in = t
+		}
+		if out == "" && delta[t].Sign() > 0 {
+			out = t
+		}
+	}
+	if out == "" && withdrawn.Sign() > 0 && strings.EqualFold(txn.From, addr) {
+		out = evmNative
+	}
+	if in == "" || out == "" || in == out {
+		return nil, nil
+	}
+
+	amountIn := new(big.Int)
+	if d := delta[in]; d != nil && d.Sign() < 0 {
+		amountIn.Neg(d)
+	} else if call.amountIn != nil {
+		amountIn.Set(call.amountIn)
+	}
+	amountOut := new(big.Int)
+	if d := delta[out]; d != nil && d.Sign() > 0 {
+		amountOut.Set(d)
+	} else if out == evmNative {
+		amountOut.Set(withdrawn)
+		if last := swaps[len(swaps)-1]; amountOut.Sign() == 0 && last.Topics[0] == topicSwapV4 {
+			// V4 pools hold ETH itself as currency0; a positive delta is paid out
+			amountOut = signedWord(last.Data, 0)
+		}
+	}
+	if amountIn.Sign() <= 0 || amountOut.Sign() <= 0 {
+		return nil, nil
+	}
+
+	infoIn := s.evmTokenInfo(ctx, rpcURL, chain, in, cache)
+	infoOut := s.evmTokenInfo(ctx, rpcURL, chain, out, cache)
+	sw := evmSwap{
+		Platform:  config.ClassifyEVMDEX(router),
+		Router:    router,
+		Pool:      strings.ToLower(swaps[0].Address),
+		TokenIn:   in,
+		AmountIn:  tokenValueBig(amountIn, infoIn.decimals),
+		TokenOut:  out,
+		AmountOut: tokenValueBig(amountOut, infoOut.decimals),
+	}
+	if sw.Platform == "" {
+		sw.Platform = swapKinds[swaps[0].Topics[0]]
+	}
+	if swaps[0].Topics[0] == topicSwapV4 && len(swaps[0].Topics) > 1 {
+		sw.Pool = swaps[0].Topics[1] // pool id; all V4 pools live in the PoolManager
+	}
+
+	// Gas: effective price from the receipt, tip above the block's base fee
+	ts, baseFee := s.blockHeader(ctx, rpcURL, rc.BlockNumber)
+	gasUsed := hexBig(rc.GasUsed)
+	price := hexBig(rc.EffectiveGasPrice)
+	if price.Sign() == 0 {
+		price = hexBig(txn.GasPrice)
+	}
+	tip := new(big.Int)
+	if baseFee != nil {
+		tip.Sub(price, baseFee)
+	} else {
+		tip = hexBig(txn.MaxPriorityFeePerGas)
+	}
+	sw.GasUsed = gasUsed.Int64()
+	sw.EffectiveGasPrice = tokenValueBig(price, 9)
+	sw.PriorityTip = tokenValueBig(tip, 9)
+
+	tx := db.WalletTransaction{
+		WalletID:    walletID,
+		TxHash:      hash,
+		Chain:       chain,
+		FromAddress: address,
+		ToAddress:   router,
+		Timestamp:   ts,
+		BlockNumber: hexBig(rc.BlockNumber).Int64(),
+		Platform:    sw.Platform,
+		PriorityFee: tokenValueBig(new(big.Int).Mul(price, gasUsed), 18), // total gas cost, as the explorer path stores it
+	}
+	if evmQuote(infoIn.symbol, chain) || !evmQuote(infoOut.symbol, chain) {
+		tx.TxType = "swap_buy"
+		tx.TokenAddress, tx.TokenSymbol, tx.AmountToken = out, infoOut.symbol, sw.AmountOut
+		tx.AmountUSD = s.quoteUSDAt(ctx, chain, infoIn.symbol, sw.AmountIn, ts)
+	} else {
+		tx.TxType = "swap_sell"
+		tx.TokenAddress, tx.TokenSymbol, tx.AmountToken = in, infoIn.symbol, sw.AmountIn
+		tx.AmountUSD = s.quoteUSDAt(ctx, chain, infoOut.symbol, sw.AmountOut, ts)
+	}
+	if meta, err := json.Marshal(sw); err == nil {
+		tx.Metadata = string(meta)
+	}
+	return &tx, nil
+}
+
+// evmSwapPass decodes each transaction of one scan pass at most once, so a
+// swap found through one of its transfers isn't looked up again for the
+// other. Without an RPC endpoint for the chain it decodes nothing.
+type evmSwapPass struct {
+	s        *Scanner
+	rpcURL   string
+	walletID int64
+	address  string
+	chain    config.Chain
+	tokens   map[string]tokenInfo
+	swaps    map[string]bool // hash -> is a swap
+}
+
+func (s *Scanner) newSwapPass(walletID int64, address string, chain config.Chain, tokens map[string]tokenInfo) *evmSwapPass {
+	return &evmSwapPass{s: s, rpcURL: s.cfg.EVMRPC[chain], walletID: walletID, address: address,
+		chain: chain, tokens: tokens, swaps: map[string]bool{}}
+}
+
+// store decodes hash and stores it if it is one of the wallet's swaps. swap
+// reports whether it is (the caller then skips the transaction's transfer
+// rows), stored whether this call inserted it.
+func (p *evmSwapPass) store(ctx context.Context, hash string) (swap, stored bool) {
+	if p.rpcURL == "" || hash == "" {
+		return false, false
+	}
+	if done, ok := p.swaps[hash]; ok {
+		return done, false
+	}
+	tx, err := p.s.evmSwapTx(ctx, p.rpcURL, p.walletID, p.address, p.chain, hash, p.tokens)
+	if err != nil {
+		log.Debug().Err(err).Str("tx", abbrev(hash)).Msg("swap decode failed")
+	}
+	p.swaps[hash] = tx != nil
+	if tx == nil {
+		return false, false
+	}
+	return true, p.s.store.InsertTransaction(*tx) == nil
+}
+
+// storeRow is store for one row of an Etherscan list action. Plain
+// transfers in txlist carry no calldata and are never swaps.
+func (p *evmSwapPass) storeRow(ctx context.Context, action string, etx etherscanResult) (swap, stored bool) {
+	if action == "txlist" && (str(etx, "input") == "0x" || str(etx, "input") == "") {
+		return false, false
+	}
+	return p.store(ctx, str(etx, "hash"))
+}
+
+// evmTokenInfo is getTokenInfo that also knows the native coin.
+func (s *Scanner) evmTokenInfo(ctx context.Context, rpcURL string, chain config.Chain, token string, cache map[string]tokenInfo) tokenInfo {
+	if token == evmNative {
+		return tokenInfo{symbol: nativeSymbol(chain), decimals: 18}
+	}
+	return s.getTokenInfo(ctx, rpcURL, token, cache)
+}
+
+// evmQuote reports whether symbol is the quote side of a swap: the native
+// coin, its wrapped form or a stablecoin.
+func evmQuote(symbol string, chain config.Chain) bool {
+	return symbol == nativeSymbol(chain) || explorerStables[symbol]
+}
+
+// blockHeader returns a block's timestamp and base fee (nil before London).
+func (s *Scanner) blockHeader(ctx context.Context, rpcURL, blockHex string) (time.Time, *big.Int) {
+	result, err := s.rpcCall(ctx, rpcURL, "eth_getBlockByNumber", []interface{}{blockHex, false})
+	if err != nil {
+		return time.Time{}, nil
+	}
+	var block struct {
+		Timestamp     string  `json:"timestamp"`
+		BaseFeePerGas *string `json:"baseFeePerGas"`
+	}
+	json.Unmarshal(result, &block)
+	var baseFee *big.Int
+	if block.BaseFeePerGas != nil {
+		baseFee = hexBig(*block.BaseFeePerGas)
+	}
+	return time.Unix(hexBig(block.Timestamp).Int64(), 0), baseFee
+}
+
+// ── Router calldata ─────────────────────────────────────────
+
+// Router entry points
+const (
+	selURExecute         = "3593564c" // Universal Router execute(bytes,bytes[],uint256)
+	selURExecuteNoExpiry = "24856bc3" // execute(bytes,bytes[])
+	sel1inchV5Swap       = "12aa3caf" // swap(address,SwapDescription,bytes,bytes)
+	sel1inchV6Swap       = "07ed2379" // swap(address,SwapDescription,bytes)
+	sel0xTransformERC20  = "415565b0" // transformERC20(address,address,uint256,uint256,(uint32,bytes)[])
+	sel0xSellToUniswap   = "d9627aa4" // sellToUniswap(address[],uint256,uint256,bool)
+)
+
+// Universal Router commands (low 6 bits of each command byte)
+const (
+	urV3SwapExactIn  = 0x00
+	urV3SwapExactOut = 0x01
+	urV2SwapExactIn  = 0x08
+	urV2SwapExactOut = 0x09
+	urWrapETH        = 0x0b
+	urUnwrapWETH     = 0x0c
+)
+
+// decodeRouterCall reads the input and output token (and input amount,
+// where the call fixes it) from Universal Router, 1inch and 0x calldata.
+// Anything else decodes to an empty call and is resolved from the receipt.
+func decodeRouterCall(input string) routerCall {
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil || len(data) < 4 {
+		return routerCall{}
+	}
+	sel, args := hex.EncodeToString(data[:4]), data[4:]
+	var c routerCall
+	switch sel {
+	case selURExecute, selURExecuteNoExpiry:
+		c = decodeUniversalRouter(args)
+	case sel1inchV5Swap, sel1inchV6Swap:
+		// SwapDescription is static, so it sits inline after the executor:
+		// srcToken, dstToken, srcReceiver, dstReceiver, amount, minReturn, flags
+		c = routerCall{tokenIn: wordAddr(args, 1), tokenOut: wordAddr(args, 2), amountIn: wordBig(args, 5)}
+	case sel0xTransformERC20:
+		c = routerCall{tokenIn: wordAddr(args, 0), tokenOut: wordAddr(args, 1), amountIn: wordBig(args, 2)}
+	case sel0xSellToUniswap:
+		if path := dynAddrs(args, 0); len(path) >= 2 {
+			c = routerCall{tokenIn: path[0], tokenOut: path[len(path)-1], amountIn: wordBig(args, 1)}
+		}
+	}
+	if c.tokenIn == zeroAddress {
+		c.tokenIn = ""
+	}
+	if c.tokenOut == zeroAddress {
+		c.tokenOut = ""
+	}
+	return c
+}
+
+// decodeUniversalRouter walks execute()'s command list: the first swap's
+// input and the last swap's output, with WRAP_ETH before or UNWRAP_WETH
+// after turning that side into ETH. V4 swaps and other commands are skipped.
+func decodeUniversalRouter(args []byte) routerCall {
+	commands := dynBytes(args, 0)
+	inputs := dynBytesArray(args, 1)
+	var c routerCall
+	swapped := false
+	for i, cmd := range commands {
+		if i >= len(inputs) {
+			break
+		}
+		in := inputs[i]
+		var tokenIn, tokenOut string
+		var amountIn *big.Int
+		switch cmd & 0x3f {
+		case urWrapETH:
+			if !swapped {
+				c.tokenIn = evmNative
+			}
+			continue
+		case urUnwrapWETH:
+			if swapped {
+				c.tokenOut = evmNative
+			}
+			continue
+		case urV3SwapExactIn, urV3SwapExactOut:
+			// path is tokenA(20) fee(3) tokenB(20)..., reversed for exact-out
+			path := dynBytes(in, 3)
+			if len(path) < 43 {
+				continue
+			}
+			first, last := "0x"+hex.EncodeToString(path[:20]), "0x"+hex.EncodeToString(path[len(path)-20:])
+			tokenIn, tokenOut = first, last
+			if cmd&0x3f == urV3SwapExactOut {
+				tokenIn, tokenOut = last, first
+			} else {
+				amountIn = wordBig(in, 1)
+			}
+		case urV2SwapExactIn, urV2SwapExactOut:
+			path := dynAddrs(in, 3)
+			if len(path) < 2 {
+				continue
+			}
+			tokenIn, tokenOut = path[0], path[len(path)-1]
+			if cmd&0x3f == urV2SwapExactIn {
+				amountIn = wordBig(in, 1)
+			}
+		default:
+			continue
+		}
+		if !swapped {
+			if c.tokenIn == "" {
+				c.tokenIn, c.amountIn = tokenIn, amountIn
+			}
+			swapped = true
+		}
+		c.tokenOut = tokenOut
+	}
+	return c
+}
+
+// ── ABI helpers ─────────────────────────────────────────────
+
+// word returns the i-th 32-byte word of b, or nil past the end.
+func word(b []byte, i int) []byte {
+	if i < 0 || (i+1)*32 > len(b) {
+		return nil
+	}
+	return b[i*32 : (i+1)*32]
+}
+
+func wordBig(b []byte, i int) *big.Int {
+	w := word(b, i)
+	if w == nil {
+		return nil
+	}
+	return new(big.Int).SetBytes(w)
+}
+
+func wordAddr(b []byte, i int) string {
+	w := word(b, i)
+	if w == nil {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(w[12:])
+}
+
+// signedWord reads the i-th word of hex log data as a two's-complement int256.
+func signedWord(data string, i int) *big.Int {
+	b, _ := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	v := wordBig(b, i)
+	if v == nil {
+		return new(big.Int)
+	}
+	if len(b) > i*32 && b[i*32]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return v
+}
+
+// dynOffset follows the offset in head word i to its tail position.
+func dynOffset(b []byte, i int) (int, bool) {
+	off := wordBig(b, i)
+	if off == nil || !off.IsInt64() || off.Int64() < 0 || off.Int64()+32 > int64(len(b)) {
+		return 0, false
+	}
+	return int(off.Int64()), true
+}
+
+// dynBytes decodes the `bytes` argument whose offset is head word i.
+func dynBytes(b []byte, i int) []byte {
+	off, ok := dynOffset(b, i)
+	if !ok {
+		return nil
+	}
+	n := new(big.Int).SetBytes(b[off : off+32])
+	if !n.IsInt64() || int64(off+32)+n.Int64() > int64(len(b)) {
+		return nil
+	}
+	return b[off+32 : off+32+int(n.Int64())]
+}
+
+// dynBytesArray decodes the `bytes[]` argument whose offset is head word i.
+func dynBytesArray(b []byte, i int) [][]byte {
+	off, ok := dynOffset(b, i)
+	if !ok {
+		return nil
+	}
+	arr := b[off+32:]
+	n := new(big.Int).SetBytes(b[off : off+32])
+	if !n.IsInt64() || n.Int64() > int64(len(arr)/32) {
+		return nil
+	}
+	out := make([][]byte, 0, n.Int64())
+	for j := 0; j < int(n.Int64()); j++ {
+		out = append(out, dynBytes(arr, j))
+	}
+	return out
+}
+
+// dynAddrs decodes the `address[]` argument whose offset is head word i.
+func dynAddrs(b []byte, i int) []string {
+	off, ok := dynOffset(b, i)
+	if !ok {
+		return nil
+	}
+	arr := b[off+32:]
+	n := new(big.Int).SetBytes(b[off : off+32])
+	if !n.IsInt64() || n.Int64() > int64(len(arr)/32) {
+		return nil
+	}
+	out := make([]string, 0, n.Int64())
+	for j := 0; j < int(n.Int64()); j++ {
+		out = append(out, wordAddr(arr, j))
+	}
+	return out
+}
+
+// hexBig parses a 0x-prefixed quantity, treating garbage as zero.
+func hexBig(s string) *big.Int {
+	v, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		return new(big.Int)
+	}
+	return v
+}
@@ -0,0 +1,307 @@
+package scanner
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+const (
+	evmWallet = "0x00000000000000000000000000000000000b0b03"
+	evmToken  = "0x00000000000000000000000000000000000070c1"
+	evmUSDC   = "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913"
+	evmWETH   = "0x4200000000000000000000000000000000000006"
+	evmPool   = "0x00000000000000000000000000000000000000a1"
+	evmRouter = "0x00000000000000000000000000000000000000e1"
+)
+
+// abiDyn is the tail encoding of a dynamic argument, length word included.
+type abiDyn []byte
+
+// abiEncode lays out args as a call's head and tail: []byte arguments are
+// static words, abiDyn ones go to the tail behind an offset.
+func abiEncode(args ...interface{}) []byte {
+	var head, tail []byte
+	for _, a := range args {
+		switch v := a.(type) {
+		case abiDyn:
+			head = append(head, word32(big.NewInt(int64(32*len(args)+len(tail))).Bytes())...)
+			tail = append(tail, v...)
+		case []byte:
+			head = append(head, word32(v)...)
+		}
+	}
+	return append(head, tail...)
+}
+
+func abiBytes(b []byte) abiDyn {
+	padded := append(append([]byte{}, b...), make([]byte, (32-len(b)%32)%32)...)
+	return abiDyn(append(word32(big.NewInt(int64(len(b))).Bytes()), padded...))
+}
+
+func abiAddrs(addrs ...string) abiDyn {
+	out := word32(big.NewInt(int64(len(addrs))).Bytes())
+	for _, a := range addrs {
+		out = append(out, word32(addrBytes(a))...)
+	}
+	return abiDyn(out)
+}
+
+func abiBytesArray(items ...[]byte) abiDyn {
+	var args []interface{}
+	for _, it := range items {
+		args = append(args, abiBytes(it))
+	}
+	return abiDyn(append(word32(big.NewInt(int64(len(items))).Bytes()), abiEncode(args...)...))
+}
+
+func addrBytes(a string) []byte {
+	b, _ := hex.DecodeString(strings.TrimPrefix(a, "0x"))
+	return b
+}
+
+func uintBytes(v int64) []byte { return big.NewInt(v).Bytes() }
+
+func calldata(selector string, args ...interface{}) string {
+	return "0x" + selector + hex.EncodeToString(abiEncode(args...))
+}
+
+// v3Path is a Uniswap V3 packed path with a 0.3% fee between hops.
+func v3Path(tokens ...string) []byte {
+	var p []byte
+	for i, t := range tokens {
+		if i > 0 {
+			p = append(p, 0x00, 0x0b, 0xb8)
+		}
+		p = append(p, addrBytes(t)...)
+	}
+	return p
+}
+
+func TestDecodeRouterCall(t *testing.T) {
+	execute := func(commands []byte, inputs ...[]byte) string {
+		return calldata(selURExecute, abiBytes(commands), abiBytesArray(inputs...), uintBytes(1700000000))
+	}
+	v3In := func(amount int64, path []byte) []byte {
+		return abiEncode(addrBytes(evmWallet), uintBytes(amount), uintBytes(1), abiBytes(path), uintBytes(1))
+	}
+	v2In := func(amount int64, path ...string) []byte {
+		return abiEncode(addrBytes(evmWallet), uintBytes(amount), uintBytes(1), abiAddrs(path...), uintBytes(1))
+	}
+	wrap := abiEncode(addrBytes(evmRouter), uintBytes(1000))
+	unwrap := abiEncode(addrBytes(evmWallet), uintBytes(1))
+	tests := []struct {
+		name     string
+		input    string
+		in, out  string
+		amountIn int64 // 0 when the call doesn't fix it
+	}{
+		{
+			name:  "UR wrap then V3 exact in",
+			input: execute([]byte{urWrapETH, urV3SwapExactIn}, wrap, v3In(1000, v3Path(evmWETH, evmToken))),
+			in:    evmNative, out: evmToken,
+		},
+		{
+			name:  "UR V2 exact in then unwrap",
+			input: execute([]byte{urV2SwapExactIn, urUnwrapWETH}, v2In(500, evmToken, evmWETH), unwrap),
+			in:    evmToken, out: evmNative, amountIn: 500,
+		},
+		{
+			name:  "UR V3 exact out reads the path backwards",
+			input: execute([]byte{urV3SwapExactOut}, v3In(42, v3Path(evmToken, evmUSDC))),
+			in:    evmUSDC, out: evmToken,
+		},
+		{
+			name: "UR multi-hop keeps first input and last output",
+			input: execute([]byte{urV3SwapExactIn | 0x80, urV2SwapExactIn},
+				v3In(700, v3Path(evmUSDC, evmWETH)), v2In(0, evmWETH, evmToken)),
+			in: evmUSDC, out: evmToken, amountIn: 700,
+		},
+		{
+			name: "1inch v6 swap",
+			input: calldata(sel1inchV6Swap, addrBytes(evmRouter), addrBytes(evmNative), addrBytes(evmToken),
+				addrBytes(evmRouter), addrBytes(evmWallet), uintBytes(9000), uintBytes(1), uintBytes(0)),
+			in: evmNative, out: evmToken, amountIn: 9000,
+		},
+		{
+			name:  "0x transformERC20 with zero output address",
+			input: calldata(sel0xTransformERC20, addrBytes(evmToken), addrBytes(zeroAddress), uintBytes(77), uintBytes(1)),
+			in:    evmToken, amountIn: 77,
+		},
+		{
+			name:  "0x sellToUniswap",
+			input: calldata(sel0xSellToUniswap, abiAddrs(evmToken, evmWETH, evmUSDC), uintBytes(300), uintBytes(1), uintBytes(0)),
+			in:    evmToken, out: evmUSDC, amountIn: 300,
+		},
+		{name: "unknown selector", input: calldata("a9059cbb", addrBytes(evmWallet), uintBytes(1))},
+		{name: "truncated UR call", input: "0x" + selURExecute + "00000020"},
+		{name: "not hex", input: "0xzz"},
+		{name: "empty", input: "0x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := decodeRouterCall(tt.input)
+			if c.tokenIn != tt.in || c.tokenOut != tt.out {
+				t.Errorf("tokens = %q -> %q, want %q -> %q", c.tokenIn, c.tokenOut, tt.in, tt.out)
+			}
+			var amount int64
+			if c.amountIn != nil {
+				amount = c.amountIn.Int64()
+			}
+			if amount != tt.amountIn {
+				t.Errorf("amountIn = %v, want %d", c.amountIn, tt.amountIn)
+			}
+		})
+	}
+}
+
+func transferLog(token, from, to string, amount *big.Int) map[string]interface{} {
+	return map[string]interface{}{"address": token, "topics": []string{erc20TransferTopic, topicAddress(from), topicAddress(to)},
+		"data": "0x" + hex.EncodeToString(word32(amount.Bytes()))}
+}
+
+func swapLog(topic string, topics ...string) map[string]interface{} {
+	return map[string]interface{}{"address": evmPool, "topics": append([]string{topic}, topics...), "data": "0x"}
+}
+
+func ether(units int64, decimals int) *big.Int {
+	return new(big.Int).Mul(big.NewInt(units), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+func TestEVMSwapTx(t *testing.T) {
+	const poolID = "0x" + "ab00000000000000000000000000000000000000000000000000000000000001"
+	tests := []struct {
+		name      string
+		status    string
+		logs      []map[string]interface{}
+		value     string
+		want      string // tx type; "" for no swap
+		token     string
+		amount    float64
+		pool, dex string
+		amountUSD float64
+		amountIn  float64
+		amountOut float64
+	}{
+		{
+			name:   "USDC buy through a V3 pool",
+			status: "0x1",
+			logs: []map[string]interface{}{
+				transferLog(evmUSDC, evmWallet, evmPool, ether(100, 6)),
+				swapLog(topicSwapV3, topicAddress(evmRouter), topicAddress(evmWallet)),
+				transferLog(evmToken, evmPool, evmWallet, ether(5000, 18)),
+			},
+			want: "swap_buy", token: evmToken, amount: 5000, pool: evmPool, dex: "uniswap_v3",
+			amountUSD: 100, amountIn: 100, amountOut: 5000,
+		},
+		{
+			name:   "sell paid out in unwrapped ETH",
+			status: "0x1",
+			logs: []map[string]interface{}{
+				transferLog(evmToken, evmWallet, evmPool, ether(2000, 18)),
+				swapLog(topicSwapV2, topicAddress(evmRouter), topicAddress(evmRouter)),
+				{"address": evmWETH, "topics": []string{topicWithdrawal, topicAddress(evmRouter)},
+					"data": "0x" + hex.EncodeToString(word32(new(big.Int).Div(ether(1, 18), big.NewInt(2)).Bytes()))},
+			},
+			want: "swap_sell", token: evmToken, amount: 2000, pool: evmPool, dex: "uniswap_v2",
+			amountUSD: 1500, amountIn: 2000, amountOut: 0.5,
+		},
+		{
+			name:   "ETH buy on a V4 pool",
+			status: "0x1",
+			value:  "0xde0b6b3a7640000", // 1 ETH
+			logs: []map[string]interface{}{
+				swapLog(topicSwapV4, poolID, topicAddress(evmRouter)),
+				transferLog(evmToken, evmPool, evmWallet, ether(10, 18)),
+			},
+			want: "swap_buy", token: evmToken, amount: 10, pool: poolID, dex: "uniswap_v4",
+			amountUSD: 3000, amountIn: 1, amountOut: 10,
+		},
+		{
+			name:   "reverted",
+			status: "0x0",
+			logs:   []map[string]interface{}{swapLog(topicSwapV3)},
+		},
+		{
+			name:   "transfers without a pool swap",
+			status: "0x1",
+			logs: []map[string]interface{}{
+				transferLog(evmUSDC, evmWallet, evmPool, ether(100, 6)),
+				transferLog(evmToken, evmPool, evmWallet, ether(5000, 18)),
+			},
+		},
+		{
+			name:   "swap the wallet only paid into",
+			status: "0x1",
+			logs: []map[string]interface{}{
+				transferLog(evmUSDC, evmWallet, evmPool, ether(100, 6)),
+				swapLog(topicSwapV3),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestScanner(t)
+			at := time.Unix(0x65000000, 0).Truncate(time.Minute)
+			if err := store.UpsertCandles([]db.PriceCandle{{Asset: "native:ETH", Resolution: 60, OpenTime: at, Close: 3000}}); err != nil {
+				t.Fatal(err)
+			}
+			receipt, _ := json.Marshal(map[string]interface{}{
+				"status": tt.status, "blockNumber": "0x10", "gasUsed": "0x30d40", // 200k
+				"effectiveGasPrice": "0x77359400", "logs": tt.logs, // 2 gwei
+			})
+			value := tt.value
+			if value == "" {
+				value = "0x0"
+			}
+			txn, _ := json.Marshal(map[string]string{"from": evmWallet, "to": evmRouter, "input": "0x", "value": value})
+			srv := fakeRPC(t, map[string]string{
+				"eth_getTransactionReceipt": string(receipt),
+				"eth_getTransactionByHash":  string(txn),
+				"eth_getBlockByNumber":      `{"timestamp":"0x65000000","baseFeePerGas":"0x59682f00"}`, // 1.5 gwei
+			})
+			cache := map[string]tokenInfo{evmUSDC: {"USDC", 6}, evmToken: {"MEME", 18}}
+
+			tx, err := s.evmSwapTx(context.Background(), srv.URL, 1, evmWallet, config.ChainBase, "0xabc", cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if tx != nil {
+					t.Fatalf("decoded a %s, want no swap", tx.TxType)
+				}
+				return
+			}
+			if tx == nil {
+				t.Fatal("not decoded as a swap")
+			}
+			if tx.TxType != tt.want || tx.TokenAddress != tt.token || !near(tx.AmountToken, tt.amount) || !near(tx.AmountUSD, tt.amountUSD) {
+				t.Errorf("tx = %s %s %v ($%v), want %s %s %v ($%v)", tx.TxType, tx.TokenAddress, tx.AmountToken, tx.AmountUSD,
+					tt.want, tt.token, tt.amount, tt.amountUSD)
+			}
+			if !near(tx.PriorityFee, 0.0004) || tx.BlockNumber != 16 {
+				t.Errorf("gas cost = %v at block %d, want 0.0004 at 16", tx.PriorityFee, tx.BlockNumber)
+			}
+			var sw evmSwap
+			if err := json.Unmarshal([]byte(tx.Metadata), &sw); err != nil {
+				t.Fatal(err)
+			}
+			if sw.Pool != tt.pool || sw.Platform != tt.dex || tx.Platform != tt.dex {
+				t.Errorf("pool %s on %s, want %s on %s", sw.Pool, sw.Platform, tt.pool, tt.dex)
+			}
+			if !near(sw.AmountIn, tt.amountIn) || !near(sw.AmountOut, tt.amountOut) {
+				t.Errorf("amounts = %v in, %v out; want %v, %v", sw.AmountIn, sw.AmountOut, tt.amountIn, tt.amountOut)
+			}
+			if sw.GasUsed != 200_000 || !near(sw.EffectiveGasPrice, 2) || !near(sw.PriorityTip, 0.5) {
+				t.Errorf("gas = %d at %v gwei, tip %v; want 200000 at 2, tip 0.5", sw.GasUsed, sw.EffectiveGasPrice, sw.PriorityTip)
+			}
+		})
+	}
+}
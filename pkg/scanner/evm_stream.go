@@ -0,0 +1,390 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// subscription kinds on an EVM stream
+const (
+	subWalletFrom = "wallet_from"
+	subWalletTo   = "wallet_to"
+	subToken      = "token"
+)
+
+// EVMStream follows one EVM chain over eth_subscribe("logs") on
+// cfg.EVMWS[chain]. Two subscriptions cover ERC-20 Transfers from and to
+// every tracked wallet with confidence >= cfg.StreamMinConfidence; each is
+// decoded like a polled eth_getLogs result, stored and passed to the
+// handlers. A third covers Transfers of the tokens the BuyerWatcher is
+// watching, so their buyers are reported within a block. Works against any
+// node with WebSocket support, including a local anvil or geth --dev.
+//
+// Each log is handled once per subscription, keyed by tx hash and log index,
+// so a node replaying logs after a resubscribe doesn't repeat events. Logs a
+// reorg removes delete the tx they stored; the replacement block's logs
+// arrive as new ones.
+type EVMStream struct {
+	streamBase
+	sc     *Scanner
+	chain  config.Chain
+	wsURL  string
+	buyers BuyerWatcher
+
+	wallets   map[string]db.TrackedWallet // lowercase address -> wallet
+	subs      map[string]string           // subscription id -> kind
+	contracts map[string]bool             // token recipients that turned out to be contracts
+	swaps     map[string]bool             // "hash:wallet" of swaps already handled
+	logs      map[string]bool             // "kind:hash:index" of logs already handled
+	reorged   map[string]bool             // tx hashes whose logs a reorg removed
+	walletSet string                      // wallets the current subscriptions cover
+	tokenSet  string                      // tokens the current subscription covers
+}
+
+func NewEVMStream(sc *Scanner, chain config.Chain) *EVMStream {
+	st := &EVMStream{sc: sc, chain: chain, wsURL: sc.cfg.EVMWS[chain]}
+	st.stats.Chain = chain
+	return st
+}
+
+// WatchBuyers follows the transfers of w's watched tokens and reports
+// their recipients as buyers.
+func (st *EVMStream) WatchBuyers(w BuyerWatcher) {
+	st.buyers = w
+}
+
+// Run keeps a subscription session open until ctx ends, reconnecting with
+// backoff when the socket drops.
+func (st *EVMStream) Run(ctx context.Context) error {
+	return st.run(ctx, st.session)
+}
+
+func (st *EVMStream) session(ctx context.Context) error {
+	conn, err := dialWS(ctx, st.wsURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	st.reset()
+	st.connected(ctx, conn, st.notify)
+
+	if err := st.sync(ctx, conn); err != nil {
+		return err
+	}
+	log.Info().Str("chain", string(st.chain)).Int("wallets", len(st.wallets)).Msg("📡 evm stream connected")
+
+	t := time.NewTicker(streamResync)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-conn.Done():
+			return conn.Err()
+		case <-t.C:
+			if err := st.sync(ctx, conn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reset clears what a session learned; subscriptions die with the socket.
+func (st *EVMStream) reset() {
+	st.mu.Lock()
+	st.wallets, st.subs, st.contracts, st.swaps = map[string]db.TrackedWallet{}, map[string]string{}, map[string]bool{}, map[string]bool{}
+	st.logs, st.reorged = map[string]bool{}, map[string]bool{}
+	st.mu.Unlock()
+	st.walletSet, st.tokenSet = "", ""
+}
+
+// sync replaces the wallet or token subscriptions when the set they should
+// cover has changed. Address lists go into a single topic or address filter,
+// so the subscription count stays at three however many wallets there are.
+func (st *EVMStream) sync(ctx context.Context, conn *wsConn) error {
+	ws, err := st.sc.streamWallets(st.chain)
+	if err != nil {
+		log.Warn().Err(err).Str("chain", string(st.chain)).Msg("evm stream: load wallets")
+		return nil
+	}
+	wallets := map[string]db.TrackedWallet{}
+	var addrs []string
+	for _, w := range ws {
+		a := strings.ToLower(w.Address)
+		if _, dup := wallets[a]; !dup {
+			addrs = append(addrs, a)
+		}
+		wallets[a] = w
+	}
+	st.mu.Lock()
+	st.wallets = wallets
+	st.mu.Unlock()
+
+	if key := setKey(addrs); key != st.walletSet {
+		var topics []string
+		for _, a := range addrs {
+			topics = append(topics, "0x000000000000000000000000"+a[2:])
+		}
+		filters := map[string]interface{}{}
+		if len(topics) > 0 {
+			filters[subWalletFrom] = map[string]interface{}{"topics": []interface{}{erc20TransferTopic, topics}}
+			filters[subWalletTo] = map[string]interface{}{"topics": []interface{}{erc20TransferTopic, nil, topics}}
+		}
+		if err := st.resubscribe(ctx, conn, filters, subWalletFrom, subWalletTo); err != nil {
+			return err
+		}
+		st.walletSet = key
+	}
+
+	if st.buyers != nil {
+		var tokens []string
+		for _, t := range st.buyers.WatchedTokens(st.chain) {
+			tokens = append(tokens, strings.ToLower(t))
+		}
+		if key := setKey(tokens); key != st.tokenSet {
+			filters := map[string]interface{}{}
+			if len(tokens) > 0 {
+				filters[subToken] = map[string]interface{}{"address": tokens, "topics": []interface{}{erc20TransferTopic}}
+			}
+			if err := st.resubscribe(ctx, conn, filters, subToken); err != nil {
+				return err
+			}
+			st.tokenSet = key
+		}
+	}
+
+	st.mu.Lock()
+	n := len(st.subs)
+	st.mu.Unlock()
+	st.setSubscriptions(n)
+	return nil
+}
+
+// resubscribe drops the subscriptions of the given kinds and opens one per
+// filter. Only connection failures are returned; a filter the node rejects
+// is logged and retried on the next change.
+func (st *EVMStream) resubscribe(ctx context.Context, conn *wsConn, filters map[string]interface{}, kinds ...string) error {
+	drop := map[string]bool{}
+	for _, k := range kinds {
+		drop[k] = true
+	}
+	st.mu.Lock()
+	var old []string
+	for id, k := range st.subs {
+		if drop[k] {
+			old = append(old, id)
+			delete(st.subs, id)
+		}
+	}
+	st.mu.Unlock()
+	for _, id := range old {
+		if _, err := conn.call(ctx, "eth_unsubscribe", []interface{}{id}); err != nil && conn.Err() != nil {
+			return err
+		}
+	}
+	for _, k := range kinds {
+		f, ok := filters[k]
+		if !ok {
+			continue
+		}
+		id, err := conn.subscribe(ctx, "eth_subscribe", []interface{}{"logs", f})
+		if err != nil {
+			if conn.Err() != nil || ctx.Err() != nil {
+				return err
+			}
+			log.Warn().Err(err).Str("chain", string(st.chain)).Str("kind", k).Msg("evm stream: subscribe failed")
+			continue
+		}
+		st.mu.Lock()
+		st.subs[id] = k
+		st.mu.Unlock()
+	}
+	return nil
+}
+
+// setKey is a canonical form of an address set, to tell when it changed.
+func setKey(addrs []string) string {
+	sorted := append([]string(nil), addrs...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func (st *EVMStream) notify(ctx context.Context, n wsNotification) {
+	if n.Method != "eth_subscription" {
+		return
+	}
+	var l struct {
+		evmLog
+		Removed bool `json:"removed"`
+	}
+	if json.Unmarshal(n.Result, &l) != nil {
+		return
+	}
+	st.mu.Lock()
+	kind := st.subs[n.Subscription]
+	st.mu.Unlock()
+	if kind == "" {
+		return
+	}
+	if l.Removed {
+		// handled inline so it lands before the replacement block's logs
+		st.removeLog(l.evmLog, kind)
+		return
+	}
+	if !st.firstSeen(l.evmLog, kind) {
+		return
+	}
+	switch kind {
+	case subWalletFrom, subWalletTo:
+		go st.handleWallet(ctx, l.evmLog, kind == subWalletFrom)
+	case subToken:
+		go st.handleToken(ctx, l.evmLog)
+	}
+}
+
+// firstSeen records a log for kind and reports whether it is new.
+func (st *EVMStream) firstSeen(l evmLog, kind string) bool {
+	key := kind + ":" + l.TxHash + ":" + l.LogIndex
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.logs[key] {
+		return false
+	}
+	if len(st.logs) > 10000 {
+		st.logs, st.reorged = map[string]bool{}, map[string]bool{}
+	}
+	st.logs[key] = true
+	delete(st.reorged, l.TxHash)
+	return true
+}
+
+// removeLog forgets a log a reorg removed and, for the wallet
+// subscriptions, deletes the tx it stored. A handler still working on the
+// log checks reorged after its insert.
+func (st *EVMStream) removeLog(l evmLog, kind string) {
+	st.mu.Lock()
+	for _, k := range []string{subWalletFrom, subWalletTo, subToken} {
+		delete(st.logs, k+":"+l.TxHash+":"+l.LogIndex)
+	}
+	for key := range st.swaps {
+		if strings.HasPrefix(key, l.TxHash+":") {
+			delete(st.swaps, key)
+		}
+	}
+	st.reorged[l.TxHash] = true
+	st.mu.Unlock()
+	if kind != subToken {
+		st.dropTx(l.TxHash)
+	}
+}
+
+func (st *EVMStream) dropTx(hash string) {
+	n, err := st.sc.store.DeleteTransaction(hash, st.chain)
+	if err != nil {
+		log.Warn().Err(err).Str("tx", abbrev(hash)).Msg("evm stream: delete reorged tx")
+		return
+	}
+	if n > 0 {
+		log.Info().Str("chain", string(st.chain)).Str("tx", abbrev(hash)).Msg("📡 reorged out, tx deleted")
+	}
+}
+
+// handleWallet stores a tracked wallet's transfer, or the swap it is part
+// of. A swap's two transfers arrive separately; only the first is passed on.
+// A transfer between two tracked wallets arrives on both subscriptions; each
+// handles its own side.
+func (st *EVMStream) handleWallet(ctx context.Context, l evmLog, outgoing bool) {
+	from, to, _, ok := parseERC20Log(l)
+	if !ok {
+		return
+	}
+	addr := to
+	if outgoing {
+		addr = from
+	}
+	st.mu.Lock()
+	w, ok := st.wallets[strings.ToLower(addr)]
+	st.mu.Unlock()
+	if !ok {
+		return
+	}
+	rpcURL := st.sc.cfg.EVMRPC[st.chain]
+	tx, ok := db.WalletTransaction{}, false
+	if swap, err := st.sc.evmSwapTx(ctx, rpcURL, w.ID, w.Address, st.chain, l.TxHash, map[string]tokenInfo{}); err == nil && swap != nil {
+		key := l.TxHash + ":" + w.Address
+		st.mu.Lock()
+		seen := st.swaps[key]
+		if len(st.swaps) > 10000 {
+			st.swaps = map[string]bool{}
+		}
+		st.swaps[key] = true
+		st.mu.Unlock()
+		if seen {
+			return
+		}
+		tx, ok = *swap, true
+	} else {
+		tx, ok = st.sc.erc20LogTx(ctx, rpcURL, w.ID, w.Address, st.chain, l, map[string]tokenInfo{})
+	}
+	if !ok {
+		return
+	}
+	if err := st.sc.store.InsertTransaction(tx); err != nil {
+		log.Warn().Err(err).Str("tx", abbrev(l.TxHash)).Msg("evm stream: store tx")
+		return
+	}
+	st.mu.Lock()
+	gone := st.reorged[l.TxHash]
+	st.mu.Unlock()
+	if gone {
+		st.dropTx(l.TxHash) // removed while it was being decoded
+		return
+	}
+	st.emit(ctx, TradeEvent{Wallet: w, Tx: tx})
+}
+
+// handleToken reports the recipient of a watched token's transfer as a
+// buyer, unless it is a contract (pool, router, the token itself) or a burn.
+func (st *EVMStream) handleToken(ctx context.Context, l evmLog) {
+	_, to, amount, ok := parseERC20Log(l)
+	if !ok || amount.Sign() == 0 {
+		return
+	}
+	to = strings.ToLower(to)
+	token := strings.ToLower(l.Address)
+	if to == zeroAddress || to == token || config.ClassifyEVMDEX(to) != "" {
+		return
+	}
+	st.mu.Lock()
+	known := st.contracts[to]
+	st.mu.Unlock()
+	if known {
+		return
+	}
+	if contract, err := st.sc.isContract(ctx, st.sc.cfg.EVMRPC[st.chain], to); err != nil || contract {
+		if contract {
+			st.mu.Lock()
+			st.contracts[to] = true
+			st.mu.Unlock()
+		}
+		return
+	}
+	st.buyers.OnTokenBuyer(ctx, st.chain, token, TokenBuyer{
+		Address:   to,
+		TxHash:    l.TxHash,
+		Timestamp: time.Now().UTC(),
+		Source:    "stream",
+		Chain:     st.chain,
+	})
+}
@@ -0,0 +1,204 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// fakeRPC is a JSON-RPC node answering each method with a canned result;
+// other methods get an error.
+func fakeRPC(t *testing.T, results map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		res, ok := results[req.Method]
+		if !ok {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"no %s"}}`, req.ID, req.Method)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func topicAddress(addr string) string {
+	return "0x000000000000000000000000" + strings.TrimPrefix(strings.ToLower(addr), "0x")
+}
+
+// streamWallet tracks address on chain and returns it as the stream sees it.
+func streamWallet(t *testing.T, store *db.SQLStore, address string, chain config.Chain) db.TrackedWallet {
+	t.Helper()
+	kolID, err := store.UpsertKOL("kol", "kol", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := store.UpsertWallet(kolID, address, chain, "main", 1, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.TrackedWallet{ID: id, KOLID: kolID, Address: address, Chain: chain, Confidence: 1}
+}
+
+func waitEvent(t *testing.T, events <-chan TradeEvent) TradeEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(10 * time.Second):
+		t.Fatal("no trade event")
+	}
+	return TradeEvent{}
+}
+
+func TestEVMStreamReorgAndDedupe(t *testing.T) {
+	s, store := newTestScanner(t)
+	srv := fakeRPC(t, map[string]string{
+		"eth_getTransactionReceipt": `{"status":"0x1","logs":[]}`,
+		"eth_getBlockByNumber":      `{"timestamp":"0x65000000"}`,
+	})
+	s.cfg.EVMRPC = map[config.Chain]string{config.ChainBase: srv.URL}
+
+	const wallet = "0x00000000000000000000000000000000000b0b01"
+	w := streamWallet(t, store, wallet, config.ChainBase)
+	st := NewEVMStream(s, config.ChainBase)
+	st.reset()
+	st.wallets[wallet] = w
+	st.subs["0x1"] = subWalletTo
+	events := make(chan TradeEvent, 4)
+	st.OnTrade(func(ctx context.Context, ev TradeEvent) { events <- ev })
+
+	notify := func(removed bool) {
+		res, _ := json.Marshal(map[string]interface{}{
+			"address": "0x00000000000000000000000000000000000070c0",
+			"topics": []string{erc20TransferTopic,
+				topicAddress("0x00000000000000000000000000000000000a11ce"), topicAddress(wallet)},
+			"data":            "0x" + strings.Repeat("0", 48) + "0de0b6b3a7640000", // 1e18
+			"blockNumber":     "0x10",
+			"transactionHash": "0xfeed",
+			"logIndex":        "0x3",
+			"removed":         removed,
+		})
+		st.notify(context.Background(), wsNotification{Method: "eth_subscription", Subscription: "0x1", Result: res})
+	}
+	stored := func() int {
+		t.Helper()
+		txs, err := store.GetTransactionsForWallet(w.ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(txs)
+	}
+
+	notify(false)
+	ev := waitEvent(t, events)
+	if ev.Tx.TxType != "transfer_in" || ev.Tx.AmountToken != 1 {
+		t.Errorf("event tx = %s %v, want transfer_in 1", ev.Tx.TxType, ev.Tx.AmountToken)
+	}
+	notify(false) // replayed after a resubscribe
+	if stored() != 1 {
+		t.Fatalf("%d txs stored, want 1", stored())
+	}
+
+	notify(true)
+	if n := stored(); n != 0 {
+		t.Errorf("%d txs left after the reorg, want 0", n)
+	}
+	if len(events) != 0 {
+		t.Errorf("%d extra events", len(events))
+	}
+
+	notify(false) // the tx made it into the replacement block
+	waitEvent(t, events)
+	if n := stored(); n != 1 {
+		t.Errorf("%d txs stored after re-inclusion, want 1", n)
+	}
+}
+
+// emitTransferCode deploys a contract that, called with (to, amount), emits
+// Transfer(msg.sender, to, amount).
+const emitTransferCode = "0x6031" + "80600b6000396000f3" +
+	"602035600052600035337f" + "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" + "60206000a300"
+
+// TestEVMStreamNode runs the stream against a dev node: start anvil (or geth
+// --dev) and set TEST_EVM_RPC_URL and TEST_EVM_WS_URL, e.g.
+// http://127.0.0.1:8545 and ws://127.0.0.1:8545.
+func TestEVMStreamNode(t *testing.T) {
+	rpcURL, wsURL := os.Getenv("TEST_EVM_RPC_URL"), os.Getenv("TEST_EVM_WS_URL")
+	if rpcURL == "" || wsURL == "" {
+		t.Skip("TEST_EVM_RPC_URL and TEST_EVM_WS_URL not set")
+	}
+	s, store := newTestScanner(t)
+	s.cfg.EVMRPC = map[config.Chain]string{config.ChainBase: rpcURL}
+	s.cfg.EVMWS = map[config.Chain]string{config.ChainBase: wsURL}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	call := func(method string, params ...interface{}) json.RawMessage {
+		t.Helper()
+		res, err := s.rpcCall(ctx, rpcURL, method, params)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		return res
+	}
+	// send submits tx from the node's first account and waits for its receipt
+	send := func(tx map[string]string) (string, json.RawMessage) {
+		t.Helper()
+		var accounts []string
+		json.Unmarshal(call("eth_accounts"), &accounts)
+		if len(accounts) == 0 {
+			t.Fatal("node has no unlocked account")
+		}
+		tx["from"] = accounts[0]
+		var hash string
+		json.Unmarshal(call("eth_sendTransaction", tx), &hash)
+		for ctx.Err() == nil {
+			if rc := call("eth_getTransactionReceipt", hash); string(rc) != "null" {
+				return hash, rc
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+		t.Fatal("tx not mined")
+		return "", nil
+	}
+	var deployed struct {
+		ContractAddress string `json:"contractAddress"`
+	}
+	_, rc := send(map[string]string{"data": emitTransferCode})
+	json.Unmarshal(rc, &deployed)
+
+	const wallet = "0x00000000000000000000000000000000000b0b02"
+	w := streamWallet(t, store, wallet, config.ChainBase)
+	st := NewEVMStream(s, config.ChainBase)
+	events := make(chan TradeEvent, 4)
+	st.OnTrade(func(ctx context.Context, ev TradeEvent) { events <- ev })
+	go st.Run(ctx)
+	for st.Stats().Subscriptions < 2 {
+		if ctx.Err() != nil {
+			t.Fatal("stream never subscribed")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	hash, _ := send(map[string]string{"to": deployed.ContractAddress,
+		"data": "0x" + strings.TrimPrefix(topicAddress(wallet), "0x") + strings.Repeat("0", 62) + "64"})
+	ev := waitEvent(t, events)
+	if ev.Wallet.ID != w.ID || ev.Tx.TxType != "transfer_in" || !strings.EqualFold(ev.Tx.TxHash, hash) {
+		t.Errorf("event = wallet %d %s %s, want wallet %d transfer_in %s", ev.Wallet.ID, ev.Tx.TxType, ev.Tx.TxHash, w.ID, hash)
+	}
+	if txs, _ := store.GetTransactionsForWallet(w.ID, 10); len(txs) != 1 {
+		t.Errorf("%d txs stored, want 1", len(txs))
+	}
+}
@@ -0,0 +1,399 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// ── Historical Prices ───────────────────────────────────────
+// Trades are valued at their own time from OHLC candles kept in the store.
+// A lookup the store can't answer fetches the bars around it — Binance klines
+// for SOL/ETH/BNB, GeckoTerminal pool OHLCV for tokens and as the fallback for
+// the native coins — stores them, and looks again.
+
+const (
+	binanceAPI       = "https://api.binance.com/api/v3"
+	geckoTerminalAPI = "https://api.geckoterminal.com/api/v2"
+)
+
+// priceSpotWindow: trades younger than this are valued at the spot price,
+// since their candle may not be closed or indexed yet.
+const priceSpotWindow = 5 * time.Minute
+
+// candleBatch is how many bars one fetch asks for, centred on the trade.
+const candleBatch = 500
+
+// candleResolution is a bar size tried for a lookup, with how long before
+// the trade its bar may open and still price it (DEX OHLCV skips minutes
+// without trades).
+type candleResolution struct {
+	secs    int
+	maxAge  time.Duration
+	binance string // kline interval
+	gecko   string // ohlcv timeframe
+}
+
+var candleResolutions = []candleResolution{
+	{60, 15 * time.Minute, "1m", "minute"},
+	{3600, 2 * time.Hour, "1h", "hour"},
+	{86400, 48 * time.Hour, "1d", "day"},
+}
+
+// errNoPrice means no source had a price for the asset at that time.
+var errNoPrice = errors.New("no price data")
+
+// candleFetches remembers the windows already fetched, so a gap in the
+// source's data isn't refetched on every lookup. Entries expire after an
+// hour, when the source may have filled the gap.
+var (
+	candleFetches     = map[string]time.Time{}
+	candleFetchesLock sync.Mutex
+)
+
+// priceAsset is the candle key for token on chain. "" (and the 0xeeee…
+// placeholder, and the wrapped coin) mean the native coin.
+func priceAsset(chain config.Chain, token string) (asset string, native bool) {
+	ci, _ := config.LookupChain(chain)
+	if token == "" || token == evmNative || strings.EqualFold(token, ci.WrappedNative) {
+		return "native:" + nativeSymbol(chain), true
+	}
+	if chain.IsEVM() {
+		token = strings.ToLower(token)
+	}
+	return string(chain) + ":" + token, false
+}
+
+// PriceAt returns the USD price of token on chain at time at ("" is the
+// chain's native coin). Stablecoins are $1; recent trades use the spot price.
+// Older ones are priced from candles only: without a bar it returns an error
+// rather than today's price, which would pass for a historical one.
+func (s *Scanner) PriceAt(ctx context.Context, chain config.Chain, token string, at time.Time) (float64, error) {
+	if config.IsStablecoin(chain, token) {
+		return 1, nil
+	}
+	asset, native := priceAsset(chain, token)
+	if native && (at.IsZero() || time.Since(at) < priceSpotWindow) {
+		return s.getNativePrice(ctx, chain), nil
+	}
+	if at.IsZero() {
+		return 0, errNoPrice
+	}
+
+	for _, r := range candleResolutions {
+		if c, err := s.store.GetCandleAt(asset, r.secs, at, r.maxAge); err == nil && c != nil {
+			return c.Close, nil
+		}
+	}
+	var lastErr error = errNoPrice
+	for _, r := range candleResolutions {
+		if !claimCandleFetch(asset, r, at) {
+			continue
+		}
+		candles, err := s.fetchCandles(ctx, chain, asset, token, native, r, at)
+		if err != nil {
+			log.Debug().Err(err).Str("asset", asset).Int("res", r.secs).Msg("candle fetch failed")
+			lastErr = err
+			continue
+		}
+		if err := s.store.UpsertCandles(candles); err != nil {
+			return 0, err
+		}
+		if c, err := s.store.GetCandleAt(asset, r.secs, at, r.maxAge); err == nil && c != nil {
+			return c.Close, nil
+		}
+	}
+	return 0, lastErr
+}
+
+// usdAt values amount of token at time at, or 0 if there is no price.
+func (s *Scanner) usdAt(ctx context.Context, chain config.Chain, token string, amount float64, at time.Time) float64 {
+	if amount == 0 {
+		return 0
+	}
+	p, err := s.PriceAt(ctx, chain, token, at)
+	if err != nil {
+		return 0
+	}
+	return amount * p
+}
+
+// quoteUSDAt values the quote side of a trade at its time. asset is a
+// symbol (ETH, WETH, USDC...) or a Solana mint; only the native coin, its
+// wrapped form and stablecoins are quote assets, anything else is 0.
+func (s *Scanner) quoteUSDAt(ctx context.Context, chain config.Chain, asset string, amount float64, at time.Time) float64 {
+	switch {
+	case asset == nativeSymbol(chain), asset == "W"+nativeSymbol(chain), asset == wsolMint:
+		return s.usdAt(ctx, chain, "", amount, at)
+	case explorerStables[asset], solQuoteMints[asset]:
+		return amount
+	}
+	return 0
+}
+
+// claimCandleFetch reports whether the window of bars around at still needs
+// fetching, and marks it fetched.
+func claimCandleFetch(asset string, r candleResolution, at time.Time) bool {
+	window := at.Unix() / int64(r.secs*candleBatch)
+	key := fmt.Sprintf("%s|%d|%d", asset, r.secs, window)
+	candleFetchesLock.Lock()
+	defer candleFetchesLock.Unlock()
+	if t, ok := candleFetches[key]; ok && time.Since(t) < time.Hour {
+		return false
+	}
+	candleFetches[key] = time.Now()
+	return true
+}
+
+// fetchCandles gets the bars of one resolution around at: Binance for the
+// native coins, GeckoTerminal for tokens or when Binance fails.
+func (s *Scanner) fetchCandles(ctx context.Context, chain config.Chain, asset, token string, native bool, r candleResolution, at time.Time) ([]db.PriceCandle, error) {
+	if native {
+		candles, err := s.binanceKlines(ctx, asset, nativeSymbol(chain)+"USDT", r, at)
+		if err == nil && len(candles) > 0 {
+			return candles, nil
+		}
+		ci, _ := config.LookupChain(chain)
+		token = ci.WrappedNative
+	}
+	return s.geckoOHLCV(ctx, chain, asset, token, r, at)
+}
+
+// binanceKlines fetches candleBatch bars of symbol centred on at.
+func (s *Scanner) binanceKlines(ctx context.Context, asset, symbol string, r candleResolution, at time.Time) ([]db.PriceCandle, error) {
+	start := at.Add(-time.Duration(r.secs*candleBatch/2) * time.Second)
+	body, err := s.getJSON(ctx, endpoint(binanceAPI, []string{"klines"}, url.Values{
+		"symbol":    {symbol},
+		"interval":  {r.binance},
+		"startTime": {strconv.FormatInt(start.UnixMilli(), 10)},
+		"limit":     {strconv.Itoa(candleBatch)},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	// [openTime, open, high, low, close, volume, closeTime, ...]
+	var rows [][]json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("binance klines: %w", err)
+	}
+	var out []db.PriceCandle
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		var openMs int64
+		var o, h, l, c string
+		json.Unmarshal(row[0], &openMs)
+		json.Unmarshal(row[1], &o)
+		json.Unmarshal(row[2], &h)
+		json.Unmarshal(row[3], &l)
+		json.Unmarshal(row[4], &c)
+		if parseFloat(c) <= 0 {
+			continue
+		}
+		out = append(out, db.PriceCandle{
+			Asset: asset, Resolution: r.secs, OpenTime: time.UnixMilli(openMs).UTC(),
+			Open: parseFloat(o), High: parseFloat(h), Low: parseFloat(l), Close: parseFloat(c),
+			Source: "binance",
+		})
+	}
+	return out, nil
+}
+
+// geckoOHLCV fetches candleBatch bars of token's USD price ending after at,
+// from its most liquid pool on GeckoTerminal.
+func (s *Scanner) geckoOHLCV(ctx context.Context, chain config.Chain, asset, token string, r candleResolution, at time.Time) ([]db.PriceCandle, error) {
+	ci, _ := config.LookupChain(chain)
+	network := ci.GeckoNetwork
+	if network == "" || token == "" {
+		return nil, errNoPrice
+	}
+	pool, err := s.geckoTopPool(ctx, network, token)
+	if err != nil {
+		return nil, err
+	}
+	before := at.Add(time.Duration(r.secs*candleBatch/2) * time.Second)
+	if before.After(time.Now()) {
+		before = time.Now()
+	}
+	body, err := s.getJSON(ctx, endpoint(geckoTerminalAPI, []string{"networks", network, "pools", pool, "ohlcv", r.gecko}, url.Values{
+		"before_timestamp": {strconv.FormatInt(before.Unix(), 10)},
+		"limit":            {strconv.Itoa(candleBatch)},
+		"currency":         {"usd"},
+		"token":            {token},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data struct {
+			Attributes struct {
+				OHLCV [][]float64 `json:"ohlcv_list"` // [time, open, high, low, close, volume]
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("geckoterminal ohlcv: %w", err)
+	}
+	var out []db.PriceCandle
+	for _, row := range resp.Data.Attributes.OHLCV {
+		if len(row) < 5 || row[4] <= 0 {
+			continue
+		}
+		out = append(out, db.PriceCandle{
+			Asset: asset, Resolution: r.secs, OpenTime: time.Unix(int64(row[0]), 0).UTC(),
+			Open: row[1], High: row[2], Low: row[3], Close: row[4],
+			Source: "geckoterminal",
+		})
+	}
+	return out, nil
+}
+
+// geckoPools caches each token's most liquid pool address.
+var (
+	geckoPools     = map[string]string{}
+	geckoPoolsLock sync.Mutex
+)
+
+func (s *Scanner) geckoTopPool(ctx context.Context, network, token string) (string, error) {
+	key := network + ":" + token
+	geckoPoolsLock.Lock()
+	pool, ok := geckoPools[key]
+	geckoPoolsLock.Unlock()
+	if ok {
+		return pool, nil
+	}
+	body, err := s.getJSON(ctx, endpoint(geckoTerminalAPI, []string{"networks", network, "tokens", token, "pools"}, nil))
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Data []struct {
+			Attributes struct {
+				Address string `json:"address"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("geckoterminal pools: %w", err)
+	}
+	if len(resp.Data) == 0 || resp.Data[0].Attributes.Address == "" {
+		return "", errNoPrice
+	}
+	pool = resp.Data[0].Attributes.Address // sorted by liquidity
+	geckoPoolsLock.Lock()
+	geckoPools[key] = pool
+	geckoPoolsLock.Unlock()
+	return pool, nil
+}
+
+// ── Repricing ───────────────────────────────────────────────
+
+// RepriceStats summarizes a RepriceTransactions run.
+type RepriceStats struct {
+	Checked  int `json:"checked"`
+	Updated  int `json:"updated"`
+	Unpriced int `json:"unpriced"`
+}
+
+// RepriceTransactions revalues stored transactions from since onwards at
+// their own time, fetching any candles that are missing. With dryRun the
+// new values are computed but not written.
+func (s *Scanner) RepriceTransactions(ctx context.Context, since time.Time, dryRun bool) (RepriceStats, error) {
+	var st RepriceStats
+	var after int64
+	for {
+		page, err := s.store.GetTransactionsAfter(after, since, 500)
+		if err != nil {
+			return st, err
+		}
+		for _, t := range page {
+			if ctx.Err() != nil {
+				return st, ctx.Err()
+			}
+			after = t.ID
+			st.Checked++
+			usd, ok := s.tradeUSD(ctx, t)
+			if !ok {
+				st.Unpriced++
+				continue
+			}
+			if math.Abs(usd-t.AmountUSD) < 0.01 {
+				continue
+			}
+			if !dryRun {
+				if err := s.store.UpdateTransactionUSD(t.ID, usd); err != nil {
+					return st, err
+				}
+			}
+			st.Updated++
+		}
+		if len(page) < 500 {
+			return st, nil
+		}
+	}
+}
+
+// tradeUSD values a stored transaction at its own time from what the row
+// records: the quote leg of a decoded swap (metadata), a native or
+// stablecoin amount, or else the token amount at the token's own price.
+func (s *Scanner) tradeUSD(ctx context.Context, t db.WalletTransaction) (float64, bool) {
+	var m struct {
+		InMint    string  `json:"in_mint"`
+		InAmount  float64 `json:"in_amount"`
+		OutMint   string  `json:"out_mint"`
+		OutAmount float64 `json:"out_amount"`
+		TokenIn   string  `json:"token_in"`
+		AmountIn  float64 `json:"amount_in"`
+		TokenOut  string  `json:"token_out"`
+		AmountOut float64 `json:"amount_out"`
+	}
+	if t.Metadata != "" && json.Unmarshal([]byte(t.Metadata), &m) == nil {
+		legs := []struct {
+			token  string
+			amount float64
+		}{{m.InMint, m.InAmount}, {m.OutMint, m.OutAmount}, {m.TokenIn, m.AmountIn}, {m.TokenOut, m.AmountOut}}
+		for _, l := range legs {
+			if l.token != "" && l.amount > 0 && isQuoteToken(t.Chain, l.token) {
+				p, err := s.PriceAt(ctx, t.Chain, l.token, t.Timestamp)
+				return l.amount * p, err == nil && p > 0
+			}
+		}
+	}
+
+	var token string
+	switch {
+	case t.TokenAddress == "" && t.TokenSymbol == nativeSymbol(t.Chain):
+		token = ""
+	case t.TokenAddress == "" && explorerStables[t.TokenSymbol]:
+		return t.AmountToken, true
+	case t.TokenAddress != "":
+		token = t.TokenAddress
+	default:
+		return 0, false
+	}
+	p, err := s.PriceAt(ctx, t.Chain, token, t.Timestamp)
+	if err != nil || p == 0 {
+		return 0, false
+	}
+	return t.AmountToken * p, true
+}
+
+// isQuoteToken reports whether token is the chain's coin, its wrapped form
+// or a stablecoin.
+func isQuoteToken(chain config.Chain, token string) bool {
+	_, native := priceAsset(chain, token)
+	return native || config.IsStablecoin(chain, token)
+}
@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+func TestRepriceLeavesUnpricedRows(t *testing.T) {
+	s, store := newTestScanner(t)
+	useAPIs(t, s, fakeAPIs{}) // every candle and spot price source is down
+	w := streamWallet(t, store, "RepriceWa11et", config.ChainSolana)
+
+	priced := time.Now().UTC().Add(-72 * time.Hour).Truncate(time.Minute)
+	unpriced := priced.Add(-24 * time.Hour)
+	if err := store.UpsertCandles([]db.PriceCandle{{Asset: "native:SOL", Resolution: 60, OpenTime: priced, Close: 150}}); err != nil {
+		t.Fatal(err)
+	}
+	for hash, at := range map[string]time.Time{"priced": priced, "unpriced": unpriced} {
+		if err := store.InsertTransaction(db.WalletTransaction{WalletID: w.ID, TxHash: hash, Chain: config.ChainSolana,
+			TxType: "transfer_in", TokenSymbol: "SOL", AmountToken: 2, AmountUSD: 123, Timestamp: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := s.RepriceTransactions(context.Background(), time.Time{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Checked != 2 || st.Updated != 1 || st.Unpriced != 1 {
+		t.Errorf("stats = %+v, want 2 checked, 1 updated, 1 unpriced", st)
+	}
+	txs, err := store.GetTransactionsForWallet(w.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range txs {
+		want := map[string]float64{"priced": 300, "unpriced": 123}[tx.TxHash]
+		if !near(tx.AmountUSD, want) {
+			t.Errorf("%s amount_usd = %v, want %v", tx.TxHash, tx.AmountUSD, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// ChainDataProvider is one source of on-chain data: an indexer API, a block
+// explorer or a raw node. Providers implement what their backend can serve
+// and return ErrUnsupported for the rest. The Scanner tries the providers
+// that support a chain in configured priority order (cfg.ScanProviders) and
+// falls back to the next one when a call fails.
+type ChainDataProvider interface {
+	Name() string
+	// Supports reports whether the provider is configured for chain.
+	Supports(chain config.Chain) bool
+	// FetchTransactions stores the wallet's transactions newer than its
+	// scan cursors, advances the cursors and returns how many were stored.
+	FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error)
+	FetchFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error)
+	LinkedWallets(ctx context.Context, address string, chain config.Chain) ([]db.FundingSource, error)
+	TokenBuyers(ctx context.Context, tokenAddr string, chain config.Chain) ([]TokenBuyer, error)
+	// IdentifyAddress classifies an address ("contract", a service label or
+	// "unknown"). Static known-address lists are checked before providers.
+	IdentifyAddress(ctx context.Context, address string, chain config.Chain) (string, error)
+}
+
+// historyBackfiller is implemented by providers that can walk a wallet's
+// full history (see BackfillWallet).
+type historyBackfiller interface {
+	Backfill(ctx context.Context, walletID int64, address string, chain config.Chain, maxPages int) (int, bool, error)
+}
+
+// ErrUnsupported is returned by a provider for calls its backend can't serve;
+// the scanner moves on to the next provider without logging a failure.
+var ErrUnsupported = errors.New("not supported by provider")
+
+// errNoProvider means no configured provider could serve a call.
+var errNoProvider = errors.New("no data provider configured")
+
+// unsupported is embedded by providers to default every call to ErrUnsupported.
+type unsupported struct{}
+
+func (unsupported) FetchTransactions(context.Context, int64, string, config.Chain) (int, error) {
+	return 0, ErrUnsupported
+}
+func (unsupported) FetchFunding(context.Context, string, config.Chain) (*db.FundingAnalysis, error) {
+	return nil, ErrUnsupported
+}
+func (unsupported) LinkedWallets(context.Context, string, config.Chain) ([]db.FundingSource, error) {
+	return nil, ErrUnsupported
+}
+func (unsupported) TokenBuyers(context.Context, string, config.Chain) ([]TokenBuyer, error) {
+	return nil, ErrUnsupported
+}
+func (unsupported) IdentifyAddress(context.Context, string, config.Chain) (string, error) {
+	return "", ErrUnsupported
+}
+
+// defaultProviders builds the built-in providers named in cfg.ScanProviders,
+// in that order. They share the scanner's throttled client, store and prices.
+func defaultProviders(s *Scanner) []ChainDataProvider {
+	all := map[string]ChainDataProvider{
+		"helius":     &heliusProvider{Scanner: s},
+		"solana_rpc": &solanaRPCProvider{Scanner: s},
+		"evm_rpc":    &evmRPCProvider{Scanner: s},
+		"etherscan":  &etherscanProvider{Scanner: s},
+		"trongrid":   &tronProvider{Scanner: s},
+		"esplora":    &esploraProvider{Scanner: s},
+		"birdeye":    &birdeyeProvider{Scanner: s},
+		"solscan":    &solscanProvider{Scanner: s},
+	}
+	var out []ChainDataProvider
+	for _, name := range s.cfg.ScanProviders {
+		if p, ok := all[name]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// SetProviders replaces the scanner's providers, highest priority first,
+// e.g. with fakes in tests.
+func (s *Scanner) SetProviders(ps ...ChainDataProvider) {
+	s.providers = ps
+}
+
+// withFallback calls fn on each provider that supports chain until one
+// succeeds. Providers returning ErrUnsupported are skipped quietly; other
+// failures are logged and the last one is returned if nobody succeeds.
+func withFallback[T any](s *Scanner, chain config.Chain, op string, fn func(ChainDataProvider) (T, error)) (T, error) {
+	var last T
+	err := fmt.Errorf("%s on %s: %w", op, chain, errNoProvider)
+	for _, p := range s.providers {
+		if !p.Supports(chain) {
+			continue
+		}
+		v, e := fn(p)
+		if e == nil {
+			return v, nil
+		}
+		if errors.Is(e, ErrUnsupported) {
+			continue
+		}
+		log.Debug().Err(e).Str("provider", p.Name()).Str("op", op).Str("chain", string(chain)).Msg("provider failed, trying next")
+		last, err = v, fmt.Errorf("%s: %w", p.Name(), e)
+	}
+	return last, err
+}
+
+// endpoint joins an API base, path segments and query parameters into a URL,
+// escaping each piece.
+func endpoint(base string, path []string, q url.Values) string {
+	u := strings.TrimRight(base, "/")
+	for _, seg := range path {
+		u += "/" + url.PathEscape(seg)
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// fakeProvider serves one chain: FetchTransactions and IdentifyAddress
+// return its fields, the rest is unsupported.
+type fakeProvider struct {
+	unsupported
+	name  string
+	chain config.Chain
+	txs   int
+	label string
+	err   error
+	calls int
+}
+
+func (f *fakeProvider) Name() string                     { return f.name }
+func (f *fakeProvider) Supports(chain config.Chain) bool { return chain == f.chain }
+
+func (f *fakeProvider) FetchTransactions(context.Context, int64, string, config.Chain) (int, error) {
+	f.calls++
+	return f.txs, f.err
+}
+
+func (f *fakeProvider) IdentifyAddress(context.Context, string, config.Chain) (string, error) {
+	f.calls++
+	return f.label, f.err
+}
+
+func TestScanWalletFallback(t *testing.T) {
+	ok := func(name string, n int) *fakeProvider {
+		return &fakeProvider{name: name, chain: config.ChainBase, txs: n, label: name}
+	}
+	failing := func(name string, err error) *fakeProvider {
+		return &fakeProvider{name: name, chain: config.ChainBase, err: err}
+	}
+	tests := []struct {
+		name      string
+		providers []*fakeProvider
+		want      int
+		wantErr   string // substring; "" for success
+		wantCalls []int
+	}{
+		{name: "first answers", providers: []*fakeProvider{ok("a", 3), ok("b", 7)}, want: 3, wantCalls: []int{1, 0}},
+		{
+			name:      "unsupported is skipped",
+			providers: []*fakeProvider{failing("a", ErrUnsupported), ok("b", 2)},
+			want:      2, wantCalls: []int{1, 1},
+		},
+		{
+			name:      "failure falls through",
+			providers: []*fakeProvider{failing("a", errors.New("rate limited")), ok("b", 5)},
+			want:      5, wantCalls: []int{1, 1},
+		},
+		{
+			name:      "other chains are not asked",
+			providers: []*fakeProvider{{name: "sol", chain: config.ChainSolana, txs: 9}, ok("b", 1)},
+			want:      1, wantCalls: []int{0, 1},
+		},
+		{
+			name:      "last failure is returned",
+			providers: []*fakeProvider{failing("a", errors.New("timeout")), failing("b", errors.New("HTTP 500"))},
+			wantErr:   "b: HTTP 500", wantCalls: []int{1, 1},
+		},
+		{
+			name:      "unsupported everywhere",
+			providers: []*fakeProvider{failing("a", ErrUnsupported)},
+			wantErr:   errNoProvider.Error(), wantCalls: []int{1},
+		},
+		{name: "no providers", wantErr: errNoProvider.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestScanner(t)
+			var ps []ChainDataProvider
+			for _, p := range tt.providers {
+				ps = append(ps, p)
+			}
+			s.SetProviders(ps...)
+
+			n, err := s.ScanWallet(context.Background(), 1, "0xwallet", config.ChainBase)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || n != tt.want {
+				t.Errorf("ScanWallet = %d, %v; want %d", n, err, tt.want)
+			}
+			for i, p := range tt.providers {
+				if p.calls != tt.wantCalls[i] {
+					t.Errorf("provider %s called %d times, want %d", p.name, p.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIdentifyAddressFallback(t *testing.T) {
+	s, _ := newTestScanner(t)
+	down := &fakeProvider{name: "down", chain: config.ChainBase, err: errors.New("HTTP 502")}
+	up := &fakeProvider{name: "up", chain: config.ChainBase, label: "contract"}
+	s.SetProviders(down, up)
+	ctx := context.Background()
+
+	ff := config.FixedFloatAddresses(config.ChainBase)[0]
+	if got := s.identifyAddress(ctx, strings.ToLower(ff), config.ChainBase); got != "fixedfloat" {
+		t.Errorf("known FixedFloat wallet = %q", got)
+	}
+	if down.calls+up.calls != 0 {
+		t.Error("providers asked about a statically known address")
+	}
+	if got := s.identifyAddress(ctx, "0x00000000000000000000000000000000000c0de1", config.ChainBase); got != "contract" {
+		t.Errorf("fallback label = %q, want contract", got)
+	}
+	up.err = errors.New("HTTP 502")
+	if got := s.identifyAddress(ctx, "0x00000000000000000000000000000000000c0de1", config.ChainBase); got != "unknown" {
+		t.Errorf("label with every provider down = %q, want unknown", got)
+	}
+
+	fa, err := s.CheckFunding(ctx, "0xwallet", config.ChainBase)
+	if err != nil || fa == nil || fa.NativeSymbol != "ETH" {
+		t.Errorf("funding with no provider = %+v, %v; want an empty analysis", fa, err)
+	}
+	if linked, err := s.FindLinkedWallets(ctx, "0xwallet", config.ChainBase, 1); linked != nil || err != nil {
+		t.Errorf("linked wallets with no provider = %v, %v; want nil, nil", linked, err)
+	}
+}
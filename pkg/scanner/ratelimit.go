@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows rate requests/second on average, bursting up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// throttledTransport rate-limits outgoing requests with one token bucket per
+// API host, so Helius, each Etherscan-family key and each RPC endpoint are
+// throttled independently no matter how many scan workers share them.
+type throttledTransport struct {
+	next  http.RoundTripper
+	rates map[string]float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	waiting map[string]int
+}
+
+func newThrottledTransport(rates map[string]float64, next http.RoundTripper) *throttledTransport {
+	return &throttledTransport{next: next, rates: rates, buckets: map[string]*tokenBucket{}, waiting: map[string]int{}}
+}
+
+func (t *throttledTransport) bucket(host string) *tokenBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.buckets[host]
+	if !ok {
+		rate, ok := t.rates[host]
+		if !ok {
+			rate = t.rates["default"]
+		}
+		if rate <= 0 {
+			return nil // unlimited
+		}
+		b = newTokenBucket(rate)
+		t.buckets[host] = b
+	}
+	return b
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	if b := t.bucket(host); b != nil {
+		t.mu.Lock()
+		t.waiting[host]++
+		t.mu.Unlock()
+		err := b.wait(req.Context())
+		t.mu.Lock()
+		t.waiting[host]--
+		t.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// ProviderStat is one API host's limiter state.
+type ProviderStat struct {
+	Host    string  `json:"host"`
+	Rate    float64 `json:"rate_per_sec"`
+	Waiting int     `json:"waiting"`
+}
+
+func (t *throttledTransport) stats() []ProviderStat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]ProviderStat, 0, len(t.buckets))
+	for host, b := range t.buckets {
+		out = append(out, ProviderStat{Host: host, Rate: b.rate, Waiting: t.waiting[host]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
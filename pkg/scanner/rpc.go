@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -44,8 +46,41 @@ type rpcError struct {
 	Message string `json:"message"`
 }
 
-// rpcCall performs a JSON-RPC call to the given EVM RPC endpoint.
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// rpcCall performs a JSON-RPC call against the pool rpcURL belongs to,
+// starting at its first healthy endpoint and failing over to the next on
+// transport errors, bad responses or node-side errors. URLs outside any pool
+// are called directly.
 func (s *Scanner) rpcCall(ctx context.Context, rpcURL, method string, params []interface{}) (json.RawMessage, error) {
+	pool := s.poolFor(rpcURL)
+	if pool == nil {
+		return s.rpcDo(ctx, rpcURL, method, params)
+	}
+	var lastErr error
+	for _, u := range pool.order() {
+		result, err := s.rpcDo(ctx, u, method, params)
+		switch {
+		case err == nil:
+			pool.record(u, nil)
+			return result, nil
+		case ctx.Err() != nil:
+			return nil, err
+		case !retryable(err):
+			pool.record(u, nil) // the node answered; the call itself is bad
+			return nil, err
+		}
+		pool.record(u, err)
+		log.Debug().Err(err).Str("chain", string(pool.chain)).Str("rpc", config.RedactURL(u)).Str("method", method).Msg("rpc call failed, trying next endpoint")
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// rpcDo sends one JSON-RPC request to a single endpoint.
+func (s *Scanner) rpcDo(ctx context.Context, rpcURL, method string, params []interface{}) (json.RawMessage, error) {
 	reqBody, _ := json.Marshal(rpcRequest{
 		JSONRPC: "2.0",
 		Method:  method,
@@ -69,13 +104,16 @@ func (s *Scanner) rpcCall(ctx context.Context, rpcURL, method string, params []i
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rpc http %d", resp.StatusCode)
+	}
 
 	var rpcResp rpcResponse
 	if err := json.Unmarshal(body, &rpcResp); err != nil {
 		return nil, fmt.Errorf("rpc unmarshal: %w", err)
 	}
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("rpc error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return nil, rpcResp.Error
 	}
 	return rpcResp.Result, nil
 }
@@ -107,8 +145,8 @@ func (s *Scanner) getERC20Transfers(ctx context.Context, rpcURL, address string,
 		"toBlock":   toBlock,
 		"topics":    []interface{}{erc20TransferTopic, nil, paddedAddr},
 	}
-	resultTo, err := s.rpcCall(ctx, rpcURL, "eth_getLogs", []interface{}{filterTo})
-	if err == nil {
+	resultTo, errTo := s.rpcCall(ctx, rpcURL, "eth_getLogs", []interface{}{filterTo})
+	if errTo == nil {
 		var logs []evmLog
 		json.Unmarshal(resultTo, &logs)
 		allLogs = append(allLogs, logs...)
@@ -120,14 +158,18 @@ func (s *Scanner) getERC20Transfers(ctx context.Context, rpcURL, address string,
 		"toBlock":   toBlock,
 		"topics":    []interface{}{erc20TransferTopic, paddedAddr},
 	}
-	resultFrom, err := s.rpcCall(ctx, rpcURL, "eth_getLogs", []interface{}{filterFrom})
-	if err == nil {
+	resultFrom, errFrom := s.rpcCall(ctx, rpcURL, "eth_getLogs", []interface{}{filterFrom})
+	if errFrom == nil {
 		var logs []evmLog
 		json.Unmarshal(resultFrom, &logs)
 		allLogs = append(allLogs, logs...)
 	}
 
-	return allLogs, nil
+	// partial results are still returned; the error keeps the cursor in place
+	if errTo != nil {
+		return allLogs, errTo
+	}
+	return allLogs, errFrom
 }
 
 // parseERC20Log extracts from, to, amount from an ERC-20 Transfer log entry.
@@ -146,17 +188,77 @@ func parseERC20Log(l evmLog) (from, to string, amount *big.Int, ok bool) {
 	return from, to, amount, true
 }
 
+// erc20LogTx turns a Transfer log involving address into the wallet's
+// transaction: a stablecoin leaving is a buy, one arriving a sell, and other
+// tokens are plain transfers. Stablecoin and WETH/WBNB legs are valued at
+// the block's time.
+func (s *Scanner) erc20LogTx(ctx context.Context, rpcURL string, walletID int64, address string, chain config.Chain, l evmLog, cache map[string]tokenInfo) (db.WalletTransaction, bool) {
+	from, to, amount, ok := parseERC20Log(l)
+	if !ok || amount.Sign() == 0 {
+		return db.WalletTransaction{}, false
+	}
+
+	stables := map[string]bool{
+		"USDC": true, "USDT": true, "BUSD": true, "DAI": true,
+		"WETH": true, "WBNB": true, "FRAX": true,
+		"WPOL": true, "WAVAX": true, "USDB": true, "USDC.e": true,
+	}
+
+	tokenAddr := strings.ToLower(l.Address)
+	info := s.getTokenInfo(ctx, rpcURL, tokenAddr, cache)
+
+	value := tokenValueBig(amount, info.decimals)
+
+	txType := "transfer_in"
+	if strings.EqualFold(from, address) {
+		txType = "transfer_out"
+		if stables[info.symbol] {
+			txType = "swap_buy"
+		}
+	} else if strings.EqualFold(to, address) && stables[info.symbol] {
+		txType = "swap_sell"
+	}
+
+	ts := s.getBlockTimestamp(ctx, rpcURL, l.BlockNumber)
+	amountUSD := s.quoteUSDAt(ctx, chain, info.symbol, value, ts)
+
+	// DEX detection
+	counterparty := to
+	if strings.EqualFold(to, address) {
+		counterparty = from
+	}
+	platform := ""
+	if dex := config.ClassifyEVMDEX(counterparty); dex != "" {
+		platform = dex
+	}
+
+	return db.WalletTransaction{
+		WalletID:     walletID,
+		TxHash:       l.TxHash,
+		Chain:        chain,
+		TxType:       txType,
+		TokenAddress: tokenAddr,
+		TokenSymbol:  info.symbol,
+		AmountToken:  value,
+		AmountUSD:    amountUSD,
+		FromAddress:  from,
+		ToAddress:    to,
+		Timestamp:    ts,
+		Platform:     platform,
+	}, true
+}
+
 // ── eth_getCode: Contract Detection ─────────────────────────
 // Replaces Etherscan contract ABI check.
 
-func (s *Scanner) isContract(ctx context.Context, rpcURL, address string) bool {
+func (s *Scanner) isContract(ctx context.Context, rpcURL, address string) (bool, error) {
 	result, err := s.rpcCall(ctx, rpcURL, "eth_getCode", []interface{}{address, "latest"})
 	if err != nil {
-		return false
+		return false, err
 	}
 	var code string
 	json.Unmarshal(result, &code)
-	return code != "0x" && code != "0x0" && len(code) > 4
+	return code != "0x" && code != "0x0" && len(code) > 4, nil
 }
 
 // ── eth_getBalance: Native balance ──────────────────────────
@@ -209,115 +311,96 @@ func (s *Scanner) getBlockTimestamp(ctx context.Context, rpcURL string, blockHex
 // ── RPC-based EVM Scanner ───────────────────────────────────
 // Uses JSON-RPC directly instead of Etherscan API.
 
-// scanEVMviaRPC scans an EVM wallet using direct JSON-RPC calls to Chainstack.
-// Falls back to Etherscan if RPC URL is not configured.
-func (s *Scanner) scanEVMviaRPC(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
-	rpcURL := s.cfg.EVMRPC[chain]
-	if rpcURL == "" {
-		// No RPC configured — fall back to Etherscan
-		return s.scanEVM(ctx, walletID, address, chain)
-	}
+// evmRPCProvider scans EVM wallets over JSON-RPC (Chainstack or any node):
+// ERC-20 transfers from eth_getLogs, native transfers from the explorer's
+// txlist when a key is set.
+type evmRPCProvider struct {
+	unsupported
+	*Scanner
+}
+
+func (r *evmRPCProvider) Name() string { return "evm_rpc" }
+
+func (r *evmRPCProvider) Supports(chain config.Chain) bool {
+	return chain.IsEVM() && r.cfg.EVMRPC[chain] != ""
+}
+
+// FetchTransactions scans an EVM wallet using direct JSON-RPC calls.
+func (r *evmRPCProvider) FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
+	rpcURL := r.cfg.EVMRPC[chain]
 
 	native := nativeSymbol(chain)
-	nativePrice := s.getNativePrice(ctx, chain)
 	count := 0
 
 	// Get current block to set scan range
-	currentBlock, err := s.getBlockNumber(ctx, rpcURL)
+	currentBlock, err := r.getBlockNumber(ctx, rpcURL)
 	if err != nil {
-		log.Warn().Err(err).Str("chain", string(chain)).Msg("RPC getBlockNumber failed, falling back to Etherscan")
-		return s.scanEVM(ctx, walletID, address, chain)
+		return 0, fmt.Errorf("eth_blockNumber: %w", err)
 	}
 
-	// Scan last ~50K blocks (~7 days on ETH, ~2 days on BSC, ~3 days on Base)
+	// Scan from the last pass's block, but never more than the last ~50K
+	// blocks (~7 days on ETH, ~2 days on BSC, ~3 days on Base); anything
+	// older is left to backfill.
 	fromBlock := currentBlock - 50000
 	if fromBlock < 0 {
 		fromBlock = 0
 	}
+	cursor, _ := r.store.GetScanCursor(walletID, "rpc:logs")
+	if c := parseInt64(cursor); cursor != "" && c >= fromBlock {
+		fromBlock = c
+	} else if cursor != "" {
+		log.Warn().Str("addr", abbrev(address)).Str("chain", string(chain)).
+			Int64("from", c).Int64("to", fromBlock).Msg("log cursor too old for one pass; run backfill to fill the gap")
+	}
 	fromHex := fmt.Sprintf("0x%x", fromBlock)
-	toHex := "latest"
+	toHex := fmt.Sprintf("0x%x", currentBlock)
 
 	// 1. ERC-20 Token Transfers via eth_getLogs
-	logs, err := s.getERC20Transfers(ctx, rpcURL, address, fromHex, toHex)
+	logs, err := r.getERC20Transfers(ctx, rpcURL, address, fromHex, toHex)
 	if err != nil {
 		log.Warn().Err(err).Msg("eth_getLogs failed")
 	}
 
-	stables := map[string]bool{
-		"USDC": true, "USDT": true, "BUSD": true, "DAI": true,
-		"WETH": true, "WBNB": true, "FRAX": true,
-	}
-
 	// Track token addresses to get symbol/decimals
 	tokenInfoCache := map[string]tokenInfo{}
 
+	// Swaps are decoded from their receipt into one row; the rest are
+	// stored transfer by transfer
+	pass := r.newSwapPass(walletID, address, chain, tokenInfoCache)
 	for _, l := range logs {
-		from, to, amount, ok := parseERC20Log(l)
-		if !ok || amount.Sign() == 0 {
-			continue
-		}
-
-		tokenAddr := strings.ToLower(l.Address)
-		info := s.getTokenInfo(ctx, rpcURL, tokenAddr, tokenInfoCache)
-
-		value := tokenValueBig(amount, info.decimals)
-
-		txType := "transfer_in"
-		if strings.EqualFold(from, address) {
-			txType = "transfer_out"
-			if stables[info.symbol] {
-				txType = "swap_buy"
+		if swap, stored := pass.store(ctx, l.TxHash); swap {
+			if stored {
+				count++
 			}
-		} else if strings.EqualFold(to, address) && stables[info.symbol] {
-			txType = "swap_sell"
-		}
-
-		amountUSD := 0.0
-		if stables[info.symbol] {
-			amountUSD = value
-		}
-
-		// DEX detection
-		counterparty := to
-		if strings.EqualFold(to, address) {
-			counterparty = from
-		}
-		platform := ""
-		if dex := config.ClassifyEVMDEX(counterparty); dex != "" {
-			platform = dex
+			continue
 		}
-
-		ts := s.getBlockTimestamp(ctx, rpcURL, l.BlockNumber)
-
-		if s.store.InsertTransaction(db.WalletTransaction{
-			WalletID:     walletID,
-			TxHash:       l.TxHash,
-			Chain:        chain,
-			TxType:       txType,
-			TokenAddress: tokenAddr,
-			TokenSymbol:  info.symbol,
-			AmountToken:  value,
-			AmountUSD:    amountUSD,
-			FromAddress:  from,
-			ToAddress:    to,
-			Timestamp:    ts,
-			Platform:     platform,
-		}) == nil {
+		if tx, ok := r.erc20LogTx(ctx, rpcURL, walletID, address, chain, l, tokenInfoCache); ok && r.store.InsertTransaction(tx) == nil {
 			count++
 		}
 	}
 
+	if err == nil {
+		r.store.SetScanCursor(walletID, "rpc:logs", strconv.FormatInt(currentBlock, 10))
+	}
+
 	// 2. Native transfers — we still need Etherscan/trace for this (no eth_getLogs for native)
 	// Use Etherscan txlist as fallback for native ETH/BNB transfers
-	apiURL := s.cfg.GetExplorerURL(chain)
-	apiKey := s.cfg.GetExplorerKey(chain)
+	apiURL := r.cfg.GetExplorerURL(chain)
+	apiKey := r.cfg.GetExplorerKey(chain)
 	if apiURL != "" && apiKey != "" {
-		txs, _ := s.etherscanList(ctx, apiURL, apiKey, address, "txlist")
+		cursor, _ := r.store.GetScanCursor(walletID, "etherscan:txlist")
+		txs, next, _ := r.etherscanSince(ctx, apiURL, apiKey, address, "txlist", cursor)
 		for _, etx := range txs {
 			hash := str(etx, "hash")
 			if hash == "" {
 				continue
 			}
+			if swap, stored := pass.storeRow(ctx, "txlist", etx); swap {
+				if stored {
+					count++
+				}
+				continue
+			}
 			from := str(etx, "from")
 			to := str(etx, "to")
 			value := weiToEth(str(etx, "value"))
@@ -340,9 +423,9 @@ func (s *Scanner) scanEVMviaRPC(ctx context.Context, walletID int64, address str
 				}
 			}
 
-			if s.store.InsertTransaction(db.WalletTransaction{
+			if r.store.InsertTransaction(db.WalletTransaction{
 				WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
-				TokenSymbol: native, AmountToken: value, AmountUSD: value * nativePrice,
+				TokenSymbol: native, AmountToken: value, AmountUSD: r.usdAt(ctx, chain, "", value, ts),
 				FromAddress: from, ToAddress: to, Timestamp: ts,
 				BlockNumber: parseInt64(str(etx, "blockNumber")),
 				Platform:    platform,
@@ -351,6 +434,9 @@ func (s *Scanner) scanEVMviaRPC(ctx context.Context, walletID int64, address str
 				count++
 			}
 		}
+		if next != "" && next != cursor {
+			r.store.SetScanCursor(walletID, "etherscan:txlist", next)
+		}
 	}
 
 	log.Info().Str("addr", abbrev(address)).Str("chain", string(chain)).
@@ -358,6 +444,21 @@ func (s *Scanner) scanEVMviaRPC(ctx context.Context, walletID int64, address str
 	return count, nil
 }
 
+// IdentifyAddress reports "contract" for addresses with code, via eth_getCode.
+func (r *evmRPCProvider) IdentifyAddress(ctx context.Context, address string, chain config.Chain) (string, error) {
+	if !strings.HasPrefix(address, "0x") {
+		return "", ErrUnsupported
+	}
+	isContract, err := r.isContract(ctx, r.cfg.EVMRPC[chain], address)
+	if err != nil {
+		return "", err
+	}
+	if isContract {
+		return "contract", nil
+	}
+	return "unknown", nil
+}
+
 // ── Token Info via RPC ──────────────────────────────────────
 
 type tokenInfo struct {
@@ -472,153 +573,148 @@ func tokenValueBig(amount *big.Int, decimals int) float64 {
 // Uses getSignaturesForAddress + getTransaction for direct Solana RPC scanning
 // as alternative to Helius parsed transactions API.
 
-// scanSolanaViaRPC scans a Solana wallet using standard JSON-RPC
-// (works with any RPC: Chainstack, QuickNode, Alchemy, public).
-// Falls back to Helius if available for richer parsed data.
-func (s *Scanner) scanSolanaViaRPC(ctx context.Context, walletID int64, address string) (int, error) {
-	// If Helius is available, prefer it — it gives parsed swap data (DEX name, token transfers)
-	// that standard RPC doesn't provide without manual instruction parsing
-	if s.cfg.HeliusAPIKey != "" {
-		return s.scanSolana(ctx, walletID, address)
-	}
+// solanaRPCProvider scans Solana wallets over standard JSON-RPC (works with
+// any RPC: Chainstack, QuickNode, Alchemy, public). Helius ranks above it by
+// default since its parsed swaps carry the DEX name.
+type solanaRPCProvider struct {
+	unsupported
+	*Scanner
+}
 
-	rpcURL := s.cfg.SolanaRPCURL
-	if rpcURL == "" {
-		return 0, fmt.Errorf("no Solana RPC configured")
-	}
+func (r *solanaRPCProvider) Name() string { return "solana_rpc" }
 
-	// getSignaturesForAddress — get recent tx signatures
-	result, err := s.rpcCall(ctx, rpcURL, "getSignaturesForAddress", []interface{}{
-		address,
-		map[string]interface{}{"limit": 100},
-	})
-	if err != nil {
-		return 0, fmt.Errorf("getSignaturesForAddress: %w", err)
-	}
+func (r *solanaRPCProvider) Supports(chain config.Chain) bool {
+	return chain == config.ChainSolana && r.cfg.SolanaRPCURL != ""
+}
 
-	var sigs []struct {
-		Signature string `json:"signature"`
-		Slot      int64  `json:"slot"`
-		BlockTime *int64 `json:"blockTime"`
-		Err       interface{} `json:"err"`
-	}
-	json.Unmarshal(result, &sigs)
+func (r *solanaRPCProvider) FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
+	rpcURL := r.cfg.SolanaRPCURL
 
-	solPrice := s.getSolPrice(ctx)
+	// getSignaturesForAddress — signatures since the last pass
+	const limit = 100
 	count := 0
-
-	for _, sig := range sigs {
-		if sig.Err != nil {
-			continue // skip failed txs
-		}
-
-		// getTransaction with maxSupportedTransactionVersion
-		txResult, err := s.rpcCall(ctx, rpcURL, "getTransaction", []interface{}{
-			sig.Signature,
-			map[string]interface{}{
-				"encoding":                       "jsonParsed",
-				"maxSupportedTransactionVersion": 0,
-			},
-		})
+	err := r.scanNewestFirst(walletID, address, "solana_rpc", limit, func(before, until string) ([]string, error) {
+		sigs, err := r.signaturesPage(ctx, rpcURL, address, before, until, limit)
 		if err != nil {
-			continue
-		}
-
-		var parsed struct {
-			BlockTime   *int64 `json:"blockTime"`
-			Meta        *struct {
-				Fee               int64 `json:"fee"`
-				PreBalances       []int64 `json:"preBalances"`
-				PostBalances      []int64 `json:"postBalances"`
-				PreTokenBalances  []solTokenBalance `json:"preTokenBalances"`
-				PostTokenBalances []solTokenBalance `json:"postTokenBalances"`
-				Err               interface{} `json:"err"`
-			} `json:"meta"`
-			Transaction *struct {
-				Message struct {
-					AccountKeys []struct {
-						Pubkey string `json:"pubkey"`
-					} `json:"accountKeys"`
-				} `json:"message"`
-			} `json:"transaction"`
+			return nil, err
 		}
-		if json.Unmarshal(txResult, &parsed) != nil || parsed.Meta == nil || parsed.Meta.Err != nil {
-			continue
+		ids := make([]string, len(sigs))
+		for i, sig := range sigs {
+			ids[i] = sig.Signature
+			if sig.Err != nil {
+				continue // skip failed txs
+			}
+			if r.storeSolanaRPCTx(ctx, rpcURL, walletID, address, sig.Signature) {
+				count++
+			}
 		}
+		return ids, nil
+	})
+	if err != nil && count == 0 {
+		return 0, err
+	}
 
-		ts := time.Time{}
-		if parsed.BlockTime != nil {
-			ts = time.Unix(*parsed.BlockTime, 0)
-		}
+	log.Info().Str("addr", abbrev(address)).Int("txs", count).Str("mode", "rpc").Msg("scanned solana")
+	return count, nil
+}
 
-		tx := db.WalletTransaction{
-			WalletID:    walletID,
-			TxHash:      sig.Signature,
-			Chain:       config.ChainSolana,
-			Timestamp:   ts,
-			PriorityFee: float64(parsed.Meta.Fee),
-		}
+// storeSolanaRPCTx fetches one transaction, derives the wallet's SOL and
+// token balance changes, and stores it.
+func (s *Scanner) storeSolanaRPCTx(ctx context.Context, rpcURL string, walletID int64, address, signature string) bool {
+	tx, err := s.solanaRPCTx(ctx, rpcURL, walletID, address, signature)
+	if err != nil || tx == nil {
+		return false
+	}
+	return s.store.InsertTransaction(*tx) == nil
+}
 
-		// Find address index in account keys
-		addrIdx := -1
-		if parsed.Transaction != nil {
-			for i, ak := range parsed.Transaction.Message.AccountKeys {
-				if ak.Pubkey == address {
-					addrIdx = i
-					break
-				}
-			}
-		}
+// errTxNotFound means the node doesn't have the transaction (yet).
+var errTxNotFound = errors.New("transaction not found")
+
+// solanaRPCTx fetches one transaction at confirmed commitment and decodes the
+// wallet's side of it. It returns nil if the transaction failed or didn't
+// move the wallet's SOL or tokens.
+func (s *Scanner) solanaRPCTx(ctx context.Context, rpcURL string, walletID int64, address, signature string) (*db.WalletTransaction, error) {
+	// getTransaction with maxSupportedTransactionVersion
+	txResult, err := s.rpcCall(ctx, rpcURL, "getTransaction", []interface{}{
+		signature,
+		map[string]interface{}{
+			"encoding":                       "jsonParsed",
+			"maxSupportedTransactionVersion": 0,
+			"commitment":                     "confirmed",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if string(txResult) == "null" {
+		return nil, errTxNotFound
+	}
 
-		// Check SOL balance change
-		if addrIdx >= 0 && addrIdx < len(parsed.Meta.PreBalances) && addrIdx < len(parsed.Meta.PostBalances) {
-			solDiff := float64(parsed.Meta.PostBalances[addrIdx]-parsed.Meta.PreBalances[addrIdx]) / 1e9
-			if solDiff > 0.001 {
-				tx.TxType = "transfer_in"
-				tx.TokenSymbol = "SOL"
-				tx.AmountToken = solDiff
-				tx.AmountUSD = solDiff * solPrice
-			} else if solDiff < -0.001 {
-				// Could be a swap (sent SOL, received tokens) or transfer out
-				tx.AmountUSD = -solDiff * solPrice
-			}
-		}
+	var parsed solParsedTx
+	if err := json.Unmarshal(txResult, &parsed); err != nil {
+		return nil, fmt.Errorf("getTransaction %s: %w", signature, err)
+	}
+	if parsed.Meta == nil || parsed.Meta.Err != nil {
+		return nil, nil
+	}
 
-		// Check token balance changes
-		preTokens := mapTokenBalances(parsed.Meta.PreTokenBalances, address)
-		postTokens := mapTokenBalances(parsed.Meta.PostTokenBalances, address)
-
-		for mint, postAmt := range postTokens {
-			preAmt := preTokens[mint]
-			diff := postAmt - preAmt
-			if diff > 0 {
-				tx.TxType = "swap_buy"
-				tx.TokenAddress = mint
-				tx.AmountToken = diff
-			} else if diff < 0 {
-				tx.TxType = "swap_sell"
-				tx.TokenAddress = mint
-				tx.AmountToken = -diff
-			}
-		}
-		// Tokens that disappeared entirely
-		for mint, preAmt := range preTokens {
-			if _, ok := postTokens[mint]; !ok && preAmt > 0 {
-				tx.TxType = "swap_sell"
-				tx.TokenAddress = mint
-				tx.AmountToken = preAmt
-			}
-		}
+	ts := time.Time{}
+	if parsed.BlockTime != nil {
+		ts = time.Unix(*parsed.BlockTime, 0)
+	}
 
-		if tx.TxType != "" {
-			if s.store.InsertTransaction(tx) == nil {
-				count++
-			}
-		}
+	// Priority fee as Helius reports it (the whole fee in lamports), plus
+	// any Jito tip, so both paths fingerprint the same.
+	d := decodeSolanaTx(&parsed, address)
+	tx := db.WalletTransaction{
+		WalletID:    walletID,
+		TxHash:      signature,
+		Chain:       config.ChainSolana,
+		Timestamp:   ts,
+		Platform:    d.Platform,
+		PriorityFee: float64(d.FeeLamports + d.JitoTipLamports),
+	}
+	if meta, err := json.Marshal(d); err == nil {
+		tx.Metadata = string(meta)
 	}
 
-	log.Info().Str("addr", abbrev(address)).Int("txs", count).Str("mode", "rpc").Msg("scanned solana")
-	return count, nil
+	if d.isSwap() {
+		switch {
+		case solQuoteMints[d.InMint] || !solQuoteMints[d.OutMint]:
+			tx.TxType = "swap_buy"
+			tx.TokenAddress, tx.AmountToken = d.OutMint, d.OutAmount
+			tx.AmountUSD = s.quoteUSDAt(ctx, config.ChainSolana, d.InMint, d.InAmount, ts)
+		default:
+			tx.TxType = "swap_sell"
+			tx.TokenAddress, tx.AmountToken = d.InMint, d.InAmount
+			tx.AmountUSD = s.quoteUSDAt(ctx, config.ChainSolana, d.OutMint, d.OutAmount, ts)
+		}
+		return &tx, nil
+	}
+
+	// Not a DEX swap: a SOL or SPL transfer in or out
+	switch {
+	case d.OutMint == wsolMint && d.InMint == "":
+		tx.TxType = "transfer_in"
+		tx.TokenSymbol = "SOL"
+		tx.AmountToken = d.OutAmount
+		tx.AmountUSD = s.usdAt(ctx, config.ChainSolana, "", d.OutAmount, ts)
+	case d.InMint == wsolMint && d.OutMint == "":
+		tx.TxType = "transfer_out"
+		tx.TokenSymbol = "SOL"
+		tx.AmountToken = d.InAmount
+		tx.AmountUSD = s.usdAt(ctx, config.ChainSolana, "", d.InAmount, ts)
+	case d.OutMint != "":
+		tx.TxType = "transfer_in"
+		tx.TokenAddress, tx.AmountToken = d.OutMint, d.OutAmount
+	case d.InMint != "":
+		tx.TxType = "transfer_out"
+		tx.TokenAddress, tx.AmountToken = d.InMint, d.InAmount
+	default:
+		return nil, nil
+	}
+	return &tx, nil
 }
 
 type solTokenBalance struct {
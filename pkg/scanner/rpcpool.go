@@ -0,0 +1,285 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+// maxCallFailures is how many consecutive failed calls quarantine an
+// endpoint until its next successful probe.
+const maxCallFailures = 3
+
+// rpcEndpoint is one RPC URL and what the last probe and calls saw of it.
+type rpcEndpoint struct {
+	url         string
+	head        int64
+	latency     time.Duration
+	checked     time.Time
+	failures    int // consecutive failed calls
+	lastErr     string
+	quarantined bool
+}
+
+// rpcPool holds a chain's RPC endpoints in configured order. Calls go to the
+// first healthy endpoint and fail over down the list; endpoints that fail
+// probes, fall behind the best head or keep failing calls are quarantined
+// and only tried once every healthy one has failed.
+type rpcPool struct {
+	chain config.Chain
+
+	mu        sync.Mutex
+	endpoints []*rpcEndpoint
+}
+
+func newRPCPool(chain config.Chain, urls []string) *rpcPool {
+	p := &rpcPool{chain: chain}
+	for _, u := range urls {
+		p.endpoints = append(p.endpoints, &rpcEndpoint{url: u})
+	}
+	return p
+}
+
+// order returns the endpoint URLs to try: healthy ones in configured order,
+// then quarantined ones as a last resort.
+func (p *rpcPool) order() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var healthy, rest []string
+	for _, e := range p.endpoints {
+		if e.quarantined {
+			rest = append(rest, e.url)
+		} else {
+			healthy = append(healthy, e.url)
+		}
+	}
+	return append(healthy, rest...)
+}
+
+func (p *rpcPool) endpoint(u string) *rpcEndpoint {
+	for _, e := range p.endpoints {
+		if e.url == u {
+			return e
+		}
+	}
+	return nil
+}
+
+// record updates an endpoint after a call through it.
+func (p *rpcPool) record(u string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.endpoint(u)
+	if e == nil {
+		return
+	}
+	if err == nil {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	e.lastErr = err.Error()
+	if e.failures >= maxCallFailures && !e.quarantined {
+		e.quarantined = true
+		log.Warn().Err(err).Str("chain", string(p.chain)).Str("rpc", config.RedactURL(u)).Msg("rpc endpoint quarantined after repeated failures")
+	}
+}
+
+// rpcProbe is one endpoint's head height and round-trip time.
+type rpcProbe struct {
+	url     string
+	head    int64
+	latency time.Duration
+	err     error
+}
+
+// apply records a round of probes. Endpoints that failed, or whose head is
+// more than maxLag behind the best one, are quarantined; the rest are
+// released.
+func (p *rpcPool) apply(probes []rpcProbe, maxLag time.Duration) {
+	// the chain's block (slot) time turns a lag in blocks into time, so one
+	// max-lag setting works across chains
+	ci, _ := config.LookupChain(p.chain)
+	blockTime := ci.BlockTime
+	var best int64
+	for _, pr := range probes {
+		if pr.err == nil && pr.head > best {
+			best = pr.head
+		}
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pr := range probes {
+		e := p.endpoint(pr.url)
+		if e == nil {
+			continue
+		}
+		e.checked, e.latency = now, pr.latency
+		var reason string
+		switch {
+		case pr.err != nil:
+			reason, e.lastErr = "probe failed", pr.err.Error()
+		default:
+			e.head = pr.head
+			if lag := time.Duration(best-pr.head) * blockTime; lag > maxLag {
+				reason, e.lastErr = "stale head", fmt.Sprintf("%d blocks (%s) behind", best-pr.head, lag)
+			}
+		}
+		switch {
+		case reason != "" && !e.quarantined:
+			log.Warn().Str("chain", string(p.chain)).Str("rpc", config.RedactURL(e.url)).Str("detail", e.lastErr).Msg("rpc endpoint quarantined: " + reason)
+		case reason == "" && e.quarantined:
+			log.Info().Str("chain", string(p.chain)).Str("rpc", config.RedactURL(e.url)).Msg("rpc endpoint healthy again")
+		}
+		e.quarantined = reason != ""
+		if !e.quarantined {
+			e.failures, e.lastErr = 0, ""
+		}
+	}
+}
+
+// newRPCPools builds one pool per chain from cfg.RPCEndpoints.
+func newRPCPools(cfg *config.Config) map[config.Chain]*rpcPool {
+	pools := map[config.Chain]*rpcPool{}
+	for ch, urls := range cfg.RPCEndpoints {
+		if len(urls) > 0 {
+			pools[ch] = newRPCPool(ch, urls)
+		}
+	}
+	return pools
+}
+
+// poolFor returns the pool an RPC URL belongs to. Callers address a chain's
+// pool by any of its endpoints, normally the primary in cfg.EVMRPC or
+// cfg.SolanaRPCURL.
+func (s *Scanner) poolFor(rpcURL string) *rpcPool {
+	for _, p := range s.pools {
+		p.mu.Lock()
+		e := p.endpoint(rpcURL)
+		p.mu.Unlock()
+		if e != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// retryable reports whether a failed call is worth sending to another
+// endpoint. Errors the node computed deterministically (reverts, bad
+// params) would fail the same way everywhere.
+func retryable(err error) bool {
+	var re *rpcError
+	if errors.As(err, &re) {
+		return re.Code != 3 && re.Code != -32602
+	}
+	return true
+}
+
+// probe asks one endpoint for its head: getSlot on Solana, eth_blockNumber
+// elsewhere.
+func (s *Scanner) probe(ctx context.Context, chain config.Chain, rpcURL string) rpcProbe {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	var head int64
+	var err error
+	if chain == config.ChainSolana {
+		var raw json.RawMessage
+		if raw, err = s.rpcDo(ctx, rpcURL, "getSlot", []interface{}{}); err == nil {
+			err = json.Unmarshal(raw, &head)
+		}
+	} else {
+		var raw json.RawMessage
+		if raw, err = s.rpcDo(ctx, rpcURL, "eth_blockNumber", []interface{}{}); err == nil {
+			var hexBlock string
+			if err = json.Unmarshal(raw, &hexBlock); err == nil {
+				head, err = strconv.ParseInt(strings.TrimPrefix(hexBlock, "0x"), 16, 64)
+			}
+		}
+	}
+	return rpcProbe{url: rpcURL, head: head, latency: time.Since(start), err: err}
+}
+
+// CheckRPCHealth probes every endpoint of every chain once and updates
+// quarantine state.
+func (s *Scanner) CheckRPCHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for ch, p := range s.pools {
+		wg.Add(1)
+		go func(ch config.Chain, p *rpcPool) {
+			defer wg.Done()
+			p.mu.Lock()
+			urls := make([]string, len(p.endpoints))
+			for i, e := range p.endpoints {
+				urls[i] = e.url
+			}
+			p.mu.Unlock()
+			probes := make([]rpcProbe, len(urls))
+			for i, u := range urls {
+				probes[i] = s.probe(ctx, ch, u)
+			}
+			p.apply(probes, s.cfg.RPCMaxLag)
+		}(ch, p)
+	}
+	wg.Wait()
+}
+
+// RunHealthChecks probes the RPC endpoints every cfg.RPCHealthInterval until
+// ctx ends.
+func (s *Scanner) RunHealthChecks(ctx context.Context) error {
+	s.CheckRPCHealth(ctx)
+	t := time.NewTicker(s.cfg.RPCHealthInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			s.CheckRPCHealth(ctx)
+		}
+	}
+}
+
+// RPCStat is one endpoint's health as shown on the dashboard. URLs are
+// redacted since many embed an API key.
+type RPCStat struct {
+	Chain       config.Chain `json:"chain"`
+	URL         string       `json:"url"`
+	Head        int64        `json:"head"`
+	LatencyMS   int64        `json:"latency_ms"`
+	Checked     *time.Time   `json:"checked,omitempty"`
+	Failures    int          `json:"failures"`
+	Quarantined bool         `json:"quarantined"`
+	LastError   string       `json:"last_error,omitempty"`
+}
+
+// RPCStats reports every endpoint's last probe, primary first per chain.
+func (s *Scanner) RPCStats() []RPCStat {
+	var out []RPCStat
+	for ch, p := range s.pools {
+		p.mu.Lock()
+		for _, e := range p.endpoints {
+			st := RPCStat{Chain: ch, URL: config.RedactURL(e.url), Head: e.head, LatencyMS: e.latency.Milliseconds(),
+				Failures: e.failures, Quarantined: e.quarantined, LastError: e.lastErr}
+			if !e.checked.IsZero() {
+				t := e.checked
+				st.Checked = &t
+			}
+			out = append(out, st)
+		}
+		p.mu.Unlock()
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Chain < out[j].Chain })
+	return out
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kol-tracker/pkg/config"
+)
+
+func TestRPCPoolQuarantine(t *testing.T) {
+	// Ethereum's 12s blocks make a one-minute max lag five blocks
+	const maxLag = time.Minute
+	down := errors.New("HTTP 502")
+	calls := func(u string, err error, n int) func(*rpcPool) {
+		return func(p *rpcPool) {
+			for i := 0; i < n; i++ {
+				p.record(u, err)
+			}
+		}
+	}
+	probes := func(prs ...rpcProbe) func(*rpcPool) {
+		return func(p *rpcPool) { p.apply(prs, maxLag) }
+	}
+	tests := []struct {
+		name  string
+		steps []func(*rpcPool)
+		want  []string
+	}{
+		{name: "configured order", want: []string{"a", "b", "c"}},
+		{
+			name:  "repeated call failures quarantine",
+			steps: []func(*rpcPool){calls("a", down, maxCallFailures)},
+			want:  []string{"b", "c", "a"},
+		},
+		{
+			name:  "fewer failures don't",
+			steps: []func(*rpcPool){calls("a", down, maxCallFailures-1)},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "a success resets the count",
+			steps: []func(*rpcPool){calls("a", down, maxCallFailures-1), calls("a", nil, 1), calls("a", down, 1)},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "failed probe quarantines",
+			steps: []func(*rpcPool){probes(rpcProbe{url: "a", err: down}, rpcProbe{url: "b", head: 100}, rpcProbe{url: "c", head: 100})},
+			want:  []string{"b", "c", "a"},
+		},
+		{
+			name:  "head lagging past max lag quarantines",
+			steps: []func(*rpcPool){probes(rpcProbe{url: "a", head: 100}, rpcProbe{url: "b", head: 94}, rpcProbe{url: "c", head: 95})},
+			want:  []string{"a", "c", "b"},
+		},
+		{
+			name: "good probe releases",
+			steps: []func(*rpcPool){
+				calls("a", down, maxCallFailures),
+				probes(rpcProbe{url: "b", head: 90}, rpcProbe{url: "c", head: 100}),
+				probes(rpcProbe{url: "a", head: 100}, rpcProbe{url: "b", head: 100}, rpcProbe{url: "c", head: 100}),
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "quarantined keep configured order at the back",
+			steps: []func(*rpcPool){
+				calls("c", down, maxCallFailures), calls("a", down, maxCallFailures), calls("unknown", down, maxCallFailures),
+			},
+			want: []string{"b", "a", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newRPCPool(config.ChainEthereum, []string{"a", "b", "c"})
+			for _, step := range tt.steps {
+				step(p)
+			}
+			if got := p.order(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	p := newRPCPool(config.ChainEthereum, []string{"a"})
+	calls("a", down, maxCallFailures)(p)
+	probes(rpcProbe{url: "a", head: 100})(p)
+	if e := p.endpoint("a"); e.quarantined || e.failures != 0 || e.lastErr != "" {
+		t.Errorf("released endpoint = %+v, want failures and error cleared", *e)
+	}
+}
@@ -3,9 +3,11 @@ package scanner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,58 +20,70 @@ import (
 )
 
 type Scanner struct {
-	cfg    *config.Config
-	store  *db.Store
-	client *http.Client
+	cfg       *config.Config
+	store     db.Store
+	client    *http.Client
+	limits    *throttledTransport
+	pools     map[config.Chain]*rpcPool
+	providers []ChainDataProvider // priority order
 }
 
-func New(cfg *config.Config, store *db.Store) *Scanner {
-	return &Scanner{cfg: cfg, store: store, client: &http.Client{Timeout: 30 * time.Second}}
+// New returns a scanner whose HTTP calls are throttled per API host
+// (cfg.RateLimits), shared by everything that scans through it, using the
+// providers in cfg.ScanProviders. RPC calls fail over across each chain's
+// cfg.RPCEndpoints; run RunHealthChecks to quarantine stale endpoints.
+func New(cfg *config.Config, store db.Store) *Scanner {
+	limits := newThrottledTransport(cfg.RateLimits, http.DefaultTransport)
+	s := &Scanner{cfg: cfg, store: store, limits: limits, pools: newRPCPools(cfg), client: &http.Client{Timeout: 30 * time.Second, Transport: limits}}
+	s.providers = defaultProviders(s)
+	return s
 }
 
-// ScanWallet dispatches to the right chain scanner.
-// Prefers direct RPC (Chainstack) when available, falls back to Etherscan/Helius.
+// ProviderStats reports each API host's rate limit and waiting requests.
+func (s *Scanner) ProviderStats() []ProviderStat {
+	return s.limits.stats()
+}
+
+// ScanWallet stores a wallet's new transactions from the first provider that
+// can serve its chain, falling back down the priority list on failure.
 func (s *Scanner) ScanWallet(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
-	if chain == config.ChainSolana {
-		// Prefer Helius for Solana (richer parsed data), fall back to standard RPC
-		if s.cfg.HeliusAPIKey != "" {
-			return s.scanSolana(ctx, walletID, address)
-		}
-		return s.scanSolanaViaRPC(ctx, walletID, address)
-	}
-	// For EVM: prefer RPC for token transfers (no rate limit), Etherscan for native txs
-	if s.cfg.EVMRPC[chain] != "" {
-		return s.scanEVMviaRPC(ctx, walletID, address, chain)
-	}
-	return s.scanEVM(ctx, walletID, address, chain)
+	return withFallback(s, chain, "scan", func(p ChainDataProvider) (int, error) {
+		return p.FetchTransactions(ctx, walletID, address, chain)
+	})
 }
 
-// CheckFunding dispatches funding analysis to the right chain.
+// CheckFunding analyzes how a wallet was funded. Without a provider for the
+// chain the analysis is empty rather than an error.
 func (s *Scanner) CheckFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error) {
-	if chain == config.ChainSolana {
-		return s.checkSolanaFunding(ctx, address)
+	fa, err := withFallback(s, chain, "funding", func(p ChainDataProvider) (*db.FundingAnalysis, error) {
+		return p.FetchFunding(ctx, address, chain)
+	})
+	if errors.Is(err, errNoProvider) {
+		return &db.FundingAnalysis{Address: address, Chain: chain, NativeSymbol: nativeSymbol(chain)}, nil
 	}
-	return s.checkEVMFunding(ctx, address, chain)
+	return fa, err
 }
 
 // FindLinkedWallets traces transfers to discover connected wallets.
 func (s *Scanner) FindLinkedWallets(ctx context.Context, address string, chain config.Chain, depth int) ([]db.FundingSource, error) {
-	if chain == config.ChainSolana {
-		return s.findSolanaLinked(ctx, address)
+	linked, err := withFallback(s, chain, "linked wallets", func(p ChainDataProvider) ([]db.FundingSource, error) {
+		return p.LinkedWallets(ctx, address, chain)
+	})
+	if errors.Is(err, errNoProvider) {
+		return nil, nil
 	}
-	return s.findEVMLinked(ctx, address, chain)
+	return linked, err
 }
 
 // GetRecentTokenBuyers fetches addresses that recently bought a token.
 func (s *Scanner) GetRecentTokenBuyers(ctx context.Context, tokenAddr string, chain config.Chain) ([]TokenBuyer, error) {
-	if chain == config.ChainSolana && s.cfg.BirdeyeAPIKey != "" {
-		return s.birdeyeBuyers(ctx, tokenAddr)
-	}
-	// EVM: query recent token transfer events from block explorer
-	if strings.HasPrefix(tokenAddr, "0x") {
-		return s.evmTokenBuyers(ctx, tokenAddr, chain)
+	buyers, err := withFallback(s, chain, "token buyers", func(p ChainDataProvider) ([]TokenBuyer, error) {
+		return p.TokenBuyers(ctx, tokenAddr, chain)
+	})
+	if errors.Is(err, errNoProvider) {
+		return nil, nil
 	}
-	return nil, nil
+	return buyers, err
 }
 
 type TokenBuyer struct {
@@ -83,156 +97,178 @@ type TokenBuyer struct {
 
 // ── Solana ──────────────────────────────────────────────────
 
-func (s *Scanner) scanSolana(ctx context.Context, walletID int64, address string) (int, error) {
-	if s.cfg.HeliusAPIKey == "" {
-		return 0, fmt.Errorf("helius API key required for solana scanning")
-	}
+const (
+	heliusAPI  = "https://api.helius.xyz/v0"
+	birdeyeAPI = "https://public-api.birdeye.so"
+	solscanAPI = "https://pro-api.solscan.io/v2.0"
+)
 
-	solMints := map[string]bool{
-		"So11111111111111111111111111111111111111112":  true,
-		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": true, // USDC
-		"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB":  true, // USDT
-	}
-	solPrice := s.getSolPrice(ctx)
+// heliusProvider serves Solana from Helius's parsed transaction API, which
+// labels swaps with their DEX.
+type heliusProvider struct {
+	unsupported
+	*Scanner
+}
 
-	count := 0
+func (h *heliusProvider) Name() string { return "helius" }
+
+func (h *heliusProvider) Supports(chain config.Chain) bool {
+	return chain == config.ChainSolana && h.cfg.HeliusAPIKey != ""
+}
+
+func (h *heliusProvider) FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
+	count, failed := 0, 0
+	var lastErr error
 
 	// Scan BOTH swap and transfer tx types for full coverage
 	for _, txType := range []string{"SWAP", "TRANSFER"} {
-		url := fmt.Sprintf("https://api.helius.xyz/v0/addresses/%s/transactions?api-key=%s&type=%s&limit=100",
-			address, s.cfg.HeliusAPIKey, txType)
-
-		body, err := s.getJSON(ctx, url)
+		stream := "helius:" + txType
+		err := h.scanNewestFirst(walletID, address, stream, heliusPageSize, func(before, until string) ([]string, error) {
+			page, err := h.heliusPage(ctx, address, txType, before, until)
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]string, len(page))
+			for i, raw := range page {
+				if h.storeHeliusTx(ctx, walletID, address, txType, raw) {
+					count++
+				}
+				ids[i] = signatureOf(raw)
+			}
+			return ids, nil
+		})
 		if err != nil {
 			log.Warn().Err(err).Str("type", txType).Msg("helius fetch failed")
-			continue
+			failed, lastErr = failed+1, err
 		}
+	}
 
-		var txs []json.RawMessage
-		json.Unmarshal(body, &txs)
-
-		for _, raw := range txs {
-			var p struct {
-				Signature      string `json:"signature"`
-				Timestamp      int64  `json:"timestamp"`
-				Type           string `json:"type"`
-				Source         string `json:"source"`
-				Fee            int64  `json:"fee"`
-				FeePayer       string `json:"feePayer"`
-				TokenTransfers []struct {
-					Mint            string  `json:"mint"`
-					FromUserAccount string  `json:"fromUserAccount"`
-					ToUserAccount   string  `json:"toUserAccount"`
-					TokenAmount     float64 `json:"tokenAmount"`
-				} `json:"tokenTransfers"`
-				NativeTransfers []struct {
-					FromUserAccount string `json:"fromUserAccount"`
-					ToUserAccount   string `json:"toUserAccount"`
-					Amount          int64  `json:"amount"`
-				} `json:"nativeTransfers"`
-			}
-			if json.Unmarshal(raw, &p) != nil {
-				continue
-			}
+	if failed == 2 && count == 0 {
+		return 0, lastErr // nothing came back; let the next provider try
+	}
+	log.Info().Str("addr", abbrev(address)).Int("txs", count).Msg("scanned solana")
+	return count, nil
+}
 
-			tx := db.WalletTransaction{
-				WalletID:    walletID,
-				TxHash:      p.Signature,
-				Chain:       config.ChainSolana,
-				Timestamp:   time.Unix(p.Timestamp, 0),
-				Platform:    p.Source,
-				PriorityFee: float64(p.Fee),
-			}
+// solQuoteMints are SOL/stable mints; the other side of a swap is the token.
+var solQuoteMints = map[string]bool{
+	"So11111111111111111111111111111111111111112":  true,
+	"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": true, // USDC
+	"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB":  true, // USDT
+}
 
-			// For SWAP transactions: classify token buys/sells
-			if txType == "SWAP" {
-				for _, tt := range p.TokenTransfers {
-					if tt.ToUserAccount == address && !solMints[tt.Mint] {
-						tx.TxType = "swap_buy"
-						tx.TokenAddress = tt.Mint
-						tx.AmountToken = tt.TokenAmount
-					} else if tt.FromUserAccount == address && !solMints[tt.Mint] {
-						tx.TxType = "swap_sell"
-						tx.TokenAddress = tt.Mint
-						tx.AmountToken = tt.TokenAmount
-					}
-				}
-			}
+// storeHeliusTx classifies one Helius parsed transaction and stores it.
+func (s *Scanner) storeHeliusTx(ctx context.Context, walletID int64, address, txType string, raw json.RawMessage) bool {
+	var p struct {
+		Signature      string `json:"signature"`
+		Timestamp      int64  `json:"timestamp"`
+		Type           string `json:"type"`
+		Source         string `json:"source"`
+		Fee            int64  `json:"fee"`
+		FeePayer       string `json:"feePayer"`
+		TokenTransfers []struct {
+			Mint            string  `json:"mint"`
+			FromUserAccount string  `json:"fromUserAccount"`
+			ToUserAccount   string  `json:"toUserAccount"`
+			TokenAmount     float64 `json:"tokenAmount"`
+		} `json:"tokenTransfers"`
+		NativeTransfers []struct {
+			FromUserAccount string `json:"fromUserAccount"`
+			ToUserAccount   string `json:"toUserAccount"`
+			Amount          int64  `json:"amount"`
+		} `json:"nativeTransfers"`
+	}
+	if json.Unmarshal(raw, &p) != nil {
+		return false
+	}
 
-			// For TRANSFER transactions: classify SOL/SPL transfers
-			if txType == "TRANSFER" {
-				// SPL token transfers
-				for _, tt := range p.TokenTransfers {
-					if tt.ToUserAccount == address {
-						tx.TxType = "transfer_in"
-						tx.TokenAddress = tt.Mint
-						tx.AmountToken = tt.TokenAmount
-					} else if tt.FromUserAccount == address {
-						tx.TxType = "transfer_out"
-						tx.TokenAddress = tt.Mint
-						tx.AmountToken = tt.TokenAmount
-					}
-				}
-				// Native SOL transfers (only if no token transfer set the type)
-				if tx.TxType == "" {
-					for _, nt := range p.NativeTransfers {
-						sol := float64(nt.Amount) / 1e9
-						if sol < 0.001 {
-							continue // skip dust/fees
-						}
-						if nt.ToUserAccount == address {
-							tx.TxType = "transfer_in"
-							tx.TokenSymbol = "SOL"
-							tx.AmountToken = sol
-							tx.FromAddress = nt.FromUserAccount
-						} else if nt.FromUserAccount == address {
-							tx.TxType = "transfer_out"
-							tx.TokenSymbol = "SOL"
-							tx.AmountToken = sol
-							tx.ToAddress = nt.ToUserAccount
-						}
-					}
-				}
+	tx := db.WalletTransaction{
+		WalletID:    walletID,
+		TxHash:      p.Signature,
+		Chain:       config.ChainSolana,
+		Timestamp:   time.Unix(p.Timestamp, 0),
+		Platform:    p.Source,
+		PriorityFee: float64(p.Fee),
+	}
+	// Jito tips count towards the priority fee, as on the RPC path
+	for _, nt := range p.NativeTransfers {
+		if nt.FromUserAccount == address && jitoTipAccounts[nt.ToUserAccount] {
+			tx.PriorityFee += float64(nt.Amount)
+		}
+	}
+
+	// For SWAP transactions: classify token buys/sells
+	if txType == "SWAP" {
+		for _, tt := range p.TokenTransfers {
+			if tt.ToUserAccount == address && !solQuoteMints[tt.Mint] {
+				tx.TxType = "swap_buy"
+				tx.TokenAddress = tt.Mint
+				tx.AmountToken = tt.TokenAmount
+			} else if tt.FromUserAccount == address && !solQuoteMints[tt.Mint] {
+				tx.TxType = "swap_sell"
+				tx.TokenAddress = tt.Mint
+				tx.AmountToken = tt.TokenAmount
 			}
+		}
+	}
 
-			// Calculate USD for native SOL transfers
+	// For TRANSFER transactions: classify SOL/SPL transfers
+	if txType == "TRANSFER" {
+		// SPL token transfers
+		for _, tt := range p.TokenTransfers {
+			if tt.ToUserAccount == address {
+				tx.TxType = "transfer_in"
+				tx.TokenAddress = tt.Mint
+				tx.AmountToken = tt.TokenAmount
+			} else if tt.FromUserAccount == address {
+				tx.TxType = "transfer_out"
+				tx.TokenAddress = tt.Mint
+				tx.AmountToken = tt.TokenAmount
+			}
+		}
+		// Native SOL transfers (only if no token transfer set the type)
+		if tx.TxType == "" {
 			for _, nt := range p.NativeTransfers {
 				sol := float64(nt.Amount) / 1e9
-				if nt.FromUserAccount == address || nt.ToUserAccount == address {
-					tx.AmountUSD = sol * solPrice
+				if sol < 0.001 {
+					continue // skip dust/fees
 				}
-			}
-
-			if tx.TxType != "" {
-				if s.store.InsertTransaction(tx) == nil {
-					count++
+				if nt.ToUserAccount == address {
+					tx.TxType = "transfer_in"
+					tx.TokenSymbol = "SOL"
+					tx.AmountToken = sol
+					tx.FromAddress = nt.FromUserAccount
+				} else if nt.FromUserAccount == address {
+					tx.TxType = "transfer_out"
+					tx.TokenSymbol = "SOL"
+					tx.AmountToken = sol
+					tx.ToAddress = nt.ToUserAccount
 				}
 			}
 		}
 	}
 
-	log.Info().Str("addr", abbrev(address)).Int("txs", count).Msg("scanned solana")
-	return count, nil
-}
-
-func (s *Scanner) checkSolanaFunding(ctx context.Context, address string) (*db.FundingAnalysis, error) {
-	fa := &db.FundingAnalysis{Address: address, Chain: config.ChainSolana, NativeSymbol: "SOL"}
+	// Calculate USD for native SOL transfers, at the SOL price of the time
+	for _, nt := range p.NativeTransfers {
+		sol := float64(nt.Amount) / 1e9
+		if nt.FromUserAccount == address || nt.ToUserAccount == address {
+			tx.AmountUSD = s.usdAt(ctx, config.ChainSolana, "", sol, tx.Timestamp)
+		}
+	}
 
-	if s.cfg.HeliusAPIKey == "" {
-		return fa, nil
+	if tx.TxType == "" {
+		return false
 	}
+	return s.store.InsertTransaction(tx) == nil
+}
 
-	url := fmt.Sprintf("https://api.helius.xyz/v0/addresses/%s/transactions?api-key=%s&type=TRANSFER&limit=50",
-		address, s.cfg.HeliusAPIKey)
+func (h *heliusProvider) FetchFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error) {
+	fa := &db.FundingAnalysis{Address: address, Chain: config.ChainSolana, NativeSymbol: "SOL"}
 
-	body, err := s.getJSON(ctx, url)
+	txs, err := h.recentTransfers(ctx, address)
 	if err != nil {
 		return fa, err
 	}
-
-	var txs []json.RawMessage
-	json.Unmarshal(body, &txs)
-
 	if len(txs) == 0 {
 		fa.IsNewWallet = true
 		return fa, nil
@@ -255,7 +291,7 @@ func (s *Scanner) checkSolanaFunding(ctx context.Context, address string) (*db.F
 				sol := float64(nt.Amount) / 1e9
 				fa.TotalFunded += sol
 
-				srcType := s.identifyAddress(ctx, nt.FromUserAccount, config.ChainSolana)
+				srcType := h.identifyAddress(ctx, nt.FromUserAccount, config.ChainSolana)
 				if srcType != "unknown" {
 					fa.FundingSources = append(fa.FundingSources, db.FundingSource{
 						SourceAddress: nt.FromUserAccount,
@@ -279,22 +315,12 @@ func (s *Scanner) checkSolanaFunding(ctx context.Context, address string) (*db.F
 	return fa, nil
 }
 
-func (s *Scanner) findSolanaLinked(ctx context.Context, address string) ([]db.FundingSource, error) {
-	if s.cfg.HeliusAPIKey == "" {
-		return nil, nil
-	}
-
-	url := fmt.Sprintf("https://api.helius.xyz/v0/addresses/%s/transactions?api-key=%s&type=TRANSFER&limit=50",
-		address, s.cfg.HeliusAPIKey)
-
-	body, err := s.getJSON(ctx, url)
+func (h *heliusProvider) LinkedWallets(ctx context.Context, address string, chain config.Chain) ([]db.FundingSource, error) {
+	txs, err := h.recentTransfers(ctx, address)
 	if err != nil {
 		return nil, err
 	}
 
-	var txs []json.RawMessage
-	json.Unmarshal(body, &txs)
-
 	seen := map[string]bool{address: true}
 	var linked []db.FundingSource
 
@@ -335,18 +361,48 @@ func (s *Scanner) findSolanaLinked(ctx context.Context, address string) ([]db.Fu
 	return linked, nil
 }
 
-func (s *Scanner) birdeyeBuyers(ctx context.Context, tokenAddr string) ([]TokenBuyer, error) {
-	url := fmt.Sprintf("https://public-api.birdeye.so/defi/txs/token?address=%s&tx_type=swap&sort_type=desc&limit=50", tokenAddr)
+// recentTransfers returns the latest 50 parsed TRANSFER transactions.
+func (h *heliusProvider) recentTransfers(ctx context.Context, address string) ([]json.RawMessage, error) {
+	body, err := h.getJSON(ctx, endpoint(heliusAPI, []string{"addresses", address, "transactions"}, url.Values{
+		"api-key": {h.cfg.HeliusAPIKey}, "type": {"TRANSFER"}, "limit": {"50"},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var txs []json.RawMessage
+	json.Unmarshal(body, &txs)
+	return txs, nil
+}
+
+// birdeyeProvider finds recent buyers of Solana tokens.
+type birdeyeProvider struct {
+	unsupported
+	*Scanner
+}
+
+func (b *birdeyeProvider) Name() string { return "birdeye" }
 
-	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	req.Header.Set("X-API-KEY", s.cfg.BirdeyeAPIKey)
+func (b *birdeyeProvider) Supports(chain config.Chain) bool {
+	return chain == config.ChainSolana && b.cfg.BirdeyeAPIKey != ""
+}
+
+func (b *birdeyeProvider) TokenBuyers(ctx context.Context, tokenAddr string, chain config.Chain) ([]TokenBuyer, error) {
+	u := endpoint(birdeyeAPI, []string{"defi", "txs", "token"}, url.Values{
+		"address": {tokenAddr}, "tx_type": {"swap"}, "sort_type": {"desc"}, "limit": {"50"},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("X-API-KEY", b.cfg.BirdeyeAPIKey)
 	req.Header.Set("x-chain", "solana")
 
-	resp, err := s.client.Do(req)
+	resp, err := b.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d from birdeye", resp.StatusCode)
+	}
 
 	var result struct {
 		Data struct {
@@ -380,179 +436,221 @@ func (s *Scanner) birdeyeBuyers(ctx context.Context, tokenAddr string) ([]TokenB
 
 // ── EVM (ETH / Base / BSC) ─────────────────────────────────
 
-func (s *Scanner) scanEVM(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
-	apiURL := s.cfg.GetExplorerURL(chain)
-	apiKey := s.cfg.GetExplorerKey(chain)
+// etherscanProvider serves EVM chains from the Etherscan-family explorer
+// APIs (Etherscan, Basescan, BSCScan), one host and key per chain.
+type etherscanProvider struct {
+	unsupported
+	*Scanner
+}
+
+func (e *etherscanProvider) Name() string { return "etherscan" }
+
+func (e *etherscanProvider) Supports(chain config.Chain) bool {
+	return chain.IsEVM() && e.cfg.GetExplorerURL(chain) != "" && e.cfg.GetExplorerKey(chain) != ""
+}
+
+func (e *etherscanProvider) FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
+	apiURL := e.cfg.GetExplorerURL(chain)
+	apiKey := e.cfg.GetExplorerKey(chain)
 	if apiURL == "" || apiKey == "" {
 		return 0, fmt.Errorf("no explorer config for %s", chain)
 	}
 
 	native := nativeSymbol(chain)
-	nativePrice := s.getNativePrice(ctx, chain)
-	count := 0
-
-	// Normal txs — extract native transfers + detect DEX interactions
-	txs, _ := s.etherscanList(ctx, apiURL, apiKey, address, "txlist")
-	for _, etx := range txs {
-		hash := str(etx, "hash")
-		if hash == "" {
-			continue
-		}
-		from := str(etx, "from")
-		to := str(etx, "to")
-		value := weiToEth(str(etx, "value"))
-		ts := parseUnixStr(str(etx, "timeStamp"))
-		gasPrice := weiToEth(str(etx, "gasPrice"))     // in ETH
-		gasUsed := parseFloat(str(etx, "gasUsed"))
-
-		txType := "transfer_out"
-		if strings.EqualFold(to, address) {
-			txType = "transfer_in"
+	count, failed := 0, 0
+	var lastErr error
+	pass := e.newSwapPass(walletID, address, chain, map[string]tokenInfo{})
+
+	// Normal txs (native transfers + DEX interactions), ERC-20 token
+	// transfers, and internal txs (DEX routers often send ETH via internal
+	// calls, not direct transfers)
+	for _, action := range []string{"txlist", "tokentx", "txlistinternal"} {
+		stream := "etherscan:" + action
+		cursor, _ := e.store.GetScanCursor(walletID, stream)
+		txs, next, err := e.etherscanSince(ctx, apiURL, apiKey, address, action, cursor)
+		if err != nil {
+			log.Debug().Err(err).Str("action", action).Msg("etherscan fetch failed")
+			failed, lastErr = failed+1, err
 		}
-
-		// Detect DEX router interactions (swap via native ETH/BNB)
-		platform := ""
-		if dex := config.ClassifyEVMDEX(to); dex != "" {
-			platform = dex
-			if strings.EqualFold(from, address) && value > 0 {
-				txType = "swap_buy" // sending ETH to DEX = buying tokens
+		for _, etx := range txs {
+			if swap, stored := pass.storeRow(ctx, action, etx); swap {
+				if stored {
+					count++
+				}
+				continue
 			}
-		} else if dex := config.ClassifyEVMDEX(from); dex != "" {
-			platform = dex
-			if strings.EqualFold(to, address) && value > 0 {
-				txType = "swap_sell" // receiving ETH from DEX = sold tokens
+			if e.storeEtherscanTx(ctx, action, walletID, address, chain, etx, native) {
+				count++
 			}
 		}
-
-		// Extract priority fee (gas tip) — EIP-1559: gasPrice includes base+tip
-		// We store total gas cost as the fee fingerprint (used for bot detection)
-		priorityFee := gasPrice * gasUsed // total gas cost in ETH
-
-		if s.store.InsertTransaction(db.WalletTransaction{
-			WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
-			TokenSymbol: native, AmountToken: value, AmountUSD: value * nativePrice,
-			FromAddress: from, ToAddress: to, Timestamp: ts,
-			BlockNumber: parseInt64(str(etx, "blockNumber")),
-			Platform:    platform,
-			PriorityFee: priorityFee,
-		}) == nil {
-			count++
+		if next != "" && next != cursor {
+			e.store.SetScanCursor(walletID, stream, next)
 		}
 	}
 
-	// ERC-20 token transfers — detect swaps and extract DEX info
-	tokenTxs, _ := s.etherscanList(ctx, apiURL, apiKey, address, "tokentx")
-	stables := map[string]bool{
-		"USDC": true, "USDT": true, "BUSD": true, "DAI": true,
-		"WETH": true, "WBNB": true, "UST": true, "FRAX": true,
+	if failed == 3 && count == 0 {
+		return 0, lastErr
 	}
+	log.Info().Str("addr", abbrev(address)).Str("chain", string(chain)).Int("txs", count).Msg("scanned EVM")
+	return count, nil
+}
 
-	for _, etx := range tokenTxs {
-		hash := str(etx, "hash")
-		if hash == "" {
-			continue
-		}
-		from := str(etx, "from")
-		to := str(etx, "to")
-		symbol := str(etx, "tokenSymbol")
-		decimals := int(parseInt64(str(etx, "tokenDecimal")))
-		if decimals == 0 {
-			decimals = 18
-		}
-		value := tokenValue(str(etx, "value"), decimals)
+// explorerStables are symbols treated as the quote side of a swap.
+var explorerStables = map[string]bool{
+	"USDC": true, "USDT": true, "BUSD": true, "DAI": true,
+	"WETH": true, "WBNB": true, "UST": true, "FRAX": true,
+	"WPOL": true, "WAVAX": true, "USDB": true, "USDC.e": true,
+}
 
-		txType := "transfer_in"
-		if strings.EqualFold(from, address) {
-			txType = "transfer_out"
-			if stables[symbol] {
-				txType = "swap_buy" // sending stables = buying tokens
-			}
-		} else if strings.EqualFold(to, address) && stables[symbol] {
-			txType = "swap_sell" // receiving stables = sold tokens
-		}
+// storeEtherscanTx classifies one row of an Etherscan account list action
+// and stores it.
+func (s *Scanner) storeEtherscanTx(ctx context.Context, action string, walletID int64, address string, chain config.Chain, etx etherscanResult, native string) bool {
+	hash := str(etx, "hash")
+	if hash == "" {
+		return false
+	}
+	switch action {
+	case "txlist":
+		return s.storeNormalTx(ctx, walletID, address, chain, hash, etx, native)
+	case "tokentx":
+		return s.storeTokenTx(ctx, walletID, address, chain, hash, etx)
+	case "txlistinternal":
+		return s.storeInternalTx(ctx, walletID, address, chain, hash, etx, native)
+	}
+	return false
+}
 
-		// For stablecoins, AmountUSD ≈ AmountToken
-		amountUSD := 0.0
-		if stables[symbol] {
-			amountUSD = value
-		}
+// storeNormalTx extracts native transfers and detects DEX interactions.
+func (s *Scanner) storeNormalTx(ctx context.Context, walletID int64, address string, chain config.Chain, hash string, etx etherscanResult, native string) bool {
+	from := str(etx, "from")
+	to := str(etx, "to")
+	value := weiToEth(str(etx, "value"))
+	ts := parseUnixStr(str(etx, "timeStamp"))
+	gasPrice := weiToEth(str(etx, "gasPrice"))     // in ETH
+	gasUsed := parseFloat(str(etx, "gasUsed"))
+
+	txType := "transfer_out"
+	if strings.EqualFold(to, address) {
+		txType = "transfer_in"
+	}
 
-		// Try to detect DEX from "from" or "to" in token transfer context
-		platform := ""
-		// In ERC-20 transfers, the "from"/"to" might not be the DEX router itself
-		// But we can check if the *other* participant is a known router
-		counterparty := to
-		if strings.EqualFold(to, address) {
-			counterparty = from
+	// Detect DEX router interactions (swap via native ETH/BNB)
+	platform := ""
+	if dex := config.ClassifyEVMDEX(to); dex != "" {
+		platform = dex
+		if strings.EqualFold(from, address) && value > 0 {
+			txType = "swap_buy" // sending ETH to DEX = buying tokens
 		}
-		if dex := config.ClassifyEVMDEX(counterparty); dex != "" {
-			platform = dex
+	} else if dex := config.ClassifyEVMDEX(from); dex != "" {
+		platform = dex
+		if strings.EqualFold(to, address) && value > 0 {
+			txType = "swap_sell" // receiving ETH from DEX = sold tokens
 		}
+	}
 
-		if s.store.InsertTransaction(db.WalletTransaction{
-			WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
-			TokenAddress: str(etx, "contractAddress"), TokenSymbol: symbol,
-			AmountToken: value, AmountUSD: amountUSD, FromAddress: from, ToAddress: to,
-			Timestamp: parseUnixStr(str(etx, "timeStamp")),
-			Platform: platform,
-		}) == nil {
-			count++
-		}
+	// Extract priority fee (gas tip) — EIP-1559: gasPrice includes base+tip
+	// We store total gas cost as the fee fingerprint (used for bot detection)
+	priorityFee := gasPrice * gasUsed // total gas cost in ETH
+
+	return s.store.InsertTransaction(db.WalletTransaction{
+		WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
+		TokenSymbol: native, AmountToken: value, AmountUSD: s.usdAt(ctx, chain, "", value, ts),
+		FromAddress: from, ToAddress: to, Timestamp: ts,
+		BlockNumber: parseInt64(str(etx, "blockNumber")),
+		Platform:    platform,
+		PriorityFee: priorityFee,
+	}) == nil
+}
+
+// storeTokenTx detects swaps and DEX info from an ERC-20 transfer.
+func (s *Scanner) storeTokenTx(ctx context.Context, walletID int64, address string, chain config.Chain, hash string, etx etherscanResult) bool {
+	from := str(etx, "from")
+	to := str(etx, "to")
+	symbol := str(etx, "tokenSymbol")
+	decimals := int(parseInt64(str(etx, "tokenDecimal")))
+	if decimals == 0 {
+		decimals = 18
 	}
+	value := tokenValue(str(etx, "value"), decimals)
 
-	// Internal transactions — catches ETH received from DEX swaps
-	// (DEX routers often send ETH via internal calls, not direct transfers)
-	internalTxs, _ := s.etherscanList(ctx, apiURL, apiKey, address, "txlistinternal")
-	for _, etx := range internalTxs {
-		hash := str(etx, "hash")
-		if hash == "" {
-			continue
-		}
-		from := str(etx, "from")
-		to := str(etx, "to")
-		value := weiToEth(str(etx, "value"))
-		if value == 0 {
-			continue
+	txType := "transfer_in"
+	if strings.EqualFold(from, address) {
+		txType = "transfer_out"
+		if explorerStables[symbol] {
+			txType = "swap_buy" // sending stables = buying tokens
 		}
+	} else if strings.EqualFold(to, address) && explorerStables[symbol] {
+		txType = "swap_sell" // receiving stables = sold tokens
+	}
 
-		txType := "transfer_in"
-		platform := ""
-		if strings.EqualFold(to, address) {
-			// Receiving ETH via internal tx — check if from a DEX router
-			if dex := config.ClassifyEVMDEX(from); dex != "" {
-				txType = "swap_sell" // DEX router sent us ETH = we sold tokens
-				platform = dex
-			}
-		} else if strings.EqualFold(from, address) {
-			txType = "transfer_out"
-		}
+	// Stablecoins are worth their face value; WETH/WBNB the coin's price then
+	ts := parseUnixStr(str(etx, "timeStamp"))
+	amountUSD := s.quoteUSDAt(ctx, chain, symbol, value, ts)
+
+	// Try to detect DEX from "from" or "to" in token transfer context
+	platform := ""
+	// In ERC-20 transfers, the "from"/"to" might not be the DEX router itself
+	// But we can check if the *other* participant is a known router
+	counterparty := to
+	if strings.EqualFold(to, address) {
+		counterparty = from
+	}
+	if dex := config.ClassifyEVMDEX(counterparty); dex != "" {
+		platform = dex
+	}
 
-		if s.store.InsertTransaction(db.WalletTransaction{
-			WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
-			TokenSymbol: native, AmountToken: value, AmountUSD: value * nativePrice,
-			FromAddress: from, ToAddress: to,
-			Timestamp: parseUnixStr(str(etx, "timeStamp")),
-			Platform:  platform,
-		}) == nil {
-			count++
+	return s.store.InsertTransaction(db.WalletTransaction{
+		WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
+		TokenAddress: str(etx, "contractAddress"), TokenSymbol: symbol,
+		AmountToken: value, AmountUSD: amountUSD, FromAddress: from, ToAddress: to,
+		Timestamp: ts,
+		Platform: platform,
+	}) == nil
+}
+
+// storeInternalTx catches ETH received from DEX swaps.
+func (s *Scanner) storeInternalTx(ctx context.Context, walletID int64, address string, chain config.Chain, hash string, etx etherscanResult, native string) bool {
+	from := str(etx, "from")
+	to := str(etx, "to")
+	value := weiToEth(str(etx, "value"))
+	if value == 0 {
+		return false
+	}
+
+	ts := parseUnixStr(str(etx, "timeStamp"))
+
+	txType := "transfer_in"
+	platform := ""
+	if strings.EqualFold(to, address) {
+		// Receiving ETH via internal tx — check if from a DEX router
+		if dex := config.ClassifyEVMDEX(from); dex != "" {
+			txType = "swap_sell" // DEX router sent us ETH = we sold tokens
+			platform = dex
 		}
+	} else if strings.EqualFold(from, address) {
+		txType = "transfer_out"
 	}
 
-	log.Info().Str("addr", abbrev(address)).Str("chain", string(chain)).Int("txs", count).Msg("scanned EVM")
-	return count, nil
+	return s.store.InsertTransaction(db.WalletTransaction{
+		WalletID: walletID, TxHash: hash, Chain: chain, TxType: txType,
+		TokenSymbol: native, AmountToken: value, AmountUSD: s.usdAt(ctx, chain, "", value, ts),
+		FromAddress: from, ToAddress: to,
+		Timestamp: ts,
+		Platform:  platform,
+	}) == nil
 }
 
-func (s *Scanner) checkEVMFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error) {
+func (e *etherscanProvider) FetchFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error) {
 	native := nativeSymbol(chain)
 	fa := &db.FundingAnalysis{Address: address, Chain: chain, NativeSymbol: native}
 
-	apiURL := s.cfg.GetExplorerURL(chain)
-	apiKey := s.cfg.GetExplorerKey(chain)
+	apiURL := e.cfg.GetExplorerURL(chain)
+	apiKey := e.cfg.GetExplorerKey(chain)
 	if apiURL == "" || apiKey == "" {
 		return fa, nil
 	}
 
-	txs, err := s.etherscanList(ctx, apiURL, apiKey, address, "txlist")
+	txs, err := e.etherscanList(ctx, apiURL, apiKey, address, "txlist")
 	if err != nil || len(txs) == 0 {
 		fa.IsNewWallet = true
 		return fa, err
@@ -568,7 +666,7 @@ func (s *Scanner) checkEVMFunding(ctx context.Context, address string, chain con
 		value := weiToEth(str(etx, "value"))
 		fa.TotalFunded += value
 
-		srcType := s.identifyAddress(ctx, from, chain)
+		srcType := e.identifyAddress(ctx, from, chain)
 		if srcType != "unknown" {
 			fa.FundingSources = append(fa.FundingSources, db.FundingSource{
 				SourceAddress: from, Amount: value, Token: native,
@@ -588,14 +686,14 @@ func (s *Scanner) checkEVMFunding(ctx context.Context, address string, chain con
 	return fa, nil
 }
 
-func (s *Scanner) findEVMLinked(ctx context.Context, address string, chain config.Chain) ([]db.FundingSource, error) {
-	apiURL := s.cfg.GetExplorerURL(chain)
-	apiKey := s.cfg.GetExplorerKey(chain)
+func (e *etherscanProvider) LinkedWallets(ctx context.Context, address string, chain config.Chain) ([]db.FundingSource, error) {
+	apiURL := e.cfg.GetExplorerURL(chain)
+	apiKey := e.cfg.GetExplorerKey(chain)
 	if apiURL == "" {
 		return nil, nil
 	}
 
-	txs, _ := s.etherscanList(ctx, apiURL, apiKey, address, "txlist")
+	txs, _ := e.etherscanList(ctx, apiURL, apiKey, address, "txlist")
 	seen := map[string]bool{strings.ToLower(address): true}
 	var linked []db.FundingSource
 
@@ -625,13 +723,15 @@ func (s *Scanner) findEVMLinked(ctx context.Context, address string, chain confi
 
 // ── Shared helpers ──────────────────────────────────────────
 
+// identifyAddress classifies an address from the static known-address lists,
+// then asks the providers. Anything unresolved is "unknown".
 func (s *Scanner) identifyAddress(ctx context.Context, address string, chain config.Chain) string {
-	for _, addr := range config.KnownFixedFloatAddresses[chain] {
+	for _, addr := range config.FixedFloatAddresses(chain) {
 		if strings.EqualFold(address, addr) {
 			return "fixedfloat"
 		}
 	}
-	for _, addr := range config.KnownBridgeContracts[chain] {
+	for _, addr := range config.BridgeContracts(chain) {
 		if strings.EqualFold(address, addr) {
 			return "bridge"
 		}
@@ -641,64 +741,81 @@ func (s *Scanner) identifyAddress(ctx context.Context, address string, chain con
 	if label := config.IdentifyKnownEVMAddress(address); label != "" {
 		return label
 	}
-
-	// Solscan label check (Solana)
-	if chain == config.ChainSolana && s.cfg.SolscanAPIKey != "" {
-		url := fmt.Sprintf("https://pro-api.solscan.io/v2.0/account/%s", address)
-		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-		req.Header.Set("token", s.cfg.SolscanAPIKey)
-		if resp, err := s.client.Do(req); err == nil {
-			defer resp.Body.Close()
-			var data struct {
-				Data struct{ Label string `json:"account_label"` } `json:"data"`
-			}
-			json.NewDecoder(resp.Body).Decode(&data)
-			if data.Data.Label != "" {
-				return matchServiceLabel(data.Data.Label)
-			}
-		}
+	if label := config.IdentifyKnownTronAddress(address); label != "" {
+		return label
 	}
 
-	// EVM contract detection — prefer RPC, fallback to Etherscan
-	if strings.HasPrefix(address, "0x") && chain != config.ChainSolana {
-		// Try RPC first (faster, no rate limit)
-		if rpcURL := s.cfg.EVMRPC[chain]; rpcURL != "" {
-			if s.isContract(ctx, rpcURL, address) {
-				return "contract"
-			}
-			return "unknown"
-		}
+	label, err := withFallback(s, chain, "identify", func(p ChainDataProvider) (string, error) {
+		return p.IdentifyAddress(ctx, address, chain)
+	})
+	if err != nil || label == "" {
+		return "unknown"
+	}
+	return label
+}
 
-		// Etherscan fallback
-		apiURL := s.cfg.GetExplorerURL(chain)
-		apiKey := s.cfg.GetExplorerKey(chain)
-		if apiURL != "" && apiKey != "" {
-			url := fmt.Sprintf("%s?module=contract&action=getabi&address=%s&apikey=%s",
-				apiURL, address, apiKey)
-			body, err := s.getJSON(ctx, url)
-			if err == nil {
-				var result struct {
-					Status string `json:"status"`
-					Result string `json:"result"`
-				}
-				json.Unmarshal(body, &result)
-				if result.Status == "1" && result.Result != "Contract source code not verified" {
-					return "contract"
-				}
-			}
-		}
+// IdentifyAddress checks whether an EVM address is a verified contract.
+func (e *etherscanProvider) IdentifyAddress(ctx context.Context, address string, chain config.Chain) (string, error) {
+	if !strings.HasPrefix(address, "0x") {
+		return "", ErrUnsupported
+	}
+	body, err := e.getJSON(ctx, endpoint(e.cfg.GetExplorerURL(chain), nil, url.Values{
+		"module": {"contract"}, "action": {"getabi"}, "address": {address}, "apikey": {e.cfg.GetExplorerKey(chain)},
+	}))
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Status string `json:"status"`
+		Result string `json:"result"`
+	}
+	json.Unmarshal(body, &result)
+	if result.Status == "1" && result.Result != "Contract source code not verified" {
+		return "contract", nil
 	}
+	return "unknown", nil
+}
+
+// solscanProvider labels Solana addresses (exchanges, services) via Solscan.
+type solscanProvider struct {
+	unsupported
+	*Scanner
+}
 
-	return "unknown"
+func (p *solscanProvider) Name() string { return "solscan" }
+
+func (p *solscanProvider) Supports(chain config.Chain) bool {
+	return chain == config.ChainSolana && p.cfg.SolscanAPIKey != ""
+}
+
+func (p *solscanProvider) IdentifyAddress(ctx context.Context, address string, chain config.Chain) (string, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", endpoint(solscanAPI, []string{"account", address}, nil), nil)
+	req.Header.Set("token", p.cfg.SolscanAPIKey)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("HTTP %d from solscan", resp.StatusCode)
+	}
+	var data struct {
+		Data struct{ Label string `json:"account_label"` } `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&data)
+	if data.Data.Label != "" {
+		return matchServiceLabel(data.Data.Label), nil
+	}
+	return "unknown", nil
 }
 
 type etherscanResult = map[string]interface{}
 
 func (s *Scanner) etherscanList(ctx context.Context, apiURL, apiKey, address, action string) ([]etherscanResult, error) {
-	url := fmt.Sprintf("%s?module=account&action=%s&address=%s&startblock=0&endblock=99999999&page=1&offset=100&sort=desc&apikey=%s",
-		apiURL, action, address, apiKey)
-
-	body, err := s.getJSON(ctx, url)
+	body, err := s.getJSON(ctx, endpoint(apiURL, nil, url.Values{
+		"module": {"account"}, "action": {action}, "address": {address},
+		"startblock": {"0"}, "endblock": {"99999999"}, "page": {"1"}, "offset": {"100"}, "sort": {"desc"}, "apikey": {apiKey},
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -715,8 +832,8 @@ func (s *Scanner) etherscanList(ctx context.Context, apiURL, apiKey, address, ac
 	return result.Result, nil
 }
 
-func (s *Scanner) getJSON(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+func (s *Scanner) getJSON(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -727,7 +844,7 @@ func (s *Scanner) getJSON(ctx context.Context, url string) ([]byte, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host)
 	}
 	return io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB max
 }
@@ -738,14 +855,13 @@ func str(m map[string]interface{}, key string) string {
 }
 
 func nativeSymbol(chain config.Chain) string {
-	if chain == config.ChainBSC {
-		return "BNB"
-	}
-	return "ETH"
+	return config.NativeSymbol(chain)
 }
 
 // ── Live Price Fetching ─────────────────────────────────────
 
+const dexScreenerAPI = "https://api.dexscreener.com/latest/dex"
+
 var (
 	priceCache     = map[string]cachedPrice{}
 	priceCacheLock sync.RWMutex
@@ -756,37 +872,19 @@ type cachedPrice struct {
 	fetched time.Time
 }
 
-// getSolPrice returns the current SOL/USD price via DexScreener (free, no API key).
-// Caches for 60 seconds to avoid rate limits.
-func (s *Scanner) getSolPrice(ctx context.Context) float64 {
-	return s.getTokenPrice(ctx, "solana", "So11111111111111111111111111111111111111112")
-}
-
-// getETHPrice returns the current ETH/USD price.
-func (s *Scanner) getETHPrice(ctx context.Context) float64 {
-	return s.getTokenPrice(ctx, "ethereum", "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
-}
-
-// getBNBPrice returns the current BNB/USD price.
-func (s *Scanner) getBNBPrice(ctx context.Context) float64 {
-	return s.getTokenPrice(ctx, "bsc", "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
-}
-
-// getNativePrice returns the native token price for any chain.
+// getNativePrice returns the current USD price of the chain's coin, via its
+// wrapped token on DexScreener (free, no API key).
 func (s *Scanner) getNativePrice(ctx context.Context, chain config.Chain) float64 {
-	switch chain {
-	case config.ChainSolana:
-		return s.getSolPrice(ctx)
-	case config.ChainBSC:
-		return s.getBNBPrice(ctx)
-	default: // ETH, Base (uses ETH)
-		return s.getETHPrice(ctx)
-	}
+	ci, _ := config.LookupChain(chain)
+	return s.getTokenPrice(ctx, chain, ci.WrappedNative)
 }
 
-// getTokenPrice fetches from DexScreener with 60s cache.
-func (s *Scanner) getTokenPrice(ctx context.Context, dexChain, tokenAddr string) float64 {
-	cacheKey := dexChain + ":" + tokenAddr
+// getTokenPrice fetches from DexScreener with 60s cache, using the pairs on
+// chain when DexScreener knows it.
+func (s *Scanner) getTokenPrice(ctx context.Context, chain config.Chain, tokenAddr string) float64 {
+	ci, _ := config.LookupChain(chain)
+	dexChain := ci.DexScreener
+	cacheKey := string(chain) + ":" + tokenAddr
 
 	priceCacheLock.RLock()
 	if c, ok := priceCache[cacheKey]; ok && time.Since(c.fetched) < 60*time.Second {
@@ -795,10 +893,12 @@ func (s *Scanner) getTokenPrice(ctx context.Context, dexChain, tokenAddr string)
 	}
 	priceCacheLock.RUnlock()
 
-	url := fmt.Sprintf("https://api.dexscreener.com/latest/dex/tokens/%s", tokenAddr)
-	body, err := s.getJSON(ctx, url)
+	if tokenAddr == "" {
+		return fallbackPrice(chain)
+	}
+	body, err := s.getJSON(ctx, endpoint(dexScreenerAPI, []string{"tokens", tokenAddr}, nil))
 	if err != nil {
-		return fallbackPrice(dexChain)
+		return fallbackPrice(chain)
 	}
 
 	var result struct {
@@ -809,13 +909,16 @@ func (s *Scanner) getTokenPrice(ctx context.Context, dexChain, tokenAddr string)
 		} `json:"pairs"`
 	}
 	if json.Unmarshal(body, &result) != nil || len(result.Pairs) == 0 {
-		return fallbackPrice(dexChain)
+		return fallbackPrice(chain)
 	}
 
 	// Pick highest liquidity pair
 	bestPrice := 0.0
 	bestLiq := 0.0
 	for _, p := range result.Pairs {
+		if dexChain != "" && p.ChainID != dexChain {
+			continue // same address on another chain (e.g. OP-stack WETH)
+		}
 		if price := parseFloat(p.PriceUSD); price > 0 && p.Liquidity.USD > bestLiq {
 			bestPrice = price
 			bestLiq = p.Liquidity.USD
@@ -829,21 +932,16 @@ func (s *Scanner) getTokenPrice(ctx context.Context, dexChain, tokenAddr string)
 		return bestPrice
 	}
 
-	return fallbackPrice(dexChain)
+	return fallbackPrice(chain)
 }
 
-func fallbackPrice(chain string) float64 {
-	// Conservative fallback prices if API is down
-	switch chain {
-	case "solana":
-		return 150.0
-	case "ethereum":
-		return 2500.0
-	case "bsc":
-		return 300.0
-	default:
-		return 2500.0
+// fallbackPrice is the registry's conservative native price for when the
+// API is down.
+func fallbackPrice(chain config.Chain) float64 {
+	if ci, _ := config.LookupChain(chain); ci.FallbackPrice > 0 {
+		return ci.FallbackPrice
 	}
+	return 2500.0
 }
 
 func parseFloat(s string) float64 {
@@ -853,20 +951,23 @@ func parseFloat(s string) float64 {
 
 // ── EVM Token Buyers ────────────────────────────────────────
 
-// evmTokenBuyers fetches recent buyers of an ERC-20 token using Etherscan/Basescan token transfer API.
+// TokenBuyers fetches recent buyers of an ERC-20 token using Etherscan/Basescan token transfer API.
 // Enriches with USD amounts by looking up token price from DexScreener.
-func (s *Scanner) evmTokenBuyers(ctx context.Context, tokenAddr string, chain config.Chain) ([]TokenBuyer, error) {
-	apiURL := s.cfg.GetExplorerURL(chain)
-	apiKey := s.cfg.GetExplorerKey(chain)
+func (e *etherscanProvider) TokenBuyers(ctx context.Context, tokenAddr string, chain config.Chain) ([]TokenBuyer, error) {
+	if !strings.HasPrefix(tokenAddr, "0x") {
+		return nil, ErrUnsupported
+	}
+	apiURL := e.cfg.GetExplorerURL(chain)
+	apiKey := e.cfg.GetExplorerKey(chain)
 	if apiURL == "" || apiKey == "" {
 		return nil, fmt.Errorf("no explorer config for %s", chain)
 	}
 
 	// Fetch recent token transfers for this contract
-	url := fmt.Sprintf("%s?module=account&action=tokentx&contractaddress=%s&page=1&offset=100&sort=desc&apikey=%s",
-		apiURL, tokenAddr, apiKey)
-
-	body, err := s.getJSON(ctx, url)
+	body, err := e.getJSON(ctx, endpoint(apiURL, nil, url.Values{
+		"module": {"account"}, "action": {"tokentx"}, "contractaddress": {tokenAddr},
+		"page": {"1"}, "offset": {"100"}, "sort": {"desc"}, "apikey": {apiKey},
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -881,14 +982,7 @@ func (s *Scanner) evmTokenBuyers(ctx context.Context, tokenAddr string, chain co
 	}
 
 	// Look up token price once for all buyers (DexScreener, cached 60s)
-	dexChain := "ethereum"
-	switch chain {
-	case config.ChainBSC:
-		dexChain = "bsc"
-	case config.ChainBase:
-		dexChain = "base"
-	}
-	tokenPrice := s.getTokenPrice(ctx, dexChain, tokenAddr)
+	tokenPrice := e.getTokenPrice(ctx, chain, tokenAddr)
 
 	// Deduplicate buyers (wallets that received the token)
 	seen := map[string]bool{}
@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// newTestScanner returns a scanner with no providers over a fresh SQLite
+// store; tests add the providers they exercise.
+func newTestScanner(t *testing.T) (*Scanner, *db.SQLStore) {
+	t.Helper()
+	store, err := db.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s := New(&config.Config{}, store)
+	s.SetProviders()
+	return s, store
+}
+
+// useExplorer points chain's explorer API at url for the rest of the test.
+func useExplorer(t *testing.T, chain config.Chain, url string) {
+	t.Helper()
+	ci, _ := config.LookupChain(chain)
+	config.RegisterChain(config.ChainInfo{Chain: chain, ExplorerAPI: url})
+	t.Cleanup(func() { config.RegisterChain(config.ChainInfo{Chain: chain, ExplorerAPI: ci.ExplorerAPI}) })
+}
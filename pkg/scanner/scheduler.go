@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/db"
+)
+
+// ScanJob scans one wallet. It runs on a scheduler worker; HTTP throttling
+// happens inside the Scanner, so jobs just call it.
+type ScanJob func(ctx context.Context, w db.TrackedWallet)
+
+// Scheduler runs scan cycles over the tracked wallets with a fixed pool of
+// workers. Each cycle queues wallets by priority: confidence, boosted for
+// wallets that traded recently.
+type Scheduler struct {
+	store   db.Store
+	workers int
+	job     ScanJob
+
+	mu    sync.Mutex
+	stats SchedulerStats
+}
+
+// SchedulerStats is the queue state shown on the dashboard.
+type SchedulerStats struct {
+	Workers       int        `json:"workers"`
+	Running       bool       `json:"running"`
+	Queued        int        `json:"queued"`
+	InFlight      int        `json:"in_flight"`
+	CycleWallets  int        `json:"cycle_wallets"`
+	CycleStarted  *time.Time `json:"cycle_started,omitempty"`
+	LastCycleSecs float64    `json:"last_cycle_secs"`
+	SkippedTicks  int        `json:"skipped_ticks"` // ticks that arrived while a cycle was still running
+}
+
+func NewScheduler(store db.Store, workers int, job ScanJob) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Scheduler{store: store, workers: workers, job: job, stats: SchedulerStats{Workers: workers}}
+}
+
+// Stats returns a snapshot of the queue.
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// TryCycle runs a cycle unless one is already in progress, in which case the
+// tick is counted as skipped. Call it in its own goroutine from a ticker so a
+// slow cycle shows up as skipped ticks instead of silently dropped ones.
+func (s *Scheduler) TryCycle(ctx context.Context, minConfidence float64) {
+	s.mu.Lock()
+	if s.stats.Running {
+		s.stats.SkippedTicks++
+		queued := s.stats.Queued
+		s.mu.Unlock()
+		log.Warn().Int("queued", queued).Msg("scan cycle still running; consider more SCAN_WORKERS or a longer CHAIN_SCAN_INTERVAL")
+		return
+	}
+	s.stats.Running = true
+	s.mu.Unlock()
+	s.Cycle(ctx, minConfidence)
+}
+
+// Cycle scans every wallet with confidence >= minConfidence once, highest
+// priority first, and returns when all are done or ctx ends.
+func (s *Scheduler) Cycle(ctx context.Context, minConfidence float64) {
+	start := time.Now()
+	wallets := s.queue(minConfidence)
+
+	s.mu.Lock()
+	s.stats.Running = true
+	s.stats.Queued = len(wallets)
+	s.stats.CycleWallets = len(wallets)
+	s.stats.CycleStarted = &start
+	s.mu.Unlock()
+
+	jobs := make(chan db.TrackedWallet)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w := range jobs {
+				s.mu.Lock()
+				s.stats.Queued--
+				s.stats.InFlight++
+				s.mu.Unlock()
+				s.job(ctx, w)
+				s.mu.Lock()
+				s.stats.InFlight--
+				s.mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, w := range wallets {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- w:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.mu.Lock()
+	s.stats.Running = false
+	s.stats.Queued = 0
+	s.stats.LastCycleSecs = time.Since(start).Seconds()
+	s.mu.Unlock()
+}
+
+// queue returns the wallets to scan this cycle, highest priority first.
+func (s *Scheduler) queue(minConfidence float64) []db.TrackedWallet {
+	ws, err := s.store.GetAllTrackedAddresses()
+	if err != nil {
+		log.Warn().Err(err).Msg("scan queue: load wallets")
+		return nil
+	}
+	active, _ := s.store.GetLastActivity()
+	now := time.Now()
+
+	var out []db.TrackedWallet
+	prio := map[int64]float64{}
+	for _, w := range ws {
+		if w.Confidence < minConfidence {
+			continue
+		}
+		out = append(out, w)
+		prio[w.ID] = scanPriority(w.Confidence, active[w.ID], now)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return prio[out[i].ID] > prio[out[j].ID] })
+	return out
+}
+
+// scanPriority is the wallet's confidence plus a recency bonus: wallets that
+// traded in the last day or week are more likely to have new activity.
+func scanPriority(confidence float64, lastActive, now time.Time) float64 {
+	p := confidence
+	switch age := now.Sub(lastActive); {
+	case lastActive.IsZero():
+	case age < 24*time.Hour:
+		p += 1
+	case age < 7*24*time.Hour:
+		p += 0.5
+	}
+	return p
+}
@@ -0,0 +1,340 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ── Solana instruction decoding ─────────────────────────────
+// Classifies a raw getTransaction (jsonParsed) the way Helius's enhanced API
+// does, so scanning without a Helius key keeps the DEX, priority fee and Jito
+// tip fingerprints the analyzer relies on.
+
+const (
+	wsolMint             = "So11111111111111111111111111111111111111112"
+	computeBudgetProgram = "ComputeBudget111111111111111111111111111111"
+	jupiterV6Program     = "JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4"
+)
+
+// solDEX is a swap program: the platform name Helius reports for it and the
+// index of the pool account in its swap instructions.
+type solDEX struct {
+	platform string
+	pool     func(data []byte, accounts []string) string
+}
+
+// poolAt returns the account at i, for programs whose swaps all share a layout.
+func poolAt(i int) func([]byte, []string) string {
+	return func(_ []byte, accounts []string) string {
+		if i < len(accounts) {
+			return accounts[i]
+		}
+		return ""
+	}
+}
+
+// Anchor instruction discriminators, sha256("global:<name>")[:8].
+var (
+	discSwap    = mustHex("f8c69e91e17587c8")
+	discSwapV2  = mustHex("2b04ed0b1ac91e62")
+	discPumpBuy = mustHex("66063d1201daebea")
+	discPumpSel = mustHex("33e685a4017f83ad")
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func hasDisc(data, disc []byte) bool {
+	return len(data) >= 8 && string(data[:8]) == string(disc)
+}
+
+var solDEXPrograms = map[string]solDEX{
+	jupiterV6Program: {platform: "JUPITER"},
+	"JUP4Fb2cqiRUcaTHdrPC8h2gNsA2ETXiPDD33WcGuJB": {platform: "JUPITER"},
+	// Raydium AMM v4: swapBaseIn (9) / swapBaseOut (11), amm at 1
+	"675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wgSUuUjzX": {platform: "RAYDIUM", pool: func(data []byte, accounts []string) string {
+		if len(data) > 0 && (data[0] == 9 || data[0] == 11) {
+			return poolAt(1)(data, accounts)
+		}
+		return ""
+	}},
+	// Raydium CLMM: swap / swap_v2, pool_state at 2
+	"CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK": {platform: "RAYDIUM", pool: func(data []byte, accounts []string) string {
+		if hasDisc(data, discSwap) || hasDisc(data, discSwapV2) {
+			return poolAt(2)(data, accounts)
+		}
+		return ""
+	}},
+	// Raydium CPMM: pool_state at 3
+	"CPMMoo8L3F4NbTegBCKVNunggL7H1ZpdTHKxQB5qKP1C": {platform: "RAYDIUM", pool: poolAt(3)},
+	// Orca Whirlpool: whirlpool at 2 in swap, 4 in swap_v2
+	"whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc": {platform: "ORCA", pool: func(data []byte, accounts []string) string {
+		switch {
+		case hasDisc(data, discSwap):
+			return poolAt(2)(data, accounts)
+		case hasDisc(data, discSwapV2):
+			return poolAt(4)(data, accounts)
+		}
+		return ""
+	}},
+	// Meteora DLMM (lb_pair at 0) and dynamic AMM (pool at 0)
+	"LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9YuVaPwxo":  {platform: "METEORA", pool: poolAt(0)},
+	"Eo7WjKq67rjJQSZxS6z3YkapzY3eMj6Xy8X5EQVn5UaB": {platform: "METEORA", pool: poolAt(0)},
+	// Pump.fun bonding curve: buy / sell, bonding_curve at 3
+	"6EF8rrecthR5Dkzon8Nwu78hRvfCKubJ14M5uBEwF6P": {platform: "PUMP_FUN", pool: func(data []byte, accounts []string) string {
+		if hasDisc(data, discPumpBuy) || hasDisc(data, discPumpSel) {
+			return poolAt(3)(data, accounts)
+		}
+		return ""
+	}},
+	// PumpSwap AMM (graduated pump.fun tokens): pool at 0
+	"pAMMBay6oceH9fJKBRHGP5D4bD4sWpmSwMn52FMfXEA": {platform: "PUMP_AMM", pool: poolAt(0)},
+}
+
+// jitoTipAccounts are the Jito block engine's tip receivers.
+var jitoTipAccounts = map[string]bool{
+	"96gYZGLnJYVFmbjzopPSU6QiEV5fGqZNyN9nmNhvrZU5": true,
+	"HFqU5x63VTqvQss8hp11i4wVV8bD44PvwucfZ2bU7gRe": true,
+	"Cw8CFyM9FkoMi7K7Crf6HNQqf4uEMzpKw6QNghXLvLkY": true,
+	"ADaUMid9yfUytqMBgopwjb2DTLSokTSzL1zt6iGPaS49": true,
+	"DfXygSm4jCyNCybVYYK6DwvWqjKee8pbDmJGcLWNDXjh": true,
+	"ADuUkR4vqLUMWXxW9gh6D6L8pMSawimctcNZ5pGwDcEt": true,
+	"DttWaMuVvTiduZRnguLF7jNxTgiMBZ1hyAumKUiL2KRL": true,
+	"3AVi9Tg9Uo68tJfuvoKvqKNWKkC5wPdSSdeBnizKZ6jT": true,
+}
+
+// solParsedTx is the part of a jsonParsed getTransaction result the decoder
+// reads.
+type solParsedTx struct {
+	BlockTime *int64 `json:"blockTime"`
+	Meta      *struct {
+		Fee               int64             `json:"fee"`
+		PreBalances       []int64           `json:"preBalances"`
+		PostBalances      []int64           `json:"postBalances"`
+		PreTokenBalances  []solTokenBalance `json:"preTokenBalances"`
+		PostTokenBalances []solTokenBalance `json:"postTokenBalances"`
+		InnerInstructions []struct {
+			Index        int              `json:"index"`
+			Instructions []solInstruction `json:"instructions"`
+		} `json:"innerInstructions"`
+		Err interface{} `json:"err"`
+	} `json:"meta"`
+	Transaction *struct {
+		Message struct {
+			AccountKeys []struct {
+				Pubkey string `json:"pubkey"`
+			} `json:"accountKeys"`
+			Instructions []solInstruction `json:"instructions"`
+		} `json:"message"`
+	} `json:"transaction"`
+}
+
+// solInstruction is an instruction as jsonParsed returns it: programs the
+// node knows (system, spl-token) come parsed, the rest as base58 data.
+type solInstruction struct {
+	ProgramID string   `json:"programId"`
+	Accounts  []string `json:"accounts"`
+	Data      string   `json:"data"`
+	Parsed    *struct {
+		Type string `json:"type"`
+		Info struct {
+			Source      string          `json:"source"`
+			Destination string          `json:"destination"`
+			Lamports    json.RawMessage `json:"lamports"`
+		} `json:"info"`
+	} `json:"parsed"`
+}
+
+// solDecoded is one transaction from the wallet's point of view.
+type solDecoded struct {
+	Platform  string  `json:"dex,omitempty"`
+	Pool      string  `json:"pool,omitempty"`
+	InMint    string  `json:"in_mint,omitempty"`
+	InAmount  float64 `json:"in_amount,omitempty"`
+	OutMint   string  `json:"out_mint,omitempty"`
+	OutAmount float64 `json:"out_amount,omitempty"`
+
+	CULimit          uint32 `json:"cu_limit,omitempty"`
+	CUPrice          uint64 `json:"cu_price_micro_lamports,omitempty"`
+	PriorityLamports int64  `json:"priority_lamports,omitempty"`
+	JitoTipLamports  int64  `json:"jito_tip_lamports,omitempty"`
+	FeeLamports      int64  `json:"fee_lamports"`
+}
+
+// isSwap reports whether the wallet traded one asset for another. Platform
+// is empty when the route went through a program the table doesn't know.
+func (d solDecoded) isSwap() bool {
+	return d.InMint != "" && d.OutMint != "" && d.InMint != d.OutMint
+}
+
+// decodeSolanaTx reads the DEX, pool, compute budget and Jito tip from the
+// instructions, and the wallet's input and output from its net balance
+// changes (SOL and wrapped SOL count as one asset, net of fee and tip).
+func decodeSolanaTx(p *solParsedTx, address string) solDecoded {
+	var d solDecoded
+	if p.Meta == nil || p.Transaction == nil {
+		return d
+	}
+	d.FeeLamports = p.Meta.Fee
+
+	inner := map[int][]solInstruction{}
+	for _, ii := range p.Meta.InnerInstructions {
+		inner[ii.Index] = ii.Instructions
+	}
+	topLevel := 0
+	for i, ix := range p.Transaction.Message.Instructions {
+		if ix.ProgramID == computeBudgetProgram {
+			readComputeBudget(ix.Data, &d)
+			continue
+		}
+		topLevel++
+		d.noteInstruction(ix, address)
+		for _, in := range inner[i] {
+			d.noteInstruction(in, address)
+		}
+	}
+	if d.CUPrice > 0 {
+		limit := uint64(d.CULimit)
+		if limit == 0 {
+			limit = uint64(math.Min(float64(200_000*topLevel), 1_400_000))
+		}
+		d.PriorityLamports = int64((d.CUPrice*limit + 999_999) / 1_000_000)
+	}
+
+	// net balance changes by mint
+	delta := map[string]float64{}
+	pre := mapTokenBalances(p.Meta.PreTokenBalances, address)
+	post := mapTokenBalances(p.Meta.PostTokenBalances, address)
+	for mint, v := range post {
+		delta[mint] += v
+	}
+	for mint, v := range pre {
+		delta[mint] -= v
+	}
+	for i, ak := range p.Transaction.Message.AccountKeys {
+		if ak.Pubkey != address || i >= len(p.Meta.PreBalances) || i >= len(p.Meta.PostBalances) {
+			continue
+		}
+		lamports := p.Meta.PostBalances[i] - p.Meta.PreBalances[i] + d.JitoTipLamports
+		if i == 0 {
+			lamports += p.Meta.Fee // the fee payer is always account 0
+		}
+		delta[wsolMint] += float64(lamports) / 1e9
+	}
+
+	var inAmt, outAmt float64
+	for mint, v := range delta {
+		if math.Abs(v) < dustFor(mint) {
+			continue
+		}
+		switch {
+		case v < 0 && -v*weightFor(mint) > inAmt*weightFor(d.InMint):
+			d.InMint, d.InAmount, inAmt = mint, -v, -v
+		case v > 0 && v*weightFor(mint) > outAmt*weightFor(d.OutMint):
+			d.OutMint, d.OutAmount, outAmt = mint, v, v
+		}
+	}
+	return d
+}
+
+// dustFor is the smallest balance change worth reporting: ATA rent and
+// rounding on SOL, anything on other mints.
+func dustFor(mint string) float64 {
+	if mint == wsolMint {
+		return 0.0025
+	}
+	return 1e-12
+}
+
+// weightFor prefers quote assets when a wallet's balance moved in several
+// mints, so the swap's SOL/stable leg is picked over incidental dust.
+func weightFor(mint string) float64 {
+	if solQuoteMints[mint] {
+		return 1e6
+	}
+	return 1
+}
+
+// noteInstruction records what one instruction says about the swap: the
+// DEX (an aggregator wins over the pools it routes through), the first
+// pool, and Jito tips paid by the wallet.
+func (d *solDecoded) noteInstruction(ix solInstruction, address string) {
+	if ix.Parsed != nil && ix.ProgramID == "11111111111111111111111111111111" && ix.Parsed.Type == "transfer" &&
+		ix.Parsed.Info.Source == address && jitoTipAccounts[ix.Parsed.Info.Destination] {
+		var lamports int64
+		json.Unmarshal(ix.Parsed.Info.Lamports, &lamports)
+		d.JitoTipLamports += lamports
+		return
+	}
+	dex, ok := solDEXPrograms[ix.ProgramID]
+	if !ok {
+		return
+	}
+	if d.Platform == "" || ix.ProgramID == jupiterV6Program {
+		d.Platform = dex.platform
+	}
+	if d.Pool == "" && dex.pool != nil {
+		d.Pool = dex.pool(base58Decode(ix.Data), ix.Accounts)
+	}
+}
+
+// readComputeBudget decodes SetComputeUnitLimit (2, u32) and
+// SetComputeUnitPrice (3, u64 micro-lamports).
+func readComputeBudget(data string, d *solDecoded) {
+	b := base58Decode(data)
+	switch {
+	case len(b) >= 5 && b[0] == 2:
+		d.CULimit = binary.LittleEndian.Uint32(b[1:5])
+	case len(b) >= 9 && b[0] == 3:
+		d.CUPrice = binary.LittleEndian.Uint64(b[1:9])
+	}
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Decode decodes Bitcoin-alphabet base58, returning nil on bad input.
+func base58Decode(s string) []byte {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	out := n.Bytes()
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), out...)
+}
+
+// base58Encode encodes b in the Bitcoin base58 alphabet.
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < len(b) && b[i] == 0; i++ {
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
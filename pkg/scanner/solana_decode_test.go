@@ -0,0 +1,206 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+const (
+	solWallet   = "WaLLet1111111111111111111111111111111111111"
+	solMint     = "MemeMint11111111111111111111111111111111111"
+	usdcMint    = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	pumpProgram = "6EF8rrecthR5Dkzon8Nwu78hRvfCKubJ14M5uBEwF6P"
+	jitoTip     = "96gYZGLnJYVFmbjzopPSU6QiEV5fGqZNyN9nmNhvrZU5"
+)
+
+// solFixture describes a jsonParsed transaction whose fee payer is the
+// wallet: its SOL balance and token balances before and after, and the
+// instructions (inner ones keyed by their top-level index).
+type solFixture struct {
+	fee        int64
+	pre, post  int64
+	preTokens  map[string]float64
+	postTokens map[string]float64
+	ixs        []map[string]interface{}
+	inner      map[int][]map[string]interface{}
+}
+
+func (f solFixture) tx(t *testing.T) *solParsedTx {
+	t.Helper()
+	balances := func(tokens map[string]float64) []map[string]interface{} {
+		var out []map[string]interface{}
+		for mint, amount := range tokens {
+			amount := amount
+			out = append(out, map[string]interface{}{"accountIndex": 1, "mint": mint, "owner": solWallet,
+				"uiTokenAmount": map[string]interface{}{"uiAmount": &amount}})
+		}
+		return out
+	}
+	var inner []map[string]interface{}
+	for i, ixs := range f.inner {
+		inner = append(inner, map[string]interface{}{"index": i, "instructions": ixs})
+	}
+	raw, _ := json.Marshal(map[string]interface{}{
+		"blockTime": 1700000000,
+		"meta": map[string]interface{}{
+			"fee": f.fee, "preBalances": []int64{f.pre, 0}, "postBalances": []int64{f.post, 0},
+			"preTokenBalances": balances(f.preTokens), "postTokenBalances": balances(f.postTokens),
+			"innerInstructions": inner,
+		},
+		"transaction": map[string]interface{}{"message": map[string]interface{}{
+			"accountKeys":  []map[string]string{{"pubkey": solWallet}, {"pubkey": "TokenAcct111"}},
+			"instructions": f.ixs,
+		}},
+	})
+	var p solParsedTx
+	if err := json.Unmarshal(raw, &p); err != nil {
+		t.Fatal(err)
+	}
+	return &p
+}
+
+func solIx(program string, data []byte, accounts ...string) map[string]interface{} {
+	return map[string]interface{}{"programId": program, "data": base58Encode(data), "accounts": accounts}
+}
+
+func cuLimit(units uint32) map[string]interface{} {
+	b := make([]byte, 5)
+	b[0] = 2
+	binary.LittleEndian.PutUint32(b[1:], units)
+	return solIx(computeBudgetProgram, b)
+}
+
+func cuPrice(microLamports uint64) map[string]interface{} {
+	b := make([]byte, 9)
+	b[0] = 3
+	binary.LittleEndian.PutUint64(b[1:], microLamports)
+	return solIx(computeBudgetProgram, b)
+}
+
+func solTransfer(from, to string, lamports int64) map[string]interface{} {
+	return map[string]interface{}{"programId": "11111111111111111111111111111111", "parsed": map[string]interface{}{
+		"type": "transfer", "info": map[string]interface{}{"source": from, "destination": to, "lamports": lamports}}}
+}
+
+func TestDecodeSolanaTx(t *testing.T) {
+	pumpBuy := append(append([]byte{}, discPumpBuy...), make([]byte, 16)...)
+	tests := []struct {
+		name   string
+		fx     solFixture
+		want   solDecoded
+		isSwap bool
+	}{
+		{
+			name: "pump.fun buy with priority fee and Jito tip",
+			fx: solFixture{
+				fee: 5000, pre: 10_000_000_000, post: 10_000_000_000 - 1_000_000_000 - 5000 - 100_000,
+				postTokens: map[string]float64{solMint: 35_000},
+				ixs: []map[string]interface{}{
+					cuLimit(100_000), cuPrice(2_500_000),
+					solIx(pumpProgram, pumpBuy, "global", "feeRecipient", solMint, "BondingCurve1", "curveATA", "userATA", solWallet),
+					solTransfer(solWallet, jitoTip, 100_000),
+				},
+			},
+			want: solDecoded{Platform: "PUMP_FUN", Pool: "BondingCurve1", InMint: wsolMint, InAmount: 1, OutMint: solMint,
+				OutAmount: 35_000, CULimit: 100_000, CUPrice: 2_500_000, PriorityLamports: 250_000, JitoTipLamports: 100_000, FeeLamports: 5000},
+			isSwap: true,
+		},
+		{
+			name: "Jupiter sell routed through Raydium",
+			fx: solFixture{
+				fee: 5000, pre: 1_000_000_000, post: 3_000_000_000 - 5000,
+				preTokens: map[string]float64{solMint: 500}, postTokens: map[string]float64{solMint: 0},
+				ixs: []map[string]interface{}{solIx(jupiterV6Program, []byte{0xe5, 0x17, 0xcb, 0x97}, solWallet)},
+				inner: map[int][]map[string]interface{}{0: {
+					solIx("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wgSUuUjzX", []byte{9, 1, 2}, "tokenProgram", "RaydiumAmm1"),
+				}},
+			},
+			want: solDecoded{Platform: "JUPITER", Pool: "RaydiumAmm1", InMint: solMint, InAmount: 500, OutMint: wsolMint,
+				OutAmount: 2, FeeLamports: 5000},
+			isSwap: true,
+		},
+		{
+			name: "default compute limit per instruction",
+			fx: solFixture{
+				fee: 5000, pre: 1_000_000_000, post: 1_000_000_000 - 5000,
+				ixs: []map[string]interface{}{cuPrice(1_000_000), solIx("Memo1111", nil), solIx("Memo1111", nil)},
+			},
+			want: solDecoded{CUPrice: 1_000_000, PriorityLamports: 400_000, FeeLamports: 5000},
+		},
+		{
+			name: "USDC leg wins over ATA rent",
+			fx: solFixture{
+				fee: 5000, pre: 1_000_000_000, post: 1_000_000_000 - 5000 - 2_039_280,
+				preTokens: map[string]float64{usdcMint: 80}, postTokens: map[string]float64{usdcMint: 30, solMint: 12},
+				ixs: []map[string]interface{}{
+					solIx("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc", append(append([]byte{}, discSwapV2...), 0),
+						"tokenProgramA", "tokenProgramB", "memo", solWallet, "Whirlpool1"),
+				},
+			},
+			want: solDecoded{Platform: "ORCA", Pool: "Whirlpool1", InMint: usdcMint, InAmount: 50, OutMint: solMint,
+				OutAmount: 12, FeeLamports: 5000},
+			isSwap: true,
+		},
+		{
+			name: "plain SOL transfer is no swap",
+			fx: solFixture{
+				fee: 5000, pre: 5_000_000_000, post: 4_000_000_000 - 5000,
+				ixs: []map[string]interface{}{solTransfer(solWallet, "Friend111", 1_000_000_000)},
+			},
+			want: solDecoded{InMint: wsolMint, InAmount: 1, FeeLamports: 5000},
+		},
+		{
+			name: "tip paid by someone else is not the wallet's",
+			fx: solFixture{
+				fee: 5000, pre: 1_000_000_000, post: 1_000_000_000 - 5000,
+				ixs: []map[string]interface{}{solTransfer("Bundler111", jitoTip, 50_000)},
+			},
+			want: solDecoded{FeeLamports: 5000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeSolanaTx(tt.fx.tx(t), solWallet)
+			if !near(got.InAmount, tt.want.InAmount) || !near(got.OutAmount, tt.want.OutAmount) {
+				t.Errorf("amounts = %v in, %v out; want %v, %v", got.InAmount, got.OutAmount, tt.want.InAmount, tt.want.OutAmount)
+			}
+			got.InAmount, got.OutAmount = tt.want.InAmount, tt.want.OutAmount
+			if got != tt.want {
+				t.Errorf("decoded = %+v\nwant      %+v", got, tt.want)
+			}
+			if got.isSwap() != tt.isSwap {
+				t.Errorf("isSwap = %v, want %v", got.isSwap(), tt.isSwap)
+			}
+		})
+	}
+
+	if got := decodeSolanaTx(&solParsedTx{}, solWallet); got != (solDecoded{}) {
+		t.Errorf("tx without meta decoded to %+v", got)
+	}
+}
+
+func TestBase58(t *testing.T) {
+	tests := []struct {
+		raw     []byte
+		encoded string
+	}{
+		{nil, ""},
+		{[]byte{0}, "1"},
+		{[]byte{0, 0, 1}, "112"},
+		{[]byte("hello world"), "StV1DL6CwTryKyV"},
+		{make([]byte, 32), "11111111111111111111111111111111"},
+	}
+	for _, tt := range tests {
+		if got := base58Encode(tt.raw); got != tt.encoded {
+			t.Errorf("base58Encode(%x) = %q, want %q", tt.raw, got, tt.encoded)
+		}
+		if got := base58Decode(tt.encoded); !bytes.Equal(got, tt.raw) {
+			t.Errorf("base58Decode(%q) = %x, want %x", tt.encoded, got, tt.raw)
+		}
+	}
+	if base58Decode("0OIl") != nil {
+		t.Error("base58Decode accepted characters outside the alphabet")
+	}
+}
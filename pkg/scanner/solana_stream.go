@@ -0,0 +1,187 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// SolanaStream follows tracked Solana wallets in real time. Each wallet with
+// confidence >= cfg.StreamMinConfidence gets a logsSubscribe on
+// cfg.SolanaWSURL; every confirmed transaction that mentions it is fetched
+// over RPC, decoded like a polled one, stored and passed to the handlers.
+// Polling scans keep running and fill anything missed while disconnected.
+type SolanaStream struct {
+	streamBase
+	sc *Scanner
+
+	subs   map[string]db.TrackedWallet // subscription id -> wallet
+	byAddr map[string]string           // address -> subscription id
+}
+
+func NewSolanaStream(sc *Scanner) *SolanaStream {
+	st := &SolanaStream{sc: sc}
+	st.stats.Chain = config.ChainSolana
+	return st
+}
+
+// Run keeps a subscription session open until ctx ends, reconnecting with
+// backoff when the socket drops.
+func (st *SolanaStream) Run(ctx context.Context) error {
+	return st.run(ctx, st.session)
+}
+
+// session runs one connection: subscribe, then handle notifications and
+// resync until the socket fails.
+func (st *SolanaStream) session(ctx context.Context) error {
+	conn, err := dialWS(ctx, st.sc.cfg.SolanaWSURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	st.mu.Lock()
+	st.subs, st.byAddr = map[string]db.TrackedWallet{}, map[string]string{}
+	st.mu.Unlock()
+	st.connected(ctx, conn, st.notify)
+
+	if err := st.sync(ctx, conn); err != nil {
+		return err
+	}
+	log.Info().Int("wallets", st.Stats().Subscriptions).Msg("📡 solana stream connected")
+
+	t := time.NewTicker(streamResync)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-conn.Done():
+			return conn.Err()
+		case <-t.C:
+			if err := st.sync(ctx, conn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sync subscribes wallets that qualify and unsubscribes ones that no longer
+// do. A failed subscribe is skipped and retried on the next resync.
+func (st *SolanaStream) sync(ctx context.Context, conn *wsConn) error {
+	ws, err := st.sc.streamWallets(config.ChainSolana)
+	if err != nil {
+		log.Warn().Err(err).Msg("solana stream: load wallets")
+		return nil
+	}
+	want := map[string]db.TrackedWallet{}
+	for _, w := range ws {
+		want[w.Address] = w
+	}
+
+	st.mu.Lock()
+	var drop []string
+	for addr, id := range st.byAddr {
+		if w, ok := want[addr]; ok {
+			st.subs[id] = w // keep confidence and label current
+			delete(want, addr)
+		} else {
+			drop = append(drop, addr)
+		}
+	}
+	st.mu.Unlock()
+
+	for _, addr := range drop {
+		st.mu.Lock()
+		id := st.byAddr[addr]
+		delete(st.byAddr, addr)
+		delete(st.subs, id)
+		st.mu.Unlock()
+		if _, err := conn.call(ctx, "logsUnsubscribe", []interface{}{subIDParam(id)}); err != nil && conn.Err() != nil {
+			return err
+		}
+	}
+	for addr, w := range want {
+		id, err := conn.subscribe(ctx, "logsSubscribe", []interface{}{
+			map[string]interface{}{"mentions": []string{addr}},
+			map[string]interface{}{"commitment": "confirmed"},
+		})
+		if err != nil {
+			if conn.Err() != nil || ctx.Err() != nil {
+				return err
+			}
+			log.Debug().Err(err).Str("addr", abbrev(addr)).Msg("solana stream: subscribe failed")
+			continue
+		}
+		st.mu.Lock()
+		st.subs[id], st.byAddr[addr] = w, id
+		st.mu.Unlock()
+	}
+
+	st.mu.Lock()
+	n := len(st.subs)
+	st.mu.Unlock()
+	st.setSubscriptions(n)
+	return nil
+}
+
+// notify handles one logsNotification in the background so a slow
+// getTransaction never holds up the socket.
+func (st *SolanaStream) notify(ctx context.Context, n wsNotification) {
+	if n.Method != "logsNotification" {
+		return
+	}
+	var res struct {
+		Value struct {
+			Signature string      `json:"signature"`
+			Err       interface{} `json:"err"`
+		} `json:"value"`
+	}
+	if json.Unmarshal(n.Result, &res) != nil || res.Value.Signature == "" || res.Value.Err != nil {
+		return
+	}
+	st.mu.Lock()
+	w, ok := st.subs[n.Subscription]
+	st.mu.Unlock()
+	if !ok {
+		return
+	}
+	go st.handle(ctx, w, res.Value.Signature)
+}
+
+// handle fetches, stores and dispatches one transaction. The RPC node can
+// lag the WebSocket by a moment, so a missing transaction is retried.
+func (st *SolanaStream) handle(ctx context.Context, w db.TrackedWallet, signature string) {
+	var tx *db.WalletTransaction
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		tx, err = st.sc.solanaRPCTx(ctx, st.sc.cfg.SolanaRPCURL, w.ID, w.Address, signature)
+		if !errors.Is(err, errTxNotFound) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+	if err != nil {
+		log.Debug().Err(err).Str("sig", abbrev(signature)).Msg("solana stream: fetch tx")
+		return
+	}
+	if tx == nil {
+		return // failed or didn't move the wallet's funds
+	}
+	if err := st.sc.store.InsertTransaction(*tx); err != nil {
+		log.Warn().Err(err).Str("sig", abbrev(signature)).Msg("solana stream: store tx")
+		return
+	}
+
+	st.emit(ctx, TradeEvent{Wallet: w, Tx: *tx})
+}
@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// TradeEvent is a tracked wallet's transaction, delivered by a stream within
+// seconds of confirming. Tx has already been stored.
+type TradeEvent struct {
+	Wallet db.TrackedWallet
+	Tx     db.WalletTransaction
+}
+
+// TradeHandler receives stream events. Handlers run on the stream's
+// goroutines and should return quickly.
+type TradeHandler func(ctx context.Context, ev TradeEvent)
+
+// BuyerWatcher supplies the tokens a stream should follow for new buyers and
+// receives the buyers it sees (the fresh-wallet monitor implements it).
+type BuyerWatcher interface {
+	WatchedTokens(chain config.Chain) []string
+	OnTokenBuyer(ctx context.Context, chain config.Chain, token string, buyer TokenBuyer)
+}
+
+// StreamStats is a stream's connection state as shown on the dashboard.
+type StreamStats struct {
+	Chain         config.Chain `json:"chain"`
+	Connected     bool         `json:"connected"`
+	Subscriptions int          `json:"subscriptions"`
+	Events        int          `json:"events"`
+	Reconnects    int          `json:"reconnects"`
+	LastEvent     *time.Time   `json:"last_event,omitempty"`
+	LastError     string       `json:"last_error,omitempty"`
+}
+
+// streamResync is how often a stream reconciles its subscriptions with the
+// tracked wallets and token watches.
+const streamResync = 15 * time.Second
+
+// streamBase is the handler list, stats and reconnect loop shared by the
+// chain streams.
+type streamBase struct {
+	mu       sync.Mutex
+	handlers []TradeHandler
+	stats    StreamStats
+}
+
+// OnTrade registers a handler for every decoded transaction.
+func (b *streamBase) OnTrade(h TradeHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Stats returns a snapshot of the connection state.
+func (b *streamBase) Stats() StreamStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// emit counts an event and passes it to the handlers.
+func (b *streamBase) emit(ctx context.Context, ev TradeEvent) {
+	now := time.Now()
+	b.mu.Lock()
+	b.stats.Events++
+	b.stats.LastEvent = &now
+	handlers := append([]TradeHandler(nil), b.handlers...)
+	b.mu.Unlock()
+
+	log.Info().Str("chain", string(ev.Tx.Chain)).Str("addr", abbrev(ev.Wallet.Address)).Str("type", ev.Tx.TxType).
+		Str("token", abbrev(ev.Tx.TokenAddress)).Float64("usd", ev.Tx.AmountUSD).Msg("📡 live tx")
+	for _, h := range handlers {
+		h(ctx, ev)
+	}
+}
+
+func (b *streamBase) setSubscriptions(n int) {
+	b.mu.Lock()
+	b.stats.Subscriptions = n
+	b.mu.Unlock()
+}
+
+// run keeps sessions going until ctx ends, reconnecting with backoff when
+// the socket drops. The backoff resets after a session that lasted a minute.
+func (b *streamBase) run(ctx context.Context, session func(context.Context) error) error {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		b.mu.Lock()
+		b.stats.Connected, b.stats.Subscriptions = false, 0
+		b.stats.Reconnects++
+		if err != nil {
+			b.stats.LastError = err.Error()
+		}
+		chain := b.stats.Chain
+		b.mu.Unlock()
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		log.Warn().Err(err).Str("chain", string(chain)).Dur("retry_in", backoff).Msg("stream disconnected")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// connected marks the session up and starts delivering conn's notifications
+// to notify until the connection ends.
+func (b *streamBase) connected(ctx context.Context, conn *wsConn, notify func(context.Context, wsNotification)) {
+	b.mu.Lock()
+	b.stats.Connected = true
+	b.mu.Unlock()
+	go func() {
+		for {
+			select {
+			case <-conn.Done():
+				return
+			case n := <-conn.Notifications():
+				notify(ctx, n)
+			}
+		}
+	}()
+}
+
+// streamWallets returns the tracked wallets on chain a stream should follow.
+func (s *Scanner) streamWallets(chain config.Chain) ([]db.TrackedWallet, error) {
+	ws, err := s.store.GetAllTrackedAddresses()
+	if err != nil {
+		return nil, err
+	}
+	minConf := s.cfg.Current().StreamMinConfidence
+	var out []db.TrackedWallet
+	for _, w := range ws {
+		if w.Chain == chain && w.Confidence >= minConf {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,418 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/kol-tracker/pkg/config"
+	"github.com/kol-tracker/pkg/db"
+)
+
+// ── Tron ────────────────────────────────────────────────────
+
+const (
+	tronscanAPI   = "https://apilist.tronscanapi.com/api"
+	tronPageSize  = 200
+	tronSunPerTRX = 1e6
+)
+
+// tronProvider serves Tron from the TronGrid REST API: TRX transfers from
+// the account's transactions, TRC-20 (USDT above all) from its token
+// transfers. Tronscan's address tags label exchanges and services. Swaps on
+// SunSwap are not decoded; Tron matters here as the funding leg of
+// FixedFloat and ChangeNOW orders, which start as plain USDT transfers.
+type tronProvider struct {
+	unsupported
+	*Scanner
+}
+
+func (t *tronProvider) Name() string { return "trongrid" }
+
+func (t *tronProvider) Supports(chain config.Chain) bool {
+	return chain == config.ChainTron && t.cfg.GetExplorerURL(chain) != ""
+}
+
+// tronTx is a row of /v1/accounts/{address}/transactions. Only
+// TransferContract rows (plain TRX sends) are used.
+type tronTx struct {
+	TxID           string `json:"txID"`
+	BlockNumber    int64  `json:"blockNumber"`
+	BlockTimestamp int64  `json:"block_timestamp"` // ms
+	Ret            []struct {
+		ContractRet string `json:"contractRet"`
+		Fee         int64  `json:"fee"` // sun
+	} `json:"ret"`
+	RawData struct {
+		Contract []struct {
+			Type      string `json:"type"`
+			Parameter struct {
+				Value struct {
+					Amount       int64  `json:"amount"` // sun
+					OwnerAddress string `json:"owner_address"`
+					ToAddress    string `json:"to_address"`
+				} `json:"value"`
+			} `json:"parameter"`
+		} `json:"contract"`
+	} `json:"raw_data"`
+}
+
+// transfer returns the TRX sent by a successful TransferContract, with
+// base58 addresses.
+func (tx tronTx) transfer() (from, to string, trx float64, ok bool) {
+	if len(tx.RawData.Contract) != 1 || tx.RawData.Contract[0].Type != "TransferContract" {
+		return "", "", 0, false
+	}
+	if len(tx.Ret) > 0 && tx.Ret[0].ContractRet != "" && tx.Ret[0].ContractRet != "SUCCESS" {
+		return "", "", 0, false
+	}
+	v := tx.RawData.Contract[0].Parameter.Value
+	return tronBase58(v.OwnerAddress), tronBase58(v.ToAddress), float64(v.Amount) / tronSunPerTRX, true
+}
+
+// trc20Transfer is a row of /v1/accounts/{address}/transactions/trc20.
+type trc20Transfer struct {
+	TransactionID string `json:"transaction_id"`
+	TokenInfo     struct {
+		Symbol   string `json:"symbol"`
+		Address  string `json:"address"`
+		Decimals int    `json:"decimals"`
+	} `json:"token_info"`
+	BlockTimestamp int64  `json:"block_timestamp"` // ms
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+}
+
+// tronGet calls TronGrid, sending the API key when one is configured.
+func (s *Scanner) tronGet(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if key := s.cfg.GetExplorerKey(config.ChainTron); key != "" {
+		req.Header.Set("TRON-PRO-API-KEY", key)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+// tronList pages through an account list ("transactions" or
+// "transactions/trc20") following TronGrid's fingerprints, up to maxPages.
+// Rows that arrived before an error are returned with it.
+func tronList[T any](ctx context.Context, s *Scanner, address, list string, q url.Values, maxPages int) ([]T, error) {
+	path := append([]string{"v1", "accounts", address}, strings.Split(list, "/")...)
+	if q.Get("limit") == "" {
+		q.Set("limit", strconv.Itoa(tronPageSize))
+	}
+	var all []T
+	for i := 0; i < maxPages; i++ {
+		body, err := s.tronGet(ctx, endpoint(s.cfg.GetExplorerURL(config.ChainTron), path, q))
+		if err != nil {
+			return all, err
+		}
+		var page struct {
+			Data    []T    `json:"data"`
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+			Meta    struct {
+				Fingerprint string `json:"fingerprint"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return all, fmt.Errorf("trongrid %s: %w", list, err)
+		}
+		if !page.Success {
+			return all, fmt.Errorf("trongrid %s: %s", list, page.Error)
+		}
+		all = append(all, page.Data...)
+		if page.Meta.Fingerprint == "" || len(page.Data) == 0 {
+			break
+		}
+		q.Set("fingerprint", page.Meta.Fingerprint)
+	}
+	return all, nil
+}
+
+// tronSince returns rows of a list newer than the cursor (a block timestamp
+// in ms) and the next cursor. Without a cursor only the latest page is
+// fetched, like the other providers' first pass.
+func tronSince[T any](ctx context.Context, s *Scanner, address, list, cursor string, ts func(T) int64) ([]T, string, error) {
+	q := url.Values{"only_confirmed": {"true"}}
+	pages := incrementalPages
+	if cursor == "" {
+		q.Set("order_by", "block_timestamp,desc")
+		pages = 1
+	} else {
+		// min_timestamp is inclusive: the rows of the cursor's block are
+		// read again and ignored on insert
+		q.Set("order_by", "block_timestamp,asc")
+		q.Set("min_timestamp", cursor)
+	}
+	rows, err := tronList[T](ctx, s, address, list, q, pages)
+	next := parseInt64(cursor)
+	for _, r := range rows {
+		if v := ts(r); v > next {
+			next = v
+		}
+	}
+	if next == 0 {
+		return rows, "", err
+	}
+	return rows, strconv.FormatInt(next, 10), err
+}
+
+func (t *tronProvider) FetchTransactions(ctx context.Context, walletID int64, address string, chain config.Chain) (int, error) {
+	count, failed := 0, 0
+	var lastErr error
+
+	cursor, _ := t.store.GetScanCursor(walletID, "trongrid:trx")
+	txs, next, err := tronSince(ctx, t.Scanner, address, "transactions", cursor, func(tx tronTx) int64 { return tx.BlockTimestamp })
+	if err != nil {
+		log.Debug().Err(err).Msg("trongrid transactions fetch failed")
+		failed, lastErr = failed+1, err
+	}
+	for _, tx := range txs {
+		if t.storeTronTx(ctx, walletID, address, tx) {
+			count++
+		}
+	}
+	if next != "" && next != cursor {
+		t.store.SetScanCursor(walletID, "trongrid:trx", next)
+	}
+
+	cursor, _ = t.store.GetScanCursor(walletID, "trongrid:trc20")
+	transfers, next, err := tronSince(ctx, t.Scanner, address, "transactions/trc20", cursor, func(tr trc20Transfer) int64 { return tr.BlockTimestamp })
+	if err != nil {
+		log.Debug().Err(err).Msg("trongrid trc20 fetch failed")
+		failed, lastErr = failed+1, err
+	}
+	for _, tr := range transfers {
+		if t.storeTRC20Transfer(ctx, walletID, address, tr) {
+			count++
+		}
+	}
+	if next != "" && next != cursor {
+		t.store.SetScanCursor(walletID, "trongrid:trc20", next)
+	}
+
+	if failed == 2 && count == 0 {
+		return 0, lastErr
+	}
+	log.Info().Str("addr", abbrev(address)).Str("chain", string(chain)).Int("txs", count).Msg("scanned Tron")
+	return count, nil
+}
+
+// storeTronTx stores a TRX transfer. The fee burned for bandwidth and energy
+// goes in PriorityFee, as gas cost does on EVM chains.
+func (s *Scanner) storeTronTx(ctx context.Context, walletID int64, address string, tx tronTx) bool {
+	from, to, trx, ok := tx.transfer()
+	if !ok || trx == 0 {
+		return false
+	}
+	txType := "transfer_out"
+	if to == address {
+		txType = "transfer_in"
+	}
+	ts := time.UnixMilli(tx.BlockTimestamp)
+	fee := 0.0
+	if len(tx.Ret) > 0 {
+		fee = float64(tx.Ret[0].Fee) / tronSunPerTRX
+	}
+	return s.store.InsertTransaction(db.WalletTransaction{
+		WalletID: walletID, TxHash: tx.TxID, Chain: config.ChainTron, TxType: txType,
+		TokenSymbol: "TRX", AmountToken: trx, AmountUSD: s.usdAt(ctx, config.ChainTron, "", trx, ts),
+		FromAddress: from, ToAddress: to, Timestamp: ts, BlockNumber: tx.BlockNumber,
+		PriorityFee: fee,
+	}) == nil
+}
+
+// storeTRC20Transfer stores a token transfer. Unlike ERC-20 rows, stables
+// leaving a Tron wallet are not taken for swap buys: on Tron they are nearly
+// always payments and exchange deposits.
+func (s *Scanner) storeTRC20Transfer(ctx context.Context, walletID int64, address string, tr trc20Transfer) bool {
+	if tr.TransactionID == "" || (tr.Type != "" && tr.Type != "Transfer") {
+		return false
+	}
+	value := tokenValue(tr.Value, tr.TokenInfo.Decimals)
+	if value == 0 {
+		return false
+	}
+	txType := "transfer_in"
+	if tr.From == address {
+		txType = "transfer_out"
+	}
+	ts := time.UnixMilli(tr.BlockTimestamp)
+	amountUSD := s.quoteUSDAt(ctx, config.ChainTron, tr.TokenInfo.Symbol, value, ts)
+	if amountUSD == 0 && config.IsStablecoin(config.ChainTron, tr.TokenInfo.Address) {
+		amountUSD = value
+	}
+	return s.store.InsertTransaction(db.WalletTransaction{
+		WalletID: walletID, TxHash: tr.TransactionID, Chain: config.ChainTron, TxType: txType,
+		TokenAddress: tr.TokenInfo.Address, TokenSymbol: tr.TokenInfo.Symbol,
+		AmountToken: value, AmountUSD: amountUSD, FromAddress: tr.From, ToAddress: tr.To,
+		Timestamp: ts,
+	}) == nil
+}
+
+// FetchFunding looks at the earliest TRX and TRC-20 transfers into the
+// wallet. USDT funding is reported in USDT, not converted to TRX.
+func (t *tronProvider) FetchFunding(ctx context.Context, address string, chain config.Chain) (*db.FundingAnalysis, error) {
+	fa := &db.FundingAnalysis{Address: address, Chain: chain, NativeSymbol: "TRX"}
+	q := func() url.Values {
+		return url.Values{"only_to": {"true"}, "only_confirmed": {"true"}, "order_by": {"block_timestamp,asc"}, "limit": {"50"}}
+	}
+	txs, err := tronList[tronTx](ctx, t.Scanner, address, "transactions", q(), 1)
+	if err != nil {
+		return fa, err
+	}
+	transfers, err := tronList[trc20Transfer](ctx, t.Scanner, address, "transactions/trc20", q(), 1)
+	if err != nil {
+		return fa, err
+	}
+	if len(txs) == 0 && len(transfers) == 0 {
+		fa.IsNewWallet = true
+		return fa, nil
+	}
+
+	addSource := func(from, token, hash string, amount float64, ms int64) {
+		at := time.UnixMilli(ms)
+		if fa.FirstTxTime == nil || at.Before(*fa.FirstTxTime) {
+			fa.FirstTxTime = &at
+		}
+		srcType := t.identifyAddress(ctx, from, chain)
+		if srcType == "unknown" {
+			return
+		}
+		fa.FundingSources = append(fa.FundingSources, db.FundingSource{
+			SourceAddress: from, Amount: amount, Token: token, TxHash: hash,
+			SourceType: srcType, Timestamp: at.Unix(), Chain: chain,
+		})
+		log.Warn().Str("wallet", abbrev(address)).Str("chain", string(chain)).Str("token", token).
+			Str("source", srcType).Float64("amount", amount).Msg("🚨 suspicious Tron funding")
+	}
+	for _, tx := range txs {
+		from, to, trx, ok := tx.transfer()
+		if !ok || to != address || trx == 0 {
+			continue
+		}
+		fa.TotalFunded += trx
+		addSource(from, "TRX", tx.TxID, trx, tx.BlockTimestamp)
+	}
+	for _, tr := range transfers {
+		if tr.To != address {
+			continue
+		}
+		if value := tokenValue(tr.Value, tr.TokenInfo.Decimals); value > 0 {
+			addSource(tr.From, tr.TokenInfo.Symbol, tr.TransactionID, value, tr.BlockTimestamp)
+		}
+	}
+	return fa, nil
+}
+
+func (t *tronProvider) LinkedWallets(ctx context.Context, address string, chain config.Chain) ([]db.FundingSource, error) {
+	q := func() url.Values {
+		return url.Values{"only_confirmed": {"true"}, "order_by": {"block_timestamp,desc"}, "limit": {"100"}}
+	}
+	txs, err := tronList[tronTx](ctx, t.Scanner, address, "transactions", q(), 1)
+	if err != nil {
+		return nil, err
+	}
+	transfers, _ := tronList[trc20Transfer](ctx, t.Scanner, address, "transactions/trc20", q(), 1)
+
+	seen := map[string]bool{address: true}
+	var linked []db.FundingSource
+	add := func(from, to, token string, amount float64) {
+		switch {
+		case from == address && !seen[to]:
+			seen[to] = true
+			linked = append(linked, db.FundingSource{SourceAddress: to, Amount: amount, Token: token, SourceType: "sent_to", Chain: chain})
+		case to == address && !seen[from]:
+			seen[from] = true
+			linked = append(linked, db.FundingSource{SourceAddress: from, Amount: amount, Token: token, SourceType: "received_from", Chain: chain})
+		}
+	}
+	for _, tx := range txs {
+		if from, to, trx, ok := tx.transfer(); ok {
+			add(from, to, "TRX", trx)
+		}
+	}
+	for _, tr := range transfers {
+		add(tr.From, tr.To, tr.TokenInfo.Symbol, tokenValue(tr.Value, tr.TokenInfo.Decimals))
+	}
+	return linked, nil
+}
+
+// IdentifyAddress labels an address from its Tronscan tag ("Binance-Hot",
+// "FixedFloat", ...), else checks whether it is a contract.
+func (t *tronProvider) IdentifyAddress(ctx context.Context, address string, chain config.Chain) (string, error) {
+	body, err := t.getJSON(ctx, endpoint(tronscanAPI, []string{"accountv2"}, url.Values{"address": {address}}))
+	if err == nil {
+		var acct struct {
+			AddressTag string `json:"addressTag"`
+		}
+		json.Unmarshal(body, &acct)
+		if acct.AddressTag != "" {
+			if label := matchServiceLabel(acct.AddressTag); label != "unknown" {
+				return label, nil
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint(t.cfg.GetExplorerURL(chain), []string{"wallet", "getcontract"}, nil),
+		strings.NewReader(fmt.Sprintf(`{"value":%q,"visible":true}`, address)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := t.cfg.GetExplorerKey(chain); key != "" {
+		req.Header.Set("TRON-PRO-API-KEY", key)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	var contract struct {
+		ContractAddress string `json:"contract_address"`
+	}
+	json.NewDecoder(resp.Body).Decode(&contract)
+	if contract.ContractAddress != "" {
+		return "contract", nil
+	}
+	return "unknown", nil
+}
+
+// tronBase58 turns a hex Tron address (41 + 20 bytes, as TronGrid returns
+// them in transactions) into its base58check form. Anything else is
+// returned unchanged.
+func tronBase58(hexAddr string) string {
+	b, err := hex.DecodeString(hexAddr)
+	if err != nil || len(b) != 21 || b[0] != 0x41 {
+		return hexAddr
+	}
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return base58Encode(append(b, h[:4]...))
+}
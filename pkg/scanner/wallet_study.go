@@ -17,14 +17,15 @@ import (
 // When a user adds a known wallet for a KOL, this engine:
 // 1. Fetches full transaction history
 // 2. Traces all outgoing transfers to find connected wallets
-// 3. Traces all incoming transfers to find funding sources
+// 3. Traces all incoming transfers to find funding sources, and follows them
+//    back several hops (stored as the wallet's funding trace)
 // 4. Checks cross-chain presence (same EVM address on ETH/Base/BSC)
 // 5. Finds wallets that received from the same funding sources
 // 6. Discovers wallets that traded the same tokens in similar timeframes
 // 7. Runs AI-powered deep analysis via LLM (behavioral fingerprinting, risk assessment)
 type WalletStudyEngine struct {
 	scanner  *Scanner
-	store    *db.Store
+	store    db.Store
 	cfg      *config.Config
 	aiStudy  AIWalletStudier // interface to AI engine (avoid circular import)
 }
@@ -35,7 +36,7 @@ type AIWalletStudier interface {
 	IsEnabled() bool
 }
 
-func NewWalletStudyEngine(sc *Scanner, store *db.Store, cfg *config.Config) *WalletStudyEngine {
+func NewWalletStudyEngine(sc *Scanner, store db.Store, cfg *config.Config) *WalletStudyEngine {
 	return &WalletStudyEngine{scanner: sc, store: store, cfg: cfg}
 }
 
@@ -52,6 +53,7 @@ type StudyResult struct {
 	FundingSources  []db.FundingSource  `json:"funding_sources"`
 	CrossChainAddrs []CrossChainAddr    `json:"cross_chain_addrs"`
 	CoTraders       []CoTrader          `json:"co_traders"`
+	FundingTrace    *FundingTrace       `json:"funding_trace,omitempty"`
 }
 
 type LinkedWallet struct {
@@ -153,6 +155,14 @@ func (e *WalletStudyEngine) StudyWallet(ctx context.Context, kolID int64, addres
 		}
 	}
 
+	// Follow the funding back through services and intermediate wallets
+	tracer := NewDeepFundingTracer(e.scanner, e.store, e.cfg)
+	if trace, err := tracer.TraceAndSave(ctx, address, chain, e.cfg.Current().FundingTraceDepth); err == nil {
+		result.FundingTrace = trace
+	} else {
+		log.Warn().Err(err).Str("wallet", abbrev(address)).Msg("funding trace failed")
+	}
+
 	// ── Step 4: Cross-chain check (EVM wallets on multiple chains) ──
 	if strings.HasPrefix(address, "0x") {
 		for _, otherChain := range config.AllEVMChains() {
@@ -315,12 +325,12 @@ func (e *WalletStudyEngine) StudyAllWalletsForKOL(ctx context.Context, kolID int
 }
 
 func isServiceAddr(addr string, chain config.Chain) bool {
-	for _, a := range config.KnownFixedFloatAddresses[chain] {
+	for _, a := range config.FixedFloatAddresses(chain) {
 		if strings.EqualFold(addr, a) {
 			return true
 		}
 	}
-	for _, a := range config.KnownBridgeContracts[chain] {
+	for _, a := range config.BridgeContracts(chain) {
 		if strings.EqualFold(addr, a) {
 			return true
 		}
@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// ── JSON-RPC over WebSocket ─────────────────────────────────
+// Shared by the real-time streams: requests are matched to replies by id and
+// subscription notifications are delivered on a channel.
+
+// wsNotification is one subscription message, keyed by subscription id.
+type wsNotification struct {
+	Method       string
+	Subscription string
+	Result       json.RawMessage
+}
+
+type wsMessage struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	Method string          `json:"method"`
+	Params *struct {
+		Subscription json.RawMessage `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// wsConn is one WebSocket JSON-RPC connection. It is single-use: once Done
+// is closed, dial a new one.
+type wsConn struct {
+	conn  *websocket.Conn
+	notes chan wsNotification
+	done  chan struct{}
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan wsMessage
+	err     error
+}
+
+func dialWS(ctx context.Context, wsURL string) (*wsConn, error) {
+	wcfg, err := websocket.NewConfig(wsURL, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	conn, err := wcfg.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &wsConn{conn: conn, notes: make(chan wsNotification, 256), done: make(chan struct{}), pending: map[int]chan wsMessage{}}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches replies and notifications until the connection fails.
+func (c *wsConn) read() {
+	var err error
+	for {
+		var msg wsMessage
+		if err = websocket.JSON.Receive(c.conn, &msg); err != nil {
+			break
+		}
+		if msg.ID != nil {
+			c.mu.Lock()
+			ch := c.pending[*msg.ID]
+			delete(c.pending, *msg.ID)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+			continue
+		}
+		if msg.Params != nil {
+			select {
+			case c.notes <- wsNotification{Method: msg.Method, Subscription: subKey(msg.Params.Subscription), Result: msg.Params.Result}:
+			case <-c.done:
+				return
+			}
+		}
+	}
+	c.close(err)
+}
+
+func (c *wsConn) close(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	if err == nil {
+		err = errors.New("websocket closed")
+	}
+	c.err = err
+	close(c.done)
+	c.conn.Close()
+}
+
+// Close ends the connection; Done is closed and pending calls fail.
+func (c *wsConn) Close() { c.close(errors.New("websocket closed")) }
+
+// Done is closed when the connection has failed or been closed.
+func (c *wsConn) Done() <-chan struct{} { return c.done }
+
+// Err reports why the connection ended.
+func (c *wsConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Notifications delivers subscription messages in arrival order.
+func (c *wsConn) Notifications() <-chan wsNotification { return c.notes }
+
+// call sends a request and waits for its reply.
+func (c *wsConn) call(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	ch := make(chan wsMessage, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := websocket.JSON.Send(c.conn, rpcRequest{JSONRPC: "2.0", Method: method, Params: params, ID: id}); err != nil {
+		c.close(err)
+		return nil, err
+	}
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	case <-c.done:
+		return nil, c.Err()
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// subscribe calls a *Subscribe method and returns the subscription id.
+func (c *wsConn) subscribe(ctx context.Context, method string, params []interface{}) (string, error) {
+	res, err := c.call(ctx, method, params)
+	if err != nil {
+		return "", err
+	}
+	id := subKey(res)
+	if id == "" || id == "null" {
+		return "", fmt.Errorf("%s: no subscription id", method)
+	}
+	return id, nil
+}
+
+// subKey normalizes a subscription id, a number on Solana and a hex string
+// on EVM nodes, so replies and notifications can be matched.
+func subKey(raw json.RawMessage) string {
+	return strings.Trim(strings.TrimSpace(string(raw)), `"`)
+}
+
+// subIDParam turns a subscription id back into the form the node issued.
+func subIDParam(id string) interface{} {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+		return n
+	}
+	return id
+}
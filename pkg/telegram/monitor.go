@@ -21,7 +21,7 @@ import (
 // Monitor scrapes public Telegram channels via their web preview pages.
 type Monitor struct {
 	cfg        *config.Config
-	store      *db.Store
+	store      db.Store
 	client     *http.Client
 	mu         sync.RWMutex
 	lastMsgIDs map[string]string // channel -> last msg ID
@@ -29,7 +29,7 @@ type Monitor struct {
 	onTokenFound func(kolID int64, tokenAddr string, chain config.Chain, mentionTime time.Time)
 }
 
-func NewMonitor(cfg *config.Config, store *db.Store) *Monitor {
+func NewMonitor(cfg *config.Config, store db.Store) *Monitor {
 	return &Monitor{
 		cfg:        cfg,
 		store:      store,
@@ -57,6 +57,8 @@ func (m *Monitor) Run(ctx context.Context) error {
 			return ctx.Err()
 		case <-ticker.C:
 			m.pollAll(ctx)
+		case <-m.cfg.Reloaded():
+			ticker.Reset(m.cfg.Current().TelegramPollInterval)
 		}
 	}
 }
@@ -269,6 +271,14 @@ func (m *Monitor) processMessage(kolID int64, channel string, msg TGMessage) {
 			m.store.UpsertWallet(kolID, addr, config.ChainEthereum, "from_telegram", 0.6, fmt.Sprintf("tg:%s", msg.ID))
 		}
 	}
+	for _, addr := range result.TronAddresses {
+		if !tokenCASet[addr] {
+			m.store.UpsertWallet(kolID, addr, config.ChainTron, "from_telegram", 0.6, fmt.Sprintf("tg:%s", msg.ID))
+		}
+	}
+	for _, addr := range result.BitcoinAddresses {
+		m.store.UpsertWallet(kolID, addr, config.ChainBitcoin, "from_telegram", 0.6, fmt.Sprintf("tg:%s", msg.ID))
+	}
 }
 
 // AddChannel adds a new channel to monitor at runtime.
@@ -283,6 +293,19 @@ func (m *Monitor) AddChannel(channel string) {
 	m.cfg.KOLTelegramChannels = append(m.cfg.KOLTelegramChannels, channel)
 }
 
+// RemoveChannel stops polling a channel.
+func (m *Monitor) RemoveChannel(channel string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.cfg.KOLTelegramChannels[:0:0]
+	for _, c := range m.cfg.KOLTelegramChannels {
+		if !strings.EqualFold(c, channel) {
+			kept = append(kept, c)
+		}
+	}
+	m.cfg.KOLTelegramChannels = kept
+}
+
 func concatSlices(slices ...[]string) []string {
 	var r []string
 	for _, s := range slices {
@@ -22,7 +22,7 @@ import (
 // to fetch tweets in real-time and backfill historical posts.
 type Monitor struct {
 	cfg          *config.Config
-	store        *db.Store
+	store        db.Store
 	scraper      *twitterscraper.Scraper
 	mu           sync.RWMutex
 	lastTweetIDs map[string]string // handle -> last seen tweet ID
@@ -31,7 +31,7 @@ type Monitor struct {
 	loggedIn     bool
 }
 
-func NewMonitor(cfg *config.Config, store *db.Store) *Monitor {
+func NewMonitor(cfg *config.Config, store db.Store) *Monitor {
 	return &Monitor{
 		cfg:          cfg,
 		store:        store,
@@ -133,6 +133,8 @@ func (m *Monitor) Run(ctx context.Context) error {
 			return ctx.Err()
 		case <-ticker.C:
 			m.pollAll(ctx)
+		case <-m.cfg.Reloaded():
+			ticker.Reset(m.cfg.Current().TwitterPollInterval)
 		}
 	}
 }
@@ -296,6 +298,14 @@ func (m *Monitor) processTweet(kolID int64, handle string, tweetID string, text
 			m.store.UpsertWallet(kolID, addr, config.ChainEthereum, "from_tweet", 0.7, fmt.Sprintf("tweet:%s", tweetID))
 		}
 	}
+	for _, addr := range result.TronAddresses {
+		if !tokenCASet[addr] {
+			m.store.UpsertWallet(kolID, addr, config.ChainTron, "from_tweet", 0.7, fmt.Sprintf("tweet:%s", tweetID))
+		}
+	}
+	for _, addr := range result.BitcoinAddresses {
+		m.store.UpsertWallet(kolID, addr, config.ChainBitcoin, "from_tweet", 0.7, fmt.Sprintf("tweet:%s", tweetID))
+	}
 
 	for botName := range result.BotSignals {
 		log.Debug().Str("bot", botName).Str("handle", handle).Msg("bot reference detected")
@@ -314,6 +324,20 @@ func (m *Monitor) AddHandle(handle string) {
 	m.cfg.KOLTwitterHandles = append(m.cfg.KOLTwitterHandles, handle)
 }
 
+// RemoveHandle stops polling a handle. Its stored posts and the seen-tweet
+// state are kept, so re-adding it later does not reprocess old tweets.
+func (m *Monitor) RemoveHandle(handle string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.cfg.KOLTwitterHandles[:0:0]
+	for _, h := range m.cfg.KOLTwitterHandles {
+		if !strings.EqualFold(h, handle) {
+			kept = append(kept, h)
+		}
+	}
+	m.cfg.KOLTwitterHandles = kept
+}
+
 // IsLoggedIn returns whether the scraper has an active session.
 func (m *Monitor) IsLoggedIn() bool {
 	return m.loggedIn